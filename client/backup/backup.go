@@ -0,0 +1,269 @@
+// Package backup writes and reads portable encrypted backup bundles.
+//
+// Bundle is a single json file, independent of client storage schema.
+// Every item is encrypted with AES-GCM under key derived from backup passphrase with Argon2id,
+// manifest of item hashes is signed with HMAC of the same derived key.
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// FormatName is bundle format marker
+	FormatName = "yandip-backup"
+	// Version is current bundle format version
+	Version = 1
+
+	kdfArgon2id = "argon2id"
+	saltSize    = 16
+)
+
+var (
+	ErrorNotBundle       = errors.New("file is not backup bundle")
+	ErrorVersion         = errors.New("unsupported backup bundle version")
+	ErrorWrongPassphrase = errors.New("wrong backup passphrase")
+	ErrorCorrupted       = errors.New("backup bundle is corrupted")
+)
+
+// KDFParams are Argon2id parameters, memory in KiB
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultKDFParams are parameters used for new bundles
+var DefaultKDFParams = KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// Item is one backup entry, Secret is json of secret object with metadata and attachment
+type Item struct {
+	SecretID  uuid.UUID       `json:"secret_id"`
+	SecretVer int             `json:"secret_ver"`
+	TypeID    int             `json:"type_id"`
+	TimeStamp int64           `json:"timestamp"`
+	Secret    json.RawMessage `json:"secret"`
+}
+
+// Bundle is decrypted backup
+type Bundle struct {
+	Version   int
+	CreatedAt int64
+	Client    string
+	Items     []Item
+}
+
+// file is bundle file layout
+type file struct {
+	Format    string     `json:"format"`
+	Version   int        `json:"version"`
+	CreatedAt int64      `json:"created_at"`
+	Client    string     `json:"client"`
+	KDF       fileKDF    `json:"kdf"`
+	Check     []byte     `json:"check"`
+	Items     []fileItem `json:"items"`
+	Manifest  manifest   `json:"manifest"`
+}
+
+type fileKDF struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	KDFParams
+}
+
+type fileItem struct {
+	ID    uuid.UUID `json:"id"`
+	Nonce []byte    `json:"nonce"`
+	Data  []byte    `json:"data"`
+}
+
+type manifest struct {
+	Count  int            `json:"count"`
+	Hashes []manifestHash `json:"hashes"`
+	HMAC   []byte         `json:"hmac"`
+}
+
+type manifestHash struct {
+	ID     uuid.UUID `json:"id"`
+	SHA256 string    `json:"sha256"`
+}
+
+// Write returns encrypted bundle with items
+func Write(items []Item, passphrase string, client string, params KDFParams) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("backup passphrase is empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encKey, macKey := deriveKeys(passphrase, salt, params)
+	aead, err := newAEAD(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	f := file{
+		Format:    FormatName,
+		Version:   Version,
+		CreatedAt: time.Now().UnixMilli(),
+		Client:    client,
+		KDF:       fileKDF{Name: kdfArgon2id, Salt: salt, KDFParams: params},
+		Check:     passphraseCheck(macKey),
+		Items:     make([]fileItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		plain, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+
+		id := uuid.New()
+		f.Items = append(f.Items, fileItem{
+			ID:    id,
+			Nonce: nonce,
+			Data:  aead.Seal(nil, nonce, plain, id[:]),
+		})
+	}
+
+	f.Manifest = buildManifest(f, macKey)
+
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// Read verifies bundle integrity and decrypts items
+func Read(data []byte, passphrase string) (Bundle, error) {
+	f := file{}
+	if err := json.Unmarshal(data, &f); err != nil || f.Format != FormatName {
+		return Bundle{}, ErrorNotBundle
+	}
+
+	if f.Version < 1 || f.Version > Version {
+		return Bundle{}, fmt.Errorf("%w: %v", ErrorVersion, f.Version)
+	}
+
+	if f.KDF.Name != kdfArgon2id || len(f.KDF.Salt) == 0 || f.KDF.Time == 0 || f.KDF.Threads == 0 {
+		return Bundle{}, fmt.Errorf("%w: kdf parameters", ErrorCorrupted)
+	}
+
+	encKey, macKey := deriveKeys(passphrase, f.KDF.Salt, f.KDF.KDFParams)
+	if !hmac.Equal(f.Check, passphraseCheck(macKey)) {
+		return Bundle{}, ErrorWrongPassphrase
+	}
+
+	//  manifest covers bundle header and every item hash
+	expected := buildManifest(f, macKey)
+	if !hmac.Equal(f.Manifest.HMAC, expected.HMAC) {
+		return Bundle{}, fmt.Errorf("%w: manifest signature mismatch", ErrorCorrupted)
+	}
+	if f.Manifest.Count != len(f.Items) || len(f.Manifest.Hashes) != len(f.Items) {
+		return Bundle{}, fmt.Errorf("%w: manifest has %v items, bundle has %v", ErrorCorrupted, f.Manifest.Count, len(f.Items))
+	}
+	for i, h := range expected.Hashes {
+		if f.Manifest.Hashes[i] != h {
+			return Bundle{}, fmt.Errorf("%w: item %v hash mismatch", ErrorCorrupted, h.ID)
+		}
+	}
+
+	aead, err := newAEAD(encKey)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	b := Bundle{
+		Version:   f.Version,
+		CreatedAt: f.CreatedAt,
+		Client:    f.Client,
+		Items:     make([]Item, 0, len(f.Items)),
+	}
+	for _, fi := range f.Items {
+		plain, err := aead.Open(nil, fi.Nonce, fi.Data, fi.ID[:])
+		if err != nil {
+			return Bundle{}, fmt.Errorf("%w: item %v: %v", ErrorCorrupted, fi.ID, err)
+		}
+
+		item := Item{}
+		if err := json.Unmarshal(plain, &item); err != nil {
+			return Bundle{}, fmt.Errorf("%w: item %v: %v", ErrorCorrupted, fi.ID, err)
+		}
+		b.Items = append(b.Items, item)
+	}
+
+	return b, nil
+}
+
+// deriveKeys returns encryption and mac keys derived from passphrase
+func deriveKeys(passphrase string, salt []byte, p KDFParams) ([]byte, []byte) {
+	key := argon2.IDKey([]byte(passphrase), salt, p.Time, p.Memory, p.Threads, 64)
+	return key[:32], key[32:]
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// passphraseCheck returns value to detect wrong passphrase before integrity check
+func passphraseCheck(macKey []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write([]byte(FormatName + " passphrase check"))
+
+	return mac.Sum(nil)
+}
+
+// buildManifest returns manifest of bundle items signed with mac key
+func buildManifest(f file, macKey []byte) manifest {
+	m := manifest{
+		Count:  len(f.Items),
+		Hashes: make([]manifestHash, 0, len(f.Items)),
+	}
+
+	mac := hmac.New(sha256.New, macKey)
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], uint64(f.Version))
+	mac.Write(num[:])
+	binary.BigEndian.PutUint64(num[:], uint64(f.CreatedAt))
+	mac.Write(num[:])
+	mac.Write([]byte(f.Client))
+	binary.BigEndian.PutUint64(num[:], uint64(len(f.Items)))
+	mac.Write(num[:])
+
+	for _, fi := range f.Items {
+		h := sha256.New()
+		h.Write(fi.Nonce)
+		h.Write(fi.Data)
+		hash := hex.EncodeToString(h.Sum(nil))
+
+		m.Hashes = append(m.Hashes, manifestHash{ID: fi.ID, SHA256: hash})
+		mac.Write(fi.ID[:])
+		mac.Write([]byte(hash))
+	}
+	m.HMAC = mac.Sum(nil)
+
+	return m
+}
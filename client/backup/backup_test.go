@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var testParams = KDFParams{Time: 1, Memory: 64, Threads: 1}
+
+func testItems() []Item {
+	return []Item{
+		{
+			SecretID:  uuid.New(),
+			SecretVer: 3,
+			TypeID:    2,
+			TimeStamp: 1000,
+			Secret:    json.RawMessage(`{"type_id":2,"title":"Mail","login":"petr","password":"secret"}`),
+		},
+		{
+			TypeID: 4,
+			Secret: json.RawMessage(`{"type_id":4,"title":"Photo","Data":"AQID","Filename":"photo.png"}`),
+		},
+	}
+}
+
+func TestBackup_WriteRead(t *testing.T) {
+	data, err := Write(testItems(), "backup pass", "1.0", testParams)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+	require.NotContains(t, string(data), "Mail")
+
+	b, err := Read(data, "backup pass")
+	require.NoError(t, err)
+	require.Equal(t, Version, b.Version)
+	require.Equal(t, "1.0", b.Client)
+	require.Equal(t, testItems()[1], b.Items[1])
+	require.Equal(t, testItems()[0].Secret, b.Items[0].Secret)
+}
+
+func TestBackup_ReadErrors(t *testing.T) {
+	data, err := Write(testItems(), "backup pass", "1.0", testParams)
+	require.NoError(t, err)
+
+	tamper := func(fn func(f *file)) []byte {
+		f := file{}
+		require.NoError(t, json.Unmarshal(data, &f))
+		fn(&f)
+		res, err := json.Marshal(f)
+		require.NoError(t, err)
+		return res
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		pass    string
+		wantErr error
+	}{
+		{name: "wrong passphrase", data: data, pass: "wrong", wantErr: ErrorWrongPassphrase},
+		{name: "not bundle", data: []byte(`{"items": []}`), pass: "backup pass", wantErr: ErrorNotBundle},
+		{
+			name:    "newer version",
+			data:    tamper(func(f *file) { f.Version = Version + 1 }),
+			pass:    "backup pass",
+			wantErr: ErrorVersion,
+		},
+		{
+			name:    "item removed",
+			data:    tamper(func(f *file) { f.Items = f.Items[1:] }),
+			pass:    "backup pass",
+			wantErr: ErrorCorrupted,
+		},
+		{
+			name:    "item changed",
+			data:    tamper(func(f *file) { f.Items[0].Data[0] ^= 0xff }),
+			pass:    "backup pass",
+			wantErr: ErrorCorrupted,
+		},
+		{
+			name: "item changed with manifest hash",
+			data: tamper(func(f *file) {
+				f.Items[0].Data[0] ^= 0xff
+				f.Manifest.Hashes = buildManifest(*f, []byte("other key")).Hashes
+			}),
+			pass:    "backup pass",
+			wantErr: ErrorCorrupted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(tt.data, tt.pass)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
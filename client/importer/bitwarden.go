@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// bitwarden item types
+const (
+	bitwardenLogin    = 1
+	bitwardenCard     = 3
+	bitwardenIdentity = 4
+)
+
+// bitwarden custom field types
+const (
+	bitwardenFieldHidden = 1
+)
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int    `json:"type"`
+	FolderID string `json:"folderId"`
+	Name     string `json:"name"`
+	Notes    string `json:"notes"`
+	Fields   []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Totp     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]interface{} `json:"identity"`
+}
+
+// parseBitwarden reads unencrypted bitwarden json export
+func parseBitwarden(data []byte, _ Options) ([]Record, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(trimBOM(data), &export); err != nil {
+		return nil, err
+	}
+
+	if export.Encrypted {
+		return nil, ErrorEncryptedExport
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, f := range export.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	records := make([]Record, 0, len(export.Items))
+	for _, item := range export.Items {
+		info := model.Info{
+			Title:  item.Name,
+			Folder: folders[item.FolderID],
+		}
+
+		var fields []model.CustomField
+		for _, f := range item.Fields {
+			fields = append(fields, model.CustomField{
+				Name:   f.Name,
+				Value:  f.Value,
+				Hidden: f.Type == bitwardenFieldHidden,
+			})
+		}
+
+		switch {
+		case item.Type == bitwardenLogin && item.Login != nil:
+			info.TypeID = model.SecretTypes["AUTH"]
+			info.Description = item.Notes
+
+			auth := model.Auth{
+				Info:     info,
+				Login:    item.Login.Username,
+				Password: item.Login.Password,
+			}
+			for i, uri := range item.Login.URIs {
+				if i == 0 {
+					auth.URL = uri.URI
+					continue
+				}
+				fields = append(fields, model.CustomField{Name: "url", Value: uri.URI})
+			}
+			if item.Login.Totp != "" {
+				fields = append(fields, model.CustomField{Name: "totp", Value: item.Login.Totp, Hidden: true})
+			}
+			auth.Fields = fields
+
+			records = append(records, Record{Item: auth})
+
+		case item.Type == bitwardenCard && item.Card != nil:
+			info.TypeID = model.SecretTypes["CARD"]
+			info.Description = item.Notes
+
+			if item.Card.Brand != "" {
+				fields = append(fields, model.CustomField{Name: "brand", Value: item.Card.Brand})
+			}
+
+			records = append(records, Record{Item: model.Card{
+				Info:            info,
+				CardholderName:  item.Card.CardholderName,
+				PAN:             item.Card.Number,
+				ExpirationMonth: atoi(item.Card.ExpMonth),
+				ExpirationYear:  atoi(item.Card.ExpYear),
+				ServiceCode:     atoi(item.Card.Code),
+				Fields:          fields,
+			}})
+
+		case item.Type == bitwardenIdentity:
+			info.TypeID = model.SecretTypes["TEXT"]
+
+			for _, name := range sortedKeys(item.Identity) {
+				if v := stringify(item.Identity[name]); v != "" {
+					fields = append(fields, model.CustomField{Name: name, Value: v})
+				}
+			}
+
+			records = append(records, Record{Item: model.Text{
+				Info:   info,
+				Text:   item.Notes,
+				Fields: fields,
+			}})
+
+		default:
+			//  secure note and unknown types imported as text
+			info.TypeID = model.SecretTypes["TEXT"]
+
+			records = append(records, Record{Item: model.Text{
+				Info:   info,
+				Text:   item.Notes,
+				Fields: fields,
+			}})
+		}
+	}
+
+	return records, nil
+}
+
+// atoi converts string to int, returns 0 if not number
+func atoi(s string) int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+
+	return v
+}
@@ -0,0 +1,81 @@
+package importer
+
+import (
+	"net/url"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// parseChromeCSV reads chrome or edge csv export
+// name,url,username,password,note
+func parseChromeCSV(data []byte, _ Options) ([]Record, error) {
+	cols, rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		link := cols.get(row, "url")
+
+		title := cols.get(row, "name")
+		if title == "" {
+			title = hostname(link)
+		}
+
+		records = append(records, Record{Item: model.Auth{
+			Info: model.Info{
+				TypeID:      model.SecretTypes["AUTH"],
+				Title:       title,
+				Description: cols.get(row, "note"),
+			},
+			Login:    cols.get(row, "username"),
+			Password: cols.get(row, "password"),
+			URL:      link,
+		}})
+	}
+
+	return records, nil
+}
+
+// parseFirefoxCSV reads firefox csv export
+// "url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"
+func parseFirefoxCSV(data []byte, _ Options) ([]Record, error) {
+	cols, rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		link := cols.get(row, "url")
+
+		auth := model.Auth{
+			Info: model.Info{
+				TypeID: model.SecretTypes["AUTH"],
+				Title:  hostname(link),
+			},
+			Login:    cols.get(row, "username"),
+			Password: cols.get(row, "password"),
+			URL:      link,
+		}
+
+		if realm := cols.get(row, "httprealm"); realm != "" {
+			auth.Fields = append(auth.Fields, model.CustomField{Name: "http realm", Value: realm})
+		}
+
+		records = append(records, Record{Item: auth})
+	}
+
+	return records, nil
+}
+
+// hostname returns host of link, or link if it can't be parsed
+func hostname(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	return u.Hostname()
+}
@@ -0,0 +1,212 @@
+// Package importer reads secrets exported by other password managers.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Xrefullx/YanDip/client/importer/kdbx"
+)
+
+// Format is name of supported export format
+type Format string
+
+const (
+	FormatAuto           Format = "auto"
+	FormatBitwarden      Format = "bitwarden"
+	FormatOnePassword    Format = "1password"
+	FormatOnePasswordPUX Format = "1pux"
+	FormatChrome         Format = "chrome"
+	FormatFirefox        Format = "firefox"
+	FormatEdge           Format = "edge"
+	FormatKeePass        Format = "keepass"
+)
+
+var (
+	ErrorUnknownFormat   = errors.New("unknown import format")
+	ErrorEncryptedExport = errors.New("encrypted export is not supported, export unencrypted file")
+)
+
+// Record is a single parsed entry, ready to add with SecretService.
+// Item is one of model.Auth, model.Card, model.Text, model.Binary.
+// History holds previous versions of Item, oldest first.
+type Record struct {
+	Item    interface{}
+	History []interface{}
+}
+
+// Options are import parameters
+// Password and KeyFile are used to open encrypted databases
+type Options struct {
+	Format   Format
+	Password string
+	KeyFile  []byte
+}
+
+// parser reads records from export file content
+type parser func(data []byte, opts Options) ([]Record, error)
+
+var parsers = map[Format]parser{
+	FormatBitwarden:      parseBitwarden,
+	FormatOnePassword:    parseOnePasswordCSV,
+	FormatOnePasswordPUX: parseOnePasswordPUX,
+	FormatChrome:         parseChromeCSV,
+	FormatEdge:           parseChromeCSV,
+	FormatFirefox:        parseFirefoxCSV,
+	FormatKeePass:        parseKeePass,
+}
+
+// Parse reads records from data in opts format, if format is FormatAuto detects it by content.
+// Returns used format.
+func Parse(data []byte, opts Options) ([]Record, Format, error) {
+	format := opts.Format
+	if format == FormatAuto || format == "" {
+		detected, err := Detect(data)
+		if err != nil {
+			return nil, "", err
+		}
+		format = detected
+	}
+
+	p, ok := parsers[format]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %v", ErrorUnknownFormat, format)
+	}
+
+	records, err := p(data, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("error read %v export: %w", format, err)
+	}
+
+	return records, format, nil
+}
+
+// Detect detects export format by file content
+func Detect(data []byte) (Format, error) {
+	if bytes.HasPrefix(data, kdbx.Signature) {
+		return FormatKeePass, nil
+	}
+
+	//  zip archive, 1password 1pux
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrorUnknownFormat, err)
+		}
+		for _, f := range zr.File {
+			if f.Name == onePasswordPUXData {
+				return FormatOnePasswordPUX, nil
+			}
+		}
+
+		return "", fmt.Errorf("%w: zip archive without %v", ErrorUnknownFormat, onePasswordPUXData)
+	}
+
+	data = trimBOM(data)
+
+	//  json, bitwarden
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var head struct {
+			Encrypted bool              `json:"encrypted"`
+			Items     []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &head); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrorUnknownFormat, err)
+		}
+		if head.Encrypted {
+			return "", ErrorEncryptedExport
+		}
+		if head.Items == nil {
+			return "", fmt.Errorf("%w: json without items", ErrorUnknownFormat)
+		}
+
+		return FormatBitwarden, nil
+	}
+
+	//  csv, detect by header
+	header, err := csv.NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrorUnknownFormat, err)
+	}
+	cols := columnIndex(header)
+
+	switch {
+	case cols.has("title", "username", "password"):
+		return FormatOnePassword, nil
+	case cols.has("name", "url", "username", "password"):
+		return FormatChrome, nil
+	case cols.has("url", "username", "password", "httprealm"):
+		return FormatFirefox, nil
+	}
+
+	return "", ErrorUnknownFormat
+}
+
+// columns maps lower case csv header name to column index
+type columns map[string]int
+
+func columnIndex(header []string) columns {
+	res := make(columns, len(header))
+	for i, name := range header {
+		res[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	return res
+}
+
+// has checks all names exist in header
+func (c columns) has(names ...string) bool {
+	for _, name := range names {
+		if _, ok := c[name]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// get returns row value of column, empty if column not exist
+func (c columns) get(row []string, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+
+	return strings.TrimSpace(row[i])
+}
+
+// readCSV reads csv with header, returns header index and rows
+func readCSV(data []byte) (columns, [][]string, error) {
+	r := csv.NewReader(bytes.NewReader(trimBOM(data)))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows = append(rows, row)
+	}
+
+	return columnIndex(header), rows, nil
+}
+
+func trimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+}
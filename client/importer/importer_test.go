@@ -0,0 +1,335 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/importer/kdbx"
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+const puxData = `{
+  "accounts": [{
+    "attrs": {"name": "Petr"},
+    "vaults": [{
+      "attrs": {"name": "Private"},
+      "items": [
+        {
+          "state": "active",
+          "categoryUuid": "001",
+          "overview": {"title": "Mail", "url": "https://mail.example.com", "tags": ["work"]},
+          "details": {
+            "loginFields": [
+              {"value": "petr", "designation": "username"},
+              {"value": "secret", "designation": "password"}
+            ],
+            "notesPlain": "work mail",
+            "sections": [{"title": "", "fields": [{"title": "one-time password", "id": "otp", "value": {"totp": "otpauth://totp/mail"}}]}]
+          }
+        },
+        {
+          "state": "active",
+          "categoryUuid": "002",
+          "overview": {"title": "Visa"},
+          "details": {
+            "sections": [{"title": "", "fields": [
+              {"title": "cardholder name", "id": "cardholder", "value": {"string": "PETR"}},
+              {"title": "number", "id": "ccnum", "value": {"creditCardNumber": "4111111111111111"}},
+              {"title": "verification number", "id": "cvv", "value": {"concealed": "123"}},
+              {"title": "expiry date", "id": "expiry", "value": {"monthYear": 202702}},
+              {"title": "issuing bank", "id": "bank", "value": {"string": "Tinkoff"}}
+            ]}]
+          }
+        },
+        {
+          "state": "active",
+          "categoryUuid": "003",
+          "overview": {"title": "Wifi"},
+          "details": {"notesPlain": "password is 123"}
+        },
+        {
+          "state": "archived",
+          "categoryUuid": "001",
+          "overview": {"title": "Old"},
+          "details": {}
+        }
+      ]
+    }]
+  }]
+}`
+
+func TestImporter_Parse(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		format     Format
+		wantFormat Format
+		want       []interface{}
+	}{
+		{
+			name:       "bitwarden json",
+			data:       mustRead(t, "bitwarden.json"),
+			wantFormat: FormatBitwarden,
+			want: []interface{}{
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail", Description: "work mail", Folder: "Work"},
+					Login:    "petr",
+					Password: "secret",
+					URL:      "https://mail.example.com",
+					Fields: []model.CustomField{
+						{Name: "pin", Value: "1234", Hidden: true},
+						{Name: "url", Value: "https://m.example.com"},
+						{Name: "totp", Value: "otpauth://totp/mail", Hidden: true},
+					},
+				},
+				model.Text{
+					Info: model.Info{TypeID: model.SecretTypes["TEXT"], Title: "Wifi"},
+					Text: "password is 123",
+				},
+				model.Card{
+					Info:            model.Info{TypeID: model.SecretTypes["CARD"], Title: "Visa"},
+					CardholderName:  "PETR",
+					PAN:             "4111 1111 1111 1111",
+					ExpirationMonth: 2,
+					ExpirationYear:  2027,
+					ServiceCode:     123,
+					Fields:          []model.CustomField{{Name: "brand", Value: "Visa"}},
+				},
+				model.Text{
+					Info: model.Info{TypeID: model.SecretTypes["TEXT"], Title: "Passport"},
+					Fields: []model.CustomField{
+						{Name: "firstName", Value: "Petr"},
+						{Name: "lastName", Value: "Petrov"},
+						{Name: "passportNumber", Value: "1234"},
+					},
+				},
+			},
+		},
+		{
+			name:       "1password csv",
+			data:       mustRead(t, "1password.csv"),
+			wantFormat: FormatOnePassword,
+			want: []interface{}{
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail", Description: "work mail", Tags: []string{"work", "mail"}},
+					Login:    "petr",
+					Password: "secret",
+					URL:      "https://mail.example.com",
+					Fields:   []model.CustomField{{Name: "totp", Value: "otpauth://totp/mail", Hidden: true}},
+				},
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Shop"},
+					Login:    "petr",
+					Password: "shop",
+					URL:      "https://shop.example.com",
+				},
+			},
+		},
+		{
+			name:       "1password 1pux",
+			data:       mustPUX(t, puxData),
+			wantFormat: FormatOnePasswordPUX,
+			want: []interface{}{
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail", Description: "work mail", Folder: "Private", Tags: []string{"work"}},
+					Login:    "petr",
+					Password: "secret",
+					URL:      "https://mail.example.com",
+					Fields:   []model.CustomField{{Name: "one-time password", Value: "otpauth://totp/mail", Hidden: true}},
+				},
+				model.Card{
+					Info:            model.Info{TypeID: model.SecretTypes["CARD"], Title: "Visa", Folder: "Private"},
+					CardholderName:  "PETR",
+					PAN:             "4111111111111111",
+					ExpirationMonth: 2,
+					ExpirationYear:  2027,
+					ServiceCode:     123,
+					Fields:          []model.CustomField{{Name: "issuing bank", Value: "Tinkoff"}},
+				},
+				model.Text{
+					Info: model.Info{TypeID: model.SecretTypes["TEXT"], Title: "Wifi", Folder: "Private"},
+					Text: "password is 123",
+				},
+			},
+		},
+		{
+			name:       "chrome csv",
+			data:       mustRead(t, "chrome.csv"),
+			wantFormat: FormatChrome,
+			want: []interface{}{
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "mail.example.com"},
+					Login:    "petr",
+					Password: "secret",
+					URL:      "https://mail.example.com/login",
+				},
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "shop.example.com", Description: "my shop"},
+					Login:    "petr",
+					Password: "shop",
+					URL:      "https://shop.example.com/",
+				},
+			},
+		},
+		{
+			name:       "edge csv with format",
+			data:       mustRead(t, "chrome.csv"),
+			format:     FormatEdge,
+			wantFormat: FormatEdge,
+			want: []interface{}{
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "mail.example.com"},
+					Login:    "petr",
+					Password: "secret",
+					URL:      "https://mail.example.com/login",
+				},
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "shop.example.com", Description: "my shop"},
+					Login:    "petr",
+					Password: "shop",
+					URL:      "https://shop.example.com/",
+				},
+			},
+		},
+		{
+			name:       "firefox csv",
+			data:       mustRead(t, "firefox.csv"),
+			wantFormat: FormatFirefox,
+			want: []interface{}{
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "mail.example.com"},
+					Login:    "petr",
+					Password: "secret",
+					URL:      "https://mail.example.com",
+				},
+				model.Auth{
+					Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "intranet.example.com"},
+					Login:    "admin",
+					Password: "pass",
+					URL:      "https://intranet.example.com",
+					Fields:   []model.CustomField{{Name: "http realm", Value: "Intranet"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := tt.format
+			if format == "" {
+				format = FormatAuto
+			}
+
+			records, gotFormat, err := Parse(tt.data, Options{Format: format})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, gotFormat)
+
+			got := make([]interface{}, 0, len(records))
+			for _, rec := range records {
+				got = append(got, rec.Item)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestImporter_KeePass(t *testing.T) {
+	data := mustRead(t, "keepass.kdbx")
+
+	_, _, err := Parse(data, Options{Format: FormatAuto, Password: "wrong"})
+	require.ErrorIs(t, err, kdbx.ErrorWrongCredentials)
+
+	records, format, err := Parse(data, Options{Format: FormatAuto, Password: "pass"})
+	require.NoError(t, err)
+	assert.Equal(t, FormatKeePass, format)
+
+	require.Len(t, records, 3)
+	assert.Equal(t, Record{
+		Item: model.Auth{
+			Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail", Tags: []string{"work", "mail"}},
+			Login:    "petr",
+			Password: "secret",
+			Fields:   []model.CustomField{{Name: "PIN", Value: "1234", Hidden: true}},
+		},
+		History: []interface{}{
+			model.Auth{
+				Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail"},
+				Password: "first",
+			},
+		},
+	}, records[0])
+	assert.Equal(t, Record{Item: model.Binary{
+		Info:        model.Info{TypeID: model.SecretTypes["BINARY"], Title: "Mail/note.txt", Tags: []string{"work", "mail"}},
+		Data:        []byte("attached note"),
+		ContentType: "text/plain; charset=utf-8",
+		Filename:    "note.txt",
+	}}, records[1])
+	assert.Equal(t, Record{Item: model.Auth{
+		Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "VPN", Folder: "Work"},
+		Password: "vpn",
+	}}, records[2])
+}
+
+func TestImporter_Detect_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name:    "encrypted bitwarden",
+			data:    `{"encrypted": true, "items": []}`,
+			wantErr: ErrorEncryptedExport,
+		},
+		{
+			name:    "json without items",
+			data:    `{"list": []}`,
+			wantErr: ErrorUnknownFormat,
+		},
+		{
+			name:    "unknown csv",
+			data:    "a,b,c\n1,2,3\n",
+			wantErr: ErrorUnknownFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse([]byte(tt.data), Options{Format: FormatAuto})
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func mustRead(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	return data
+}
+
+// mustPUX returns 1pux archive with export data
+func mustPUX(t *testing.T, exportData string) []byte {
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create("export.attributes")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`{"version": 3}`))
+	require.NoError(t, err)
+
+	w, err = zw.Create(onePasswordPUXData)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(exportData))
+	require.NoError(t, err)
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
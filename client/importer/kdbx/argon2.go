@@ -0,0 +1,290 @@
+package kdbx
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// argon2 is implementation of Argon2 version 1.3 (RFC 9106).
+// golang.org/x/crypto/argon2 has no Argon2d, which is KeePass default KDF.
+
+// argon2 types
+const (
+	argon2d  = 0
+	argon2id = 2
+)
+
+const (
+	argon2Version = 0x13
+	syncPoints    = 4
+	blockLength   = 128
+)
+
+type block [blockLength]uint64
+
+// argon2Key derives key with Argon2d or Argon2id, memory in KiB
+func argon2Key(mode int, password, salt, secret, data []byte, time, memory uint32, threads uint32, keyLen uint32) []byte {
+	h0 := argon2InitHash(mode, password, salt, secret, data, time, memory, threads, keyLen)
+
+	memory = memory / (syncPoints * threads) * (syncPoints * threads)
+	if memory < 2*syncPoints*threads {
+		memory = 2 * syncPoints * threads
+	}
+
+	B := argon2InitBlocks(&h0, memory, threads)
+	argon2ProcessBlocks(mode, B, time, memory, threads)
+
+	return argon2ExtractKey(B, memory, threads, keyLen)
+}
+
+func argon2InitHash(mode int, password, salt, secret, data []byte, time, memory, threads, keyLen uint32) [blake2b.Size + 8]byte {
+	var (
+		h0     [blake2b.Size + 8]byte
+		params [24]byte
+		tmp    [4]byte
+	)
+
+	b2, _ := blake2b.New512(nil)
+	binary.LittleEndian.PutUint32(params[0:4], threads)
+	binary.LittleEndian.PutUint32(params[4:8], keyLen)
+	binary.LittleEndian.PutUint32(params[8:12], memory)
+	binary.LittleEndian.PutUint32(params[12:16], time)
+	binary.LittleEndian.PutUint32(params[16:20], argon2Version)
+	binary.LittleEndian.PutUint32(params[20:24], uint32(mode))
+	b2.Write(params[:])
+
+	for _, v := range [][]byte{password, salt, secret, data} {
+		binary.LittleEndian.PutUint32(tmp[:], uint32(len(v)))
+		b2.Write(tmp[:])
+		b2.Write(v)
+	}
+	b2.Sum(h0[:0])
+
+	return h0
+}
+
+func argon2InitBlocks(h0 *[blake2b.Size + 8]byte, memory, threads uint32) []block {
+	var block0 [1024]byte
+	B := make([]block, memory)
+
+	for lane := uint32(0); lane < threads; lane++ {
+		j := lane * (memory / threads)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+
+		for i := uint32(0); i < 2; i++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], i)
+			blake2bHash(block0[:], h0[:])
+			for k := range B[j+i] {
+				B[j+i][k] = binary.LittleEndian.Uint64(block0[k*8:])
+			}
+		}
+	}
+
+	return B
+}
+
+func argon2ProcessBlocks(mode int, B []block, time, memory, threads uint32) {
+	lanes := memory / threads
+	segments := lanes / syncPoints
+
+	processSegment := func(n, slice, lane uint32) {
+		var addresses, in, zero block
+
+		dataIndependent := mode == argon2id && n == 0 && slice < syncPoints/2
+		if dataIndependent {
+			in[0] = uint64(n)
+			in[1] = uint64(lane)
+			in[2] = uint64(slice)
+			in[3] = uint64(memory)
+			in[4] = uint64(time)
+			in[5] = uint64(mode)
+		}
+
+		index := uint32(0)
+		if n == 0 && slice == 0 {
+			//  first two blocks of lane are already generated
+			index = 2
+			if dataIndependent {
+				in[6]++
+				processBlock(&addresses, &in, &zero, false)
+				processBlock(&addresses, &addresses, &zero, false)
+			}
+		}
+
+		offset := lane*lanes + slice*segments + index
+		for index < segments {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += lanes
+			}
+
+			var random uint64
+			if dataIndependent {
+				if index%blockLength == 0 {
+					in[6]++
+					processBlock(&addresses, &in, &zero, false)
+					processBlock(&addresses, &addresses, &zero, false)
+				}
+				random = addresses[index%blockLength]
+			} else {
+				random = B[prev][0]
+			}
+
+			newOffset := indexAlpha(random, lanes, segments, threads, n, slice, lane, index)
+			processBlock(&B[offset], &B[prev], &B[newOffset], true)
+
+			index, offset = index+1, offset+1
+		}
+	}
+
+	for n := uint32(0); n < time; n++ {
+		for slice := uint32(0); slice < syncPoints; slice++ {
+			for lane := uint32(0); lane < threads; lane++ {
+				processSegment(n, slice, lane)
+			}
+		}
+	}
+}
+
+func argon2ExtractKey(B []block, memory, threads, keyLen uint32) []byte {
+	lanes := memory / threads
+	for lane := uint32(0); lane < threads-1; lane++ {
+		for i, v := range B[(lane*lanes)+lanes-1] {
+			B[memory-1][i] ^= v
+		}
+	}
+
+	var last [1024]byte
+	for i, v := range B[memory-1] {
+		binary.LittleEndian.PutUint64(last[i*8:], v)
+	}
+
+	key := make([]byte, keyLen)
+	blake2bHash(key, last[:])
+
+	return key
+}
+
+// indexAlpha returns index of reference block
+func indexAlpha(rand uint64, lanes, segments, threads, n, slice, lane, index uint32) uint32 {
+	refLane := uint32(rand>>32) % threads
+	if n == 0 && slice == 0 {
+		refLane = lane
+	}
+
+	m, s := 3*segments, ((slice+1)%syncPoints)*segments
+	if lane == refLane {
+		m += index
+	}
+	if n == 0 {
+		m, s = slice*segments, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+	if index == 0 || lane == refLane {
+		m--
+	}
+
+	p := rand & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * uint64(m)) >> 32
+
+	return refLane*lanes + uint32((uint64(s)+uint64(m)-(p+1))%uint64(lanes))
+}
+
+// processBlock is compression function G, if xor result is xored with out
+func processBlock(out, in1, in2 *block, xor bool) {
+	var t block
+	for i := range t {
+		t[i] = in1[i] ^ in2[i]
+	}
+
+	//  rows
+	for i := 0; i < blockLength; i += 16 {
+		blamka(&t[i+0], &t[i+1], &t[i+2], &t[i+3], &t[i+4], &t[i+5], &t[i+6], &t[i+7],
+			&t[i+8], &t[i+9], &t[i+10], &t[i+11], &t[i+12], &t[i+13], &t[i+14], &t[i+15])
+	}
+	//  columns
+	for i := 0; i < blockLength/8; i += 2 {
+		blamka(&t[i], &t[i+1], &t[16+i], &t[16+i+1], &t[32+i], &t[32+i+1], &t[48+i], &t[48+i+1],
+			&t[64+i], &t[64+i+1], &t[80+i], &t[80+i+1], &t[96+i], &t[96+i+1], &t[112+i], &t[112+i+1])
+	}
+
+	if xor {
+		for i := range t {
+			out[i] ^= in1[i] ^ in2[i] ^ t[i]
+		}
+		return
+	}
+	for i := range t {
+		out[i] = in1[i] ^ in2[i] ^ t[i]
+	}
+}
+
+// blamka is BLAKE2b round with multiplication
+func blamka(v0, v1, v2, v3, v4, v5, v6, v7, v8, v9, v10, v11, v12, v13, v14, v15 *uint64) {
+	gb(v0, v4, v8, v12)
+	gb(v1, v5, v9, v13)
+	gb(v2, v6, v10, v14)
+	gb(v3, v7, v11, v15)
+
+	gb(v0, v5, v10, v15)
+	gb(v1, v6, v11, v12)
+	gb(v2, v7, v8, v13)
+	gb(v3, v4, v9, v14)
+}
+
+func gb(a, b, c, d *uint64) {
+	*a += *b + 2*uint64(uint32(*a))*uint64(uint32(*b))
+	*d = bits.RotateLeft64(*d^*a, -32)
+	*c += *d + 2*uint64(uint32(*c))*uint64(uint32(*d))
+	*b = bits.RotateLeft64(*b^*c, -24)
+	*a += *b + 2*uint64(uint32(*a))*uint64(uint32(*b))
+	*d = bits.RotateLeft64(*d^*a, -16)
+	*c += *d + 2*uint64(uint32(*c))*uint64(uint32(*d))
+	*b = bits.RotateLeft64(*b^*c, -63)
+}
+
+// blake2bHash is variable length hash function H'
+func blake2bHash(out []byte, in []byte) {
+	var b2 hash.Hash
+	if n := len(out); n < blake2b.Size {
+		b2, _ = blake2b.New(n, nil)
+	} else {
+		b2, _ = blake2b.New512(nil)
+	}
+
+	var buffer [blake2b.Size]byte
+	binary.LittleEndian.PutUint32(buffer[:4], uint32(len(out)))
+	b2.Write(buffer[:4])
+	b2.Write(in)
+
+	if len(out) <= blake2b.Size {
+		b2.Sum(out[:0])
+		return
+	}
+
+	outLen := len(out)
+	b2.Sum(buffer[:0])
+	b2.Reset()
+	copy(out, buffer[:32])
+	out = out[32:]
+	for len(out) > blake2b.Size {
+		b2.Write(buffer[:])
+		b2.Sum(buffer[:0])
+		copy(out, buffer[:32])
+		out = out[32:]
+		b2.Reset()
+	}
+
+	if outLen%blake2b.Size > 0 {
+		r := ((outLen + 31) / 32) - 2
+		b2, _ = blake2b.New(outLen-32*r, nil)
+	}
+	b2.Write(buffer[:])
+	b2.Sum(out[:0])
+}
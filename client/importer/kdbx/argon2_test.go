@@ -0,0 +1,46 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestArgon2_RFCVectors(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	tests := []struct {
+		name string
+		mode int
+		tag  string
+	}{
+		{
+			name: "argon2d",
+			mode: argon2d,
+			tag:  "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb",
+		},
+		{
+			name: "argon2id",
+			mode: argon2id,
+			tag:  "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := argon2Key(tt.mode, password, salt, secret, data, 3, 32, 4, 32)
+			require.Equal(t, tt.tag, hex.EncodeToString(key))
+		})
+	}
+}
+
+func TestArgon2_EqualsXCrypto(t *testing.T) {
+	key := argon2Key(argon2id, []byte("password"), []byte("somesaltsomesalt"), nil, nil, 2, 64, 2, 64)
+	require.Equal(t, argon2.IDKey([]byte("password"), []byte("somesaltsomesalt"), 2, 64, 2, 64), key)
+}
@@ -0,0 +1,486 @@
+// Package kdbx reads KeePass KDBX 4 databases.
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/twofish"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+var (
+	ErrorNotKDBX          = errors.New("file is not KeePass database")
+	ErrorVersion          = errors.New("unsupported KeePass database version, KDBX 4 required")
+	ErrorUnsupported      = errors.New("unsupported KeePass database parameter")
+	ErrorWrongCredentials = errors.New("wrong password or key file")
+	ErrorCorrupted        = errors.New("KeePass database is corrupted")
+)
+
+// Signature is first bytes of KDBX file
+var Signature = []byte{0x03, 0xd9, 0xa2, 0x9a, 0x67, 0xfb, 0x4b, 0xb5}
+
+// outer header field ids
+const (
+	headerEnd         = 0
+	headerCipherID    = 2
+	headerCompression = 3
+	headerMasterSeed  = 4
+	headerEncrytionIV = 7
+	headerKDFParams   = 11
+)
+
+// limits of KDF parameters read from file, crafted file must not hang client or exhaust memory
+const (
+	maxAESRounds        = 300_000_000
+	maxArgon2Memory     = 4 << 30
+	maxArgon2Iterations = 10_000
+	maxArgon2Lanes      = 256
+)
+
+// inner header field ids
+const (
+	innerEnd       = 0
+	innerStreamID  = 1
+	innerStreamKey = 2
+	innerBinary    = 3
+)
+
+var (
+	cipherAES256   = mustUUID("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustUUID("d6038a2b8b6f4cb5a524339a31dbb59a")
+	cipherTwofish  = mustUUID("ad68f29f576f4bb9a36ad47af965346c")
+
+	kdfAES      = mustUUID("c9d9f39a628a4460bf740d08c18a4fea")
+	kdfArgon2d  = mustUUID("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id = mustUUID("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// Credentials are database composite key components
+type Credentials struct {
+	Password string
+	KeyFile  []byte
+}
+
+// Database is decrypted KeePass database
+type Database struct {
+	Root Group
+}
+
+// Group is KeePass group with entries and subgroups
+type Group struct {
+	UUID    string
+	Name    string
+	Entries []Entry
+	Groups  []Group
+}
+
+// Entry is KeePass entry, History holds previous versions, oldest first
+type Entry struct {
+	UUID        string
+	Tags        []string
+	Fields      []Field
+	Attachments []Attachment
+	History     []Entry
+}
+
+// Field is entry string field
+type Field struct {
+	Key       string
+	Value     string
+	Protected bool
+}
+
+// Attachment is entry binary
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Get returns value of entry field
+func (e Entry) Get(key string) string {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+
+	return ""
+}
+
+type header struct {
+	cipherID    [16]byte
+	compressed  bool
+	masterSeed  []byte
+	iv          []byte
+	kdf         variantDictionary
+	raw         []byte
+	payloadFrom int
+}
+
+// Read decrypts KDBX 4 database
+func Read(data []byte, cred Credentials) (*Database, error) {
+	h, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	composite, err := compositeKey(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	transformed, err := transformKey(composite, h.kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	//  header hash and hmac follow header
+	if len(data) < h.payloadFrom+64 {
+		return nil, ErrorCorrupted
+	}
+	hash := sha256.Sum256(h.raw)
+	if !bytes.Equal(hash[:], data[h.payloadFrom:h.payloadFrom+32]) {
+		return nil, fmt.Errorf("%w: header hash mismatch", ErrorCorrupted)
+	}
+
+	hmacKey := sha512.Sum512(append(append(append([]byte{}, h.masterSeed...), transformed...), 0x01))
+	if !bytes.Equal(blockHMAC(hmacKey[:], ^uint64(0), h.raw), data[h.payloadFrom+32:h.payloadFrom+64]) {
+		return nil, ErrorWrongCredentials
+	}
+
+	encrypted, err := readBlocks(data[h.payloadFrom+64:], hmacKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	masterKey := sha256.Sum256(append(append([]byte{}, h.masterSeed...), transformed...))
+	payload, err := decryptPayload(h, masterKey[:], encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.compressed {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorCorrupted, err)
+		}
+		if payload, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorCorrupted, err)
+		}
+	}
+
+	stream, binaries, xmlData, err := readInnerHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseXML(xmlData, stream, binaries)
+}
+
+// readHeader reads signature, version and outer header fields
+func readHeader(data []byte) (header, error) {
+	h := header{}
+	if len(data) < 12 || !bytes.Equal(data[:8], Signature) {
+		return h, ErrorNotKDBX
+	}
+
+	if major := binary.LittleEndian.Uint16(data[10:12]); major != 4 {
+		return h, fmt.Errorf("%w: version %v", ErrorVersion, major)
+	}
+
+	pos := 12
+	for {
+		if len(data) < pos+5 {
+			return h, ErrorCorrupted
+		}
+
+		id := data[pos]
+		size := int(binary.LittleEndian.Uint32(data[pos+1 : pos+5]))
+		pos += 5
+		if size < 0 || len(data) < pos+size {
+			return h, ErrorCorrupted
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		switch id {
+		case headerEnd:
+			h.raw = data[:pos]
+			h.payloadFrom = pos
+			if h.masterSeed == nil || h.iv == nil || h.kdf == nil {
+				return h, fmt.Errorf("%w: required header field is missing", ErrorCorrupted)
+			}
+			return h, nil
+		case headerCipherID:
+			if len(value) != 16 {
+				return h, ErrorCorrupted
+			}
+			copy(h.cipherID[:], value)
+		case headerCompression:
+			if len(value) != 4 {
+				return h, ErrorCorrupted
+			}
+			h.compressed = binary.LittleEndian.Uint32(value) == 1
+		case headerMasterSeed:
+			h.masterSeed = value
+		case headerEncrytionIV:
+			h.iv = value
+		case headerKDFParams:
+			kdf, err := readVariantDictionary(value)
+			if err != nil {
+				return h, err
+			}
+			h.kdf = kdf
+		}
+	}
+}
+
+// compositeKey returns composite key of password and key file
+func compositeKey(cred Credentials) ([]byte, error) {
+	var parts []byte
+
+	if cred.Password != "" || len(cred.KeyFile) == 0 {
+		p := sha256.Sum256([]byte(cred.Password))
+		parts = append(parts, p[:]...)
+	}
+
+	if len(cred.KeyFile) > 0 {
+		k, err := keyFileKey(cred.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, k...)
+	}
+
+	res := sha256.Sum256(parts)
+	return res[:], nil
+}
+
+// transformKey applies KDF to composite key
+func transformKey(composite []byte, params variantDictionary) ([]byte, error) {
+	uuid, ok := params["$UUID"].([]byte)
+	if !ok || len(uuid) != 16 {
+		return nil, fmt.Errorf("%w: kdf uuid", ErrorCorrupted)
+	}
+
+	switch {
+	case bytes.Equal(uuid, kdfAES[:]):
+		seed, _ := params["S"].([]byte)
+		rounds, _ := params["R"].(uint64)
+		if len(seed) != 32 {
+			return nil, fmt.Errorf("%w: aes kdf seed", ErrorCorrupted)
+		}
+		if rounds > maxAESRounds {
+			return nil, fmt.Errorf("%w: aes kdf rounds %v exceed %v", model.ErrorParamNotValid, rounds, maxAESRounds)
+		}
+
+		return aesKDF(composite, seed, rounds)
+
+	case bytes.Equal(uuid, kdfArgon2d[:]), bytes.Equal(uuid, kdfArgon2id[:]):
+		mode := argon2d
+		if bytes.Equal(uuid, kdfArgon2id[:]) {
+			mode = argon2id
+		}
+
+		salt, _ := params["S"].([]byte)
+		parallelism, _ := params["P"].(uint32)
+		memory, _ := params["M"].(uint64)
+		iterations, _ := params["I"].(uint64)
+		version, _ := params["V"].(uint32)
+		secret, _ := params["K"].([]byte)
+		assoc, _ := params["A"].([]byte)
+
+		if version != argon2Version {
+			return nil, fmt.Errorf("%w: argon2 version %x", ErrorUnsupported, version)
+		}
+		if len(salt) == 0 || parallelism == 0 || iterations == 0 || memory < 1024 {
+			return nil, fmt.Errorf("%w: argon2 parameters", ErrorCorrupted)
+		}
+		if memory > maxArgon2Memory || iterations > maxArgon2Iterations || parallelism > maxArgon2Lanes {
+			return nil, fmt.Errorf("%w: argon2 parameters exceed limits: memory %v, iterations %v, parallelism %v",
+				model.ErrorParamNotValid, memory, iterations, parallelism)
+		}
+
+		return argon2Key(mode, composite, salt, secret, assoc,
+			uint32(iterations), uint32(memory/1024), parallelism, 32), nil
+	}
+
+	return nil, fmt.Errorf("%w: kdf %x", ErrorUnsupported, uuid)
+}
+
+// aesKDF encrypts key with aes ecb rounds times
+func aesKDF(key []byte, seed []byte, rounds uint64) ([]byte, error) {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	res := append([]byte{}, key...)
+	for i := uint64(0); i < rounds; i++ {
+		block.Encrypt(res[:16], res[:16])
+		block.Encrypt(res[16:], res[16:])
+	}
+
+	sum := sha256.Sum256(res)
+	return sum[:], nil
+}
+
+// blockHMAC returns hmac of data with block key for index
+func blockHMAC(baseKey []byte, index uint64, data []byte) []byte {
+	var idx [8]byte
+	binary.LittleEndian.PutUint64(idx[:], index)
+	key := sha512.Sum512(append(idx[:], baseKey...))
+
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+// readBlocks reads hmac block stream, checks blocks hmac
+func readBlocks(data []byte, hmacKey []byte) ([]byte, error) {
+	var res []byte
+
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, fmt.Errorf("%w: unexpected end of blocks", ErrorCorrupted)
+		}
+
+		mac := data[:32]
+		size := int(binary.LittleEndian.Uint32(data[32:36]))
+		if size < 0 || len(data) < 36+size {
+			return nil, fmt.Errorf("%w: block size", ErrorCorrupted)
+		}
+
+		var idx [8]byte
+		binary.LittleEndian.PutUint64(idx[:], index)
+		signed := append(append(idx[:], data[32:36]...), data[36:36+size]...)
+		if !hmac.Equal(mac, blockHMAC(hmacKey, index, signed)) {
+			return nil, fmt.Errorf("%w: block %v hmac mismatch", ErrorCorrupted, index)
+		}
+
+		if size == 0 {
+			return res, nil
+		}
+
+		res = append(res, data[36:36+size]...)
+		data = data[36+size:]
+	}
+}
+
+// decryptPayload decrypts payload with header cipher
+func decryptPayload(h header, key []byte, data []byte) ([]byte, error) {
+	switch h.cipherID {
+	case cipherChaCha20:
+		if len(h.iv) != chacha20.NonceSize {
+			return nil, fmt.Errorf("%w: chacha20 iv", ErrorCorrupted)
+		}
+		c, err := chacha20.NewUnauthenticatedCipher(key, h.iv)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]byte, len(data))
+		c.XORKeyStream(res, data)
+
+		return res, nil
+
+	case cipherAES256, cipherTwofish:
+		var block cipher.Block
+		var err error
+		if h.cipherID == cipherAES256 {
+			block, err = aes.NewCipher(key)
+		} else {
+			block, err = twofish.NewCipher(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(h.iv) != block.BlockSize() || len(data)%block.BlockSize() != 0 || len(data) == 0 {
+			return nil, fmt.Errorf("%w: cbc data", ErrorCorrupted)
+		}
+		res := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, h.iv).CryptBlocks(res, data)
+
+		//  pkcs7 padding
+		pad := int(res[len(res)-1])
+		if pad == 0 || pad > block.BlockSize() {
+			return nil, fmt.Errorf("%w: padding", ErrorCorrupted)
+		}
+
+		return res[:len(res)-pad], nil
+	}
+
+	return nil, fmt.Errorf("%w: cipher %x", ErrorUnsupported, h.cipherID)
+}
+
+// readInnerHeader reads inner header, returns protected values stream, binaries and xml
+func readInnerHeader(data []byte) (cipher.Stream, [][]byte, []byte, error) {
+	var (
+		streamID  uint32
+		streamKey []byte
+		binaries  [][]byte
+	)
+
+	pos := 0
+	for {
+		if len(data) < pos+5 {
+			return nil, nil, nil, fmt.Errorf("%w: inner header", ErrorCorrupted)
+		}
+
+		id := data[pos]
+		size := int(binary.LittleEndian.Uint32(data[pos+1 : pos+5]))
+		pos += 5
+		if size < 0 || len(data) < pos+size {
+			return nil, nil, nil, fmt.Errorf("%w: inner header", ErrorCorrupted)
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		switch id {
+		case innerEnd:
+			stream, err := newInnerStream(streamID, streamKey)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return stream, binaries, data[pos:], nil
+		case innerStreamID:
+			if len(value) != 4 {
+				return nil, nil, nil, fmt.Errorf("%w: inner stream id", ErrorCorrupted)
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case innerStreamKey:
+			streamKey = value
+		case innerBinary:
+			if len(value) == 0 {
+				return nil, nil, nil, fmt.Errorf("%w: binary", ErrorCorrupted)
+			}
+			//  first byte is binary flags
+			binaries = append(binaries, value[1:])
+		}
+	}
+}
+
+func mustUUID(s string) [16]byte {
+	var res [16]byte
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != len(res) {
+		panic("kdbx: invalid uuid " + s)
+	}
+	copy(res[:], raw)
+
+	return res
+}
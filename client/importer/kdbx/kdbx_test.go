@@ -0,0 +1,365 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+const keyFileV2 = `<?xml version="1.0" encoding="utf-8"?>
+<KeyFile>
+	<Meta>
+		<Version>2.0</Version>
+	</Meta>
+	<Key>
+		<Data Hash="%s">
+			%s
+		</Data>
+	</Key>
+</KeyFile>`
+
+func TestRead(t *testing.T) {
+	rawKey := bytes.Repeat([]byte{0x11}, 32)
+	keyHash := sha256.Sum256(rawKey)
+	keyFile := []byte(fmt.Sprintf(keyFileV2, hex.EncodeToString(keyHash[:4]), hex.EncodeToString(rawKey)))
+
+	tests := []struct {
+		name   string
+		params testParams
+		cred   Credentials
+	}{
+		{
+			name:   "aes kdf aes cipher",
+			params: testParams{cipher: cipherAES256, kdf: kdfAES, stream: streamChaCha20, password: "pass", compress: true},
+			cred:   Credentials{Password: "pass"},
+		},
+		{
+			name:   "argon2d kdf chacha20 cipher salsa20 stream",
+			params: testParams{cipher: cipherChaCha20, kdf: kdfArgon2d, stream: streamSalsa20, password: "pass"},
+			cred:   Credentials{Password: "pass"},
+		},
+		{
+			name:   "password and key file",
+			params: testParams{cipher: cipherAES256, kdf: kdfArgon2id, stream: streamChaCha20, password: "pass", keyFile: rawKey},
+			cred:   Credentials{Password: "pass", KeyFile: keyFile},
+		},
+		{
+			name:   "only key file",
+			params: testParams{cipher: cipherAES256, kdf: kdfAES, stream: streamChaCha20, keyFile: rawKey},
+			cred:   Credentials{KeyFile: rawKey},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeTestDB(t, tt.params)
+
+			db, err := Read(data, tt.cred)
+			require.NoError(t, err)
+
+			require.Equal(t, "Root", db.Root.Name)
+			require.Len(t, db.Root.Entries, 1)
+			require.Len(t, db.Root.Groups, 1)
+
+			mail := db.Root.Entries[0]
+			assert.Equal(t, "Mail", mail.Get("Title"))
+			assert.Equal(t, "petr", mail.Get("UserName"))
+			assert.Equal(t, "secret", mail.Get("Password"))
+			assert.Equal(t, "1234", mail.Get("PIN"))
+			assert.Equal(t, []string{"work", "mail"}, mail.Tags)
+			assert.Equal(t, []Attachment{{Name: "note.txt", Data: []byte("attached note")}}, mail.Attachments)
+
+			require.Len(t, mail.History, 1)
+			assert.Equal(t, "first", mail.History[0].Get("Password"))
+
+			//  recycle bin is skipped
+			work := db.Root.Groups[0]
+			assert.Equal(t, "Work", work.Name)
+			require.Len(t, work.Entries, 1)
+			assert.Equal(t, "vpn", work.Entries[0].Get("Password"))
+		})
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	data := writeTestDB(t, testParams{cipher: cipherAES256, kdf: kdfAES, stream: streamChaCha20, password: "pass"})
+
+	_, err := Read(data, Credentials{Password: "wrong"})
+	require.ErrorIs(t, err, ErrorWrongCredentials)
+
+	_, err = Read([]byte("not a database"), Credentials{})
+	require.ErrorIs(t, err, ErrorNotKDBX)
+
+	v3 := append([]byte{}, data...)
+	binary.LittleEndian.PutUint16(v3[10:12], 3)
+	_, err = Read(v3, Credentials{Password: "pass"})
+	require.ErrorIs(t, err, ErrorVersion)
+
+	broken := append([]byte{}, data...)
+	broken[len(broken)-50] ^= 0xff
+	_, err = Read(broken, Credentials{Password: "pass"})
+	require.ErrorIs(t, err, ErrorCorrupted)
+}
+
+func TestTransformKey_Limits(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x03}, 32)
+	argon2Params := func(memory uint64, iterations uint64, parallelism uint32) variantDictionary {
+		return variantDictionary{"$UUID": kdfArgon2id[:], "V": uint32(argon2Version), "S": seed,
+			"M": memory, "I": iterations, "P": parallelism}
+	}
+
+	tests := []struct {
+		name   string
+		params variantDictionary
+	}{
+		{
+			name:   "aes kdf rounds",
+			params: variantDictionary{"$UUID": kdfAES[:], "S": seed, "R": uint64(1) << 40},
+		},
+		{
+			name:   "argon2 memory",
+			params: argon2Params(1<<40, 2, 2),
+		},
+		{
+			name:   "argon2 iterations",
+			params: argon2Params(64*1024, 1<<32, 2),
+		},
+		{
+			name:   "argon2 parallelism",
+			params: argon2Params(64*1024, 2, 1<<20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := transformKey(make([]byte, 32), tt.params)
+			require.ErrorIs(t, err, model.ErrorParamNotValid)
+		})
+	}
+}
+
+type testParams struct {
+	cipher   [16]byte
+	kdf      [16]byte
+	stream   uint32
+	password string
+	keyFile  []byte
+	compress bool
+}
+
+// writeTestDB writes KDBX 4 database with test content
+func writeTestDB(t *testing.T, p testParams) []byte {
+	seed := bytes.Repeat([]byte{0x01}, 32)
+	innerKey := bytes.Repeat([]byte{0x02}, 64)
+
+	kdf := variantWriter{}
+	kdf.add(variantBytes, "$UUID", p.kdf[:])
+	if p.kdf == kdfAES {
+		kdf.add(variantUInt64, "R", u64(100))
+		kdf.add(variantBytes, "S", bytes.Repeat([]byte{0x03}, 32))
+	} else {
+		kdf.add(variantUInt32, "V", u32(argon2Version))
+		kdf.add(variantBytes, "S", bytes.Repeat([]byte{0x03}, 32))
+		kdf.add(variantUInt32, "P", u32(2))
+		kdf.add(variantUInt64, "M", u64(64*1024))
+		kdf.add(variantUInt64, "I", u64(2))
+	}
+
+	var iv []byte
+	if p.cipher == cipherChaCha20 {
+		iv = bytes.Repeat([]byte{0x04}, 12)
+	} else {
+		iv = bytes.Repeat([]byte{0x04}, 16)
+	}
+
+	compression := uint32(0)
+	if p.compress {
+		compression = 1
+	}
+
+	head := bytes.Buffer{}
+	head.Write(Signature)
+	head.Write([]byte{0x01, 0x00, 0x04, 0x00})
+	writeField(&head, headerCipherID, p.cipher[:])
+	writeField(&head, headerCompression, u32(compression))
+	writeField(&head, headerMasterSeed, seed)
+	writeField(&head, headerEncrytionIV, iv)
+	writeField(&head, headerKDFParams, kdf.bytes())
+	writeField(&head, headerEnd, []byte{0x0d, 0x0a, 0x0d, 0x0a})
+
+	params, err := readVariantDictionary(kdf.bytes())
+	require.NoError(t, err)
+
+	cred := Credentials{Password: p.password, KeyFile: p.keyFile}
+	composite, err := compositeKey(cred)
+	require.NoError(t, err)
+	transformed, err := transformKey(composite, params)
+	require.NoError(t, err)
+
+	hmacKey := sha512.Sum512(append(append(append([]byte{}, seed...), transformed...), 0x01))
+	masterKey := sha256.Sum256(append(append([]byte{}, seed...), transformed...))
+
+	//  inner header and xml
+	stream, err := newInnerStream(p.stream, innerKey)
+	require.NoError(t, err)
+
+	inner := bytes.Buffer{}
+	writeField(&inner, innerStreamID, u32(p.stream))
+	writeField(&inner, innerStreamKey, innerKey)
+	writeField(&inner, innerBinary, append([]byte{0x01}, []byte("attached note")...))
+	writeField(&inner, innerEnd, nil)
+	inner.WriteString(testXML(stream))
+
+	payload := inner.Bytes()
+	if p.compress {
+		buf := bytes.Buffer{}
+		gz := gzip.NewWriter(&buf)
+		_, err = gz.Write(payload)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		payload = buf.Bytes()
+	}
+
+	var encrypted []byte
+	if p.cipher == cipherChaCha20 {
+		c, err := chacha20.NewUnauthenticatedCipher(masterKey[:], iv)
+		require.NoError(t, err)
+		encrypted = make([]byte, len(payload))
+		c.XORKeyStream(encrypted, payload)
+	} else {
+		block, err := aes.NewCipher(masterKey[:])
+		require.NoError(t, err)
+		pad := aes.BlockSize - len(payload)%aes.BlockSize
+		payload = append(payload, bytes.Repeat([]byte{byte(pad)}, pad)...)
+		encrypted = make([]byte, len(payload))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, payload)
+	}
+
+	hash := sha256.Sum256(head.Bytes())
+	out := bytes.Buffer{}
+	out.Write(head.Bytes())
+	out.Write(hash[:])
+	out.Write(blockHMAC(hmacKey[:], ^uint64(0), head.Bytes()))
+
+	//  data block and final empty block
+	for i, block := range [][]byte{encrypted, nil} {
+		var idx [8]byte
+		binary.LittleEndian.PutUint64(idx[:], uint64(i))
+		size := u32(uint32(len(block)))
+		out.Write(blockHMAC(hmacKey[:], uint64(i), append(append(idx[:], size...), block...)))
+		out.Write(size)
+		out.Write(block)
+	}
+
+	return out.Bytes()
+}
+
+// testXML returns database xml, protected values are encrypted in document order
+func testXML(stream cipher.Stream) string {
+	protect := func(s string) string {
+		raw := []byte(s)
+		stream.XORKeyStream(raw, raw)
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+	uuid := func(b byte) string {
+		return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 16))
+	}
+
+	return `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<RecycleBinEnabled>True</RecycleBinEnabled>
+		<RecycleBinUUID>` + uuid(9) + `</RecycleBinUUID>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>` + uuid(1) + `</UUID>
+			<Name>Root</Name>
+			<Entry>
+				<UUID>` + uuid(2) + `</UUID>
+				<Tags>work;mail</Tags>
+				<String><Key>Title</Key><Value>Mail</Value></String>
+				<String><Key>UserName</Key><Value>petr</Value></String>
+				<String><Key>Password</Key><Value Protected="True">` + protect("secret") + `</Value></String>
+				<String><Key>PIN</Key><Value Protected="True">` + protect("1234") + `</Value></String>
+				<Binary><Key>note.txt</Key><Value Ref="0" /></Binary>
+				<History>
+					<Entry>
+						<UUID>` + uuid(2) + `</UUID>
+						<String><Key>Title</Key><Value>Mail</Value></String>
+						<String><Key>Password</Key><Value Protected="True">` + protect("first") + `</Value></String>
+					</Entry>
+				</History>
+			</Entry>
+			<Group>
+				<UUID>` + uuid(3) + `</UUID>
+				<Name>Work</Name>
+				<Entry>
+					<UUID>` + uuid(4) + `</UUID>
+					<String><Key>Title</Key><Value>VPN</Value></String>
+					<String><Key>Password</Key><Value Protected="True">` + protect("vpn") + `</Value></String>
+				</Entry>
+			</Group>
+			<Group>
+				<UUID>` + uuid(9) + `</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<UUID>` + uuid(5) + `</UUID>
+					<String><Key>Title</Key><Value>Deleted</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+}
+
+type variantWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *variantWriter) add(kind byte, key string, value []byte) {
+	if w.buf.Len() == 0 {
+		w.buf.Write([]byte{0x00, 0x01})
+	}
+	w.buf.WriteByte(kind)
+	w.buf.Write(u32(uint32(len(key))))
+	w.buf.WriteString(key)
+	w.buf.Write(u32(uint32(len(value))))
+	w.buf.Write(value)
+}
+
+func (w *variantWriter) bytes() []byte {
+	return append(append([]byte{}, w.buf.Bytes()...), variantEnd)
+}
+
+func writeField(buf *bytes.Buffer, id byte, value []byte) {
+	buf.WriteByte(id)
+	buf.Write(u32(uint32(len(value))))
+	buf.Write(value)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func u64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,76 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type keyFileXML struct {
+	Meta struct {
+		Version string `xml:"Version"`
+	} `xml:"Meta"`
+	Key struct {
+		Data struct {
+			Hash  string `xml:"Hash,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"Key"`
+}
+
+// keyFileKey returns key from key file,
+// supported xml key files version 1 and 2, 32 bytes binary, 64 hex chars and any other file hash
+func keyFileKey(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<KeyFile")) {
+		kf := keyFileXML{}
+		if err := xml.Unmarshal(trimmed, &kf); err == nil {
+			return xmlKeyFileKey(kf)
+		}
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+func xmlKeyFileKey(kf keyFileXML) ([]byte, error) {
+	value := strings.Join(strings.Fields(kf.Key.Data.Value), "")
+
+	if strings.HasPrefix(kf.Meta.Version, "1.") {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key file data", ErrorCorrupted)
+		}
+		return key, nil
+	}
+
+	if strings.HasPrefix(kf.Meta.Version, "2.") {
+		key, err := hex.DecodeString(value)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%w: key file data", ErrorCorrupted)
+		}
+
+		hash := sha256.Sum256(key)
+		if kf.Key.Data.Hash != "" && !strings.EqualFold(hex.EncodeToString(hash[:4]), kf.Key.Data.Hash) {
+			return nil, fmt.Errorf("%w: key file hash mismatch", ErrorCorrupted)
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: key file version %v", ErrorUnsupported, kf.Meta.Version)
+}
@@ -0,0 +1,64 @@
+package kdbx
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+// inner random stream ids
+const (
+	streamSalsa20  = 2
+	streamChaCha20 = 3
+)
+
+// salsaNonce is fixed KeePass Salsa20 nonce
+var salsaNonce = []byte{0xe8, 0x30, 0x09, 0x4b, 0x97, 0x20, 0x5d, 0x2a}
+
+// newInnerStream returns cipher stream for protected values
+func newInnerStream(id uint32, key []byte) (cipher.Stream, error) {
+	switch id {
+	case streamChaCha20:
+		hash := sha512.Sum512(key)
+		return chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+	case streamSalsa20:
+		return newSalsaStream(sha256.Sum256(key)), nil
+	}
+
+	return nil, fmt.Errorf("%w: inner stream %v", ErrorUnsupported, id)
+}
+
+// salsaStream is Salsa20 cipher.Stream, salsa20 package has only stateless function
+type salsaStream struct {
+	key     [32]byte
+	counter [16]byte
+	block   [64]byte
+	used    int
+}
+
+func newSalsaStream(key [32]byte) *salsaStream {
+	s := &salsaStream{key: key, used: 64}
+	copy(s.counter[:8], salsaNonce)
+
+	return s
+}
+
+// XORKeyStream implements cipher.Stream
+func (s *salsaStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == len(s.block) {
+			var zero [64]byte
+			salsa.XORKeyStream(s.block[:], zero[:], &s.counter, &s.key)
+			binary.LittleEndian.PutUint64(s.counter[8:], binary.LittleEndian.Uint64(s.counter[8:])+1)
+			s.used = 0
+		}
+
+		dst[i] = src[i] ^ s.block[s.used]
+		s.used++
+	}
+}
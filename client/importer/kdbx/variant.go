@@ -0,0 +1,97 @@
+package kdbx
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// variant dictionary value types
+const (
+	variantEnd    = 0x00
+	variantUInt32 = 0x04
+	variantUInt64 = 0x05
+	variantBool   = 0x08
+	variantInt32  = 0x0C
+	variantInt64  = 0x0D
+	variantString = 0x18
+	variantBytes  = 0x42
+)
+
+// variantDictionary is KDBX 4 typed key-value map, used for kdf parameters
+type variantDictionary map[string]interface{}
+
+// readVariantDictionary parses variant dictionary
+func readVariantDictionary(data []byte) (variantDictionary, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("%w: variant dictionary", ErrorCorrupted)
+	}
+	//  only major version is checked
+	if data[1] != 0x01 {
+		return nil, fmt.Errorf("%w: variant dictionary version %x", ErrorUnsupported, data[:2])
+	}
+
+	res := variantDictionary{}
+	pos := 2
+	for {
+		if len(data) < pos+1 {
+			return nil, fmt.Errorf("%w: variant dictionary", ErrorCorrupted)
+		}
+
+		kind := data[pos]
+		pos++
+		if kind == variantEnd {
+			return res, nil
+		}
+
+		if len(data) < pos+4 {
+			return nil, fmt.Errorf("%w: variant dictionary", ErrorCorrupted)
+		}
+		keyLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		if keyLen < 0 || len(data) < pos+keyLen+4 {
+			return nil, fmt.Errorf("%w: variant dictionary", ErrorCorrupted)
+		}
+		key := string(data[pos : pos+keyLen])
+		pos += keyLen
+
+		valueLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		if valueLen < 0 || len(data) < pos+valueLen {
+			return nil, fmt.Errorf("%w: variant dictionary", ErrorCorrupted)
+		}
+		value := data[pos : pos+valueLen]
+		pos += valueLen
+
+		switch kind {
+		case variantUInt32, variantInt32:
+			if valueLen != 4 {
+				return nil, fmt.Errorf("%w: variant %v", ErrorCorrupted, key)
+			}
+			if kind == variantUInt32 {
+				res[key] = binary.LittleEndian.Uint32(value)
+			} else {
+				res[key] = int32(binary.LittleEndian.Uint32(value))
+			}
+		case variantUInt64, variantInt64:
+			if valueLen != 8 {
+				return nil, fmt.Errorf("%w: variant %v", ErrorCorrupted, key)
+			}
+			if kind == variantUInt64 {
+				res[key] = binary.LittleEndian.Uint64(value)
+			} else {
+				res[key] = int64(binary.LittleEndian.Uint64(value))
+			}
+		case variantBool:
+			if valueLen != 1 {
+				return nil, fmt.Errorf("%w: variant %v", ErrorCorrupted, key)
+			}
+			res[key] = value[0] != 0
+		case variantString:
+			res[key] = string(value)
+		case variantBytes:
+			res[key] = append([]byte{}, value...)
+		default:
+			return nil, fmt.Errorf("%w: variant type %x", ErrorUnsupported, kind)
+		}
+	}
+}
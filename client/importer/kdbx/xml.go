@@ -0,0 +1,234 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// node is xml element
+type node struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*node
+}
+
+// child returns first child element with name
+func (n *node) child(name string) *node {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// childText returns text of first child element with name
+func (n *node) childText(name string) string {
+	if c := n.child(name); c != nil {
+		return c.text
+	}
+
+	return ""
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// parseXML parses database xml, protected values are decrypted with stream in document order
+func parseXML(data []byte, stream cipher.Stream, binaries [][]byte) (*Database, error) {
+	root, err := readTree(data, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.name != "KeePassFile" {
+		return nil, fmt.Errorf("%w: root element %v", ErrorCorrupted, root.name)
+	}
+
+	recycleBin := ""
+	if meta := root.child("Meta"); meta != nil {
+		if meta.childText("RecycleBinEnabled") != "False" {
+			recycleBin = meta.childText("RecycleBinUUID")
+		}
+	}
+
+	rootNode := root.child("Root")
+	if rootNode == nil || rootNode.child("Group") == nil {
+		return nil, fmt.Errorf("%w: database has no root group", ErrorCorrupted)
+	}
+
+	p := xmlParser{binaries: binaries, recycleBin: recycleBin}
+	group, err := p.group(rootNode.child("Group"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Database{Root: group}, nil
+}
+
+// readTree reads xml document to element tree
+func readTree(data []byte, stream cipher.Stream) (*node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		stack []*node
+		root  *node
+	)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorCorrupted, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: t.Copy().Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: unexpected end element", ErrorCorrupted)
+			}
+
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if n.attr("Protected") == "True" {
+				//  protected value are decrypted in document order
+				raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(n.text))
+				if err != nil {
+					return nil, fmt.Errorf("%w: protected value", ErrorCorrupted)
+				}
+				stream.XORKeyStream(raw, raw)
+				n.text = string(raw)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("%w: empty xml", ErrorCorrupted)
+	}
+
+	return root, nil
+}
+
+type xmlParser struct {
+	binaries   [][]byte
+	recycleBin string
+}
+
+func (p xmlParser) group(n *node) (Group, error) {
+	g := Group{
+		UUID: uuidString(n.childText("UUID")),
+		Name: n.childText("Name"),
+	}
+
+	for _, c := range n.children {
+		switch c.name {
+		case "Entry":
+			e, err := p.entry(c)
+			if err != nil {
+				return Group{}, err
+			}
+			g.Entries = append(g.Entries, e)
+		case "Group":
+			if p.recycleBin != "" && c.childText("UUID") == p.recycleBin {
+				continue
+			}
+			sub, err := p.group(c)
+			if err != nil {
+				return Group{}, err
+			}
+			g.Groups = append(g.Groups, sub)
+		}
+	}
+
+	return g, nil
+}
+
+func (p xmlParser) entry(n *node) (Entry, error) {
+	e := Entry{UUID: uuidString(n.childText("UUID"))}
+
+	for _, tag := range strings.FieldsFunc(n.childText("Tags"), func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			e.Tags = append(e.Tags, tag)
+		}
+	}
+
+	for _, c := range n.children {
+		switch c.name {
+		case "String":
+			value := c.child("Value")
+			f := Field{Key: c.childText("Key")}
+			if value != nil {
+				f.Value = value.text
+				f.Protected = value.attr("Protected") == "True"
+			}
+			e.Fields = append(e.Fields, f)
+
+		case "Binary":
+			value := c.child("Value")
+			if value == nil {
+				continue
+			}
+			ref, err := strconv.Atoi(value.attr("Ref"))
+			if err != nil || ref < 0 || ref >= len(p.binaries) {
+				return Entry{}, fmt.Errorf("%w: binary reference %q", ErrorCorrupted, value.attr("Ref"))
+			}
+			e.Attachments = append(e.Attachments, Attachment{Name: c.childText("Key"), Data: p.binaries[ref]})
+
+		case "History":
+			for _, h := range c.children {
+				if h.name != "Entry" {
+					continue
+				}
+				he, err := p.entry(h)
+				if err != nil {
+					return Entry{}, err
+				}
+				e.History = append(e.History, he)
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// uuidString returns hex uuid from base64 value
+func uuidString(s string) string {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(raw)
+}
@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Xrefullx/YanDip/client/importer/kdbx"
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// keepass standard entry fields
+var keePassStandardFields = map[string]struct{}{
+	"Title":    {},
+	"UserName": {},
+	"Password": {},
+	"URL":      {},
+	"Notes":    {},
+}
+
+// parseKeePass reads KeePass KDBX 4 database
+// groups below root are mapped to folders, attachments to binary secrets
+func parseKeePass(data []byte, opts Options) ([]Record, error) {
+	db, err := kdbx.Read(data, kdbx.Credentials{Password: opts.Password, KeyFile: opts.KeyFile})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	var walk func(g kdbx.Group, folder string)
+	walk = func(g kdbx.Group, folder string) {
+		for _, e := range g.Entries {
+			rec := Record{Item: keePassItem(e, folder)}
+			for _, h := range e.History {
+				rec.History = append(rec.History, keePassItem(h, folder))
+			}
+			records = append(records, rec)
+
+			for _, a := range e.Attachments {
+				records = append(records, Record{Item: model.Binary{
+					Info: model.Info{
+						TypeID: model.SecretTypes["BINARY"],
+						Title:  e.Get("Title") + "/" + a.Name,
+						Folder: folder,
+						Tags:   e.Tags,
+					},
+					Data:        a.Data,
+					ContentType: http.DetectContentType(a.Data),
+					Filename:    a.Name,
+				}})
+			}
+		}
+
+		for _, sub := range g.Groups {
+			walk(sub, strings.TrimPrefix(folder+"/"+sub.Name, "/"))
+		}
+	}
+	walk(db.Root, "")
+
+	return records, nil
+}
+
+// keePassItem converts entry to auth, or to text if entry has no credentials
+func keePassItem(e kdbx.Entry, folder string) interface{} {
+	var fields []model.CustomField
+	for _, f := range e.Fields {
+		if _, ok := keePassStandardFields[f.Key]; ok || f.Value == "" {
+			continue
+		}
+		fields = append(fields, model.CustomField{Name: f.Key, Value: f.Value, Hidden: f.Protected})
+	}
+
+	info := model.Info{
+		Title:  e.Get("Title"),
+		Folder: folder,
+		Tags:   e.Tags,
+	}
+
+	if e.Get("UserName") == "" && e.Get("Password") == "" && e.Get("URL") == "" {
+		info.TypeID = model.SecretTypes["TEXT"]
+		return model.Text{
+			Info:   info,
+			Text:   e.Get("Notes"),
+			Fields: fields,
+		}
+	}
+
+	info.TypeID = model.SecretTypes["AUTH"]
+	info.Description = e.Get("Notes")
+
+	return model.Auth{
+		Info:     info,
+		Login:    e.Get("UserName"),
+		Password: e.Get("Password"),
+		URL:      e.Get("URL"),
+		Fields:   fields,
+	}
+}
@@ -0,0 +1,294 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// onePasswordPUXData is name of data file in 1pux archive
+const onePasswordPUXData = "export.data"
+
+// 1password item categories
+const (
+	onePasswordLogin    = "001"
+	onePasswordCard     = "002"
+	onePasswordPassword = "005"
+)
+
+// parseOnePasswordCSV reads 1password csv export
+// Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes
+func parseOnePasswordCSV(data []byte, _ Options) ([]Record, error) {
+	cols, rows, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		auth := model.Auth{
+			Info: model.Info{
+				TypeID:      model.SecretTypes["AUTH"],
+				Title:       cols.get(row, "title"),
+				Description: cols.get(row, "notes"),
+				Tags:        splitTags(cols.get(row, "tags")),
+			},
+			Login:    cols.get(row, "username"),
+			Password: cols.get(row, "password"),
+			URL:      cols.get(row, "url"),
+		}
+
+		if otp := cols.get(row, "otpauth"); otp != "" {
+			auth.Fields = append(auth.Fields, model.CustomField{Name: "totp", Value: otp, Hidden: true})
+		}
+
+		records = append(records, Record{Item: auth})
+	}
+
+	return records, nil
+}
+
+type onePasswordPUX struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePasswordItem struct {
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Overview     struct {
+		Title string   `json:"title"`
+		URL   string   `json:"url"`
+		Tags  []string `json:"tags"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Name        string `json:"name"`
+			Designation string `json:"designation"`
+			FieldType   string `json:"fieldType"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                 `json:"title"`
+				ID    string                 `json:"id"`
+				Value map[string]interface{} `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+// parseOnePasswordPUX reads 1password 1pux archive
+func parseOnePasswordPUX(data []byte, _ Options) ([]Record, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var exportData []byte
+	for _, f := range zr.File {
+		if f.Name != onePasswordPUXData {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		exportData, err = io.ReadAll(rc)
+		if errClose := rc.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if exportData == nil {
+		return nil, fmt.Errorf("archive has no %v", onePasswordPUXData)
+	}
+
+	var export onePasswordPUX
+	if err := json.Unmarshal(exportData, &export); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				if item.State == "archived" {
+					continue
+				}
+
+				records = append(records, Record{Item: item.toSecret(vault.Attrs.Name)})
+			}
+		}
+	}
+
+	return records, nil
+}
+
+// toSecret maps 1password item to secret object by category
+func (i onePasswordItem) toSecret(folder string) interface{} {
+	info := model.Info{
+		Title:       i.Overview.Title,
+		Description: i.Details.NotesPlain,
+		Folder:      folder,
+		Tags:        i.Overview.Tags,
+	}
+
+	//  section values by field id, all of section fields goes to custom fields
+	var fields []model.CustomField
+	byID := make(map[string]string)
+	idx := make(map[string]int)
+	for _, section := range i.Details.Sections {
+		for _, f := range section.Fields {
+			value, hidden := onePasswordValue(f.Value)
+			if value == "" {
+				continue
+			}
+
+			name := f.Title
+			if name == "" {
+				name = f.ID
+			}
+
+			byID[f.ID] = value
+			idx[f.ID] = len(fields)
+			fields = append(fields, model.CustomField{Name: name, Value: value, Hidden: hidden})
+		}
+	}
+
+	switch i.CategoryUUID {
+	case onePasswordLogin, onePasswordPassword:
+		info.TypeID = model.SecretTypes["AUTH"]
+		auth := model.Auth{
+			Info:     info,
+			URL:      i.Overview.URL,
+			Password: i.Details.Password,
+			Fields:   fields,
+		}
+		for _, f := range i.Details.LoginFields {
+			switch f.Designation {
+			case "username":
+				auth.Login = f.Value
+			case "password":
+				auth.Password = f.Value
+			}
+		}
+
+		return auth
+
+	case onePasswordCard:
+		info.TypeID = model.SecretTypes["CARD"]
+		card := model.Card{
+			Info:           info,
+			CardholderName: byID["cardholder"],
+			PAN:            byID["ccnum"],
+			ServiceCode:    atoi(byID["cvv"]),
+		}
+
+		//  expiry is monthYear value as yyyymm
+		if exp := atoi(byID["expiry"]); exp > 0 {
+			card.ExpirationYear = exp / 100
+			card.ExpirationMonth = exp % 100
+		}
+
+		//  mapped to card fields values are not duplicated in custom fields
+		for _, id := range []string{"cardholder", "ccnum", "cvv", "expiry"} {
+			if n, ok := idx[id]; ok {
+				fields[n].Name = ""
+			}
+		}
+		for _, f := range fields {
+			if f.Name != "" {
+				card.Fields = append(card.Fields, f)
+			}
+		}
+
+		return card
+	}
+
+	//  secure notes, identities and other categories imported as text
+	info.TypeID = model.SecretTypes["TEXT"]
+	info.Description = ""
+
+	return model.Text{
+		Info:   info,
+		Text:   i.Details.NotesPlain,
+		Fields: fields,
+	}
+}
+
+// onePasswordValue reads typed 1password field value {"<type>": value}, returns value and hidden flag
+func onePasswordValue(v map[string]interface{}) (string, bool) {
+	for _, kind := range sortedKeys(v) {
+		value := stringify(v[kind])
+		if value == "" {
+			continue
+		}
+
+		return value, kind == "concealed" || kind == "totp"
+	}
+
+	return "", false
+}
+
+// splitTags splits comma or semicolon separated tags
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// sortedKeys returns map keys in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// stringify converts json value to string
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return fmt.Sprintf("%.0f", val)
+	case bool:
+		return fmt.Sprintf("%v", val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
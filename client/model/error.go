@@ -3,6 +3,18 @@ package model
 import "errors"
 
 var (
-	ErrorItemNotFound  = errors.New("item not found")
-	ErrorParamNotValid = errors.New("incoming parameter not valid")
+	ErrorItemNotFound   = errors.New("item not found")
+	ErrorParamNotValid  = errors.New("incoming parameter not valid")
+	ErrorAuditLogBroken = errors.New("audit log is broken")
+	ErrorCursorExpired  = errors.New("sync cursor expired")
+	//  request is not valid for server, retry gives the same result
+	ErrorRequestRejected = errors.New("request rejected by server")
+	//  client has no token or token is rejected by server
+	ErrorNotAuthorized = errors.New("client not authorized")
+	//  upload is not based on current server version, remote version must be merged first
+	ErrorVersionConflict = errors.New("version conflict with server")
+	//  secret is stored as placeholder, data must be downloaded first
+	ErrorNotDownloaded = errors.New("secret data is not downloaded")
+	//  server presented key other than pinned key, key must be checked and pinned again
+	ErrorServerKeyChanged = errors.New("server key does not match pinned key")
 )
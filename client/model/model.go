@@ -26,13 +26,75 @@ var (
 		"EDITED":  2,
 		"ACTUAL":  3,
 		"DELETED": 4,
+		//  local edit conflicts with newer remote version, secret is not synced until conflict is resolved
+		"CONFLICT": 5,
+		//  synced secret is unknown to server without tombstone, secret is not synced until user keeps or deletes it
+		"ORPHANED": 6,
+	}
+
+	// ConflictStrategies are ways sync conflict is resolved
+	ConflictStrategies = map[string]int{
+		//  conflict is saved and waits for user resolution
+		"MANUAL": 1,
+		//  local edit is uploaded over remote version
+		"KEEP_LOCAL": 2,
+		//  remote version replaces local edit, local edit is kept in secret history
+		"KEEP_REMOTE": 3,
+		//  local edit is forked into new secret, remote version replaces local edit
+		"KEEP_BOTH": 4,
+	}
+
+	// RevisionCauses are reasons of secret revision
+	RevisionCauses = map[string]int{
+		"LOCAL_EDIT": 1,
+		"DOWNLOAD":   2,
+		"CONFLICT":   3,
+		"RESTORE":    4,
+	}
+
+	// PayloadStates are states of local copy of secret data
+	PayloadStates = map[string]int{
+		"LOCAL": 0,
+		//  only description is stored, data is downloaded on first open
+		"PLACEHOLDER": 1,
+		//  secret is excluded from device, data is not downloaded
+		"EXCLUDED": 2,
+	}
+
+	// AuditOperations are operations recorded in audit log
+	AuditOperations = map[string]int{
+		"REVEAL":        1,
+		"COPY":          2,
+		"ADD":           3,
+		"EDIT":          4,
+		"TRASH":         5,
+		"RESTORE":       6,
+		"EXPORT":        7,
+		"IMPORT":        8,
+		"SYNC_UPLOAD":   9,
+		"SYNC_DOWNLOAD": 10,
+		"SYNC_DELETE":   11,
+		"SYNC_DETACH":   12,
+		"EVICT":         13,
+		"SYNC_ORPHAN":   14,
 	}
 )
 
 type Info struct {
-	TypeID      int    `json:"type_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	TypeID      int      `json:"type_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Folder      string   `json:"folder,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	//  time secret was moved to trash in milliseconds, 0 if secret is not in trash
+	TrashedAt int64 `json:"trashed_at,omitempty"`
+}
+
+// CustomField is a named value attached to secret, kept inside encrypted data
+type CustomField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Hidden bool   `json:"hidden,omitempty"`
 }
 
 type Informer interface {
@@ -50,6 +112,8 @@ type Card struct {
 	ExpirationMonth int    `json:"expiration_month"`
 	ExpirationYear  int    `json:"expiration_year"`
 	ServiceCode     int    `json:"code"`
+
+	Fields []CustomField `json:"fields,omitempty"`
 }
 
 func (c *Card) GetInfo() Info {
@@ -62,10 +126,15 @@ type Auth struct {
 	Info
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	URL      string `json:"url,omitempty"`
+
+	Fields []CustomField `json:"fields,omitempty"`
 }
 type Text struct {
 	Info
 	Text string `json:"text"`
+
+	Fields []CustomField `json:"fields,omitempty"`
 }
 
 func (a *Auth) GetInfo() Info {
@@ -89,13 +158,179 @@ type Secret struct {
 	TimeStamp int64
 
 	SecretData string
+	//  encrypted data of last synced version, base of three-way merge on conflict
+	BaseData string
+	//  state of local copy of data, one of PayloadStates
+	PayloadID int
+	//  size of encrypted data
+	Size int
+	//  version vector of last synced version, local edit is based on it
+	Vector VersionVector
+	//  hash of data at the moment it was stored, calculated by storage
+	DataHash string
+}
+
+// LocalChange is notification of secret written to storage
+type LocalChange struct {
+	ID int64
+	//  status of secret after write, 0 if secret is deleted
+	StatusID int
 }
+
+// ImportBatch describes one import run, secrets added by it can be rolled back together
+type ImportBatch struct {
+	ID        int64
+	Format    string
+	Source    string
+	Count     int
+	TimeStamp int64
+}
+
+// Revision is previous encrypted state of secret, saved before secret was overwritten
+type Revision struct {
+	Info
+
+	ID          int64
+	SecretLocID int64
+	CauseID     int
+	SecretVer   int
+	TimeStamp   int64
+
+	SecretData string
+}
+
+// Conflict is locally edited secret which has newer remote version,
+// remote version is kept until conflict is resolved
+type Conflict struct {
+	ID          int64
+	SecretLocID int64
+	SecretID    uuid.UUID
+	LocalVer    int
+	RemoteVer   int
+	TimeStamp   int64
+	//  fields changed both locally and remotely, empty if secret has no synced base version
+	Fields []string
+
+	RemoteData   string
+	RemoteVector VersionVector
+}
+
+// AuditEntry is record of audit log, it holds only metadata of operation, never secret values.
+// Hash is HMAC of entry fields and PrevHash, so entries form a chain.
+type AuditEntry struct {
+	Seq         int64
+	TimeStamp   int64
+	OperationID int
+	SecretLocID int64
+	SecretID    uuid.UUID
+	Source      string
+	Details     string
+
+	PrevHash string
+	Hash     string
+}
+
+// AuditHead points to last entry of audit log.
+// MAC is HMAC of Seq and Hash, so head can not be moved back to hide removed last entries.
+type AuditHead struct {
+	Seq  int64
+	Hash string
+	MAC  string
+}
+
+// AuditFilter is audit log query, zero fields are not used
+type AuditFilter struct {
+	SecretLocID int64
+	SecretID    uuid.UUID
+	OperationID int
+	From        int64
+	To          int64
+}
+
 type SecretMeta struct {
 	ID        int64
 	SecretID  uuid.UUID
 	SecretVer int
 	StatusID  int
+	PayloadID int
 	TimeStamp int64
+	//  hash of data at the moment it was stored
+	DataHash string
+}
+
+// SecretChange is change of remote secret from server change feed
+type SecretChange struct {
+	SecretID  uuid.UUID
+	SecretVer int
+	IsDeleted bool
+	//  deletion time and device, set if deleted
+	DeletedAt int64
+	DeletedBy uuid.UUID
+}
+
+// SyncChanges is list of remote changes after sync cursor and cursor of last change
+type SyncChanges struct {
+	Changes []SecretChange
+	Cursor  int64
+}
+
+// RemoteSecret is secret of batch sync request or response, Err is error of item in response
+type RemoteSecret struct {
+	SecretID  uuid.UUID
+	SecretVer int
+	Data      string
+	//  encrypted secret description, empty if uploaded without it
+	Meta string
+	//  size of encrypted data
+	Size int
+	//  version vector, for upload it is vector of version edit is based on
+	Vector VersionVector
+	Err    error
+}
+
+// ChangeEvent is notification of remote secret change pushed by server
+type ChangeEvent struct {
+	SecretID  uuid.UUID
+	SecretVer int
+	IsDeleted bool
+}
+
+// Tombstone is server record of deleted secret
+type Tombstone struct {
+	SecretID  uuid.UUID
+	SecretVer int
+	DeletedAt int64
+	DeletedBy uuid.UUID
+}
+
+// SyncList is remote state of secrets: versions of existing secrets, tombstones of deleted,
+// and change feed cursor of state
+type SyncList struct {
+	List       map[uuid.UUID]int
+	Tombstones map[uuid.UUID]Tombstone
+	Cursor     int64
+	//  hashes of remote data, not set for list built from changes and for secrets without hash
+	Hashes map[uuid.UUID]string
+}
+
+// ToEncodedMeta returns description of secret encrypted with master key
+func (s Info) ToEncodedMeta(masterKey string) (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	return pkg.Encode(data, masterKey)
+}
+
+// FromEncodedMeta reads description of secret encrypted with master key
+func (s *Info) FromEncodedMeta(enc string, masterKey string) error {
+	decData, err := pkg.Decode(enc, masterKey)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decData, s)
 }
 
 func (s *Info) FromEncodedData(enc string, masterKey string) error {
@@ -106,3 +341,44 @@ func (s *Info) FromEncodedData(enc string, masterKey string) error {
 
 	return json.Unmarshal(decData, s)
 }
+
+// ServerPin is SPKI pin of server key trusted for host.
+// Pin is sha256 of server certificate public key, stored on first connection to host.
+type ServerPin struct {
+	Host      string
+	Pin       string
+	TimeStamp int64
+}
+
+// DeviceIdentity is key and certificate of vault device.
+// Key is PEM private key encrypted with master key, certificate is issued by server on first login of device.
+type DeviceIdentity struct {
+	DeviceID    uuid.UUID
+	Key         string
+	Certificate string
+	TimeStamp   int64
+}
+
+// Device is device of user enrolled on server, time is unix time in milliseconds
+type Device struct {
+	ID        uuid.UUID
+	CreatedAt int64
+	//  0 if device is not revoked
+	RevokedAt int64
+	//  device of vault
+	Current bool
+}
+
+// SyncJournalEntry is state of failed sync task.
+// Task is retried after NextRetryAt, parked task is not retried until it is reset.
+type SyncJournalEntry struct {
+	TaskKey     string
+	ActionID    int
+	SecretLocID int64
+	SecretID    uuid.UUID
+	Attempts    int
+	LastError   string
+	NextRetryAt int64
+	Parked      bool
+	TimeStamp   int64
+}
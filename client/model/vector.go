@@ -0,0 +1,42 @@
+package model
+
+// VectorOrders are results of version vectors comparison
+var VectorOrders = map[string]int{
+	"EQUAL": 0,
+	//  vector includes all changes of other vector and more
+	"DESCENDANT": 1,
+	//  other vector includes all changes of vector and more
+	"ANCESTOR": 2,
+	//  both vectors have changes missing in other
+	"CONCURRENT": 3,
+}
+
+// VersionVector is count of changes of secret made by every device, key is device id.
+// Vector is stamped by server, client keeps vector of last synced version.
+type VersionVector map[string]int
+
+// Compare returns order of vector relative to other vector, one of VectorOrders
+func (v VersionVector) Compare(other VersionVector) int {
+	newer, older := false, false
+	for device, count := range v {
+		if count > other[device] {
+			newer = true
+		}
+	}
+	for device, count := range other {
+		if count > v[device] {
+			older = true
+		}
+	}
+
+	switch {
+	case newer && older:
+		return VectorOrders["CONCURRENT"]
+	case newer:
+		return VectorOrders["DESCENDANT"]
+	case older:
+		return VectorOrders["ANCESTOR"]
+	default:
+		return VectorOrders["EQUAL"]
+	}
+}
@@ -12,7 +12,40 @@ type Config struct {
 	SyncTimeoutSec    int
 	RequestsPerMinute int
 	ServerURL         string
-	StorageFile       string
+	//  transport of server requests, one of HTTP|GRPC
+	Transport string
+	//  address of server gRPC service <address>:<port>, used with GRPC transport
+	GRPCAddress string
+	StorageFile string
+	//  PEM bundle of CA certificates server is verified with, system roots if empty
+	CAFile string
+	//  SPKI pin of server key sha256/<base64>, overrides key pinned in vault
+	ServerPin string
+	//  pinning of server key, one of OFF|TOFU
+	PinMode string
+	//  count of kept previous revisions of each secret, 0 disables history
+	RevisionsKeep int
+	//  days secret stays in trash before it is deleted
+	TrashRetentionDays int
+	//  name of strategy applied to sync conflicts, one of model.ConflictStrategies
+	ConflictStrategy string
+	//  max count of secrets uploaded or downloaded with one sync request
+	BatchSize int
+	//  failed attempts of sync task before it is parked
+	SyncMaxAttempts int
+	//  binary secrets with data over size are downloaded on first open, 0 downloads all
+	LazySize int
+	//  comma separated secret types and tags not downloaded to device
+	ExcludeTypes string
+	ExcludeTags  string
+	//  secrets with data over size are not downloaded to device, 0 disables rule
+	ExcludeSize int
+	//  count of sync batches processed concurrently
+	SyncWorkers int
+	//  local changes are uploaded after no other changes for delay
+	SyncDebounceMs int
+	//  max interval of server polling, polling slows down to it while idle or offline
+	SyncMaxTimeoutSec int
 }
 
 // Default config params.
@@ -21,9 +54,48 @@ const (
 	defSyncTimeout       = 2
 	defRequestsPerMinute = 100
 	defServerURL         = "https://localhost:8085"
+	defTransport         = "HTTP"
+	defGRPCAddress       = "localhost:3200"
 	defStorageFile       = "storage.db"
+	defPinMode           = "TOFU"
+	defRevisionsKeep     = 10
+	defTrashRetention    = 30
+	defConflictStrategy  = "MANUAL"
+	defBatchSize         = 100
+	defSyncMaxAttempts   = 8
+	defSyncWorkers       = 4
+	defSyncDebounce      = 500
+	defSyncMaxTimeout    = 60
 )
 
+// conflictStrategies are names of sync conflict strategies
+var conflictStrategies = map[string]struct{}{
+	"MANUAL":      {},
+	"KEEP_LOCAL":  {},
+	"KEEP_REMOTE": {},
+	"KEEP_BOTH":   {},
+}
+
+// transports are names of server transports
+var transports = map[string]struct{}{
+	"HTTP": {},
+	"GRPC": {},
+}
+
+// pinModes are names of server key pinning modes
+var pinModes = map[string]struct{}{
+	"OFF":  {},
+	"TOFU": {},
+}
+
+// secretTypes are names of secret types
+var secretTypes = map[string]struct{}{
+	"CARD":   {},
+	"AUTH":   {},
+	"TEXT":   {},
+	"BINARY": {},
+}
+
 // NewConfig inits new config.
 // Reads flag params over default params, then redefines  with environment params.
 func NewConfig() (*Config, error) {
@@ -45,6 +117,18 @@ func (c *Config) Validate() error {
 	if len(c.ServerURL) == 0 {
 		return errors.New("server url is empty")
 	}
+	if _, ok := transports[c.Transport]; !ok {
+		return fmt.Errorf("unknown transport %q", c.Transport)
+	}
+	if c.Transport == "GRPC" && len(c.GRPCAddress) == 0 {
+		return errors.New("grpc address is empty")
+	}
+	if _, ok := pinModes[c.PinMode]; !ok {
+		return fmt.Errorf("unknown pin mode %q", c.PinMode)
+	}
+	if c.ServerPin != "" && !IsSPKIPin(c.ServerPin) {
+		return fmt.Errorf("server pin %q is not sha256/<base64> pin", c.ServerPin)
+	}
 	if len(c.StorageFile) == 0 {
 		return errors.New("storage filename is empty")
 	}
@@ -54,6 +138,38 @@ func (c *Config) Validate() error {
 	if c.RequestsPerMinute == 0 {
 		return errors.New("requests per minute is 0")
 	}
+	if c.RevisionsKeep < 0 {
+		return errors.New("revisions count is negative")
+	}
+	if c.TrashRetentionDays <= 0 {
+		return errors.New("trash retention must be positive")
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("sync batch size must be positive")
+	}
+	if c.SyncMaxAttempts <= 0 {
+		return errors.New("sync attempts count must be positive")
+	}
+	if c.SyncWorkers <= 0 {
+		return errors.New("sync workers count must be positive")
+	}
+	if c.SyncDebounceMs < 0 {
+		return errors.New("sync debounce delay must not be negative")
+	}
+	if c.SyncMaxTimeoutSec < c.SyncTimeoutSec {
+		return errors.New("max sync timeout is less than sync timeout")
+	}
+	if c.LazySize < 0 || c.ExcludeSize < 0 {
+		return errors.New("sync size limits must not be negative")
+	}
+	for _, el := range SplitList(c.ExcludeTypes) {
+		if _, ok := secretTypes[el]; !ok {
+			return fmt.Errorf("unknown excluded secret type %q", el)
+		}
+	}
+	if _, ok := conflictStrategies[c.ConflictStrategy]; !ok {
+		return fmt.Errorf("unknown conflict strategy %q", c.ConflictStrategy)
+	}
 
 	return nil
 }
@@ -65,7 +181,24 @@ func (c *Config) readFlagConfig() {
 	flag.IntVar(&flagConfig.SyncTimeoutSec, "t", defSyncTimeout, "sync timeout in seconds")
 	flag.IntVar(&flagConfig.RequestsPerMinute, "r", defRequestsPerMinute, "sync action requests per minute")
 	flag.StringVar(&flagConfig.ServerURL, "s", defServerURL, "server address http(s)://<address>:<port>")
+	flag.StringVar(&flagConfig.Transport, "transport", defTransport, "server transport HTTP|GRPC")
+	flag.StringVar(&flagConfig.GRPCAddress, "grpc", defGRPCAddress, "server gRPC address <address>:<port>")
 	flag.StringVar(&flagConfig.StorageFile, "db", defStorageFile, "storage filename")
+	flag.StringVar(&flagConfig.CAFile, "ca", "", "PEM bundle of CA certificates to verify server, system roots if empty")
+	flag.StringVar(&flagConfig.ServerPin, "pin", "", "expected server key pin sha256/<base64>, overrides pinned key")
+	flag.StringVar(&flagConfig.PinMode, "pin-mode", defPinMode, "server key pinning OFF|TOFU, TOFU pins key on first connection")
+	flag.IntVar(&flagConfig.RevisionsKeep, "rev", defRevisionsKeep, "count of kept revisions of each secret")
+	flag.IntVar(&flagConfig.TrashRetentionDays, "trash", defTrashRetention, "days deleted secrets are kept in trash")
+	flag.IntVar(&flagConfig.BatchSize, "batch", defBatchSize, "max count of secrets in one sync request")
+	flag.IntVar(&flagConfig.SyncMaxAttempts, "attempts", defSyncMaxAttempts, "failed attempts of sync task before it is parked")
+	flag.IntVar(&flagConfig.SyncWorkers, "workers", defSyncWorkers, "count of sync batches processed concurrently")
+	flag.IntVar(&flagConfig.SyncDebounceMs, "debounce", defSyncDebounce, "delay in milliseconds local changes are uploaded after")
+	flag.IntVar(&flagConfig.SyncMaxTimeoutSec, "tmax", defSyncMaxTimeout, "max server polling timeout in seconds while idle or offline")
+	flag.IntVar(&flagConfig.LazySize, "lazy", 0, "binary secrets over size in bytes are downloaded on first open, 0 downloads all")
+	flag.StringVar(&flagConfig.ExcludeTypes, "exclude-types", "", "comma separated secret types not downloaded to device")
+	flag.StringVar(&flagConfig.ExcludeTags, "exclude-tags", "", "comma separated tags of secrets not downloaded to device")
+	flag.IntVar(&flagConfig.ExcludeSize, "exclude-size", 0, "secrets over size in bytes are not downloaded to device, 0 disables rule")
+	flag.StringVar(&flagConfig.ConflictStrategy, "conflict", defConflictStrategy, "sync conflict strategy MANUAL|KEEP_LOCAL|KEEP_REMOTE|KEEP_BOTH")
 
 	flag.Parse()
 	c.redefineConfig(flagConfig)
@@ -84,10 +217,61 @@ func (c *Config) redefineConfig(nc *Config) {
 	if nc.ServerURL != "" {
 		c.ServerURL = nc.ServerURL
 	}
+	if nc.Transport != "" {
+		c.Transport = nc.Transport
+	}
+	if nc.GRPCAddress != "" {
+		c.GRPCAddress = nc.GRPCAddress
+	}
+	if nc.CAFile != "" {
+		c.CAFile = nc.CAFile
+	}
+	if nc.ServerPin != "" {
+		c.ServerPin = nc.ServerPin
+	}
+	if nc.PinMode != "" {
+		c.PinMode = nc.PinMode
+	}
 	if nc.SyncTimeoutSec != 0 {
 		c.SyncTimeoutSec = nc.SyncTimeoutSec
 	}
 	if nc.RequestsPerMinute != 0 {
 		c.RequestsPerMinute = nc.RequestsPerMinute
 	}
+	if nc.RevisionsKeep > 0 {
+		c.RevisionsKeep = nc.RevisionsKeep
+	}
+	if nc.TrashRetentionDays != 0 {
+		c.TrashRetentionDays = nc.TrashRetentionDays
+	}
+	if nc.ConflictStrategy != "" {
+		c.ConflictStrategy = nc.ConflictStrategy
+	}
+	if nc.BatchSize != 0 {
+		c.BatchSize = nc.BatchSize
+	}
+	if nc.SyncMaxAttempts != 0 {
+		c.SyncMaxAttempts = nc.SyncMaxAttempts
+	}
+	if nc.SyncWorkers != 0 {
+		c.SyncWorkers = nc.SyncWorkers
+	}
+	if nc.SyncDebounceMs != 0 {
+		c.SyncDebounceMs = nc.SyncDebounceMs
+	}
+	if nc.SyncMaxTimeoutSec != 0 {
+		c.SyncMaxTimeoutSec = nc.SyncMaxTimeoutSec
+	}
+	if nc.LazySize != 0 {
+		c.LazySize = nc.LazySize
+	}
+	if nc.ExcludeTypes != "" {
+		c.ExcludeTypes = nc.ExcludeTypes
+	}
+	if nc.ExcludeTags != "" {
+		c.ExcludeTags = nc.ExcludeTags
+	}
+	if nc.ExcludeSize != 0 {
+		c.ExcludeSize = nc.ExcludeSize
+	}
 }
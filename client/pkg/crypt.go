@@ -5,10 +5,16 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"strings"
 )
 
+// spkiPinPrefix is prefix of SPKI pin, sha256 is only supported hash
+const spkiPinPrefix = "sha256/"
+
 // GenerateRandom generates random string size N
 func GenerateRandom(size int) ([]byte, error) {
 	b := make([]byte, size)
@@ -49,6 +55,40 @@ func Encode(src []byte, key string) (string, error) {
 	return based64, nil
 }
 
+// MasterHash returns hash of master key sent to server on auth.
+// Hash of encryption key is used, so the key itself can not be restored from it.
+func MasterHash(key string) string {
+	key32 := sha256.Sum256([]byte(key))
+	hash := sha256.Sum256(key32[:])
+
+	return hex.EncodeToString(hash[:])
+}
+
+// DataHash returns hash of encrypted secret data, equal to hash calculated by server for the same data
+func DataHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// SPKIPin returns pin of certificate public key, pin does not change while server key is the same
+func SPKIPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return spkiPinPrefix + base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// IsSPKIPin returns true if pin is sha256/<base64> pin of public key
+func IsSPKIPin(pin string) bool {
+	if !strings.HasPrefix(pin, spkiPinPrefix) {
+		return false
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, spkiPinPrefix))
+
+	return err == nil && len(hash) == sha256.Size
+}
+
 // Decode decodes bytes array
 func Decode(src string, key string) ([]byte, error) {
 	data, err := base64.StdEncoding.DecodeString(src)
@@ -1,8 +1,23 @@
 package pkg
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // MakeTimestamp returns timestamp
 func MakeTimestamp() int64 {
 	return time.Now().UnixNano() / int64(time.Millisecond)
 }
+
+// SplitList splits comma separated list, empty items are skipped
+func SplitList(s string) []string {
+	res := make([]string, 0)
+	for _, el := range strings.Split(s, ",") {
+		if el = strings.TrimSpace(el); el != "" {
+			res = append(res, el)
+		}
+	}
+
+	return res
+}
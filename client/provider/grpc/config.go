@@ -0,0 +1,16 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+type GRPCConfig struct {
+	Address string
+	//  connection is secured with TLS
+	EnableTLS bool
+	//  config of TLS connection, server is verified by system roots if nil
+	TLSConfig *tls.Config
+	//  timeout of unary calls, change events stream is not limited
+	Timeout time.Duration
+}
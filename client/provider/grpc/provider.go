@@ -0,0 +1,284 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/provider"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+var _ provider.SecretProvider = (*GRPCProvider)(nil)
+
+// publicMethods are called without token
+var publicMethods = map[string]struct{}{
+	"/secret.v1.Secrets/Register": {},
+	"/secret.v1.Secrets/Login":    {},
+	"/secret.v1.Secrets/Refresh":  {},
+}
+
+type GRPCProvider struct {
+	cfg GRPCConfig
+	//  dials server with provider options
+	dial func() (*grpc.ClientConn, error)
+
+	connMu sync.RWMutex
+	conn   *grpc.ClientConn
+	client secretpb.SecretsClient
+
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	//  refresh is made by one call at a time
+	refreshMu sync.Mutex
+	//  device identity, certificate is not requested if nil
+	identity provider.DeviceIdentity
+}
+
+// NewGRPCProvider returns new gRPC provider, connection is established on first call
+func NewGRPCProvider(cfg GRPCConfig, opts ...grpc.DialOption) (*GRPCProvider, error) {
+	p := &GRPCProvider{cfg: cfg}
+
+	creds := insecure.NewCredentials()
+	if cfg.EnableTLS {
+		tlsConfig := cfg.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)
+	opts = append(opts,
+		grpc.WithUnaryInterceptor(p.unaryAuth),
+		grpc.WithStreamInterceptor(p.streamAuth),
+	)
+
+	p.dial = func() (*grpc.ClientConn, error) {
+		return grpc.Dial(cfg.Address, opts...)
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return nil, fmt.Errorf("grpc connection error: %w", err)
+	}
+	p.conn, p.client = conn, secretpb.NewSecretsClient(conn)
+
+	return p, nil
+}
+
+// Close closes connection to server
+func (p *GRPCProvider) Close() error {
+	p.connMu.RLock()
+	defer p.connMu.RUnlock()
+
+	return p.conn.Close()
+}
+
+// SetIdentity sets device identity, certificate of device is requested on login
+func (p *GRPCProvider) SetIdentity(identity provider.DeviceIdentity) {
+	p.identity = identity
+}
+
+// secrets returns client of current connection
+func (p *GRPCProvider) secrets() secretpb.SecretsClient {
+	p.connMu.RLock()
+	defer p.connMu.RUnlock()
+
+	return p.client
+}
+
+// reconnect replaces connection to server, TLS handshake of new connection presents device certificate
+func (p *GRPCProvider) reconnect() error {
+	conn, err := p.dial()
+	if err != nil {
+		return fmt.Errorf("grpc connection error: %w", err)
+	}
+
+	p.connMu.Lock()
+	prev := p.conn
+	p.conn, p.client = conn, secretpb.NewSecretsClient(conn)
+	p.connMu.Unlock()
+
+	return prev.Close()
+}
+
+// PingAuth checks connection with server and authentication
+func (p *GRPCProvider) PingAuth(ctx context.Context) error {
+	if _, err := p.secrets().Ping(ctx, &emptypb.Empty{}); err != nil {
+		return fmt.Errorf("ping request error: %w", callError(err))
+	}
+
+	return nil
+}
+
+// SetToken sets auth token to provider
+func (p *GRPCProvider) SetToken(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token = token
+}
+
+// SetRefreshToken sets refresh token, expired auth token is refreshed with it
+func (p *GRPCProvider) SetRefreshToken(refreshToken string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refreshToken = refreshToken
+}
+
+// DropAuth drops auth token and refresh token of provider
+func (p *GRPCProvider) DropAuth() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token, p.refreshToken = "", ""
+}
+
+// getToken returns current auth token
+func (p *GRPCProvider) getToken() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.token
+}
+
+// withToken returns context with auth token metadata
+// returns error if provider is not authenticated
+func (p *GRPCProvider) withToken(ctx context.Context) (context.Context, error) {
+	token := p.getToken()
+	if token == "" {
+		return nil, model.ErrorNotAuthorized
+	}
+
+	return authContext(ctx, token), nil
+}
+
+// authContext returns context with token metadata
+func authContext(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// unaryAuth adds token to call, call is limited by timeout.
+// If server rejects token, token is refreshed and call is retried once, auth is dropped only if refresh token is rejected.
+func (p *GRPCProvider) unaryAuth(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	invoke := func(ctx context.Context) error {
+		if p.cfg.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.cfg.Timeout)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if _, ok := publicMethods[method]; ok {
+		return invoke(ctx)
+	}
+
+	token := p.getToken()
+	if token == "" {
+		return model.ErrorNotAuthorized
+	}
+
+	err := invoke(authContext(ctx, token))
+	if status.Code(err) != codes.Unauthenticated {
+		return err
+	}
+
+	newToken, refreshErr := p.refreshAuth(ctx, token)
+	if errors.Is(refreshErr, model.ErrorNotAuthorized) {
+		//  rejected call error is returned, caller reports lost auth
+		return err
+	}
+	if refreshErr != nil {
+		return refreshErr
+	}
+
+	err = invoke(authContext(ctx, newToken))
+	//  token issued just now is not accepted, device is revoked
+	if status.Code(err) == codes.Unauthenticated {
+		p.DropAuth()
+	}
+
+	return err
+}
+
+// refreshAuth refreshes expired token, returns new token.
+// If token is refreshed by other call already, returns its token.
+// Auth is dropped and model.ErrorNotAuthorized is returned if token can not be refreshed.
+func (p *GRPCProvider) refreshAuth(ctx context.Context, expired string) (string, error) {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	p.mu.Lock()
+	token, refreshToken := p.token, p.refreshToken
+	p.mu.Unlock()
+
+	if token != expired && token != "" {
+		return token, nil
+	}
+	if refreshToken == "" {
+		p.DropAuth()
+		return "", model.ErrorNotAuthorized
+	}
+
+	resp, err := p.secrets().Refresh(ctx, &secretpb.RefreshRequest{RefreshToken: refreshToken})
+	if status.Code(err) == codes.Unauthenticated {
+		p.DropAuth()
+		return "", callError(err)
+	}
+	if err != nil {
+		//  auth is kept, token is refreshed on next call
+		return "", fmt.Errorf("refresh token error: %w", callError(err))
+	}
+
+	p.mu.Lock()
+	p.token, p.refreshToken = resp.GetToken(), resp.GetRefreshToken()
+	p.mu.Unlock()
+
+	return resp.GetToken(), nil
+}
+
+// streamAuth adds token to stream
+func (p *GRPCProvider) streamAuth(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, err := p.withToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// callError converts grpc status of failed call to client errors
+func callError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.Unauthenticated:
+		return fmt.Errorf("%w: %s", model.ErrorNotAuthorized, st.Message())
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition, codes.Unimplemented:
+		return fmt.Errorf("%w: %s", model.ErrorRequestRejected, st.Message())
+	case codes.Aborted:
+		return fmt.Errorf("%w: %s", model.ErrorVersionConflict, st.Message())
+	case codes.OutOfRange:
+		return fmt.Errorf("%w: %s", model.ErrorCursorExpired, st.Message())
+	default:
+		return err
+	}
+}
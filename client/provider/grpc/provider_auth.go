@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+func (p *GRPCProvider) Authorise(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) error {
+	req, err := p.loginRequest(ctx, login, pass, masterHash, deviceID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.secrets().Login(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request error: %w", callError(err))
+	}
+
+	return p.setToken(ctx, deviceID, resp)
+}
+
+func (p *GRPCProvider) Register(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) error {
+	req, err := p.loginRequest(ctx, login, pass, masterHash, deviceID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.secrets().Register(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request error: %w", callError(err))
+	}
+
+	return p.setToken(ctx, deviceID, resp)
+}
+
+// loginRequest returns login request, certificate is requested if device has no certificate
+func (p *GRPCProvider) loginRequest(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) (*secretpb.LoginRequest, error) {
+	req := &secretpb.LoginRequest{
+		Login:      login,
+		Password:   pass,
+		MasterHash: masterHash,
+		DeviceId:   deviceID.String(),
+	}
+	if p.identity == nil {
+		return req, nil
+	}
+
+	csr, err := p.identity.CertificateRequest(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("error make device certificate request: %w", err)
+	}
+	req.Csr = string(csr)
+
+	return req, nil
+}
+
+// setToken sets token and refresh token of login response to provider, saves issued device certificate.
+// Connection made without certificate is replaced, next calls present it.
+func (p *GRPCProvider) setToken(ctx context.Context, deviceID uuid.UUID, resp *secretpb.LoginResponse) error {
+	if resp.GetToken() == "" {
+		return errors.New("token is empty")
+	}
+	p.SetToken(resp.GetToken())
+	p.SetRefreshToken(resp.GetRefreshToken())
+
+	if p.identity == nil || resp.GetCertificate() == "" {
+		return nil
+	}
+	if err := p.identity.SaveCertificate(ctx, deviceID, []byte(resp.GetCertificate())); err != nil {
+		return fmt.Errorf("error save device certificate: %w", err)
+	}
+
+	return p.reconnect()
+}
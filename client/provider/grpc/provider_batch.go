@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+// UploadSecrets uploads secrets with one request, returns server id and version of every item.
+// Not valid items are not sent, item error is set in result.
+func (p *GRPCProvider) UploadSecrets(ctx context.Context, items []model.RemoteSecret) ([]model.RemoteSecret, error) {
+	res := make([]model.RemoteSecret, len(items))
+
+	//  indexes of sent items
+	sent := make([]int, 0, len(items))
+	req := &secretpb.BatchUploadRequest{Items: make([]*secretpb.Secret, 0, len(items))}
+	for i, el := range items {
+		res[i] = model.RemoteSecret{SecretID: el.SecretID}
+
+		reqItem, err := encodeSecret(el)
+		if err != nil {
+			res[i].Err = err
+			continue
+		}
+
+		req.Items = append(req.Items, reqItem)
+		sent = append(sent, i)
+	}
+
+	if len(sent) == 0 {
+		return res, nil
+	}
+
+	resp, err := p.secrets().UploadSecrets(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error secrets upload: %w", callError(err))
+	}
+	if len(resp.GetItems()) != len(sent) {
+		return nil, fmt.Errorf("error secrets upload: %v results for %v items", len(resp.GetItems()), len(sent))
+	}
+
+	for i, el := range resp.GetItems() {
+		item := &res[sent[i]]
+		if item.Err = batchItemError(el); item.Err != nil {
+			continue
+		}
+
+		saved, err := decodeSecret(el.GetSecret())
+		if err != nil || saved.SecretVer == 0 {
+			item.Err = errors.New("upload response error: response not valid")
+			continue
+		}
+		item.SecretID, item.SecretVer, item.Vector = saved.SecretID, saved.SecretVer, saved.Vector
+	}
+
+	return res, nil
+}
+
+// DownloadSecrets downloads secrets with one request, returns id, version and data of every item
+func (p *GRPCProvider) DownloadSecrets(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error) {
+	return p.downloadSecrets(ctx, ids, false)
+}
+
+// DownloadSecretsMeta downloads encrypted description and size of secrets without data with one request
+func (p *GRPCProvider) DownloadSecretsMeta(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error) {
+	return p.downloadSecrets(ctx, ids, true)
+}
+
+// downloadSecrets makes batch download request, data is not downloaded if metaOnly is set
+func (p *GRPCProvider) downloadSecrets(ctx context.Context, ids []uuid.UUID, metaOnly bool) ([]model.RemoteSecret, error) {
+	req := &secretpb.BatchDownloadRequest{Ids: make([]string, 0, len(ids)), MetaOnly: metaOnly}
+	for _, id := range ids {
+		if id == uuid.Nil {
+			return nil, fmt.Errorf("%w : not valid download param", model.ErrorParamNotValid)
+		}
+		req.Ids = append(req.Ids, id.String())
+	}
+
+	if len(ids) == 0 {
+		return []model.RemoteSecret{}, nil
+	}
+
+	resp, err := p.secrets().DownloadSecrets(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error secrets download: %w", callError(err))
+	}
+	if len(resp.GetItems()) != len(ids) {
+		return nil, fmt.Errorf("error secrets download: %v results for %v items", len(resp.GetItems()), len(ids))
+	}
+
+	res := make([]model.RemoteSecret, len(ids))
+	for i, el := range resp.GetItems() {
+		res[i] = model.RemoteSecret{SecretID: ids[i]}
+		if res[i].Err = batchItemError(el); res[i].Err != nil {
+			continue
+		}
+
+		secret, err := decodeSecret(el.GetSecret())
+		if err != nil || secret.SecretID != ids[i] || secret.SecretVer == 0 || (!metaOnly && secret.Data == "") {
+			res[i].Err = errors.New("download response error: response not valid")
+			continue
+		}
+		res[i] = secret
+	}
+
+	return res, nil
+}
+
+// batchItemError returns error of batch response item, nil if item processed
+func batchItemError(item *secretpb.BatchItem) error {
+	code := codes.Code(item.GetCode())
+	if code == codes.OK {
+		return nil
+	}
+
+	err := callError(status.Error(code, item.GetError()))
+	return fmt.Errorf("item %v response: %w", item.GetSecret().GetId(), err)
+}
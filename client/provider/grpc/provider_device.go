@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+// GetDevices returns devices of user enrolled on server
+func (p *GRPCProvider) GetDevices(ctx context.Context) ([]model.Device, error) {
+	resp, err := p.secrets().ListDevices(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("error get devices: %w", callError(err))
+	}
+
+	res := make([]model.Device, 0, len(resp.GetDevices()))
+	for _, el := range resp.GetDevices() {
+		id, err := uuid.Parse(el.GetId())
+		if err != nil {
+			return nil, fmt.Errorf("error get devices: wrong device id: %w", err)
+		}
+		res = append(res, model.Device{
+			ID:        id,
+			CreatedAt: el.GetCreatedAt(),
+			RevokedAt: el.GetRevokedAt(),
+			Current:   el.GetCurrent(),
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeDevice revokes user device
+func (p *GRPCProvider) RevokeDevice(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return fmt.Errorf("%w : device id is empty", model.ErrorParamNotValid)
+	}
+
+	if _, err := p.secrets().RevokeDevice(ctx, &secretpb.DeviceID{Id: id.String()}); err != nil {
+		return fmt.Errorf("error revoke device: %w", callError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+// SubscribeChanges opens stream of remote changes.
+// Events channel is closed when stream is broken or ctx is done.
+func (p *GRPCProvider) SubscribeChanges(ctx context.Context) (<-chan model.ChangeEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	stream, err := p.secrets().SyncEvents(ctx, &emptypb.Empty{})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("events request error: %w", callError(err))
+	}
+
+	//  server sends header when subscription is active
+	if _, err := stream.Header(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("events request error: %w", callError(err))
+	}
+
+	events := make(chan model.ChangeEvent)
+	go func() {
+		defer close(events)
+		defer cancel()
+
+		readEvents(ctx, stream, events)
+	}()
+
+	return events, nil
+}
+
+// readEvents reads change events from stream until stream end
+func readEvents(ctx context.Context, stream secretpb.Secrets_SyncEventsClient, events chan<- model.ChangeEvent) {
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				log.Printf("events stream error: %v", callError(err))
+			}
+			return
+		}
+
+		id, err := uuid.Parse(e.GetId())
+		if err != nil {
+			log.Printf("error read change event: %v", err)
+			continue
+		}
+
+		select {
+		case events <- model.ChangeEvent{SecretID: id, SecretVer: int(e.GetVer()), IsDeleted: e.GetIsDeleted()}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
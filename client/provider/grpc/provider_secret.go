@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+// UploadSecret uploads secret with encrypted description to server, returns server id, version and vector.
+// Vector of item is vector of version edit is based on, if id is nil, creates new
+func (p *GRPCProvider) UploadSecret(ctx context.Context, item model.RemoteSecret) (model.RemoteSecret, error) {
+	req, err := encodeSecret(item)
+	if err != nil {
+		return model.RemoteSecret{}, err
+	}
+
+	resp, err := p.secrets().UploadSecret(ctx, req)
+	if err != nil {
+		return model.RemoteSecret{}, fmt.Errorf("error secret upload: %w", callError(err))
+	}
+
+	res, err := decodeSecret(resp)
+	if err != nil || res.SecretVer == 0 {
+		return model.RemoteSecret{}, errors.New("upload response error: response not valid")
+	}
+
+	return model.RemoteSecret{SecretID: res.SecretID, SecretVer: res.SecretVer, Vector: res.Vector}, nil
+}
+
+// DownloadSecret downloads secret from server
+func (p *GRPCProvider) DownloadSecret(ctx context.Context, id uuid.UUID) (model.RemoteSecret, error) {
+	if id == uuid.Nil {
+		return model.RemoteSecret{}, fmt.Errorf("%w : not valid download param", model.ErrorParamNotValid)
+	}
+
+	resp, err := p.secrets().GetSecret(ctx, &secretpb.SecretID{Id: id.String()})
+	if err != nil {
+		return model.RemoteSecret{}, fmt.Errorf("error secret download: %w", callError(err))
+	}
+
+	res, err := decodeSecret(resp)
+	if err != nil || res.SecretID != id || res.SecretVer == 0 || res.Data == "" {
+		return model.RemoteSecret{}, errors.New("download response error: response not valid")
+	}
+
+	return res, nil
+}
+
+// DeleteSecret deletes secret from server
+func (p *GRPCProvider) DeleteSecret(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return fmt.Errorf("%w : not valid delete param", model.ErrorParamNotValid)
+	}
+
+	if _, err := p.secrets().DeleteSecret(ctx, &secretpb.SecretID{Id: id.String()}); err != nil {
+		return fmt.Errorf("error secret delete: %w", callError(err))
+	}
+
+	return nil
+}
+
+// encodeSecret validates secret for upload and returns it as request.
+// Encrypted data is sent as raw bytes.
+func encodeSecret(item model.RemoteSecret) (*secretpb.Secret, error) {
+	if item.SecretID == uuid.Nil && item.SecretVer > 1 {
+		return nil, fmt.Errorf("%w: ver is %v and secret id is nil", model.ErrorParamNotValid, item.SecretVer)
+	}
+	if item.SecretVer == 0 {
+		return nil, fmt.Errorf("%w: ver is %v", model.ErrorParamNotValid, item.SecretVer)
+	}
+	if len(item.Data) == 0 {
+		return nil, fmt.Errorf("%w: data is empty", model.ErrorParamNotValid)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(item.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: data is not encrypted: %v", model.ErrorParamNotValid, err)
+	}
+
+	req := &secretpb.Secret{
+		Ver:    int32(item.SecretVer),
+		Data:   data,
+		Meta:   item.Meta,
+		Vector: make(map[string]int32, len(item.Vector)),
+	}
+	if item.SecretID != uuid.Nil {
+		req.Id = item.SecretID.String()
+	}
+	for device, count := range item.Vector {
+		req.Vector[device] = int32(count)
+	}
+
+	return req, nil
+}
+
+// decodeSecret returns secret of response, returns error if id is not valid
+func decodeSecret(resp *secretpb.Secret) (model.RemoteSecret, error) {
+	id, err := uuid.Parse(resp.GetId())
+	if err != nil || id == uuid.Nil {
+		return model.RemoteSecret{}, fmt.Errorf("wrong secret id %q", resp.GetId())
+	}
+
+	res := model.RemoteSecret{
+		SecretID:  id,
+		SecretVer: int(resp.GetVer()),
+		Meta:      resp.GetMeta(),
+		Size:      int(resp.GetSize()),
+	}
+	if len(resp.GetData()) > 0 {
+		res.Data = base64.StdEncoding.EncodeToString(resp.GetData())
+	}
+	if len(resp.GetVector()) > 0 {
+		res.Vector = make(model.VersionVector, len(resp.GetVector()))
+		for device, count := range resp.GetVector() {
+			res.Vector[device] = int(count)
+		}
+	}
+
+	return res, nil
+}
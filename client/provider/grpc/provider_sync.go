@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+// GetSyncList downloads files meta info and tombstones of deleted files from server
+func (p *GRPCProvider) GetSyncList(ctx context.Context) (model.SyncList, error) {
+	resp, err := p.secrets().SyncList(ctx, &emptypb.Empty{})
+	if err != nil {
+		return model.SyncList{}, fmt.Errorf("request error: %w", callError(err))
+	}
+
+	res := model.SyncList{
+		List:       make(map[uuid.UUID]int, len(resp.GetList())),
+		Hashes:     make(map[uuid.UUID]string, len(resp.GetHashes())),
+		Tombstones: make(map[uuid.UUID]model.Tombstone, len(resp.GetTombstones())),
+		Cursor:     resp.GetCursor(),
+	}
+	for key, ver := range resp.GetList() {
+		id, err := uuid.Parse(key)
+		if err != nil {
+			return model.SyncList{}, fmt.Errorf("sync list response error: %w", err)
+		}
+		res.List[id] = int(ver)
+	}
+	for key, hash := range resp.GetHashes() {
+		id, err := uuid.Parse(key)
+		if err != nil {
+			return model.SyncList{}, fmt.Errorf("sync list response error: %w", err)
+		}
+		res.Hashes[id] = hash
+	}
+	for _, el := range resp.GetTombstones() {
+		id, err := uuid.Parse(el.GetId())
+		if err != nil {
+			return model.SyncList{}, fmt.Errorf("sync list response error: %w", err)
+		}
+		deletedBy, err := uuid.Parse(el.GetDeletedBy())
+		if err != nil {
+			return model.SyncList{}, fmt.Errorf("sync list response error: %w", err)
+		}
+
+		res.Tombstones[id] = model.Tombstone{
+			SecretID:  id,
+			SecretVer: int(el.GetVer()),
+			DeletedAt: el.GetDeletedAt(),
+			DeletedBy: deletedBy,
+		}
+	}
+
+	return res, nil
+}
+
+// GetChanges downloads changes of secrets after cursor from server change feed, all pages are downloaded.
+// Returns ErrorCursorExpired if server has no changes history for cursor, full sync list must be used.
+func (p *GRPCProvider) GetChanges(ctx context.Context, cursor int64) (model.SyncChanges, error) {
+	res := model.SyncChanges{
+		Changes: make([]model.SecretChange, 0),
+		Cursor:  cursor,
+	}
+
+	for {
+		resp, err := p.secrets().SyncChanges(ctx, &secretpb.SyncChangesRequest{Cursor: res.Cursor})
+		if err != nil {
+			err = callError(err)
+			if errors.Is(err, model.ErrorCursorExpired) {
+				return model.SyncChanges{}, model.ErrorCursorExpired
+			}
+			return model.SyncChanges{}, fmt.Errorf("request error: %w", err)
+		}
+
+		for _, el := range resp.GetChanges() {
+			change, err := decodeChange(el)
+			if err != nil {
+				return model.SyncChanges{}, fmt.Errorf("changes response error: %w", err)
+			}
+
+			res.Changes = append(res.Changes, change)
+		}
+
+		//  cursor not moved, nothing to download
+		if resp.GetCursor() <= res.Cursor {
+			return res, nil
+		}
+		res.Cursor = resp.GetCursor()
+
+		if !resp.GetHasMore() {
+			return res, nil
+		}
+	}
+}
+
+// decodeChange returns change of response
+func decodeChange(el *secretpb.Change) (model.SecretChange, error) {
+	id, err := uuid.Parse(el.GetId())
+	if err != nil {
+		return model.SecretChange{}, err
+	}
+
+	change := model.SecretChange{
+		SecretID:  id,
+		SecretVer: int(el.GetVer()),
+		IsDeleted: el.GetIsDeleted(),
+		DeletedAt: el.GetDeletedAt(),
+	}
+	if el.GetDeletedBy() != "" {
+		if change.DeletedBy, err = uuid.Parse(el.GetDeletedBy()); err != nil {
+			return model.SecretChange{}, err
+		}
+	}
+
+	return change, nil
+}
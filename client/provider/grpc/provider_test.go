@@ -0,0 +1,298 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+const testToken = "tokentoken"
+
+// testServer is fake secrets service, calls without test token are rejected
+type testServer struct {
+	secretpb.UnimplementedSecretsServer
+
+	uploadSecrets func(req *secretpb.BatchUploadRequest) (*secretpb.BatchResponse, error)
+	syncChanges   func(req *secretpb.SyncChangesRequest) (*secretpb.SyncChangesResponse, error)
+	events        []*secretpb.ChangeEvent
+	//  refresh token issued on login, it is accepted once
+	refreshToken string
+}
+
+func (s *testServer) Login(_ context.Context, req *secretpb.LoginRequest) (*secretpb.LoginResponse, error) {
+	if req.GetPassword() != "password" {
+		return nil, status.Error(codes.Unauthenticated, "incorrect login or password")
+	}
+
+	//  certificate is issued for certificate request
+	resp := &secretpb.LoginResponse{Token: testToken, RefreshToken: s.refreshToken}
+	if req.GetCsr() != "" {
+		resp.Certificate = "cert of " + req.GetCsr()
+	}
+
+	return resp, nil
+}
+
+func (s *testServer) Refresh(_ context.Context, req *secretpb.RefreshRequest) (*secretpb.LoginResponse, error) {
+	if s.refreshToken == "" || req.GetRefreshToken() != s.refreshToken {
+		return nil, status.Error(codes.Unauthenticated, "refresh token is revoked")
+	}
+	s.refreshToken = "rotated"
+
+	return &secretpb.LoginResponse{Token: testToken, RefreshToken: s.refreshToken}, nil
+}
+
+func (s *testServer) Ping(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := checkToken(ctx); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *testServer) UploadSecrets(ctx context.Context, req *secretpb.BatchUploadRequest) (*secretpb.BatchResponse, error) {
+	if err := checkToken(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.uploadSecrets(req)
+}
+
+func (s *testServer) SyncChanges(ctx context.Context, req *secretpb.SyncChangesRequest) (*secretpb.SyncChangesResponse, error) {
+	if err := checkToken(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.syncChanges(req)
+}
+
+func (s *testServer) SyncEvents(_ *emptypb.Empty, stream secretpb.Secrets_SyncEventsServer) error {
+	if err := checkToken(stream.Context()); err != nil {
+		return err
+	}
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for _, e := range s.events {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkToken returns error if call has no test token
+func checkToken(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) == 0 || values[0] != "Bearer "+testToken {
+		return status.Error(codes.Unauthenticated, "token is not valid")
+	}
+
+	return nil
+}
+
+// getTestProvider runs fake service on in-memory listener, returns provider connected to it
+func getTestProvider(t *testing.T, s *testServer) *GRPCProvider {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	secretpb.RegisterSecretsServer(srv, s)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	p, err := NewGRPCProvider(GRPCConfig{Address: "bufnet", Timeout: time.Second},
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.Close() })
+
+	return p
+}
+
+func TestProvider_AuthoriseCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deviceID := uuid.New()
+	identity := pmk.NewMockDeviceIdentity(ctrl)
+	identity.EXPECT().CertificateRequest(gomock.Any(), deviceID).Return([]byte("csr"), nil)
+	identity.EXPECT().SaveCertificate(gomock.Any(), deviceID, []byte("cert of csr")).Return(nil)
+
+	p := getTestProvider(t, &testServer{})
+	p.SetIdentity(identity)
+	ctx := context.Background()
+
+	conn := p.conn
+	require.NoError(t, p.Authorise(ctx, "login", "password", "hash", deviceID))
+
+	//  connection is replaced after certificate is issued
+	require.NotSame(t, conn, p.conn)
+	require.NoError(t, p.PingAuth(ctx))
+}
+
+func TestProvider_Authorise(t *testing.T) {
+	p := getTestProvider(t, &testServer{})
+	ctx := context.Background()
+
+	//  no call is made without token
+	require.ErrorIs(t, p.PingAuth(ctx), model.ErrorNotAuthorized)
+
+	require.ErrorIs(t, p.Authorise(ctx, "login", "wrong", "hash", uuid.New()), model.ErrorNotAuthorized)
+
+	require.NoError(t, p.Authorise(ctx, "login", "password", "hash", uuid.New()))
+	require.NoError(t, p.PingAuth(ctx))
+
+	//  token rejected by server is dropped
+	p.SetToken("expired")
+	require.ErrorIs(t, p.PingAuth(ctx), model.ErrorNotAuthorized)
+	require.ErrorIs(t, p.PingAuth(ctx), model.ErrorNotAuthorized)
+	p.mu.Lock()
+	require.Empty(t, p.token)
+	p.mu.Unlock()
+}
+
+func TestProvider_Refresh(t *testing.T) {
+	p := getTestProvider(t, &testServer{refreshToken: "refresh"})
+	ctx := context.Background()
+
+	require.NoError(t, p.Authorise(ctx, "login", "password", "hash", uuid.New()))
+
+	//  expired token is refreshed and call is retried
+	p.SetToken("expired")
+	require.NoError(t, p.PingAuth(ctx))
+	p.mu.Lock()
+	require.Equal(t, testToken, p.token)
+	require.Equal(t, "rotated", p.refreshToken)
+	p.mu.Unlock()
+
+	//  auth is dropped if refresh token is revoked
+	p.SetToken("expired")
+	p.SetRefreshToken("revoked")
+	require.ErrorIs(t, p.PingAuth(ctx), model.ErrorNotAuthorized)
+	p.mu.Lock()
+	require.Empty(t, p.token)
+	require.Empty(t, p.refreshToken)
+	p.mu.Unlock()
+}
+
+func TestProvider_UploadSecrets(t *testing.T) {
+	existID, newID, deletedID := uuid.New(), uuid.New(), uuid.New()
+
+	items := []model.RemoteSecret{
+		{SecretID: existID, SecretVer: 2, Data: "ZGF0YQ==", Vector: model.VersionVector{"device": 1}},
+		{SecretVer: 1, Data: "bmV3"},
+		//  not valid, not sent
+		{SecretVer: 1},
+		{SecretID: deletedID, SecretVer: 1, Data: "ZGVsZXRlZA=="},
+	}
+
+	p := getTestProvider(t, &testServer{
+		uploadSecrets: func(req *secretpb.BatchUploadRequest) (*secretpb.BatchResponse, error) {
+			require.Len(t, req.GetItems(), 3)
+			//  data is sent as raw bytes
+			require.Equal(t, []byte("data"), req.GetItems()[0].GetData())
+			require.Equal(t, map[string]int32{"device": 1}, req.GetItems()[0].GetVector())
+			require.Empty(t, req.GetItems()[1].GetId())
+
+			return &secretpb.BatchResponse{Items: []*secretpb.BatchItem{
+				{Secret: &secretpb.Secret{Id: existID.String(), Ver: 3, Vector: map[string]int32{"device": 2}}},
+				{Secret: &secretpb.Secret{Id: newID.String(), Ver: 1}},
+				{Secret: &secretpb.Secret{Id: deletedID.String()}, Code: uint32(codes.FailedPrecondition), Error: "element is deleted"},
+			}}, nil
+		},
+	})
+	p.SetToken(testToken)
+
+	res, err := p.UploadSecrets(context.Background(), items)
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+
+	require.NoError(t, res[0].Err)
+	require.Equal(t, 3, res[0].SecretVer)
+	require.Equal(t, model.VersionVector{"device": 2}, res[0].Vector)
+
+	require.NoError(t, res[1].Err)
+	require.Equal(t, newID, res[1].SecretID)
+
+	require.ErrorIs(t, res[2].Err, model.ErrorParamNotValid)
+	require.ErrorIs(t, res[3].Err, model.ErrorRequestRejected)
+}
+
+func TestProvider_GetChanges(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+
+	p := getTestProvider(t, &testServer{
+		syncChanges: func(req *secretpb.SyncChangesRequest) (*secretpb.SyncChangesResponse, error) {
+			switch req.GetCursor() {
+			case 0:
+				return nil, status.Error(codes.OutOfRange, "change feed cursor expired")
+			case 10:
+				return &secretpb.SyncChangesResponse{
+					Changes: []*secretpb.Change{{Id: first.String(), Ver: 2, Seq: 11}},
+					Cursor:  11,
+					HasMore: true,
+				}, nil
+			default:
+				return &secretpb.SyncChangesResponse{
+					Changes: []*secretpb.Change{{Id: second.String(), Ver: 1, Seq: 12, IsDeleted: true}},
+					Cursor:  12,
+				}, nil
+			}
+		},
+	})
+	p.SetToken(testToken)
+	ctx := context.Background()
+
+	//  all pages are downloaded
+	res, err := p.GetChanges(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), res.Cursor)
+	require.Len(t, res.Changes, 2)
+	require.Equal(t, first, res.Changes[0].SecretID)
+	require.True(t, res.Changes[1].IsDeleted)
+
+	_, err = p.GetChanges(ctx, 0)
+	require.ErrorIs(t, err, model.ErrorCursorExpired)
+}
+
+func TestProvider_SubscribeChanges(t *testing.T) {
+	id := uuid.New()
+	p := getTestProvider(t, &testServer{
+		events: []*secretpb.ChangeEvent{{Id: id.String(), Ver: 4}},
+	})
+
+	_, err := p.SubscribeChanges(context.Background())
+	require.ErrorIs(t, err, model.ErrorNotAuthorized)
+
+	p.SetToken(testToken)
+	events, err := p.SubscribeChanges(context.Background())
+	require.NoError(t, err)
+
+	e, ok := <-events
+	require.True(t, ok)
+	require.Equal(t, model.ChangeEvent{SecretID: id, SecretVer: 4}, e)
+
+	//  channel is closed when stream ends
+	_, ok = <-events
+	require.False(t, ok)
+}
@@ -1,17 +1,33 @@
 package http
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/Xrefullx/YanDip/client/model"
 )
 
+// RefreshFunc exchanges refresh token for new token and refresh token.
+// Returns model.ErrorNotAuthorized if refresh token is rejected by server.
+type RefreshFunc func(ctx context.Context, refreshToken string) (token string, newRefreshToken string, err error)
+
 type TokenClient struct {
 	http.Client
 	apiToken     *string
 	isAuthorised bool
+
+	//  mu guards tokens, refresh is made by one request at a time
+	mu           sync.Mutex
+	refreshToken string
+	refresh      RefreshFunc
 }
 
 type transport struct {
@@ -20,12 +36,12 @@ type transport struct {
 }
 
 // NewTokenClient returns new http client with custom auth inherit
-func NewTokenClient(timeout time.Duration, enableTLS bool) *TokenClient {
+// if tls config is set, https connections use it, otherwise server is verified by system roots
+func NewTokenClient(timeout time.Duration, tlsConfig *tls.Config) *TokenClient {
 	token := ""
 
 	var t transport
-	// fix for using generated cert in dev
-	if enableTLS {
+	if tlsConfig != nil {
 		t = transport{
 			RoundTripper: &http.Transport{
 				Proxy: http.ProxyFromEnvironment,
@@ -38,10 +54,7 @@ func NewTokenClient(timeout time.Duration, enableTLS bool) *TokenClient {
 				IdleConnTimeout:       90 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ExpectContinueTimeout: 1 * time.Second,
-				TLSClientConfig: &tls.Config{
-					// UNSAFE! DON'T USE IN PRODUCTION!
-					InsecureSkipVerify: true,
-				},
+				TLSClientConfig:       tlsConfig,
 			},
 			apiToken: &token,
 		}
@@ -64,35 +77,177 @@ func NewTokenClient(timeout time.Duration, enableTLS bool) *TokenClient {
 
 // SetToken sets auth token to client
 func (c *TokenClient) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	*c.apiToken = token
 	c.isAuthorised = true
 }
 
-// DropAuth drops auth token to client
+// SetRefreshToken sets refresh token, expired auth token is refreshed with it
+func (c *TokenClient) SetRefreshToken(refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshToken = refreshToken
+}
+
+// SetRefresh sets func auth token is refreshed by, token is dropped on 401 if it is not set
+func (c *TokenClient) SetRefresh(refresh RefreshFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refresh = refresh
+}
+
+// DropAuth drops auth token and refresh token of client
 func (c *TokenClient) DropAuth() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dropAuth()
+}
+
+func (c *TokenClient) dropAuth() {
 	*c.apiToken = ""
+	c.refreshToken = ""
 	c.isAuthorised = false
 }
 
+// token returns current auth token
+func (c *TokenClient) token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return *c.apiToken
+}
+
 // DoWithAuth makes request with auth header
 // returns error if client is not authenticated
 func (c *TokenClient) DoWithAuth(req *http.Request) (*http.Response, error) {
-	if len(*c.apiToken) == 0 {
-		return nil, errors.New("client not authorized")
+	return c.doWithAuth(&c.Client, req)
+}
+
+// StreamWithAuth makes long-lived request with auth header, response is not limited by client timeout.
+// Stream is closed by request context.
+func (c *TokenClient) StreamWithAuth(req *http.Request) (*http.Response, error) {
+	stream := c.Client
+	stream.Timeout = 0
+
+	return c.doWithAuth(&stream, req)
+}
+
+// doWithAuth makes request with auth header.
+// On 401 token is refreshed and request is retried once, auth is dropped only if refresh token is rejected.
+func (c *TokenClient) doWithAuth(client *http.Client, req *http.Request) (*http.Response, error) {
+	token := c.token()
+	if len(token) == 0 {
+		return nil, model.ErrorNotAuthorized
+	}
+
+	req.Header.Set("Authorization", token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	newToken, err := c.refreshAuth(req.Context(), token)
+	if errors.Is(err, model.ErrorNotAuthorized) {
+		//  401 response is returned, caller reports lost auth
+		return resp, nil
+	}
+	closeBody(resp)
+	if err != nil {
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", *c.apiToken)
+	retry, err := retryRequest(req, newToken)
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := c.Do(req)
+	resp, err = client.Do(retry)
 	if err != nil {
 		return nil, err
 	}
 
+	//  token issued just now is not accepted, device is revoked
 	if resp.StatusCode == http.StatusUnauthorized {
 		c.DropAuth()
 	}
 
-	return resp, err
+	return resp, nil
+}
+
+// refreshAuth refreshes expired token, returns new token.
+// If token is refreshed by other request already, returns its token.
+// Auth is dropped and model.ErrorNotAuthorized is returned if token can not be refreshed.
+func (c *TokenClient) refreshAuth(ctx context.Context, expired string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if *c.apiToken != expired && *c.apiToken != "" {
+		return *c.apiToken, nil
+	}
+	if c.refresh == nil || c.refreshToken == "" {
+		c.dropAuth()
+		return "", model.ErrorNotAuthorized
+	}
+
+	token, refreshToken, err := c.refresh(ctx, c.refreshToken)
+	if errors.Is(err, model.ErrorNotAuthorized) {
+		c.dropAuth()
+		return "", err
+	}
+	if err != nil {
+		//  auth is kept, token is refreshed on next request
+		return "", fmt.Errorf("refresh token error: %w", err)
+	}
+
+	*c.apiToken = token
+	c.refreshToken = refreshToken
+
+	return token, nil
+}
+
+// retryRequest returns copy of request with new auth token, body is read again
+func retryRequest(req *http.Request, token string) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body can not be sent again")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", token)
+
+	return retry, nil
+}
+
+// closeBody drains and closes response body, connection is reused
+func closeBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if err := resp.Body.Close(); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// CloseIdleConnections closes idle connections of transport, next request makes new connection
+func (t *transport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if tr, ok := t.RoundTripper.(closeIdler); ok {
+		tr.CloseIdleConnections()
+	}
 }
 
 // RoundTrip Implements RoundTripper interface
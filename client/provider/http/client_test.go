@@ -1,6 +1,9 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -11,10 +14,12 @@ import (
 	"github.com/icrowley/fake"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
 )
 
 func TestTokenClient_SetToken(t *testing.T) {
-	client := NewTokenClient(time.Second*5, false)
+	client := NewTokenClient(time.Second*5, nil)
 
 	token := fake.CharactersN(32)
 	client.SetToken(token)
@@ -46,3 +51,97 @@ func testTokenClient(t *testing.T, client *TokenClient, reqToken string) {
 
 	assert.EqualValues(t, reqToken, string(body))
 }
+
+func TestTokenClient_Refresh(t *testing.T) {
+	//  server accepts only refreshed token, echoes request body
+	server := httptest.NewServer(http.HandlerFunc(
+		func(rw http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer new" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if _, err := io.Copy(rw, req.Body); err != nil {
+				log.Fatal(err.Error())
+			}
+		}))
+	defer server.Close()
+
+	doRequest := func(client *TokenClient) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString("body"))
+		require.NoError(t, err)
+
+		return client.DoWithAuth(request)
+	}
+
+	t.Run("token is refreshed and request is retried", func(t *testing.T) {
+		client := NewTokenClient(time.Second*5, nil)
+		client.SetToken("Bearer expired")
+		client.SetRefreshToken("refresh")
+
+		calls := 0
+		client.SetRefresh(func(_ context.Context, refreshToken string) (string, string, error) {
+			calls++
+			require.Equal(t, "refresh", refreshToken)
+			return "Bearer new", "rotated", nil
+		})
+
+		resp, err := doRequest(client)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "body", string(body))
+		require.Equal(t, "Bearer new", client.token())
+		require.Equal(t, "rotated", client.refreshToken)
+
+		//  refreshed token is used by next requests
+		resp, err = doRequest(client)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("auth is dropped if refresh token is rejected", func(t *testing.T) {
+		client := NewTokenClient(time.Second*5, nil)
+		client.SetToken("Bearer expired")
+		client.SetRefreshToken("refresh")
+		client.SetRefresh(func(_ context.Context, _ string) (string, string, error) {
+			return "", "", model.ErrorNotAuthorized
+		})
+
+		resp, err := doRequest(client)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		_, err = doRequest(client)
+		require.ErrorIs(t, err, model.ErrorNotAuthorized)
+	})
+
+	t.Run("auth is kept if refresh failed", func(t *testing.T) {
+		client := NewTokenClient(time.Second*5, nil)
+		client.SetToken("Bearer expired")
+		client.SetRefreshToken("refresh")
+		client.SetRefresh(func(_ context.Context, _ string) (string, string, error) {
+			return "", "", errors.New("connection refused")
+		})
+
+		_, err := doRequest(client)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, model.ErrorNotAuthorized)
+		require.Equal(t, "Bearer expired", client.token())
+	})
+
+	t.Run("auth is dropped without refresh token", func(t *testing.T) {
+		client := NewTokenClient(time.Second*5, nil)
+		client.SetToken("Bearer expired")
+
+		resp, err := doRequest(client)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Empty(t, client.token())
+	})
+}
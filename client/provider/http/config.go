@@ -1,15 +1,28 @@
 package http
 
-import "time"
+import (
+	"crypto/tls"
+	"time"
+)
 
 type HTTPConfig struct {
 	BaseURL     string
 	AuthURL     string
 	RegisterURL string
+	RefreshURL  string
 	SyncListURL string
+	ChangesURL  string
+	EventsURL   string
 	SecretURL   string
+	DevicesURL  string
+	DeviceURL   string
+
+	BatchUploadURL   string
+	BatchDownloadURL string
 
 	PingURL           string
 	Timeout           time.Duration
 	RequestsPerMinute int
+	//  config of https connections, server is verified by system roots if nil
+	TLSConfig *tls.Config
 }
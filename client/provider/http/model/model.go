@@ -2,6 +2,8 @@ package model
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/Xrefullx/YanDip/client/model"
 	"github.com/google/uuid"
 )
@@ -11,12 +13,38 @@ type LoginRequest struct {
 	MasterHash string    `json:"master_hash"`
 	Password   string    `json:"password"`
 	DeviceID   uuid.UUID `json:"device_id"`
+	//  PEM certificate request of device, set if device has no certificate
+	CSR string `json:"csr,omitempty"`
+}
+
+// LoginResponse is body of login and refresh response, certificate is set if it is issued to device,
+// refresh token is set if server issues refresh tokens
+type LoginResponse struct {
+	Certificate  string `json:"certificate,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type DeviceRequest struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type DeviceItem struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Current   bool       `json:"current,omitempty"`
 }
 
 type SecretRequest struct {
-	Data string    `json:"data,omitempty"`
-	ID   uuid.UUID `json:"id,omitempty"`
-	Ver  int       `json:"ver,omitempty"`
+	Data   string              `json:"data,omitempty"`
+	ID     uuid.UUID           `json:"id,omitempty"`
+	Ver    int                 `json:"ver,omitempty"`
+	Meta   string              `json:"meta,omitempty"`
+	Vector model.VersionVector `json:"vector,omitempty"`
 }
 
 func (s *SecretRequest) IsValidResponseUpload() bool {
@@ -59,6 +87,61 @@ func (s *SecretRequest) IsValidDownload() bool {
 	return true
 }
 
+type BatchUploadRequest struct {
+	Items []SecretRequest `json:"items"`
+}
+
+type BatchDownloadRequest struct {
+	IDs      []uuid.UUID `json:"ids"`
+	MetaOnly bool        `json:"meta_only,omitempty"`
+}
+
+type BatchItem struct {
+	ID     uuid.UUID           `json:"id"`
+	Ver    int                 `json:"ver,omitempty"`
+	Data   string              `json:"data,omitempty"`
+	Meta   string              `json:"meta,omitempty"`
+	Vector model.VersionVector `json:"vector,omitempty"`
+	Size   int                 `json:"size,omitempty"`
+	Status int                 `json:"status"`
+	Error  string              `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Items []BatchItem `json:"items"`
+}
+
 type SyncResponse struct {
-	List map[uuid.UUID]int `json:"list"`
+	List       map[uuid.UUID]int    `json:"list"`
+	Hashes     map[uuid.UUID]string `json:"hashes,omitempty"`
+	Tombstones []TombstoneItem      `json:"tombstones"`
+	Cursor     int64                `json:"cursor"`
+}
+
+type TombstoneItem struct {
+	ID        uuid.UUID `json:"id"`
+	Ver       int       `json:"ver"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy uuid.UUID `json:"deleted_by"`
+}
+
+type ChangeItem struct {
+	ID        uuid.UUID  `json:"id"`
+	Ver       int        `json:"ver"`
+	IsDeleted bool       `json:"is_deleted,omitempty"`
+	Seq       int64      `json:"seq"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *uuid.UUID `json:"deleted_by,omitempty"`
+}
+
+type ChangeEvent struct {
+	ID        uuid.UUID `json:"id"`
+	Ver       int       `json:"ver,omitempty"`
+	IsDeleted bool      `json:"is_deleted,omitempty"`
+}
+
+type ChangesResponse struct {
+	Changes []ChangeItem `json:"changes"`
+	Cursor  int64        `json:"cursor"`
+	HasMore bool         `json:"has_more"`
 }
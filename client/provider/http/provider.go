@@ -1,12 +1,15 @@
 package http
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
 
+	"github.com/Xrefullx/YanDip/client/model"
 	"github.com/Xrefullx/YanDip/client/provider"
 )
 
@@ -15,22 +18,36 @@ var _ provider.SecretProvider = (*HTTPProvider)(nil)
 type HTTPProvider struct {
 	client *TokenClient
 	cfg    HTTPConfig
+	//  device identity, certificate is not requested if nil
+	identity provider.DeviceIdentity
 }
 
 // NewHTTPProvider returns new http provider
-// if base url starts with https, init client with tsl config
+// if base url starts with https, init client with tls config
+// expired token is refreshed with refresh token issued on login
 func NewHTTPProvider(cfg HTTPConfig) *HTTPProvider {
-	withTLS := strings.HasPrefix(cfg.BaseURL, "https://")
+	var tlsConfig *tls.Config
+	if strings.HasPrefix(cfg.BaseURL, "https://") {
+		tlsConfig = cfg.TLSConfig
+	}
 
-	return &HTTPProvider{
-		client: NewTokenClient(cfg.Timeout, withTLS),
+	p := &HTTPProvider{
+		client: NewTokenClient(cfg.Timeout, tlsConfig),
 		cfg:    cfg,
 	}
+	p.client.SetRefresh(p.refreshToken)
+
+	return p
+}
+
+// SetIdentity sets device identity, certificate of device is requested on login
+func (p *HTTPProvider) SetIdentity(identity provider.DeviceIdentity) {
+	p.identity = identity
 }
 
 // PingAuth checks connection with server and authentication
-func (p *HTTPProvider) PingAuth() error {
-	request, err := http.NewRequest(http.MethodGet, p.cfg.BaseURL+p.cfg.PingURL, nil)
+func (p *HTTPProvider) PingAuth(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.BaseURL+p.cfg.PingURL, nil)
 	if err != nil {
 		return fmt.Errorf("ping request error: %w", err)
 	}
@@ -52,6 +69,9 @@ func (p *HTTPProvider) PingAuth() error {
 		}
 	}()
 
+	if response.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("ping request error: %w", model.ErrorNotAuthorized)
+	}
 	if response.StatusCode != http.StatusOK {
 		return fmt.Errorf("ping request error: wrong response: %v - %v", response.StatusCode, respBody)
 	}
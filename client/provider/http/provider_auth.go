@@ -2,31 +2,39 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 
+	climodel "github.com/Xrefullx/YanDip/client/model"
 	"github.com/Xrefullx/YanDip/client/provider/http/model"
 	"github.com/google/uuid"
 )
 
-func (p *HTTPProvider) Authorise(login string, pass string, masterHash string, deviceID uuid.UUID) error {
-	return p.sendAuthorise(login, pass, masterHash, deviceID, p.cfg.BaseURL+p.cfg.AuthURL)
+func (p *HTTPProvider) Authorise(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) error {
+	return p.sendAuthorise(ctx, login, pass, masterHash, deviceID, p.cfg.BaseURL+p.cfg.AuthURL)
 }
 
-func (p *HTTPProvider) Register(login string, pass string, masterHash string, deviceID uuid.UUID) error {
-	return p.sendAuthorise(login, pass, masterHash, deviceID, p.cfg.BaseURL+p.cfg.RegisterURL)
+func (p *HTTPProvider) Register(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) error {
+	return p.sendAuthorise(ctx, login, pass, masterHash, deviceID, p.cfg.BaseURL+p.cfg.RegisterURL)
 }
 
 // Authorise make authorise request, get token and set it to client
-func (p *HTTPProvider) sendAuthorise(login string, pass string, masterHash string, deviceID uuid.UUID, url string) error {
+func (p *HTTPProvider) sendAuthorise(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID, url string) error {
+	csr, err := p.certificateRequest(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
 	loginData, err := json.Marshal(model.LoginRequest{
 		Login:      login,
 		Password:   pass,
 		DeviceID:   deviceID,
 		MasterHash: masterHash,
+		CSR:        string(csr),
 	})
 
 	if err != nil {
@@ -34,7 +42,7 @@ func (p *HTTPProvider) sendAuthorise(login string, pass string, masterHash strin
 	}
 
 	//  prepare request
-	request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(loginData))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(loginData))
 	if err != nil {
 		return fmt.Errorf("request error: %w", err)
 	}
@@ -69,8 +77,95 @@ func (p *HTTPProvider) sendAuthorise(login string, pass string, masterHash strin
 		return fmt.Errorf("token is empty")
 	}
 
-	// set token to client
+	var resp model.LoginResponse
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return fmt.Errorf("request error: %w", err)
+		}
+	}
+
+	// set token to client, refresh token is empty if server does not issue it
 	p.client.SetToken(token)
+	p.client.SetRefreshToken(resp.RefreshToken)
+
+	return p.saveCertificate(ctx, deviceID, resp.Certificate)
+}
+
+// refreshToken exchanges refresh token for new token and refresh token.
+// Returns model.ErrorNotAuthorized if refresh token is revoked, user must login again then.
+func (p *HTTPProvider) refreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	reqBody, err := json.Marshal(model.RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return "", "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.BaseURL+p.cfg.RefreshURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return "", "", fmt.Errorf("request error: %w", err)
+	}
+	request.Header.Set("content-type", "application/json")
+
+	//  refresh request is made without token
+	response, err := p.client.Do(request)
+	if err != nil {
+		return "", "", fmt.Errorf("request error: %w", err)
+	}
+
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("request error: %w", err)
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	if response.StatusCode == http.StatusUnauthorized {
+		return "", "", fmt.Errorf("refresh request error: %w", climodel.ErrorNotAuthorized)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("request error: response: %v - %s ", response.StatusCode, respBody)
+	}
+
+	token := response.Header.Get("Authorization")
+	if len(token) == 0 {
+		return "", "", fmt.Errorf("token is empty")
+	}
+
+	var resp model.LoginResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", "", fmt.Errorf("request error: %w", err)
+	}
+
+	return token, resp.RefreshToken, nil
+}
+
+// certificateRequest returns certificate request of device, nil if identity is not set or device has certificate
+func (p *HTTPProvider) certificateRequest(ctx context.Context, deviceID uuid.UUID) ([]byte, error) {
+	if p.identity == nil {
+		return nil, nil
+	}
+
+	csr, err := p.identity.CertificateRequest(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("error make device certificate request: %w", err)
+	}
+
+	return csr, nil
+}
+
+// saveCertificate saves device certificate issued on login.
+// Connections made without certificate are closed, next requests present it.
+func (p *HTTPProvider) saveCertificate(ctx context.Context, deviceID uuid.UUID, cert string) error {
+	if p.identity == nil || cert == "" {
+		return nil
+	}
+
+	if err := p.identity.SaveCertificate(ctx, deviceID, []byte(cert)); err != nil {
+		return fmt.Errorf("error save device certificate: %w", err)
+	}
+	p.client.CloseIdleConnections()
 
 	return nil
 }
@@ -1,11 +1,17 @@
 package http
 
 import (
+	"context"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
 	"time"
+
+	climodel "github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/provider/http/model"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
 )
 
 func TestProvider_Auth(t *testing.T) {
@@ -72,7 +78,7 @@ func TestProvider_Auth(t *testing.T) {
 			provCfg.BaseURL = server.URL
 
 			provider := NewHTTPProvider(provCfg)
-			err := provider.Authorise(authData.Login, authData.Password, authData.MasterHash, authData.DeviceID)
+			err := provider.Authorise(context.Background(), authData.Login, authData.Password, authData.MasterHash, authData.DeviceID)
 
 			tt.reqErr(t, err)
 
@@ -146,7 +152,7 @@ func TestProvider_Register(t *testing.T) {
 			provCfg.BaseURL = server.URL
 
 			provider := NewHTTPProvider(provCfg)
-			err := provider.Register(authData.Login, authData.Password, authData.MasterHash, authData.DeviceID)
+			err := provider.Register(context.Background(), authData.Login, authData.Password, authData.MasterHash, authData.DeviceID)
 
 			tt.reqErr(t, err)
 
@@ -155,3 +161,86 @@ func TestProvider_Register(t *testing.T) {
 		})
 	}
 }
+
+func TestProvider_AuthCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reqData := authData
+	reqData.CSR = "csr"
+
+	server := getTestHTTPServer(t, srvBaseCfg.New(
+		withReturnHeaders(map[string]string{"Authorization": token}),
+		withReturnBody(mustMarshal(model.LoginResponse{Certificate: "cert"})),
+		withReqMethod(http.MethodPost),
+		withReqBody(mustMarshal(reqData)),
+		withReqURL(provBaseCfg.AuthURL),
+	))
+	defer server.Close()
+
+	//  certificate is requested for login device and issued certificate is saved
+	identity := pmk.NewMockDeviceIdentity(ctrl)
+	identity.EXPECT().CertificateRequest(gomock.Any(), authData.DeviceID).Return([]byte("csr"), nil)
+	identity.EXPECT().SaveCertificate(gomock.Any(), authData.DeviceID, []byte("cert")).Return(nil)
+
+	provCfg := provBaseCfg
+	provCfg.BaseURL = server.URL
+
+	provider := NewHTTPProvider(provCfg)
+	provider.SetIdentity(identity)
+
+	err := provider.Authorise(context.Background(), authData.Login, authData.Password, authData.MasterHash, authData.DeviceID)
+	require.NoError(t, err)
+	require.EqualValues(t, token, *provider.client.apiToken)
+}
+
+func TestProvider_Refresh(t *testing.T) {
+	refreshSrvConfig := srvBaseCfg.New(
+		withReturnHeaders(map[string]string{"Authorization": token}),
+		withReturnBody(mustMarshal(model.LoginResponse{RefreshToken: "rotated"})),
+		withReqMethod(http.MethodPost),
+		withReqBody(mustMarshal(model.RefreshRequest{RefreshToken: "refresh"})),
+		withReqURL(provBaseCfg.RefreshURL),
+	)
+
+	t.Run("refresh ok", func(t *testing.T) {
+		server := getTestHTTPServer(t, refreshSrvConfig)
+		defer server.Close()
+
+		provCfg := provBaseCfg
+		provCfg.BaseURL = server.URL
+
+		newToken, refreshToken, err := NewHTTPProvider(provCfg).refreshToken(context.Background(), "refresh")
+		require.NoError(t, err)
+		require.Equal(t, token, newToken)
+		require.Equal(t, "rotated", refreshToken)
+	})
+
+	t.Run("refresh token is revoked", func(t *testing.T) {
+		server := getTestHTTPServer(t, refreshSrvConfig.New(withReturnStatus(http.StatusUnauthorized)))
+		defer server.Close()
+
+		provCfg := provBaseCfg
+		provCfg.BaseURL = server.URL
+
+		_, _, err := NewHTTPProvider(provCfg).refreshToken(context.Background(), "refresh")
+		require.ErrorIs(t, err, climodel.ErrorNotAuthorized)
+	})
+
+	t.Run("refresh token is issued on login", func(t *testing.T) {
+		server := getTestHTTPServer(t, srvBaseCfg.New(
+			withReturnHeaders(map[string]string{"Authorization": token}),
+			withReturnBody(mustMarshal(model.LoginResponse{RefreshToken: "refresh"})),
+			withReqBody(mustMarshal(authData)),
+		))
+		defer server.Close()
+
+		provCfg := provBaseCfg
+		provCfg.BaseURL = server.URL
+
+		provider := NewHTTPProvider(provCfg)
+		err := provider.Authorise(context.Background(), authData.Login, authData.Password, authData.MasterHash, authData.DeviceID)
+		require.NoError(t, err)
+		require.Equal(t, "refresh", provider.client.refreshToken)
+	})
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	prmodel "github.com/Xrefullx/YanDip/client/provider/http/model"
+)
+
+// UploadSecrets uploads secrets with one request, returns server id and version of every item.
+// Not valid items are not sent, item error is set in result.
+func (p *HTTPProvider) UploadSecrets(ctx context.Context, items []model.RemoteSecret) ([]model.RemoteSecret, error) {
+	res := make([]model.RemoteSecret, len(items))
+
+	//  indexes of sent items
+	sent := make([]int, 0, len(items))
+	req := prmodel.BatchUploadRequest{Items: make([]prmodel.SecretRequest, 0, len(items))}
+	for i, el := range items {
+		res[i] = model.RemoteSecret{SecretID: el.SecretID}
+
+		reqItem := prmodel.SecretRequest{
+			Data:   el.Data,
+			ID:     el.SecretID,
+			Ver:    el.SecretVer,
+			Meta:   el.Meta,
+			Vector: el.Vector,
+		}
+		if err := reqItem.ValidateUpload(); err != nil {
+			res[i].Err = err
+			continue
+		}
+
+		req.Items = append(req.Items, reqItem)
+		sent = append(sent, i)
+	}
+
+	if len(sent) == 0 {
+		return res, nil
+	}
+
+	resp, err := p.processBatchRequest(ctx, p.cfg.BaseURL+p.cfg.BatchUploadURL, req, len(sent))
+	if err != nil {
+		return nil, fmt.Errorf("error secrets upload: %w", err)
+	}
+
+	for i, el := range resp.Items {
+		item := &res[sent[i]]
+		if item.Err = batchItemError(el); item.Err != nil {
+			continue
+		}
+
+		reqItem := prmodel.SecretRequest{ID: el.ID, Ver: el.Ver}
+		if !reqItem.IsValidResponseUpload() {
+			item.Err = errors.New("upload response error: response not valid")
+			continue
+		}
+		item.SecretID, item.SecretVer, item.Vector = el.ID, el.Ver, el.Vector
+	}
+
+	return res, nil
+}
+
+// DownloadSecrets downloads secrets with one request, returns id, version and data of every item
+func (p *HTTPProvider) DownloadSecrets(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error) {
+	return p.downloadSecrets(ctx, ids, false)
+}
+
+// DownloadSecretsMeta downloads encrypted description and size of secrets without data with one request
+func (p *HTTPProvider) DownloadSecretsMeta(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error) {
+	return p.downloadSecrets(ctx, ids, true)
+}
+
+// downloadSecrets makes batch download request, data is not downloaded if metaOnly is set
+func (p *HTTPProvider) downloadSecrets(ctx context.Context, ids []uuid.UUID, metaOnly bool) ([]model.RemoteSecret, error) {
+	for _, id := range ids {
+		if id == uuid.Nil {
+			return nil, fmt.Errorf("%w : not valid download param", model.ErrorParamNotValid)
+		}
+	}
+
+	if len(ids) == 0 {
+		return []model.RemoteSecret{}, nil
+	}
+
+	req := prmodel.BatchDownloadRequest{IDs: ids, MetaOnly: metaOnly}
+	resp, err := p.processBatchRequest(ctx, p.cfg.BaseURL+p.cfg.BatchDownloadURL, req, len(ids))
+	if err != nil {
+		return nil, fmt.Errorf("error secrets download: %w", err)
+	}
+
+	res := make([]model.RemoteSecret, len(ids))
+	for i, el := range resp.Items {
+		res[i] = model.RemoteSecret{SecretID: ids[i]}
+		if res[i].Err = batchItemError(el); res[i].Err != nil {
+			continue
+		}
+
+		reqItem := prmodel.SecretRequest{ID: el.ID, Ver: el.Ver, Data: el.Data}
+		valid := reqItem.IsValidResponseDownload()
+		if metaOnly {
+			valid = reqItem.IsValidResponseUpload()
+		}
+		if el.ID != ids[i] || !valid {
+			res[i].Err = errors.New("download response error: response not valid")
+			continue
+		}
+		res[i].SecretVer, res[i].Data, res[i].Meta, res[i].Vector, res[i].Size = el.Ver, el.Data, el.Meta, el.Vector, el.Size
+	}
+
+	return res, nil
+}
+
+// batchItemError returns error of batch response item, nil if item processed
+func batchItemError(item prmodel.BatchItem) error {
+	if item.Status == http.StatusOK {
+		return nil
+	}
+	if item.Status == http.StatusUnprocessableEntity {
+		return fmt.Errorf("%w: item %v response: %s", model.ErrorRequestRejected, item.ID, item.Error)
+	}
+	if item.Status == http.StatusConflict {
+		return fmt.Errorf("%w: item %v response: %s", model.ErrorVersionConflict, item.ID, item.Error)
+	}
+
+	return fmt.Errorf("item %v response error: %v - %s", item.ID, item.Status, item.Error)
+}
+
+// processBatchRequest makes batch request, checks response has result of every item
+func (p *HTTPProvider) processBatchRequest(ctx context.Context, reqURL string, req interface{}, count int) (prmodel.BatchResponse, error) {
+	reqData, err := json.Marshal(req)
+	if err != nil {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch request error: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqData))
+	if err != nil {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch request error: %w", err)
+	}
+	request.Header.Set("content-type", "application/json")
+
+	//  do request
+	response, err := p.client.DoWithAuth(request)
+	if err != nil {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch request error: %w", err)
+	}
+
+	//  read body
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch response error: %w", err)
+	}
+
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch response error: %v - %s", response.StatusCode, string(respBody))
+	}
+
+	var resp prmodel.BatchResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch response error: %w", err)
+	}
+	if len(resp.Items) != count {
+		return prmodel.BatchResponse{}, fmt.Errorf("batch response error: %v results for %v items", len(resp.Items), count)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/icrowley/fake"
+	"github.com/stretchr/testify/require"
+
+	climodel "github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/provider/http/model"
+)
+
+func TestProviderBatch_UploadSecrets(t *testing.T) {
+	token := fake.CharactersN(16)
+	existID, newID := uuid.New(), uuid.New()
+
+	base := climodel.VersionVector{"device": 1}
+	saved := climodel.VersionVector{"device": 2}
+
+	items := []climodel.RemoteSecret{
+		{SecretID: existID, SecretVer: 2, Data: "exist", Vector: base},
+		{SecretVer: 1, Data: "new"},
+		//  not valid, not sent
+		{SecretVer: 1},
+		{SecretID: uuid.New(), SecretVer: 1, Data: "deleted"},
+		{SecretID: uuid.New(), SecretVer: 1, Data: "concurrent", Vector: base},
+	}
+
+	reqData := model.BatchUploadRequest{Items: []model.SecretRequest{
+		{ID: existID, Ver: 2, Data: "exist", Vector: base},
+		{Ver: 1, Data: "new"},
+		{ID: items[3].SecretID, Ver: 1, Data: "deleted"},
+		{ID: items[4].SecretID, Ver: 1, Data: "concurrent", Vector: base},
+	}}
+	respData := model.BatchResponse{Items: []model.BatchItem{
+		{ID: existID, Ver: 3, Vector: saved, Status: http.StatusOK},
+		{ID: newID, Ver: 1, Status: http.StatusOK},
+		{ID: items[3].SecretID, Status: http.StatusUnprocessableEntity, Error: "element is deleted"},
+		{ID: items[4].SecretID, Status: http.StatusConflict, Error: "version conflict"},
+	}}
+
+	server := getTestHTTPServer(t, srvBaseCfg.New(
+		withReqMethod(http.MethodPost),
+		withReqURL(provBaseCfg.BatchUploadURL),
+		withReqBody(mustMarshal(reqData)),
+		withReturnBody(mustMarshal(respData)),
+	))
+	defer server.Close()
+
+	provCfg := provBaseCfg
+	provCfg.BaseURL = server.URL
+
+	provider := NewHTTPProvider(provCfg)
+	provider.client.SetToken(token)
+
+	res, err := provider.UploadSecrets(context.Background(), items)
+	require.NoError(t, err)
+	require.Len(t, res, 5)
+
+	require.NoError(t, res[0].Err)
+	require.Equal(t, existID, res[0].SecretID)
+	require.Equal(t, 3, res[0].SecretVer)
+	require.Equal(t, saved, res[0].Vector)
+
+	require.NoError(t, res[1].Err)
+	require.Equal(t, newID, res[1].SecretID)
+	require.Equal(t, 1, res[1].SecretVer)
+
+	require.ErrorIs(t, res[2].Err, climodel.ErrorParamNotValid)
+	require.ErrorIs(t, res[3].Err, climodel.ErrorRequestRejected)
+	require.ErrorIs(t, res[4].Err, climodel.ErrorVersionConflict)
+}
+
+func TestProviderBatch_DownloadSecrets(t *testing.T) {
+	token := fake.CharactersN(16)
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	tests := []struct {
+		name     string
+		respData model.BatchResponse
+		status   int
+		reqErr   require.ErrorAssertionFunc
+	}{
+		{
+			name: "items downloaded",
+			respData: model.BatchResponse{Items: []model.BatchItem{
+				{ID: ids[0], Ver: 2, Data: "data", Status: http.StatusOK},
+				{ID: ids[1], Status: http.StatusUnprocessableEntity, Error: "element is deleted"},
+			}},
+			status: http.StatusOK,
+			reqErr: require.NoError,
+		},
+		{
+			name: "missed item result",
+			respData: model.BatchResponse{Items: []model.BatchItem{
+				{ID: ids[0], Ver: 2, Data: "data", Status: http.StatusOK},
+			}},
+			status: http.StatusOK,
+			reqErr: require.Error,
+		},
+		{
+			name:   "err 500",
+			status: http.StatusInternalServerError,
+			reqErr: require.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getTestHTTPServer(t, srvBaseCfg.New(
+				withReqMethod(http.MethodPost),
+				withReqURL(provBaseCfg.BatchDownloadURL),
+				withReqBody(mustMarshal(model.BatchDownloadRequest{IDs: ids})),
+				withReturnStatus(tt.status),
+				withReturnBody(mustMarshal(tt.respData)),
+			))
+			defer server.Close()
+
+			provCfg := provBaseCfg
+			provCfg.BaseURL = server.URL
+
+			provider := NewHTTPProvider(provCfg)
+			provider.client.SetToken(token)
+
+			res, err := provider.DownloadSecrets(context.Background(), ids)
+			tt.reqErr(t, err)
+			if err != nil {
+				return
+			}
+
+			require.NoError(t, res[0].Err)
+			require.Equal(t, climodel.RemoteSecret{SecretID: ids[0], SecretVer: 2, Data: "data"}, res[0])
+			require.Error(t, res[1].Err)
+		})
+	}
+}
+
+func TestProviderBatch_DownloadSecretsMeta(t *testing.T) {
+	token := fake.CharactersN(16)
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	server := getTestHTTPServer(t, srvBaseCfg.New(
+		withReqMethod(http.MethodPost),
+		withReqURL(provBaseCfg.BatchDownloadURL),
+		withReqBody(mustMarshal(model.BatchDownloadRequest{IDs: ids, MetaOnly: true})),
+		withReturnStatus(http.StatusOK),
+		withReturnBody(mustMarshal(model.BatchResponse{Items: []model.BatchItem{
+			{ID: ids[0], Ver: 2, Meta: "meta", Size: 1024, Status: http.StatusOK},
+			{ID: ids[1], Status: http.StatusUnprocessableEntity, Error: "element is deleted"},
+		}})),
+	))
+	defer server.Close()
+
+	provCfg := provBaseCfg
+	provCfg.BaseURL = server.URL
+
+	provider := NewHTTPProvider(provCfg)
+	provider.client.SetToken(token)
+
+	res, err := provider.DownloadSecretsMeta(context.Background(), ids)
+	require.NoError(t, err)
+
+	require.Equal(t, climodel.RemoteSecret{SecretID: ids[0], SecretVer: 2, Meta: "meta", Size: 1024}, res[0])
+	require.ErrorIs(t, res[1].Err, climodel.ErrorRequestRejected)
+}
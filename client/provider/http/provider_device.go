@@ -0,0 +1,106 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	prmodel "github.com/Xrefullx/YanDip/client/provider/http/model"
+)
+
+// GetDevices returns devices of user enrolled on server
+func (p *HTTPProvider) GetDevices(ctx context.Context) ([]model.Device, error) {
+	var items []prmodel.DeviceItem
+	if err := p.processDeviceRequest(ctx, http.MethodGet, p.cfg.BaseURL+p.cfg.DevicesURL, nil, &items); err != nil {
+		return nil, fmt.Errorf("error get devices: %w", err)
+	}
+
+	res := make([]model.Device, 0, len(items))
+	for _, el := range items {
+		device := model.Device{
+			ID:        el.ID,
+			CreatedAt: el.CreatedAt.UnixMilli(),
+			Current:   el.Current,
+		}
+		if el.RevokedAt != nil {
+			device.RevokedAt = el.RevokedAt.UnixMilli()
+		}
+		res = append(res, device)
+	}
+
+	return res, nil
+}
+
+// RevokeDevice revokes user device
+func (p *HTTPProvider) RevokeDevice(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return fmt.Errorf("%w : device id is empty", model.ErrorParamNotValid)
+	}
+
+	if err := p.processDeviceRequest(ctx, http.MethodDelete, p.cfg.BaseURL+p.cfg.DeviceURL, prmodel.DeviceRequest{ID: id}, nil); err != nil {
+		return fmt.Errorf("error revoke device: %w", err)
+	}
+
+	return nil
+}
+
+// processDeviceRequest makes device request, response body is decoded to resp if it is set
+func (p *HTTPProvider) processDeviceRequest(ctx context.Context, method string, url string, reqData interface{}, resp interface{}) error {
+	var body io.Reader
+	if reqData != nil {
+		data, err := json.Marshal(reqData)
+		if err != nil {
+			return fmt.Errorf("request error: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+	request.Header.Set("content-type", "application/json")
+
+	//  do request
+	response, err := p.client.DoWithAuth(request)
+	if err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+
+	//  read body
+	respBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return model.ErrorNotAuthorized
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusNotImplemented:
+		return fmt.Errorf("%w: %v - %s", model.ErrorRequestRejected, response.StatusCode, respBody)
+	default:
+		return fmt.Errorf("request error: response: %v - %s", response.StatusCode, respBody)
+	}
+
+	if resp == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+
+	return nil
+}
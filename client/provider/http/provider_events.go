@@ -0,0 +1,88 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	prmodel "github.com/Xrefullx/YanDip/client/provider/http/model"
+)
+
+// SubscribeChanges opens server-sent events stream of remote changes.
+// Events channel is closed when stream is broken or ctx is done.
+func (p *HTTPProvider) SubscribeChanges(ctx context.Context) (<-chan model.ChangeEvent, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.BaseURL+p.cfg.EventsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("events request error: %w", err)
+	}
+	request.Header.Set("accept", "text/event-stream")
+
+	response, err := p.client.StreamWithAuth(request)
+	if err != nil {
+		return nil, fmt.Errorf("events request error: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(response.Body)
+		if err := response.Body.Close(); err != nil {
+			log.Println(err.Error())
+		}
+		return nil, fmt.Errorf("events request error: wrong response: %v - %s", response.StatusCode, respBody)
+	}
+
+	events := make(chan model.ChangeEvent)
+	go func() {
+		defer close(events)
+		defer func() {
+			if err := response.Body.Close(); err != nil {
+				log.Println(err.Error())
+			}
+		}()
+
+		readEvents(ctx, response.Body, events)
+	}()
+
+	return events, nil
+}
+
+// readEvents reads change events from stream until stream end, comments and other events are skipped
+func readEvents(ctx context.Context, stream io.Reader, events chan<- model.ChangeEvent) {
+	scanner := bufio.NewScanner(stream)
+
+	var name, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		//  blank line dispatches event
+		case line == "":
+			if name == "change" && data != "" {
+				var e prmodel.ChangeEvent
+				if err := json.Unmarshal([]byte(data), &e); err != nil {
+					log.Printf("error read change event: %v", err)
+				} else {
+					select {
+					case events <- model.ChangeEvent{SecretID: e.ID, SecretVer: e.Ver, IsDeleted: e.IsDeleted}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			name, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		log.Printf("events stream error: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,52 +15,54 @@ import (
 	"github.com/google/uuid"
 )
 
-// UploadSecret uploads secret to server, returns server id and version
-// if id is nil, creates new
-func (p *HTTPProvider) UploadSecret(data string, id uuid.UUID, ver int) (uuid.UUID, int, error) {
+// UploadSecret uploads secret with encrypted description to server, returns server id, version and vector.
+// Vector of item is vector of version edit is based on, if id is nil, creates new
+func (p *HTTPProvider) UploadSecret(ctx context.Context, item model.RemoteSecret) (model.RemoteSecret, error) {
 	reqData := prmodel.SecretRequest{
-		Data: data,
-		ID:   id,
-		Ver:  ver,
+		Data:   item.Data,
+		ID:     item.SecretID,
+		Ver:    item.SecretVer,
+		Meta:   item.Meta,
+		Vector: item.Vector,
 	}
 	if err := reqData.ValidateUpload(); err != nil {
-		return uuid.Nil, 0, err
+		return model.RemoteSecret{}, err
 	}
 
 	resp := prmodel.SecretRequest{}
-	if err := p.processSecretRequest(reqData, http.MethodPut, &resp); err != nil {
-		return uuid.Nil, 0, fmt.Errorf("error secret upload: %w", err)
+	if err := p.processSecretRequest(ctx, reqData, http.MethodPut, &resp); err != nil {
+		return model.RemoteSecret{}, fmt.Errorf("error secret upload: %w", err)
 	}
 
 	if !resp.IsValidResponseUpload() {
-		return uuid.Nil, 0, errors.New("upload response error: response not valid")
+		return model.RemoteSecret{}, errors.New("upload response error: response not valid")
 	}
 
-	return resp.ID, resp.Ver, nil
+	return model.RemoteSecret{SecretID: resp.ID, SecretVer: resp.Ver, Vector: resp.Vector}, nil
 }
 
 // DownloadSecret downloads secret from server
-func (p *HTTPProvider) DownloadSecret(id uuid.UUID) (uuid.UUID, int, string, error) {
+func (p *HTTPProvider) DownloadSecret(ctx context.Context, id uuid.UUID) (model.RemoteSecret, error) {
 	reqData := prmodel.SecretRequest{
 		ID: id,
 	}
 	if !reqData.IsValidDownload() {
-		return uuid.Nil, 0, "", fmt.Errorf("%w : not valid download param", model.ErrorParamNotValid)
+		return model.RemoteSecret{}, fmt.Errorf("%w : not valid download param", model.ErrorParamNotValid)
 	}
 
 	resp := prmodel.SecretRequest{}
-	if err := p.processSecretRequest(reqData, http.MethodGet, &resp); err != nil {
-		return uuid.Nil, 0, "", fmt.Errorf("error secret download: %w", err)
+	if err := p.processSecretRequest(ctx, reqData, http.MethodGet, &resp); err != nil {
+		return model.RemoteSecret{}, fmt.Errorf("error secret download: %w", err)
 	}
 
 	if !resp.IsValidResponseDownload() {
-		return uuid.Nil, 0, "", errors.New("download response error: response not valid")
+		return model.RemoteSecret{}, errors.New("download response error: response not valid")
 	}
-	return resp.ID, resp.Ver, resp.Data, nil
+	return model.RemoteSecret{SecretID: resp.ID, SecretVer: resp.Ver, Data: resp.Data, Meta: resp.Meta, Vector: resp.Vector}, nil
 }
 
 // DeleteSecret deletes secret from server
-func (p *HTTPProvider) DeleteSecret(id uuid.UUID) error {
+func (p *HTTPProvider) DeleteSecret(ctx context.Context, id uuid.UUID) error {
 	reqData := prmodel.SecretRequest{
 		ID: id,
 	}
@@ -68,20 +71,20 @@ func (p *HTTPProvider) DeleteSecret(id uuid.UUID) error {
 	}
 
 	resp := prmodel.SecretRequest{}
-	if err := p.processSecretRequest(reqData, http.MethodDelete, &resp); err != nil {
+	if err := p.processSecretRequest(ctx, reqData, http.MethodDelete, &resp); err != nil {
 		return fmt.Errorf("error secret delete: %w", err)
 	}
 	return nil
 }
 
-func (p *HTTPProvider) processSecretRequest(req prmodel.SecretRequest, method string, resp *prmodel.SecretRequest) error {
+func (p *HTTPProvider) processSecretRequest(ctx context.Context, req prmodel.SecretRequest, method string, resp *prmodel.SecretRequest) error {
 	secretData, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("secret %v request error: %w", method, err)
 	}
 
 	//  prepare request
-	request, err := http.NewRequest(method, p.cfg.BaseURL+p.cfg.SecretURL, bytes.NewBuffer(secretData))
+	request, err := http.NewRequestWithContext(ctx, method, p.cfg.BaseURL+p.cfg.SecretURL, bytes.NewBuffer(secretData))
 	if err != nil {
 		return fmt.Errorf("secret %v request error: %w", method, err)
 	}
@@ -106,6 +109,12 @@ func (p *HTTPProvider) processSecretRequest(req prmodel.SecretRequest, method st
 		}
 	}()
 
+	if response.StatusCode == http.StatusUnprocessableEntity {
+		return fmt.Errorf("%w: secret %s response: %s", model.ErrorRequestRejected, method, string(respBody))
+	}
+	if response.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: secret %s response: %s", model.ErrorVersionConflict, method, string(respBody))
+	}
 	if response.StatusCode != http.StatusOK {
 		return fmt.Errorf("secret %s response error: %v - %s", method, response.StatusCode, string(respBody))
 	}
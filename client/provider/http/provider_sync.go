@@ -1,36 +1,115 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/google/uuid"
 
-	"github.com/Xrefullx/YanDip/client/provider/http/model"
+	"github.com/Xrefullx/YanDip/client/model"
+	prmodel "github.com/Xrefullx/YanDip/client/provider/http/model"
 )
 
-// GetSyncList downloads files meta info from server
-func (p *HTTPProvider) GetSyncList() (map[uuid.UUID]int, error) {
+// GetSyncList downloads files meta info and tombstones of deleted files from server
+func (p *HTTPProvider) GetSyncList(ctx context.Context) (model.SyncList, error) {
+	var respObj prmodel.SyncResponse
+	if err := p.processSyncRequest(ctx, p.cfg.BaseURL+p.cfg.SyncListURL, &respObj); err != nil {
+		return model.SyncList{}, err
+	}
+
+	res := model.SyncList{
+		List:       respObj.List,
+		Tombstones: make(map[uuid.UUID]model.Tombstone, len(respObj.Tombstones)),
+		Cursor:     respObj.Cursor,
+		Hashes:     respObj.Hashes,
+	}
+	if res.List == nil {
+		res.List = make(map[uuid.UUID]int)
+	}
+	if res.Hashes == nil {
+		res.Hashes = make(map[uuid.UUID]string)
+	}
+	for _, el := range respObj.Tombstones {
+		res.Tombstones[el.ID] = model.Tombstone{
+			SecretID:  el.ID,
+			SecretVer: el.Ver,
+			DeletedAt: el.DeletedAt.UnixMilli(),
+			DeletedBy: el.DeletedBy,
+		}
+	}
 
-	request, err := http.NewRequest(http.MethodGet, p.cfg.BaseURL+p.cfg.SyncListURL, nil)
+	return res, nil
+}
+
+// GetChanges downloads changes of secrets after cursor from server change feed, all pages are downloaded.
+// Returns ErrorCursorExpired if server has no changes history for cursor, full sync list must be used.
+func (p *HTTPProvider) GetChanges(ctx context.Context, cursor int64) (model.SyncChanges, error) {
+	res := model.SyncChanges{
+		Changes: make([]model.SecretChange, 0),
+		Cursor:  cursor,
+	}
+
+	for {
+		query := url.Values{}
+		query.Set("cursor", strconv.FormatInt(res.Cursor, 10))
+
+		var respObj prmodel.ChangesResponse
+		if err := p.processSyncRequest(ctx, p.cfg.BaseURL+p.cfg.ChangesURL+"?"+query.Encode(), &respObj); err != nil {
+			return model.SyncChanges{}, err
+		}
+
+		for _, el := range respObj.Changes {
+			change := model.SecretChange{
+				SecretID:  el.ID,
+				SecretVer: el.Ver,
+				IsDeleted: el.IsDeleted,
+			}
+			if el.DeletedAt != nil {
+				change.DeletedAt = el.DeletedAt.UnixMilli()
+			}
+			if el.DeletedBy != nil {
+				change.DeletedBy = *el.DeletedBy
+			}
+
+			res.Changes = append(res.Changes, change)
+		}
+
+		//  cursor not moved, nothing to download
+		if respObj.Cursor <= res.Cursor {
+			return res, nil
+		}
+		res.Cursor = respObj.Cursor
+
+		if !respObj.HasMore {
+			return res, nil
+		}
+	}
+}
+
+// processSyncRequest makes sync get request and reads response to resp
+func (p *HTTPProvider) processSyncRequest(ctx context.Context, reqURL string, resp interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
+		return fmt.Errorf("request error: %w", err)
 	}
 	request.Header.Set("content-type", "application/json")
 
 	//  do request
 	response, err := p.client.DoWithAuth(request)
 	if err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
+		return fmt.Errorf("request error: %w", err)
 	}
 
 	//  read body
 	respBody, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
+		return fmt.Errorf("request error: %w", err)
 	}
 
 	defer func() {
@@ -39,10 +118,13 @@ func (p *HTTPProvider) GetSyncList() (map[uuid.UUID]int, error) {
 		}
 	}()
 
-	var respObj model.SyncResponse
-	if err := json.Unmarshal(respBody, &respObj); err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
+	if response.StatusCode == http.StatusGone {
+		return model.ErrorCursorExpired
+	}
+
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return fmt.Errorf("request error: %w", err)
 	}
 
-	return respObj.List, nil
+	return nil
 }
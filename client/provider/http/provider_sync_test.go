@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	climodel "github.com/Xrefullx/YanDip/client/model"
 	"github.com/Xrefullx/YanDip/client/provider/http/model"
 )
 
@@ -17,8 +19,18 @@ func TestProviderSync_GetList(t *testing.T) {
 	okList := getMockSyncList(40)
 	token := fake.CharactersN(16)
 
+	tomb := model.TombstoneItem{ID: uuid.New(), Ver: 3, DeletedAt: time.UnixMilli(1700000000000), DeletedBy: uuid.New()}
+
+	hashes := make(map[uuid.UUID]string, len(okList))
+	for id := range okList {
+		hashes[id] = fake.CharactersN(64)
+	}
+
 	respData := model.SyncResponse{
-		List: okList,
+		List:       okList,
+		Hashes:     hashes,
+		Tombstones: []model.TombstoneItem{tomb},
+		Cursor:     42,
 	}
 
 	syncSrvConfig := srvBaseCfg.New(
@@ -31,15 +43,22 @@ func TestProviderSync_GetList(t *testing.T) {
 		serverCfg serverTestConfig
 
 		reqErr  assert.ErrorAssertionFunc
-		reqList map[uuid.UUID]int
+		reqList climodel.SyncList
 		wait    time.Duration
 	}{
 		{
 			name: "get exist list",
 			serverCfg: syncSrvConfig.New(
 				withReturnBody(mustMarshal(respData))),
-			reqErr:  assert.NoError,
-			reqList: okList,
+			reqErr: assert.NoError,
+			reqList: climodel.SyncList{
+				List: okList,
+				Tombstones: map[uuid.UUID]climodel.Tombstone{
+					tomb.ID: {SecretID: tomb.ID, SecretVer: 3, DeletedAt: 1700000000000, DeletedBy: tomb.DeletedBy},
+				},
+				Cursor: 42,
+				Hashes: hashes,
+			},
 		},
 		{
 			name: "too long request",
@@ -67,7 +86,7 @@ func TestProviderSync_GetList(t *testing.T) {
 
 			provider := NewHTTPProvider(provCfg)
 			provider.client.SetToken(token)
-			list, err := provider.GetSyncList()
+			list, err := provider.GetSyncList(context.Background())
 
 			tt.reqErr(t, err)
 
@@ -76,3 +95,162 @@ func TestProviderSync_GetList(t *testing.T) {
 		})
 	}
 }
+
+func TestProviderSync_GetListDeadline(t *testing.T) {
+	server := getTestHTTPServer(t, srvBaseCfg.New(
+		withReqMethod(http.MethodGet),
+		withReqURL(provBaseCfg.SyncListURL),
+		withReturnBody(mustMarshal(model.SyncResponse{})),
+		withSleep(provBaseCfg.Timeout/2)))
+	defer server.Close()
+
+	provCfg := provBaseCfg
+	provCfg.BaseURL = server.URL
+
+	provider := NewHTTPProvider(provCfg)
+	provider.client.SetToken(fake.CharactersN(16))
+
+	//  caller deadline is shorter than client timeout
+	ctx, cancel := context.WithTimeout(context.Background(), provBaseCfg.Timeout/10)
+	defer cancel()
+
+	_, err := provider.GetSyncList(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestProviderSync_GetChanges(t *testing.T) {
+	token := fake.CharactersN(16)
+	updated, deleted := uuid.New(), uuid.New()
+
+	respData := model.ChangesResponse{
+		Changes: []model.ChangeItem{
+			{ID: updated, Ver: 3, Seq: 11},
+			{ID: deleted, Ver: 2, IsDeleted: true, Seq: 12},
+		},
+		Cursor: 12,
+	}
+
+	changesSrvConfig := srvBaseCfg.New(
+		withReqMethod(http.MethodGet),
+		withReqURL(provBaseCfg.ChangesURL),
+	)
+
+	tests := []struct {
+		name      string
+		serverCfg serverTestConfig
+
+		reqErr     assert.ErrorAssertionFunc
+		reqChanges climodel.SyncChanges
+	}{
+		{
+			name: "get changes after cursor",
+			serverCfg: changesSrvConfig.New(
+				withReturnBody(mustMarshal(respData))),
+			reqErr: assert.NoError,
+			reqChanges: climodel.SyncChanges{
+				Changes: []climodel.SecretChange{
+					{SecretID: updated, SecretVer: 3},
+					{SecretID: deleted, SecretVer: 2, IsDeleted: true},
+				},
+				Cursor: 12,
+			},
+		},
+		{
+			name: "no changes after cursor",
+			serverCfg: changesSrvConfig.New(
+				withReturnBody(mustMarshal(model.ChangesResponse{Cursor: 10}))),
+			reqErr: assert.NoError,
+			reqChanges: climodel.SyncChanges{
+				Changes: []climodel.SecretChange{},
+				Cursor:  10,
+			},
+		},
+		{
+			name: "cursor expired",
+			serverCfg: changesSrvConfig.New(
+				withReturnStatus(http.StatusGone)),
+			reqErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, climodel.ErrorCursorExpired)
+			},
+		},
+		{
+			name: "err 500",
+			serverCfg: changesSrvConfig.New(
+				withReturnStatus(http.StatusInternalServerError)),
+			reqErr: assert.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getTestHTTPServer(t, tt.serverCfg)
+			defer server.Close()
+
+			provCfg := provBaseCfg
+			provCfg.BaseURL = server.URL
+
+			provider := NewHTTPProvider(provCfg)
+			provider.client.SetToken(token)
+			changes, err := provider.GetChanges(context.Background(), 10)
+
+			tt.reqErr(t, err)
+			require.EqualValues(t, tt.reqChanges, changes)
+		})
+	}
+}
+
+func TestProviderSync_SubscribeChanges(t *testing.T) {
+	token := fake.CharactersN(16)
+	changed, deleted := uuid.New(), uuid.New()
+
+	stream := ": connected\n\n" +
+		"event: change\ndata: " + mustMarshal(model.ChangeEvent{ID: changed, Ver: 2}) + "\n\n" +
+		": ping\n\n" +
+		"event: other\ndata: {}\n\n" +
+		"event: change\ndata: " + mustMarshal(model.ChangeEvent{ID: deleted, IsDeleted: true}) + "\n\n"
+
+	eventsSrvConfig := serverTestConfig{
+		returnHeaders: map[string]string{"content-type": "text/event-stream"},
+		returnStatus:  http.StatusOK,
+		reqMethod:     http.MethodGet,
+		reqHeaders:    map[string]string{"accept": "text/event-stream"},
+		reqURL:        provBaseCfg.EventsURL,
+	}
+
+	t.Run("read change events until stream end", func(t *testing.T) {
+		server := getTestHTTPServer(t, eventsSrvConfig.New(withReturnBody(stream)))
+		defer server.Close()
+
+		provCfg := provBaseCfg
+		provCfg.BaseURL = server.URL
+
+		provider := NewHTTPProvider(provCfg)
+		provider.client.SetToken(token)
+
+		events, err := provider.SubscribeChanges(context.Background())
+		require.NoError(t, err)
+
+		list := make([]climodel.ChangeEvent, 0)
+		for e := range events {
+			list = append(list, e)
+		}
+		require.Equal(t, []climodel.ChangeEvent{
+			{SecretID: changed, SecretVer: 2},
+			{SecretID: deleted, IsDeleted: true},
+		}, list)
+	})
+
+	t.Run("stream not opened", func(t *testing.T) {
+		server := getTestHTTPServer(t, eventsSrvConfig.New(withReturnStatus(http.StatusUnauthorized)))
+		defer server.Close()
+
+		provCfg := provBaseCfg
+		provCfg.BaseURL = server.URL
+
+		provider := NewHTTPProvider(provCfg)
+		provider.client.SetToken(token)
+
+		_, err := provider.SubscribeChanges(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -32,12 +32,19 @@ var (
 	}
 
 	provBaseCfg = HTTPConfig{
-		AuthURL:     "/api/user/login",
-		RegisterURL: "/api/user/register",
-		SecretURL:   "/api/secret",
-		SyncListURL: "/api/sync",
-		PingURL:     "/api/ping",
-		Timeout:     time.Millisecond * 500,
+		AuthURL:          "/api/user/login",
+		RegisterURL:      "/api/user/register",
+		RefreshURL:       "/api/user/refresh",
+		SecretURL:        "/api/secret",
+		BatchUploadURL:   "/api/secrets/upload",
+		BatchDownloadURL: "/api/secrets/download",
+		SyncListURL:      "/api/sync",
+		ChangesURL:       "/api/sync/changes",
+		EventsURL:        "/api/sync/events",
+		PingURL:          "/api/ping",
+		DevicesURL:       "/api/devices",
+		DeviceURL:        "/api/device",
+		Timeout:          time.Millisecond * 500,
 	}
 )
 
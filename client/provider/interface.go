@@ -1,16 +1,46 @@
 package provider
 
 import (
+	"context"
+
 	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
 )
 
 type SecretProvider interface {
-	Authorise(login string, pass string, masterHash string, deviceID uuid.UUID) error
-	Register(login string, pass string, masterHash string, deviceID uuid.UUID) error
-	PingAuth() error
+	Authorise(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) error
+	Register(ctx context.Context, login string, pass string, masterHash string, deviceID uuid.UUID) error
+	PingAuth(ctx context.Context) error
+
+	// UploadSecret uploads secret based on version vector of item, returns server id, version and vector
+	UploadSecret(ctx context.Context, item model.RemoteSecret) (model.RemoteSecret, error)
+	// DownloadSecret downloads secret with version and vector
+	DownloadSecret(ctx context.Context, id uuid.UUID) (model.RemoteSecret, error)
+	DeleteSecret(ctx context.Context, id uuid.UUID) error
+	// UploadSecrets uploads many secrets with one request, returns results in items order
+	UploadSecrets(ctx context.Context, items []model.RemoteSecret) ([]model.RemoteSecret, error)
+	// DownloadSecrets downloads many secrets with one request, returns results in ids order
+	DownloadSecrets(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error)
+	// DownloadSecretsMeta downloads encrypted description and size of many secrets without data
+	DownloadSecretsMeta(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error)
+	GetSyncList(ctx context.Context) (model.SyncList, error)
+	GetChanges(ctx context.Context, cursor int64) (model.SyncChanges, error)
+	// SubscribeChanges opens stream of remote changes made by other devices,
+	// events channel is closed when stream is broken or ctx is done
+	SubscribeChanges(ctx context.Context) (<-chan model.ChangeEvent, error)
+
+	// GetDevices returns devices of user enrolled on server
+	GetDevices(ctx context.Context) ([]model.Device, error)
+	// RevokeDevice revokes user device, server rejects token and certificate of revoked device
+	RevokeDevice(ctx context.Context, id uuid.UUID) error
+}
 
-	UploadSecret(data string, id uuid.UUID, ver int) (uuid.UUID, int, error)
-	DownloadSecret(id uuid.UUID) (uuid.UUID, int, string, error)
-	DeleteSecret(id uuid.UUID) error
-	GetSyncList() (map[uuid.UUID]int, error)
+// DeviceIdentity is key and certificate of device, device certificate authenticates device in TLS connection.
+// Certificate is requested on first login of device.
+type DeviceIdentity interface {
+	// CertificateRequest returns PEM certificate request of device, nil if device has certificate
+	CertificateRequest(ctx context.Context, deviceID uuid.UUID) ([]byte, error)
+	// SaveCertificate saves PEM certificate issued to device
+	SaveCertificate(ctx context.Context, deviceID uuid.UUID, cert []byte) error
 }
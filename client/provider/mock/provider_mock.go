@@ -0,0 +1,294 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: client/provider/interface.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Xrefullx/YanDip/client/model"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockSecretProvider is a mock of SecretProvider interface.
+type MockSecretProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecretProviderMockRecorder
+}
+
+// MockSecretProviderMockRecorder is the mock recorder for MockSecretProvider.
+type MockSecretProviderMockRecorder struct {
+	mock *MockSecretProvider
+}
+
+// NewMockSecretProvider creates a new mock instance.
+func NewMockSecretProvider(ctrl *gomock.Controller) *MockSecretProvider {
+	mock := &MockSecretProvider{ctrl: ctrl}
+	mock.recorder = &MockSecretProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecretProvider) EXPECT() *MockSecretProviderMockRecorder {
+	return m.recorder
+}
+
+// Authorise mocks base method.
+func (m *MockSecretProvider) Authorise(ctx context.Context, login, pass, masterHash string, deviceID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorise", ctx, login, pass, masterHash, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authorise indicates an expected call of Authorise.
+func (mr *MockSecretProviderMockRecorder) Authorise(ctx, login, pass, masterHash, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorise", reflect.TypeOf((*MockSecretProvider)(nil).Authorise), ctx, login, pass, masterHash, deviceID)
+}
+
+// DeleteSecret mocks base method.
+func (m *MockSecretProvider) DeleteSecret(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecret", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecret indicates an expected call of DeleteSecret.
+func (mr *MockSecretProviderMockRecorder) DeleteSecret(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockSecretProvider)(nil).DeleteSecret), ctx, id)
+}
+
+// DownloadSecret mocks base method.
+func (m *MockSecretProvider) DownloadSecret(ctx context.Context, id uuid.UUID) (model.RemoteSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadSecret", ctx, id)
+	ret0, _ := ret[0].(model.RemoteSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadSecret indicates an expected call of DownloadSecret.
+func (mr *MockSecretProviderMockRecorder) DownloadSecret(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadSecret", reflect.TypeOf((*MockSecretProvider)(nil).DownloadSecret), ctx, id)
+}
+
+// DownloadSecrets mocks base method.
+func (m *MockSecretProvider) DownloadSecrets(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadSecrets", ctx, ids)
+	ret0, _ := ret[0].([]model.RemoteSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadSecrets indicates an expected call of DownloadSecrets.
+func (mr *MockSecretProviderMockRecorder) DownloadSecrets(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadSecrets", reflect.TypeOf((*MockSecretProvider)(nil).DownloadSecrets), ctx, ids)
+}
+
+// DownloadSecretsMeta mocks base method.
+func (m *MockSecretProvider) DownloadSecretsMeta(ctx context.Context, ids []uuid.UUID) ([]model.RemoteSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadSecretsMeta", ctx, ids)
+	ret0, _ := ret[0].([]model.RemoteSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadSecretsMeta indicates an expected call of DownloadSecretsMeta.
+func (mr *MockSecretProviderMockRecorder) DownloadSecretsMeta(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadSecretsMeta", reflect.TypeOf((*MockSecretProvider)(nil).DownloadSecretsMeta), ctx, ids)
+}
+
+// GetChanges mocks base method.
+func (m *MockSecretProvider) GetChanges(ctx context.Context, cursor int64) (model.SyncChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, cursor)
+	ret0, _ := ret[0].(model.SyncChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockSecretProviderMockRecorder) GetChanges(ctx, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockSecretProvider)(nil).GetChanges), ctx, cursor)
+}
+
+// GetDevices mocks base method.
+func (m *MockSecretProvider) GetDevices(ctx context.Context) ([]model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDevices", ctx)
+	ret0, _ := ret[0].([]model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDevices indicates an expected call of GetDevices.
+func (mr *MockSecretProviderMockRecorder) GetDevices(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevices", reflect.TypeOf((*MockSecretProvider)(nil).GetDevices), ctx)
+}
+
+// GetSyncList mocks base method.
+func (m *MockSecretProvider) GetSyncList(ctx context.Context) (model.SyncList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSyncList", ctx)
+	ret0, _ := ret[0].(model.SyncList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSyncList indicates an expected call of GetSyncList.
+func (mr *MockSecretProviderMockRecorder) GetSyncList(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSyncList", reflect.TypeOf((*MockSecretProvider)(nil).GetSyncList), ctx)
+}
+
+// PingAuth mocks base method.
+func (m *MockSecretProvider) PingAuth(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PingAuth", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PingAuth indicates an expected call of PingAuth.
+func (mr *MockSecretProviderMockRecorder) PingAuth(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PingAuth", reflect.TypeOf((*MockSecretProvider)(nil).PingAuth), ctx)
+}
+
+// Register mocks base method.
+func (m *MockSecretProvider) Register(ctx context.Context, login, pass, masterHash string, deviceID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, login, pass, masterHash, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockSecretProviderMockRecorder) Register(ctx, login, pass, masterHash, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockSecretProvider)(nil).Register), ctx, login, pass, masterHash, deviceID)
+}
+
+// RevokeDevice mocks base method.
+func (m *MockSecretProvider) RevokeDevice(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeDevice", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeDevice indicates an expected call of RevokeDevice.
+func (mr *MockSecretProviderMockRecorder) RevokeDevice(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeDevice", reflect.TypeOf((*MockSecretProvider)(nil).RevokeDevice), ctx, id)
+}
+
+// SubscribeChanges mocks base method.
+func (m *MockSecretProvider) SubscribeChanges(ctx context.Context) (<-chan model.ChangeEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeChanges", ctx)
+	ret0, _ := ret[0].(<-chan model.ChangeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeChanges indicates an expected call of SubscribeChanges.
+func (mr *MockSecretProviderMockRecorder) SubscribeChanges(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeChanges", reflect.TypeOf((*MockSecretProvider)(nil).SubscribeChanges), ctx)
+}
+
+// UploadSecret mocks base method.
+func (m *MockSecretProvider) UploadSecret(ctx context.Context, item model.RemoteSecret) (model.RemoteSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSecret", ctx, item)
+	ret0, _ := ret[0].(model.RemoteSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadSecret indicates an expected call of UploadSecret.
+func (mr *MockSecretProviderMockRecorder) UploadSecret(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSecret", reflect.TypeOf((*MockSecretProvider)(nil).UploadSecret), ctx, item)
+}
+
+// UploadSecrets mocks base method.
+func (m *MockSecretProvider) UploadSecrets(ctx context.Context, items []model.RemoteSecret) ([]model.RemoteSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadSecrets", ctx, items)
+	ret0, _ := ret[0].([]model.RemoteSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadSecrets indicates an expected call of UploadSecrets.
+func (mr *MockSecretProviderMockRecorder) UploadSecrets(ctx, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadSecrets", reflect.TypeOf((*MockSecretProvider)(nil).UploadSecrets), ctx, items)
+}
+
+// MockDeviceIdentity is a mock of DeviceIdentity interface.
+type MockDeviceIdentity struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceIdentityMockRecorder
+}
+
+// MockDeviceIdentityMockRecorder is the mock recorder for MockDeviceIdentity.
+type MockDeviceIdentityMockRecorder struct {
+	mock *MockDeviceIdentity
+}
+
+// NewMockDeviceIdentity creates a new mock instance.
+func NewMockDeviceIdentity(ctrl *gomock.Controller) *MockDeviceIdentity {
+	mock := &MockDeviceIdentity{ctrl: ctrl}
+	mock.recorder = &MockDeviceIdentityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceIdentity) EXPECT() *MockDeviceIdentityMockRecorder {
+	return m.recorder
+}
+
+// CertificateRequest mocks base method.
+func (m *MockDeviceIdentity) CertificateRequest(ctx context.Context, deviceID uuid.UUID) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CertificateRequest", ctx, deviceID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CertificateRequest indicates an expected call of CertificateRequest.
+func (mr *MockDeviceIdentityMockRecorder) CertificateRequest(ctx, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CertificateRequest", reflect.TypeOf((*MockDeviceIdentity)(nil).CertificateRequest), ctx, deviceID)
+}
+
+// SaveCertificate mocks base method.
+func (m *MockDeviceIdentity) SaveCertificate(ctx context.Context, deviceID uuid.UUID, cert []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCertificate", ctx, deviceID, cert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCertificate indicates an expected call of SaveCertificate.
+func (mr *MockDeviceIdentityMockRecorder) SaveCertificate(ctx, deviceID, cert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCertificate", reflect.TypeOf((*MockDeviceIdentity)(nil).SaveCertificate), ctx, deviceID, cert)
+}
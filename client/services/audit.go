@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// Auditor records vault operations
+type Auditor interface {
+	Record(ctx context.Context, operationID int, secretLocID int64, secretID uuid.UUID, details string) error
+}
+
+var _ Auditor = (*AuditService)(nil)
+
+type AuditService struct {
+	db     storage.Storage
+	key    []byte
+	source string
+	mu     sync.Mutex
+}
+
+// NewAuditService returns new instance of audit service
+// Service appends entries to hash chained audit log, source is name of client command writing entries.
+// Entries are signed with key derived from master key, so log is verified only with the same master key.
+func NewAuditService(cfg *pkg.Config, db storage.Storage, source string) *AuditService {
+	key := sha256.Sum256([]byte("audit-log:" + cfg.MasterKey))
+
+	return &AuditService{
+		db:     db,
+		key:    key[:],
+		source: source,
+	}
+}
+
+// Record appends operation entry to audit log, details must not contain secret values
+func (s *AuditService) Record(ctx context.Context, operationID int, secretLocID int64, secretID uuid.UUID, details string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	head, err := s.db.GetAuditHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	e := model.AuditEntry{
+		Seq:         head.Seq + 1,
+		TimeStamp:   pkg.MakeTimestamp(),
+		OperationID: operationID,
+		SecretLocID: secretLocID,
+		SecretID:    secretID,
+		Source:      s.source,
+		Details:     details,
+		PrevHash:    head.Hash,
+	}
+	e.Hash = s.hash(e)
+
+	return s.db.AddAuditEntry(ctx, e, s.headMAC(e.Seq, e.Hash))
+}
+
+// Query returns audit entries matching filter
+func (s *AuditService) Query(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	return s.db.GetAuditEntries(ctx, filter)
+}
+
+// Verify checks audit log chain, returns count of verified entries.
+// Log is broken if entry is changed, removed or added not by audit service, or head is not written by audit service.
+func (s *AuditService) Verify(ctx context.Context) (int, error) {
+	list, err := s.db.GetAuditEntries(ctx, model.AuditFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	//  signed head detects removed last entries
+	head, err := s.db.GetAuditHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if head.Seq > 0 && !hmac.Equal([]byte(head.MAC), []byte(s.headMAC(head.Seq, head.Hash))) {
+		return 0, fmt.Errorf("%w: head is not signed by audit key", model.ErrorAuditLogBroken)
+	}
+
+	prevHash := ""
+	for i, e := range list {
+		if e.Seq != int64(i+1) {
+			return i, fmt.Errorf("%w: entry %v is missing", model.ErrorAuditLogBroken, i+1)
+		}
+		if e.PrevHash != prevHash {
+			return i, fmt.Errorf("%w: entry %v is not linked to previous entry", model.ErrorAuditLogBroken, e.Seq)
+		}
+		if !hmac.Equal([]byte(e.Hash), []byte(s.hash(e))) {
+			return i, fmt.Errorf("%w: entry %v is changed", model.ErrorAuditLogBroken, e.Seq)
+		}
+		prevHash = e.Hash
+	}
+
+	if head.Seq != int64(len(list)) || head.Hash != prevHash {
+		return len(list), fmt.Errorf("%w: log has %v entries, head points to entry %v", model.ErrorAuditLogBroken, len(list), head.Seq)
+	}
+
+	return len(list), nil
+}
+
+// hash returns HMAC of entry fields and previous entry hash
+func (s *AuditService) hash(e model.AuditEntry) string {
+	mac := hmac.New(sha256.New, s.key)
+
+	var num [8]byte
+	for _, v := range []int64{e.Seq, e.TimeStamp, int64(e.OperationID), e.SecretLocID} {
+		binary.BigEndian.PutUint64(num[:], uint64(v))
+		mac.Write(num[:])
+	}
+	mac.Write(e.SecretID[:])
+	for _, v := range []string{e.Source, e.Details, e.PrevHash} {
+		binary.BigEndian.PutUint64(num[:], uint64(len(v)))
+		mac.Write(num[:])
+		mac.Write([]byte(v))
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// headMAC returns HMAC of log head, head is signed separately from entries with the same key
+func (s *AuditService) headMAC(seq int64, hash string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("audit-head:"))
+
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], uint64(seq))
+	mac.Write(num[:])
+	mac.Write([]byte(hash))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordAudit records operation with auditor if it is set, error is logged not to fail completed operation
+func recordAudit(ctx context.Context, a Auditor, operation string, secretLocID int64, secretID uuid.UUID, details string) {
+	if a == nil {
+		return
+	}
+
+	if err := a.Record(ctx, model.AuditOperations[operation], secretLocID, secretID, details); err != nil {
+		log.Printf("error record audit %v of secret %v: %v", operation, secretLocID, err)
+	}
+}
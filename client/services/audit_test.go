@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+// mockAudit is audit log of mock storage kept in memory
+type mockAudit struct {
+	entries []model.AuditEntry
+	head    model.AuditHead
+}
+
+// mockAuditLog keeps audit log of mock storage in memory
+func mockAuditLog(storage *mk.MockStorage) *mockAudit {
+	audit := &mockAudit{entries: make([]model.AuditEntry, 0)}
+
+	storage.EXPECT().GetAuditHead(gomock.Any()).DoAndReturn(func(_ context.Context) (model.AuditHead, error) {
+		return audit.head, nil
+	}).AnyTimes()
+	storage.EXPECT().AddAuditEntry(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e model.AuditEntry, headMAC string) error {
+		audit.entries = append(audit.entries, e)
+		audit.head = model.AuditHead{Seq: e.Seq, Hash: e.Hash, MAC: headMAC}
+		return nil
+	}).AnyTimes()
+
+	return audit
+}
+
+func TestAudit_RecordVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	auditLog := mockAuditLog(storage)
+	entries := &auditLog.entries
+	svc := NewAuditService(&cfg, storage, "test")
+
+	secretID := uuid.New()
+	require.NoError(t, svc.Record(context.Background(), model.AuditOperations["ADD"], 1, uuid.Nil, ""))
+	require.NoError(t, svc.Record(context.Background(), model.AuditOperations["REVEAL"], 1, secretID, ""))
+	require.NoError(t, svc.Record(context.Background(), model.AuditOperations["EXPORT"], 0, uuid.Nil, "backup file, items 1"))
+
+	require.Len(t, *entries, 3)
+	for i, el := range *entries {
+		require.Equal(t, int64(i+1), el.Seq)
+		require.Equal(t, "test", el.Source)
+		if i > 0 {
+			require.Equal(t, (*entries)[i-1].Hash, el.PrevHash)
+		}
+	}
+
+	//  head of mock log points to last recorded entry
+	verify := func(list []model.AuditEntry) (int, error) {
+		storage.EXPECT().GetAuditEntries(gomock.Any(), model.AuditFilter{}).Return(list, nil)
+		return svc.Verify(context.Background())
+	}
+	valid := *entries
+
+	count, err := verify(valid)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	//  changed entry
+	changed := append([]model.AuditEntry{}, valid...)
+	changed[1].OperationID = model.AuditOperations["EDIT"]
+	count, err = verify(changed)
+	require.True(t, errors.Is(err, model.ErrorAuditLogBroken))
+	require.Equal(t, 1, count)
+
+	//  removed entry in the middle
+	_, err = verify([]model.AuditEntry{valid[0], valid[2]})
+	require.True(t, errors.Is(err, model.ErrorAuditLogBroken))
+
+	//  truncated log is detected by head
+	_, err = verify(valid[:2])
+	require.True(t, errors.Is(err, model.ErrorAuditLogBroken))
+
+	//  truncated log with head moved to new last entry is detected by head signature
+	signed := auditLog.head
+	auditLog.head = model.AuditHead{Seq: valid[1].Seq, Hash: valid[1].Hash, MAC: signed.MAC}
+	count, err = verify(valid[:2])
+	require.True(t, errors.Is(err, model.ErrorAuditLogBroken))
+	require.Zero(t, count)
+
+	auditLog.head = model.AuditHead{Seq: valid[1].Seq, Hash: valid[1].Hash}
+	_, err = verify(valid[:2])
+	require.True(t, errors.Is(err, model.ErrorAuditLogBroken))
+	auditLog.head = signed
+
+	//  log signed with other master key
+	other := NewAuditService(&pkg.Config{MasterKey: "otherKey"}, storage, "test")
+	storage.EXPECT().GetAuditEntries(gomock.Any(), model.AuditFilter{}).Return(valid, nil)
+	_, err = other.Verify(context.Background())
+	require.True(t, errors.Is(err, model.ErrorAuditLogBroken))
+}
+
+func TestAudit_SecretOperations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	entries := &mockAuditLog(storage).entries
+	svc := GetTestSecretSvc(t, storage)
+	svc.SetAuditor(NewAuditService(&cfg, storage, "test"))
+
+	secret, err := svc.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+	secret.ID, secret.SecretID = 1, uuid.New()
+
+	storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(secret, nil).AnyTimes()
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	_, err = svc.AddAuth(context.Background(), model.TestAuth)
+	require.NoError(t, err)
+	_, err = svc.RevealSecret(context.Background(), 1)
+	require.NoError(t, err)
+	require.NoError(t, svc.MarkCopied(context.Background(), 1, "password"))
+	require.NoError(t, svc.EditSecret(context.Background(), 1, model.TestAuth))
+	require.NoError(t, svc.MoveToTrash(context.Background(), 1))
+
+	ops := make([]int, 0, len(*entries))
+	details := make([]string, 0, len(*entries))
+	for _, el := range *entries {
+		ops = append(ops, el.OperationID)
+		details = append(details, el.Details)
+	}
+	require.Equal(t, []int{
+		model.AuditOperations["ADD"],
+		model.AuditOperations["REVEAL"],
+		model.AuditOperations["COPY"],
+		model.AuditOperations["EDIT"],
+		model.AuditOperations["TRASH"],
+	}, ops)
+	//  only metadata is written to log
+	require.Equal(t, []string{"", "", "field password", "", ""}, details)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/backup"
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// RestoreMode is the way backup items are restored
+type RestoreMode string
+
+const (
+	// RestoreNew adds every backup item as new secret
+	RestoreNew RestoreMode = "new"
+	// RestoreMerge skips items already in vault, updates secrets older than backup, adds others
+	RestoreMerge RestoreMode = "merge"
+)
+
+// RestoreResult is result of backup restore
+type RestoreResult struct {
+	Added   int
+	Updated int
+	Skipped int
+}
+
+type BackupService struct {
+	db      storage.Storage
+	secrets *SecretService
+	kdf     backup.KDFParams
+}
+
+// NewBackupService returns new instance of backup service
+// Service exports vault to encrypted bundle and restores it
+func NewBackupService(db storage.Storage, secrets *SecretService) *BackupService {
+	return &BackupService{
+		db:      db,
+		secrets: secrets,
+		kdf:     backup.DefaultKDFParams,
+	}
+}
+
+// Export writes all secrets to bundle file encrypted with passphrase, returns count of items
+func (s *BackupService) Export(ctx context.Context, filePath string, passphrase string) (int, error) {
+	list, err := s.db.GetSecretList(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]backup.Item, 0, len(list))
+	for _, el := range list {
+		//  bundle must be complete, placeholders are downloaded first
+		if el.PayloadID != model.PayloadStates["LOCAL"] {
+			fetched, err := s.secrets.fetchSecret(ctx, el.ID)
+			if err != nil {
+				return 0, fmt.Errorf("error read secret %v: %w", el.ID, err)
+			}
+			el = fetched
+		}
+
+		obj, err := s.secrets.ReadFromSecret(el)
+		if err != nil {
+			return 0, fmt.Errorf("error read secret %v: %w", el.ID, err)
+		}
+
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return 0, err
+		}
+
+		items = append(items, backup.Item{
+			SecretID:  el.SecretID,
+			SecretVer: el.SecretVer,
+			TypeID:    el.TypeID,
+			TimeStamp: el.TimeStamp,
+			Secret:    data,
+		})
+	}
+
+	data, err := backup.Write(items, passphrase, model.BuildVersion, s.kdf)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return 0, err
+	}
+
+	recordAudit(ctx, s.secrets.audit, "EXPORT", 0, uuid.Nil, fmt.Sprintf("backup %v, items %v", filepath.Base(filePath), len(items)))
+
+	return len(items), nil
+}
+
+// Verify checks bundle integrity and passphrase, returns decrypted bundle
+func (s *BackupService) Verify(filePath string, passphrase string) (backup.Bundle, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return backup.Bundle{}, err
+	}
+
+	return backup.Read(data, passphrase)
+}
+
+// Restore verifies bundle and adds its items to vault
+func (s *BackupService) Restore(ctx context.Context, filePath string, passphrase string, mode RestoreMode) (RestoreResult, error) {
+	if mode != RestoreNew && mode != RestoreMerge {
+		return RestoreResult{}, fmt.Errorf("%w: restore mode %v", model.ErrorParamNotValid, mode)
+	}
+
+	//  nothing is restored if bundle is not valid
+	bundle, err := s.Verify(filePath, passphrase)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	var existing map[string]int64
+	if mode == RestoreMerge {
+		if existing, err = vaultFingerprints(ctx, s.db, s.secrets); err != nil {
+			return RestoreResult{}, err
+		}
+	}
+
+	res := RestoreResult{}
+	for i, item := range bundle.Items {
+		obj, err := itemObject(item)
+		if err != nil {
+			return res, fmt.Errorf("error read backup item %v: %w", i, err)
+		}
+
+		if mode == RestoreMerge {
+			updated, skipped, err := s.merge(ctx, item, obj, existing)
+			if err != nil {
+				return res, fmt.Errorf("error restore backup item %v: %w", i, err)
+			}
+			if updated {
+				res.Updated++
+				continue
+			}
+			if skipped {
+				res.Skipped++
+				continue
+			}
+		}
+
+		if _, err := s.secrets.addSecret(ctx, obj); err != nil {
+			return res, fmt.Errorf("error restore backup item %v: %w", i, err)
+		}
+		res.Added++
+	}
+
+	recordAudit(ctx, s.secrets.audit, "IMPORT", 0, uuid.Nil, fmt.Sprintf("backup %v, added %v, updated %v, skipped %v",
+		filepath.Base(filePath), res.Added, res.Updated, res.Skipped))
+
+	return res, nil
+}
+
+// merge updates local copy of item if backup version is newer,
+// item is skipped if local copy is same or newer, or vault has same secret
+func (s *BackupService) merge(ctx context.Context, item backup.Item, obj interface{}, existing map[string]int64) (bool, bool, error) {
+	if item.SecretID != uuid.Nil {
+		local, err := s.db.GetSecretByExtID(ctx, item.SecretID)
+		if err != nil && !errors.Is(err, model.ErrorItemNotFound) {
+			return false, false, err
+		}
+
+		if err == nil && local.StatusID != model.SecretStatuses["DELETED"] {
+			if item.SecretVer <= local.SecretVer {
+				return false, true, nil
+			}
+
+			return true, false, s.secrets.EditSecret(ctx, local.ID, obj)
+		}
+	}
+
+	if _, ok := existing[fingerprint(obj)]; ok {
+		return false, true, nil
+	}
+
+	return false, false, nil
+}
+
+// itemObject reads secret object of backup item
+func itemObject(item backup.Item) (interface{}, error) {
+	info := model.Info{}
+	if err := json.Unmarshal(item.Secret, &info); err != nil {
+		return nil, err
+	}
+
+	if info.TypeID != item.TypeID {
+		return nil, fmt.Errorf("%w: item type %v, secret type %v", model.ErrorParamNotValid, item.TypeID, info.TypeID)
+	}
+
+	return objectFromData(info, item.Secret)
+}
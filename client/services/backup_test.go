@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/backup"
+	"github.com/Xrefullx/YanDip/client/model"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestBackup_ExportRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	secretSvc := GetTestSecretSvc(t, storage)
+
+	svc := NewBackupService(storage, secretSvc)
+	svc.kdf = backup.KDFParams{Time: 1, Memory: 64, Threads: 1}
+
+	authID := uuid.New()
+	auth, err := secretSvc.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+	auth.ID, auth.SecretID, auth.SecretVer = 1, authID, 2
+
+	card, err := secretSvc.ToSecret(model.TestCard)
+	require.NoError(t, err)
+	card.ID = 2
+
+	file := filepath.Join(t.TempDir(), "vault.backup")
+
+	storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{auth, card}, nil)
+	count, err := svc.Export(context.Background(), file, "backup pass")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	_, err = svc.Verify(file, "wrong")
+	require.ErrorIs(t, err, backup.ErrorWrongPassphrase)
+
+	t.Run("new", func(t *testing.T) {
+		storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Return(int64(10), nil).Times(2)
+
+		res, err := svc.Restore(context.Background(), file, "backup pass", RestoreNew)
+		require.NoError(t, err)
+		require.Equal(t, RestoreResult{Added: 2}, res)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		//  local auth has older version, card exists with same content
+		old := auth
+		old.SecretVer = 1
+		storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{old, card}, nil)
+		storage.EXPECT().GetSecretByExtID(gomock.Any(), authID).Return(old, nil)
+		storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(old, nil)
+		storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["LOCAL_EDIT"], 0).Return(nil)
+
+		res, err := svc.Restore(context.Background(), file, "backup pass", RestoreMerge)
+		require.NoError(t, err)
+		require.Equal(t, RestoreResult{Updated: 1, Skipped: 1}, res)
+	})
+}
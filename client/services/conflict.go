@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+type ConflictService struct {
+	cfg *pkg.Config
+	db  storage.Storage
+}
+
+// NewConflictService returns new instance of conflict service
+// Service lists sync conflicts saved with MANUAL strategy and resolves them
+func NewConflictService(cfg *pkg.Config, db storage.Storage) *ConflictService {
+	return &ConflictService{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// Conflicts returns list of unresolved conflicts
+func (s *ConflictService) Conflicts(ctx context.Context) ([]model.Conflict, error) {
+	return s.db.GetConflicts(ctx)
+}
+
+// Resolve resolves saved conflict with strategy, MANUAL strategy is not allowed
+func (s *ConflictService) Resolve(ctx context.Context, id int64, strategy string) error {
+	strategyID, ok := model.ConflictStrategies[strategy]
+	if !ok || strategyID == model.ConflictStrategies["MANUAL"] {
+		return fmt.Errorf("%w: conflict strategy %q", model.ErrorParamNotValid, strategy)
+	}
+
+	c, err := s.db.GetConflict(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return resolveConflict(ctx, s.db, s.cfg, c, strategyID)
+}
+
+// resolveConflict applies strategy to conflict, saved conflict is deleted after resolution
+func resolveConflict(ctx context.Context, db storage.Storage, cfg *pkg.Config, c model.Conflict, strategyID int) error {
+	secret, err := db.GetSecret(ctx, c.SecretLocID)
+	if err != nil {
+		//  secret deleted locally, nothing to resolve
+		if errors.Is(err, model.ErrorItemNotFound) {
+			return deleteConflict(ctx, db, c)
+		}
+		return err
+	}
+
+	switch strategyID {
+	case model.ConflictStrategies["KEEP_LOCAL"], model.ConflictStrategies["KEEP_REMOTE"]:
+		//  changes of not conflicted fields are kept from both sides, strategy chooses conflicted fields
+		res, ok, err := mergeConflict(cfg, secret, c, strategyID == model.ConflictStrategies["KEEP_LOCAL"])
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := saveMerged(ctx, db, cfg, secret, c, res); err != nil {
+				return err
+			}
+			break
+		}
+
+		//  no base version, whole secret is chosen
+		if strategyID == model.ConflictStrategies["KEEP_REMOTE"] {
+			if err := keepRemote(ctx, db, cfg, secret, c); err != nil {
+				return err
+			}
+			break
+		}
+
+		//  upload of local edit with remote version overwrites remote version
+		secret.SecretVer = c.RemoteVer
+		secret.Vector = c.RemoteVector
+		secret.BaseData = c.RemoteData
+		secret.StatusID = model.SecretStatuses["EDITED"]
+
+		if err := db.UpdateSecret(ctx, secret); err != nil {
+			return err
+		}
+
+	case model.ConflictStrategies["KEEP_BOTH"]:
+		//  local edit is uploaded as new secret on next sync
+		if _, err := db.AddSecret(ctx, model.Secret{
+			Info:       secret.Info,
+			SecretID:   uuid.Nil,
+			SecretVer:  1,
+			StatusID:   model.SecretStatuses["NEW"],
+			SecretData: secret.SecretData,
+		}); err != nil {
+			return fmt.Errorf("error fork local edit of secret %v: %w", secret.ID, err)
+		}
+
+		if err := keepRemote(ctx, db, cfg, secret, c); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("%w: conflict strategy %v", model.ErrorParamNotValid, strategyID)
+	}
+
+	return deleteConflict(ctx, db, c)
+}
+
+// keepRemote replaces local edit of secret with remote version, local edit is saved as revision
+func keepRemote(ctx context.Context, db storage.Storage, cfg *pkg.Config, secret model.Secret, c model.Conflict) error {
+	info := model.Info{}
+	if err := info.FromEncodedData(c.RemoteData, cfg.MasterKey); err != nil {
+		return fmt.Errorf("error read info drom encoded secret data: %w", err)
+	}
+
+	secret.Info = info
+	secret.SecretData = c.RemoteData
+	secret.BaseData = c.RemoteData
+	secret.SecretVer = c.RemoteVer
+	secret.Vector = c.RemoteVector
+	secret.StatusID = model.SecretStatuses["ACTUAL"]
+
+	return db.UpdateSecretWithRevision(ctx, secret, model.RevisionCauses["CONFLICT"], cfg.RevisionsKeep)
+}
+
+// mergeConflict merges local and remote versions of conflicted secret with last synced base version,
+// returns false if secret has no base version
+func mergeConflict(cfg *pkg.Config, secret model.Secret, c model.Conflict, preferLocal bool) (mergeResult, bool, error) {
+	if secret.BaseData == "" {
+		return mergeResult{}, false, nil
+	}
+
+	base, err := decodeObject(secret.BaseData, cfg.MasterKey)
+	if err != nil {
+		return mergeResult{}, false, fmt.Errorf("error read base version of secret %v: %w", secret.ID, err)
+	}
+	local, err := decodeObject(secret.SecretData, cfg.MasterKey)
+	if err != nil {
+		return mergeResult{}, false, fmt.Errorf("error read local version of secret %v: %w", secret.ID, err)
+	}
+	remote, err := decodeObject(c.RemoteData, cfg.MasterKey)
+	if err != nil {
+		return mergeResult{}, false, fmt.Errorf("error read remote version of secret %v: %w", secret.ID, err)
+	}
+
+	res, err := mergeSecrets(base, local, remote, preferLocal)
+	if err != nil {
+		return mergeResult{}, false, err
+	}
+
+	return res, true, nil
+}
+
+// saveMerged saves merged secret on top of remote version, merged local changes are uploaded on next sync.
+// Local edit is saved as revision.
+func saveMerged(ctx context.Context, db storage.Storage, cfg *pkg.Config, secret model.Secret, c model.Conflict, res mergeResult) error {
+	if res.IsRemote {
+		return keepRemote(ctx, db, cfg, secret, c)
+	}
+
+	info, err := itemInfo(res.Object)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(res.Object)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := pkg.Encode(data, cfg.MasterKey)
+	if err != nil {
+		return err
+	}
+
+	secret.Info = info
+	secret.SecretData = encrypted
+	secret.BaseData = c.RemoteData
+	secret.SecretVer = c.RemoteVer
+	secret.Vector = c.RemoteVector
+	secret.StatusID = model.SecretStatuses["EDITED"]
+
+	return db.UpdateSecretWithRevision(ctx, secret, model.RevisionCauses["CONFLICT"], cfg.RevisionsKeep)
+}
+
+// deleteConflict deletes saved conflict, conflict resolved before saving has no id
+func deleteConflict(ctx context.Context, db storage.Storage, c model.Conflict) error {
+	if c.ID == 0 {
+		return nil
+	}
+
+	return db.DeleteConflict(ctx, c.ID)
+}
+
+// conflictStrategy returns id of configured conflict strategy, MANUAL if not set
+func conflictStrategy(cfg *pkg.Config) int {
+	if strategyID, ok := model.ConflictStrategies[cfg.ConflictStrategy]; ok {
+		return strategyID
+	}
+
+	return model.ConflictStrategies["MANUAL"]
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+// getConflictSecrets returns locally edited secret and remote version of it
+func getConflictSecrets(t *testing.T) (model.Secret, string) {
+	svc := NewSecret(&cfg, nil)
+
+	local, err := svc.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+	local.ID, local.SecretID, local.SecretVer = 1, uuid.New(), 2
+	local.StatusID = model.SecretStatuses["EDITED"]
+
+	remoteObj := model.TestAuth
+	remoteObj.Title = "remote title"
+	remote, err := svc.ToSecret(remoteObj)
+	require.NoError(t, err)
+
+	return local, remote.SecretData
+}
+
+func TestConflict_AddCollisionManual(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	local, remoteData := getConflictSecrets(t)
+
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60, ConflictStrategy: "MANUAL"})
+
+	provider.EXPECT().DownloadSecret(gomock.Any(), local.SecretID).Return(model.RemoteSecret{SecretID: local.SecretID, SecretVer: 3, Data: remoteData}, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), local.ID).Return(local, nil)
+	storage.EXPECT().AddConflict(gomock.Any(), model.Conflict{
+		SecretLocID: local.ID,
+		SecretID:    local.SecretID,
+		LocalVer:    2,
+		RemoteVer:   3,
+		RemoteData:  remoteData,
+	}).Return(int64(1), nil)
+
+	require.NoError(t, svc.ProcessTask(context.Background(), taskCollision(model.SecretMeta{ID: local.ID, SecretID: local.SecretID}, 3)))
+}
+
+func TestConflict_AddCollisionVectors(t *testing.T) {
+	deviceA, deviceB := uuid.NewString(), uuid.NewString()
+	base := model.VersionVector{deviceA: 2, deviceB: 1}
+
+	tests := []struct {
+		name     string
+		remote   model.VersionVector
+		conflict bool
+	}{
+		{name: "remote descends from base - conflict", remote: model.VersionVector{deviceA: 2, deviceB: 2}, conflict: true},
+		{name: "remote concurrent to base - conflict", remote: model.VersionVector{deviceA: 1, deviceB: 2}, conflict: true},
+		{name: "remote equal to base - rebase", remote: base},
+		{name: "remote is ancestor of base - rebase", remote: model.VersionVector{deviceA: 1, deviceB: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := mk.NewMockStorage(ctrl)
+			provider := pmk.NewMockSecretProvider(ctrl)
+			local, remoteData := getConflictSecrets(t)
+			local.Vector = base
+
+			svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60, ConflictStrategy: "MANUAL"})
+
+			provider.EXPECT().DownloadSecret(gomock.Any(), local.SecretID).Return(model.RemoteSecret{SecretID: local.SecretID, SecretVer: 3, Data: remoteData, Vector: tt.remote}, nil)
+			storage.EXPECT().GetSecret(gomock.Any(), local.ID).Return(local, nil)
+			if tt.conflict {
+				storage.EXPECT().AddConflict(gomock.Any(), gomock.Any()).Do(func(_ context.Context, c model.Conflict) {
+					require.Equal(t, tt.remote, c.RemoteVector)
+				}).Return(int64(1), nil)
+			} else {
+				storage.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+					require.Equal(t, 3, v.SecretVer)
+					require.Equal(t, tt.remote, v.Vector)
+					require.Equal(t, local.SecretData, v.SecretData)
+					require.Equal(t, model.SecretStatuses["EDITED"], v.StatusID)
+				}).Return(nil)
+			}
+
+			require.NoError(t, svc.ProcessTask(context.Background(), taskCollision(model.SecretMeta{ID: local.ID, SecretID: local.SecretID}, 3)))
+		})
+	}
+}
+
+func TestConflict_Resolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		expect   func(storage *mk.MockStorage, local model.Secret, remoteData string)
+	}{
+		{
+			name:     "keep local - upload local edit over remote version",
+			strategy: "KEEP_LOCAL",
+			expect: func(storage *mk.MockStorage, local model.Secret, _ string) {
+				storage.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+					require.Equal(t, 3, v.SecretVer)
+					require.Equal(t, model.SecretStatuses["EDITED"], v.StatusID)
+					require.Equal(t, local.SecretData, v.SecretData)
+				}).Return(nil)
+			},
+		},
+		{
+			name:     "keep remote - local edit saved as revision",
+			strategy: "KEEP_REMOTE",
+			expect: func(storage *mk.MockStorage, _ model.Secret, remoteData string) {
+				storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["CONFLICT"], 0).Do(func(_ context.Context, v model.Secret, _ int, _ int) {
+					require.Equal(t, 3, v.SecretVer)
+					require.Equal(t, model.SecretStatuses["ACTUAL"], v.StatusID)
+					require.Equal(t, remoteData, v.SecretData)
+					require.Equal(t, "remote title", v.Title)
+				}).Return(nil)
+			},
+		},
+		{
+			name:     "keep both - local edit forked to new secret",
+			strategy: "KEEP_BOTH",
+			expect: func(storage *mk.MockStorage, local model.Secret, remoteData string) {
+				storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+					require.Equal(t, uuid.Nil, v.SecretID)
+					require.Equal(t, model.SecretStatuses["NEW"], v.StatusID)
+					require.Equal(t, local.SecretData, v.SecretData)
+				}).Return(int64(2), nil)
+				storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["CONFLICT"], 0).Do(func(_ context.Context, v model.Secret, _ int, _ int) {
+					require.Equal(t, remoteData, v.SecretData)
+				}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := mk.NewMockStorage(ctrl)
+			local, remoteData := getConflictSecrets(t)
+			local.StatusID = model.SecretStatuses["CONFLICT"]
+
+			storage.EXPECT().GetConflict(gomock.Any(), int64(5)).Return(model.Conflict{
+				ID:          5,
+				SecretLocID: local.ID,
+				SecretID:    local.SecretID,
+				LocalVer:    2,
+				RemoteVer:   3,
+				RemoteData:  remoteData,
+			}, nil)
+			storage.EXPECT().GetSecret(gomock.Any(), local.ID).Return(local, nil)
+			tt.expect(storage, local, remoteData)
+			storage.EXPECT().DeleteConflict(gomock.Any(), int64(5)).Return(nil)
+
+			require.NoError(t, NewConflictService(&cfg, storage).Resolve(context.Background(), 5, tt.strategy))
+		})
+	}
+
+	//  manual is not resolution
+	require.ErrorIs(t, NewConflictService(&cfg, nil).Resolve(context.Background(), 5, "MANUAL"), model.ErrorParamNotValid)
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/importer"
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// ImportItem is preview of one import record
+type ImportItem struct {
+	Index  int
+	TypeID int
+	Title  string
+	Folder string
+	//  count of previous versions of record
+	History int
+
+	Duplicate bool
+	//  local id of existing duplicate secret, 0 if record duplicates previous record of file
+	DuplicateOf int64
+}
+
+// ImportPreview is dry run result of import
+type ImportPreview struct {
+	Format     importer.Format
+	Items      []ImportItem
+	Duplicates int
+}
+
+// ImportResult is result of import
+type ImportResult struct {
+	BatchID int64
+	Format  importer.Format
+	Added   int
+	Skipped int
+}
+
+type ImportService struct {
+	db      storage.Storage
+	secrets *SecretService
+}
+
+// NewImportService returns new instance of import service
+// Service imports secrets from other password managers export files
+func NewImportService(db storage.Storage, secrets *SecretService) *ImportService {
+	return &ImportService{
+		db:      db,
+		secrets: secrets,
+	}
+}
+
+// Preview reads export file and returns records to import, duplicates are flagged
+func (s *ImportService) Preview(ctx context.Context, filePath string, opts importer.Options) (ImportPreview, error) {
+	preview, _, err := s.prepare(ctx, filePath, opts)
+	return preview, err
+}
+
+// Import adds records of export file to storage as one batch
+// if skipDuplicates, flagged duplicates are not added
+func (s *ImportService) Import(ctx context.Context, filePath string, opts importer.Options, skipDuplicates bool) (ImportResult, error) {
+	preview, records, err := s.prepare(ctx, filePath, opts)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	res := ImportResult{Format: preview.Format}
+	ids := make([]int64, 0, len(records))
+
+	var errAdd error
+	for i, rec := range records {
+		if skipDuplicates && preview.Items[i].Duplicate {
+			res.Skipped++
+			continue
+		}
+
+		id, err := s.addRecord(ctx, rec)
+		if id != 0 {
+			ids = append(ids, id)
+		}
+		if err != nil {
+			errAdd = fmt.Errorf("error import record %v: %w", i, err)
+			break
+		}
+	}
+
+	res.Added = len(ids)
+	recordAudit(ctx, s.secrets.audit, "IMPORT", 0, uuid.Nil, fmt.Sprintf("%v %v, added %v, skipped %v",
+		preview.Format, filepath.Base(filePath), res.Added, res.Skipped))
+	if len(ids) == 0 {
+		return res, errAdd
+	}
+
+	//  batch saved even if import stopped with error, to be able to roll back added
+	batchID, err := s.db.AddImportBatch(ctx, model.ImportBatch{
+		Format: string(preview.Format),
+		Source: filepath.Base(filePath),
+	}, ids)
+	if err != nil {
+		return res, fmt.Errorf("error save import batch: %w", err)
+	}
+	res.BatchID = batchID
+
+	return res, errAdd
+}
+
+// Batches returns list of import batches
+func (s *ImportService) Batches(ctx context.Context) ([]model.ImportBatch, error) {
+	return s.db.GetImportBatches(ctx)
+}
+
+// Rollback deletes secrets added by import batch
+func (s *ImportService) Rollback(ctx context.Context, batchID int64) error {
+	ids, err := s.db.GetImportBatchItems(ctx, batchID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := s.secrets.DeleteSoftSecret(ctx, id); err != nil {
+			return fmt.Errorf("error rollback import batch %v: %w", batchID, err)
+		}
+	}
+
+	return s.db.DeleteImportBatch(ctx, batchID)
+}
+
+// addRecord adds record to storage, record history is added as edits of secret, oldest first
+func (s *ImportService) addRecord(ctx context.Context, rec importer.Record) (int64, error) {
+	versions := append(append([]interface{}{}, rec.History...), rec.Item)
+
+	id, err := s.secrets.addSecret(ctx, versions[0])
+	if err != nil {
+		return 0, err
+	}
+
+	for _, obj := range versions[1:] {
+		if err := s.secrets.EditSecret(ctx, id, obj); err != nil {
+			return id, err
+		}
+	}
+
+	return id, nil
+}
+
+// prepare reads records of export file and checks them for duplicates with vault and each other
+func (s *ImportService) prepare(ctx context.Context, filePath string, opts importer.Options) (ImportPreview, []importer.Record, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ImportPreview{}, nil, err
+	}
+
+	records, format, err := importer.Parse(data, opts)
+	if err != nil {
+		return ImportPreview{}, nil, err
+	}
+
+	existing, err := vaultFingerprints(ctx, s.db, s.secrets)
+	if err != nil {
+		return ImportPreview{}, nil, err
+	}
+
+	preview := ImportPreview{
+		Format: format,
+		Items:  make([]ImportItem, 0, len(records)),
+	}
+	inFile := make(map[string]struct{}, len(records))
+
+	for i, rec := range records {
+		info, err := itemInfo(rec.Item)
+		if err != nil {
+			return ImportPreview{}, nil, err
+		}
+
+		item := ImportItem{
+			Index:   i,
+			TypeID:  info.TypeID,
+			Title:   info.Title,
+			Folder:  info.Folder,
+			History: len(rec.History),
+		}
+
+		fp := fingerprint(rec.Item)
+		if id, ok := existing[fp]; ok {
+			item.Duplicate = true
+			item.DuplicateOf = id
+		} else if _, ok := inFile[fp]; ok {
+			item.Duplicate = true
+		}
+		inFile[fp] = struct{}{}
+
+		if item.Duplicate {
+			preview.Duplicates++
+		}
+		preview.Items = append(preview.Items, item)
+	}
+
+	return preview, records, nil
+}
+
+// vaultFingerprints returns fingerprints of stored secrets mapped to local id
+func vaultFingerprints(ctx context.Context, db storage.Storage, secrets *SecretService) (map[string]int64, error) {
+	list, err := db.GetSecretList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]int64, len(list))
+	for _, el := range list {
+		//  placeholder data is unknown, it is not checked for duplicates
+		if el.PayloadID != model.PayloadStates["LOCAL"] {
+			continue
+		}
+
+		obj, err := secrets.ReadFromSecret(el)
+		if err != nil {
+			return nil, err
+		}
+
+		res[fingerprint(obj)] = el.ID
+	}
+
+	return res, nil
+}
+
+// fingerprint returns key of secret object, objects with same key treated as duplicates
+func fingerprint(obj interface{}) string {
+	switch el := obj.(type) {
+	case model.Auth:
+		site := el.URL
+		if site == "" {
+			site = el.Title
+		}
+		return "auth:" + strings.ToLower(strings.TrimSpace(site)) + ":" + el.Login
+	case model.Card:
+		return "card:" + strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, el.PAN)
+	case model.Text:
+		return "text:" + strings.ToLower(el.Title) + ":" + hashString([]byte(el.Text))
+	case model.Binary:
+		return "binary:" + el.Filename + ":" + hashString(el.Data)
+	}
+
+	return fmt.Sprintf("%T", obj)
+}
+
+// itemInfo returns info of secret object
+func itemInfo(obj interface{}) (model.Info, error) {
+	switch el := obj.(type) {
+	case model.Auth:
+		return el.Info, nil
+	case model.Card:
+		return el.Info, nil
+	case model.Text:
+		return el.Info, nil
+	case model.Binary:
+		return el.Info, nil
+	}
+
+	return model.Info{}, fmt.Errorf("%w: wrong secret type %T", model.ErrorParamNotValid, obj)
+}
+
+func hashString(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/importer"
+	"github.com/Xrefullx/YanDip/client/model"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+const importCSV = `name,url,username,password
+mail,https://mail.example.com,petr,secret
+shop,https://shop.example.com,petr,shop
+shop copy,https://shop.example.com,petr,shop
+`
+
+func TestImport_PreviewImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	file := filepath.Join(t.TempDir(), "passwords.csv")
+	require.NoError(t, os.WriteFile(file, []byte(importCSV), 0600))
+
+	storage := mk.NewMockStorage(ctrl)
+	secretSvc := GetTestSecretSvc(t, storage)
+
+	//  vault already has mail login
+	exist, err := secretSvc.ToSecret(model.Auth{
+		Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail"},
+		Login:    "petr",
+		Password: "old",
+		URL:      "https://mail.example.com",
+	})
+	require.NoError(t, err)
+	exist.ID = 7
+
+	storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{exist}, nil).Times(2)
+
+	svc := NewImportService(storage, secretSvc)
+
+	preview, err := svc.Preview(context.Background(), file, importer.Options{Format: importer.FormatAuto})
+	require.NoError(t, err)
+	require.Equal(t, importer.FormatChrome, preview.Format)
+	require.Equal(t, 2, preview.Duplicates)
+	require.Equal(t, []ImportItem{
+		{Index: 0, TypeID: model.SecretTypes["AUTH"], Title: "mail", Duplicate: true, DuplicateOf: 7},
+		{Index: 1, TypeID: model.SecretTypes["AUTH"], Title: "shop"},
+		{Index: 2, TypeID: model.SecretTypes["AUTH"], Title: "shop copy", Duplicate: true},
+	}, preview.Items)
+
+	//  only not duplicated record added, in one batch
+	storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Return(int64(8), nil)
+	storage.EXPECT().AddImportBatch(gomock.Any(), model.ImportBatch{Format: "chrome", Source: "passwords.csv"}, []int64{8}).Return(int64(1), nil)
+
+	res, err := svc.Import(context.Background(), file, importer.Options{Format: importer.FormatAuto}, true)
+	require.NoError(t, err)
+	require.Equal(t, ImportResult{BatchID: 1, Format: importer.FormatChrome, Added: 1, Skipped: 2}, res)
+}
+
+func TestImport_Rollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := NewImportService(storage, GetTestSecretSvc(t, storage))
+
+	secret, err := GetTestSecretSvc(t, storage).ToSecret(model.TestAuth)
+	require.NoError(t, err)
+	secret.ID, secret.StatusID = 8, model.SecretStatuses["NEW"]
+
+	//  imported secrets are moved to trash
+	storage.EXPECT().GetImportBatchItems(gomock.Any(), int64(1)).Return([]int64{8}, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), int64(8)).Return(secret, nil).Times(2)
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["LOCAL_EDIT"], 0).
+		Do(func(_ context.Context, v model.Secret, _ int, _ int) {
+			require.NotZero(t, v.TrashedAt)
+		}).Return(nil)
+	storage.EXPECT().DeleteImportBatch(gomock.Any(), int64(1)).Return(nil)
+
+	require.NoError(t, svc.Rollback(context.Background(), 1))
+}
+
+func TestImport_KeePassHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := NewImportService(storage, GetTestSecretSvc(t, storage))
+
+	file := filepath.Join("..", "importer", "testdata", "keepass.kdbx")
+	opts := importer.Options{Format: importer.FormatAuto, Password: "pass"}
+
+	storage.EXPECT().GetSecretList(gomock.Any()).Return(nil, nil)
+
+	//  mail added with first version, then edited to last, attachment and vpn added
+	gomock.InOrder(
+		storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Return(int64(1), nil),
+		storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(model.Secret{ID: 1, StatusID: model.SecretStatuses["NEW"]}, nil),
+		storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["LOCAL_EDIT"], 0).Return(nil),
+		storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Return(int64(2), nil),
+		storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Return(int64(3), nil),
+	)
+	storage.EXPECT().AddImportBatch(gomock.Any(), model.ImportBatch{Format: "keepass", Source: "keepass.kdbx"}, []int64{1, 2, 3}).Return(int64(1), nil)
+
+	res, err := svc.Import(context.Background(), file, opts, false)
+	require.NoError(t, err)
+	require.Equal(t, ImportResult{BatchID: 1, Format: importer.FormatKeePass, Added: 3}, res)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// customFieldPrefix is prefix of custom field names in merged field map
+const customFieldPrefix = "fields."
+
+// mergeResult is result of three-way merge of secret versions
+type mergeResult struct {
+	//  merged secret object
+	Object interface{}
+	//  fields changed both locally and remotely to different values, sorted
+	Conflicts []string
+	//  merged object equals remote version
+	IsRemote bool
+}
+
+// mergeSecrets merges local and remote changes of secret made since base version field by field.
+// Field changed on one side takes changed value, field changed on both sides to different values is conflict,
+// conflicted field takes local value if preferLocal, else remote value.
+// Custom fields are merged by name. Versions of different types are not merged, type is conflict.
+func mergeSecrets(base interface{}, local interface{}, remote interface{}, preferLocal bool) (mergeResult, error) {
+	baseInfo, err := itemInfo(base)
+	if err != nil {
+		return mergeResult{}, err
+	}
+	localInfo, err := itemInfo(local)
+	if err != nil {
+		return mergeResult{}, err
+	}
+	remoteInfo, err := itemInfo(remote)
+	if err != nil {
+		return mergeResult{}, err
+	}
+
+	if baseInfo.TypeID != localInfo.TypeID || baseInfo.TypeID != remoteInfo.TypeID {
+		res := mergeResult{Object: remote, Conflicts: []string{"type_id"}, IsRemote: true}
+		if preferLocal {
+			res.Object, res.IsRemote = local, false
+		}
+		return res, nil
+	}
+
+	baseFields, _, err := mergeFields(base)
+	if err != nil {
+		return mergeResult{}, err
+	}
+	localFields, localOrder, err := mergeFields(local)
+	if err != nil {
+		return mergeResult{}, err
+	}
+	remoteFields, remoteOrder, err := mergeFields(remote)
+	if err != nil {
+		return mergeResult{}, err
+	}
+
+	names := make(map[string]struct{}, len(localFields)+len(remoteFields))
+	for _, fields := range []map[string]json.RawMessage{baseFields, localFields, remoteFields} {
+		for name := range fields {
+			names[name] = struct{}{}
+		}
+	}
+
+	res := mergeResult{IsRemote: true}
+	merged := make(map[string]json.RawMessage, len(names))
+	for name := range names {
+		b, l, r := baseFields[name], localFields[name], remoteFields[name]
+
+		var v json.RawMessage
+		switch {
+		case bytes.Equal(l, r), bytes.Equal(l, b):
+			v = r
+		case bytes.Equal(r, b):
+			v = l
+		default:
+			res.Conflicts = append(res.Conflicts, name)
+			v = r
+			if preferLocal {
+				v = l
+			}
+		}
+
+		//  missing value is removed field
+		if v != nil {
+			merged[name] = v
+		}
+		if !bytes.Equal(v, r) {
+			res.IsRemote = false
+		}
+	}
+	sort.Strings(res.Conflicts)
+
+	//  custom fields keep local order, fields added remotely are appended
+	data, err := mergedObject(merged, append(localOrder, remoteOrder...))
+	if err != nil {
+		return mergeResult{}, err
+	}
+
+	info := model.Info{}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return mergeResult{}, err
+	}
+
+	if res.Object, err = objectFromData(info, data); err != nil {
+		return mergeResult{}, err
+	}
+
+	return res, nil
+}
+
+// mergeFields returns json fields of secret object, custom fields are named fields.<name>,
+// second result is order of custom field names
+func mergeFields(obj interface{}) (map[string]json.RawMessage, []string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, err
+	}
+
+	custom, ok := fields["fields"]
+	if !ok {
+		return fields, nil, nil
+	}
+	delete(fields, "fields")
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(custom, &list); err != nil {
+		return nil, nil, err
+	}
+
+	order := make([]string, 0, len(list))
+	for _, el := range list {
+		field := model.CustomField{}
+		if err := json.Unmarshal(el, &field); err != nil {
+			return nil, nil, err
+		}
+
+		fields[customFieldPrefix+field.Name] = el
+		order = append(order, field.Name)
+	}
+
+	return fields, order, nil
+}
+
+// mergedObject returns json of secret object from merged fields, custom fields are placed in order
+func mergedObject(fields map[string]json.RawMessage, order []string) ([]byte, error) {
+	obj := make(map[string]interface{}, len(fields))
+	custom := make([]json.RawMessage, 0)
+	added := make(map[string]struct{}, len(order))
+
+	for _, name := range order {
+		v, ok := fields[customFieldPrefix+name]
+		if _, dup := added[name]; !ok || dup {
+			continue
+		}
+		custom = append(custom, v)
+		added[name] = struct{}{}
+	}
+
+	for name, v := range fields {
+		if !strings.HasPrefix(name, customFieldPrefix) {
+			obj[name] = v
+		}
+	}
+	if len(custom) > 0 {
+		obj["fields"] = custom
+	}
+
+	return json.Marshal(obj)
+}
+
+// decodeObject decrypts secret data and reads secret object of it
+func decodeObject(data string, masterKey string) (interface{}, error) {
+	decData, err := pkg.Decode(data, masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info := model.Info{}
+	if err := json.Unmarshal(decData, &info); err != nil {
+		return nil, fmt.Errorf("error read info of secret data: %w", err)
+	}
+
+	return objectFromData(info, decData)
+}
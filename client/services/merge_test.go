@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestMerge_Secrets(t *testing.T) {
+	base := model.Auth{
+		Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "mail", Tags: []string{"work"}},
+		Login:    "user",
+		Password: "pass",
+		Fields: []model.CustomField{
+			{Name: "pin", Value: "1111", Hidden: true},
+			{Name: "question", Value: "cat"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		local       func(a *model.Auth)
+		remote      func(a *model.Auth)
+		preferLocal bool
+		conflicts   []string
+		isRemote    bool
+		result      func(a *model.Auth)
+	}{
+		{
+			name:     "different fields changed - both kept",
+			local:    func(a *model.Auth) { a.Password = "new pass" },
+			remote:   func(a *model.Auth) { a.Description = "note" },
+			isRemote: false,
+			result: func(a *model.Auth) {
+				a.Password = "new pass"
+				a.Description = "note"
+			},
+		},
+		{
+			name:     "same change on both sides - no conflict",
+			local:    func(a *model.Auth) { a.Password = "same" },
+			remote:   func(a *model.Auth) { a.Password = "same" },
+			isRemote: true,
+			result:   func(a *model.Auth) { a.Password = "same" },
+		},
+		{
+			name:      "same field changed - conflict, remote preferred",
+			local:     func(a *model.Auth) { a.Password = "local"; a.Login = "local user" },
+			remote:    func(a *model.Auth) { a.Password = "remote" },
+			conflicts: []string{"password"},
+			result: func(a *model.Auth) {
+				a.Password = "remote"
+				a.Login = "local user"
+			},
+		},
+		{
+			name:        "same field changed - conflict, local preferred",
+			local:       func(a *model.Auth) { a.Password = "local" },
+			remote:      func(a *model.Auth) { a.Password = "remote"; a.Tags = []string{"home"} },
+			preferLocal: true,
+			conflicts:   []string{"password"},
+			result: func(a *model.Auth) {
+				a.Password = "local"
+				a.Tags = []string{"home"}
+			},
+		},
+		{
+			name: "custom fields merged by name",
+			local: func(a *model.Auth) {
+				a.Fields = []model.CustomField{{Name: "pin", Value: "2222", Hidden: true}, {Name: "question", Value: "cat"}, {Name: "local", Value: "l"}}
+			},
+			remote: func(a *model.Auth) {
+				a.Fields = []model.CustomField{{Name: "pin", Value: "1111", Hidden: true}, {Name: "remote", Value: "r"}}
+			},
+			result: func(a *model.Auth) {
+				a.Fields = []model.CustomField{{Name: "pin", Value: "2222", Hidden: true}, {Name: "local", Value: "l"}, {Name: "remote", Value: "r"}}
+			},
+		},
+		{
+			name: "custom field changed on both sides - conflict",
+			local: func(a *model.Auth) {
+				a.Fields = []model.CustomField{{Name: "pin", Value: "2222", Hidden: true}, {Name: "question", Value: "cat"}}
+			},
+			remote: func(a *model.Auth) {
+				a.Fields = []model.CustomField{{Name: "pin", Value: "3333", Hidden: true}, {Name: "question", Value: "cat"}}
+			},
+			conflicts: []string{"fields.pin"},
+			result: func(a *model.Auth) {
+				a.Fields = []model.CustomField{{Name: "pin", Value: "3333", Hidden: true}, {Name: "question", Value: "cat"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, remote, expected := base, base, base
+			for _, el := range []*model.Auth{&local, &remote, &expected} {
+				el.Fields = append([]model.CustomField{}, base.Fields...)
+			}
+			tt.local(&local)
+			tt.remote(&remote)
+			tt.result(&expected)
+
+			res, err := mergeSecrets(base, local, remote, tt.preferLocal)
+			require.NoError(t, err)
+			require.Equal(t, tt.conflicts, res.Conflicts)
+			require.Equal(t, expected, res.Object)
+			if tt.isRemote {
+				require.True(t, res.IsRemote)
+			}
+		})
+	}
+
+	//  versions of different types are not merged
+	res, err := mergeSecrets(base, model.TestText, base, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"type_id"}, res.Conflicts)
+	require.Equal(t, base, res.Object)
+}
+
+func TestMerge_AddCollision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svcSecret := GetTestSecretSvc(t, storage)
+
+	baseObj := model.TestAuth
+	localObj, remoteObj := baseObj, baseObj
+	localObj.Password = "local password"
+	remoteObj.Description = "remote note"
+
+	base, err := svcSecret.ToSecret(baseObj)
+	require.NoError(t, err)
+	local, err := svcSecret.ToSecret(localObj)
+	require.NoError(t, err)
+	remote, err := svcSecret.ToSecret(remoteObj)
+	require.NoError(t, err)
+
+	local.ID, local.SecretID, local.SecretVer = 1, base.SecretID, 2
+	local.StatusID = model.SecretStatuses["EDITED"]
+	local.BaseData = base.SecretData
+
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60, ConflictStrategy: "MANUAL"})
+
+	//  changes of different fields are merged without conflict
+	provider.EXPECT().DownloadSecret(gomock.Any(), local.SecretID).Return(model.RemoteSecret{SecretID: local.SecretID, SecretVer: 3, Data: remote.SecretData}, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), local.ID).Return(local, nil)
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["CONFLICT"], 0).Do(func(_ context.Context, v model.Secret, _ int, _ int) {
+		require.Equal(t, 3, v.SecretVer)
+		require.Equal(t, remote.SecretData, v.BaseData)
+		require.Equal(t, model.SecretStatuses["EDITED"], v.StatusID)
+
+		obj, err := svcSecret.ReadFromSecret(v)
+		require.NoError(t, err)
+		merged := obj.(model.Auth)
+		require.Equal(t, "local password", merged.Password)
+		require.Equal(t, "remote note", merged.Description)
+	}).Return(nil)
+
+	require.NoError(t, svc.AddCollision(context.Background(), taskCollision(model.SecretMeta{ID: local.ID, SecretID: local.SecretID}, 3)))
+
+	//  same field changed, conflict saved with field names
+	remoteObj.Password = "remote password"
+	remote, err = svcSecret.ToSecret(remoteObj)
+	require.NoError(t, err)
+
+	provider.EXPECT().DownloadSecret(gomock.Any(), local.SecretID).Return(model.RemoteSecret{SecretID: local.SecretID, SecretVer: 3, Data: remote.SecretData}, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), local.ID).Return(local, nil)
+	storage.EXPECT().AddConflict(gomock.Any(), gomock.Any()).Do(func(_ context.Context, c model.Conflict) {
+		require.Equal(t, []string{"password"}, c.Fields)
+	}).Return(int64(1), nil)
+
+	require.NoError(t, svc.AddCollision(context.Background(), taskCollision(model.SecretMeta{ID: local.ID, SecretID: local.SecretID}, 3)))
+}
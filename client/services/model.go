@@ -2,6 +2,8 @@ package services
 
 import (
 	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
 )
 
 var (
@@ -13,6 +15,13 @@ var (
 		"DELETE_LOCALLY": 5,
 		"SEND_DELETE":    6,
 		"COLLISION":      7,
+		"DETACH":         8,
+		//  local data differs from remote data of same version, remote data is downloaded
+		"REPAIR": 9,
+		//  remote data is corrupted, local data of same version is uploaded
+		"REPAIR_UPLOAD": 10,
+		//  synced secret is missing remotely without tombstone, secret is marked orphaned
+		"ORPHAN": 11,
 	}
 )
 
@@ -22,4 +31,8 @@ type SyncTask struct {
 	Ver       int
 	ActionID  int
 	TimeStamp int64
+	//  tombstone of remotely deleted secret
+	Tombstone model.Tombstone
+	//  state of local copy of downloaded secret data
+	PayloadID int
 }
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// Orphans returns synced secrets unknown to server, they wait for user to keep or delete them
+func (s *SecretService) Orphans(ctx context.Context) ([]model.Secret, error) {
+	list, err := s.db.GetSecretList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]model.Secret, 0)
+	for _, el := range list {
+		if el.StatusID == model.SecretStatuses["ORPHANED"] {
+			res = append(res, el)
+		}
+	}
+
+	return res, nil
+}
+
+// ResolveOrphan keeps orphaned secret as new secret uploaded with next sync, or deletes it.
+// Deleted secret is removed locally by sync, and on server if server knows it again.
+func (s *SecretService) ResolveOrphan(ctx context.Context, id int64, keep bool) error {
+	secret, err := s.db.GetSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+	if secret.StatusID != model.SecretStatuses["ORPHANED"] {
+		return fmt.Errorf("%w: secret %v is not orphaned", model.ErrorParamNotValid, id)
+	}
+
+	if !keep {
+		secret.StatusID = model.SecretStatuses["DELETED"]
+		if err := s.db.UpdateSecret(ctx, secret); err != nil {
+			return err
+		}
+
+		recordAudit(ctx, s.audit, "SYNC_DELETE", secret.ID, secret.SecretID, "orphan deleted by user")
+		return nil
+	}
+
+	//  placeholder has no local data to upload
+	if secret.PayloadID != model.PayloadStates["LOCAL"] {
+		return fmt.Errorf("%w: data of secret %v is not stored locally", model.ErrorParamNotValid, id)
+	}
+
+	secretID := secret.SecretID
+	secret.SecretID = uuid.Nil
+	secret.SecretVer = 1
+	secret.Vector = nil
+	secret.BaseData = ""
+	secret.StatusID = model.SecretStatuses["NEW"]
+
+	if err := s.db.UpdateSecret(ctx, secret); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.audit, "SYNC_DETACH", secret.ID, secretID, "orphan kept by user")
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestOrphan_Resolve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := GetTestSecretSvc(t, storage)
+
+	orphan := model.Secret{ID: 1, SecretID: uuid.New(), SecretVer: 3, BaseData: "base", StatusID: model.SecretStatuses["ORPHANED"]}
+	actual := model.Secret{ID: 2, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"]}
+	placeholder := orphan
+	placeholder.ID, placeholder.PayloadID = 3, model.PayloadStates["PLACEHOLDER"]
+
+	storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{orphan, actual}, nil)
+
+	list, err := svc.Orphans(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []model.Secret{orphan}, list)
+
+	//  kept orphan is uploaded as new secret
+	storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(orphan, nil).Times(2)
+	storage.EXPECT().UpdateSecret(gomock.Any(), model.Secret{ID: 1, SecretVer: 1, StatusID: model.SecretStatuses["NEW"]}).Return(nil)
+	require.NoError(t, svc.ResolveOrphan(context.Background(), 1, true))
+
+	//  deleted orphan is removed by sync
+	deleted := orphan
+	deleted.StatusID = model.SecretStatuses["DELETED"]
+	storage.EXPECT().UpdateSecret(gomock.Any(), deleted).Return(nil)
+	require.NoError(t, svc.ResolveOrphan(context.Background(), 1, false))
+
+	storage.EXPECT().GetSecret(gomock.Any(), int64(2)).Return(actual, nil)
+	require.ErrorIs(t, svc.ResolveOrphan(context.Background(), 2, true), model.ErrorParamNotValid)
+
+	storage.EXPECT().GetSecret(gomock.Any(), int64(3)).Return(placeholder, nil)
+	require.ErrorIs(t, svc.ResolveOrphan(context.Background(), 3, true), model.ErrorParamNotValid)
+}
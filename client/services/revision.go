@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// FieldChange is difference of one secret field between two states
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Revisions returns saved revisions of secret, newest first
+func (s *SecretService) Revisions(ctx context.Context, id int64) ([]model.Revision, error) {
+	return s.db.GetRevisions(ctx, id)
+}
+
+// DiffRevisions returns changed fields between two states of secret,
+// revision id 0 means current state of secret
+func (s *SecretService) DiffRevisions(ctx context.Context, id int64, fromRevID int64, toRevID int64) ([]FieldChange, error) {
+	from, err := s.revisionObject(ctx, id, fromRevID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.revisionObject(ctx, id, toRevID)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffObjects(from, to)
+}
+
+// RestoreRevision restores secret content from revision as new local edit
+func (s *SecretService) RestoreRevision(ctx context.Context, id int64, revID int64) error {
+	rev, err := s.revision(ctx, id, revID)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.db.GetSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	secret.Info = rev.Info
+	secret.SecretData = rev.SecretData
+
+	//  restored secret is uploaded on next sync
+	return s.updateSecret(ctx, secret, model.RevisionCauses["RESTORE"], "RESTORE", fmt.Sprintf("revision %v", revID))
+}
+
+// revision returns revision of secret
+func (s *SecretService) revision(ctx context.Context, id int64, revID int64) (model.Revision, error) {
+	rev, err := s.db.GetRevision(ctx, revID)
+	if err != nil {
+		return model.Revision{}, err
+	}
+
+	if rev.SecretLocID != id {
+		return model.Revision{}, fmt.Errorf("%w: revision %v is not revision of secret %v", model.ErrorParamNotValid, revID, id)
+	}
+
+	return rev, nil
+}
+
+// revisionObject returns secret object of revision, or current object if revID is 0
+func (s *SecretService) revisionObject(ctx context.Context, id int64, revID int64) (interface{}, error) {
+	if revID == 0 {
+		secret, err := s.db.GetSecret(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.ReadFromSecret(secret)
+	}
+
+	rev, err := s.revision(ctx, id, revID)
+	if err != nil {
+		return nil, err
+	}
+
+	decData, err := pkg.Decode(rev.SecretData, s.cfg.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return objectFromData(rev.Info, decData)
+}
+
+// diffObjects returns changed fields of secret objects, sorted by field name
+func diffObjects(from interface{}, to interface{}) ([]FieldChange, error) {
+	fromFields, err := flattenObject(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toFields, err := flattenObject(to)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(fromFields)+len(toFields))
+	for name := range fromFields {
+		names[name] = struct{}{}
+	}
+	for name := range toFields {
+		names[name] = struct{}{}
+	}
+
+	res := make([]FieldChange, 0)
+	for name := range names {
+		if fromFields[name] != toFields[name] {
+			res = append(res, FieldChange{Field: name, Old: fromFields[name], New: toFields[name]})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Field < res[j].Field })
+
+	return res, nil
+}
+
+// flattenObject returns secret object fields as flat map,
+// custom fields are named fields.<name>, list values are joined
+func flattenObject(obj interface{}) (map[string]string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case nil:
+		case []interface{}:
+			if name == "fields" {
+				for _, f := range v {
+					field, _ := f.(map[string]interface{})
+					res[fmt.Sprintf("fields.%v", field["name"])] = fmt.Sprint(field["value"])
+				}
+				continue
+			}
+			values := make([]string, 0, len(v))
+			for _, el := range v {
+				values = append(values, fmt.Sprint(el))
+			}
+			res[name] = strings.Join(values, ", ")
+		default:
+			res[name] = fmt.Sprint(v)
+		}
+	}
+
+	return res, nil
+}
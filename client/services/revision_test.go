@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestRevision_DiffRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := GetTestSecretSvc(t, storage)
+
+	old := model.Auth{
+		Info:     model.Info{TypeID: model.SecretTypes["AUTH"], Title: "Mail", Tags: []string{"work"}},
+		Login:    "petr",
+		Password: "old",
+		Fields:   []model.CustomField{{Name: "pin", Value: "1111"}},
+	}
+	current := old
+	current.Password = "new"
+	current.Tags = []string{"work", "mail"}
+	current.Fields = []model.CustomField{{Name: "pin", Value: "2222"}}
+
+	oldSecret, err := svc.ToSecret(old)
+	require.NoError(t, err)
+	curSecret, err := svc.ToSecret(current)
+	require.NoError(t, err)
+	curSecret.ID, curSecret.SecretID, curSecret.StatusID, curSecret.TimeStamp = 1, uuid.New(), model.SecretStatuses["ACTUAL"], 100
+
+	rev := model.Revision{
+		ID:          5,
+		SecretLocID: 1,
+		CauseID:     model.RevisionCauses["DOWNLOAD"],
+		Info:        oldSecret.Info,
+		SecretData:  oldSecret.SecretData,
+	}
+
+	storage.EXPECT().GetRevision(gomock.Any(), int64(5)).Return(rev, nil).AnyTimes()
+	storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(curSecret, nil).AnyTimes()
+
+	changes, err := svc.DiffRevisions(context.Background(), 1, 5, 0)
+	require.NoError(t, err)
+	require.Equal(t, []FieldChange{
+		{Field: "fields.pin", Old: "1111", New: "2222"},
+		{Field: "password", Old: "old", New: "new"},
+		{Field: "tags", Old: "work", New: "work, mail"},
+	}, changes)
+
+	_, err = svc.DiffRevisions(context.Background(), 2, 5, 0)
+	require.ErrorIs(t, err, model.ErrorParamNotValid)
+
+	//  restored as edit of synced secret
+	restored := curSecret
+	restored.Info = rev.Info
+	restored.SecretData = rev.SecretData
+	restored.StatusID = model.SecretStatuses["EDITED"]
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), restored, model.RevisionCauses["RESTORE"], cfg.RevisionsKeep).Return(nil)
+
+	require.NoError(t, svc.RestoreRevision(context.Background(), 1, 5))
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -15,9 +16,16 @@ import (
 	"github.com/Xrefullx/YanDip/client/storage"
 )
 
+// SecretFetcher downloads data of secrets stored as placeholders
+type SecretFetcher interface {
+	FetchSecret(ctx context.Context, id int64) error
+}
+
 type SecretService struct {
-	cfg *pkg.Config
-	db  storage.Storage
+	cfg     *pkg.Config
+	db      storage.Storage
+	audit   Auditor
+	fetcher SecretFetcher
 }
 
 // NewSecret returns new instanse of secret service
@@ -29,14 +37,29 @@ func NewSecret(cfg *pkg.Config, db storage.Storage) SecretService {
 	}
 }
 
+// SetAuditor sets auditor recording secret operations
+func (s *SecretService) SetAuditor(a Auditor) {
+	s.audit = a
+}
+
+// SetFetcher sets fetcher downloading placeholder secrets on first open
+func (s *SecretService) SetFetcher(f SecretFetcher) {
+	s.fetcher = f
+}
+
 // AddAuth adds auth secret to storage
-func (s *SecretService) AddAuth(el model.Auth) (int64, error) {
-	return s.addSecret(el)
+func (s *SecretService) AddAuth(ctx context.Context, el model.Auth) (int64, error) {
+	return s.addSecret(ctx, el)
 }
 
 // AddCard addds credit card secret to storage
-func (s *SecretService) AddCard(el model.Card) (int64, error) {
-	return s.addSecret(el)
+func (s *SecretService) AddCard(ctx context.Context, el model.Card) (int64, error) {
+	return s.addSecret(ctx, el)
+}
+
+// AddText adds text secret to storage
+func (s *SecretService) AddText(ctx context.Context, el model.Text) (int64, error) {
+	return s.addSecret(ctx, el)
 }
 
 // ReadBinary reads binary secret from file
@@ -60,7 +83,7 @@ func (s SecretService) ReadBinary(filePath string) (model.Binary, error) {
 }
 
 // AddBinary adds binary secret to storage
-func (s *SecretService) AddBinary(filePath string, title string, description string) (int64, error) {
+func (s *SecretService) AddBinary(ctx context.Context, filePath string, title string, description string) (int64, error) {
 	b, err := s.ReadBinary(filePath)
 	if err != nil {
 		return 0, nil
@@ -69,10 +92,10 @@ func (s *SecretService) AddBinary(filePath string, title string, description str
 	b.Title = title
 	b.Description = description
 
-	return s.addSecret(b)
+	return s.addSecret(ctx, b)
 }
 
-func (s *SecretService) addSecret(obj interface{}) (int64, error) {
+func (s *SecretService) addSecret(ctx context.Context, obj interface{}) (int64, error) {
 	secret, err := s.ToSecret(obj)
 	if err != nil {
 		return 0, err
@@ -82,32 +105,68 @@ func (s *SecretService) addSecret(obj interface{}) (int64, error) {
 	secret.SecretID = uuid.Nil
 	secret.SecretVer = 1
 
-	id, err := s.db.AddSecret(secret)
+	id, err := s.db.AddSecret(ctx, secret)
 
 	if err != nil {
 		return 0, err
 	}
 
+	recordAudit(ctx, s.audit, "ADD", id, uuid.Nil, "")
+
 	return id, nil
 }
 
 // UpdateSecret updates secret in storage
-func (s *SecretService) UpdateSecret(secret model.Secret) error {
+func (s *SecretService) UpdateSecret(ctx context.Context, secret model.Secret) error {
+	return s.updateSecret(ctx, secret, model.RevisionCauses["LOCAL_EDIT"], "EDIT", "")
+}
+
+// updateSecret saves local change of secret and records it to audit log with operation name
+func (s *SecretService) updateSecret(ctx context.Context, secret model.Secret, causeID int, operation string, details string) error {
 	//  if el secret id == nil, el not uploaded to server, must stay status NEW
-	if secret.SecretID != uuid.Nil {
+	//  conflicted and orphaned secret stays so until it is resolved
+	if secret.SecretID != uuid.Nil && secret.StatusID != model.SecretStatuses["CONFLICT"] &&
+		secret.StatusID != model.SecretStatuses["ORPHANED"] {
 		secret.StatusID = model.SecretStatuses["EDITED"]
 	}
 
-	if err := s.db.UpdateSecret(secret); err != nil {
+	if err := s.db.UpdateSecretWithRevision(ctx, secret, causeID, s.cfg.RevisionsKeep); err != nil {
 		return err
 	}
 
+	recordAudit(ctx, s.audit, operation, secret.ID, secret.SecretID, details)
+
 	return nil
 }
 
+// EditSecret replaces content of stored secret with secret object
+func (s *SecretService) EditSecret(ctx context.Context, id int64, obj interface{}) error {
+	return s.editSecret(ctx, id, obj, "EDIT")
+}
+
+// editSecret replaces content of stored secret and records it to audit log with operation name
+func (s *SecretService) editSecret(ctx context.Context, id int64, obj interface{}, operation string) error {
+	dbSecret, err := s.db.GetSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.ToSecret(obj)
+	if err != nil {
+		return err
+	}
+
+	dbSecret.Info = secret.Info
+	dbSecret.SecretData = secret.SecretData
+	dbSecret.PayloadID = model.PayloadStates["LOCAL"]
+	dbSecret.Size = len(secret.SecretData)
+
+	return s.updateSecret(ctx, dbSecret, model.RevisionCauses["LOCAL_EDIT"], operation, "")
+}
+
 // GetSecret returns secret from storage by local id
-func (s *SecretService) GetSecret(id int64) (model.Secret, error) {
-	dbSecret, err := s.db.GetSecret(id)
+func (s *SecretService) GetSecret(ctx context.Context, id int64) (model.Secret, error) {
+	dbSecret, err := s.db.GetSecret(ctx, id)
 	if err != nil {
 		return model.Secret{}, err
 	}
@@ -115,14 +174,61 @@ func (s *SecretService) GetSecret(id int64) (model.Secret, error) {
 }
 
 // GetSecretBySecretID returns secret from storage by external id
-func (s *SecretService) GetSecretBySecretID(id uuid.UUID) (model.Secret, error) {
-	dbSecret, err := s.db.GetSecretByExtID(id)
+func (s *SecretService) GetSecretBySecretID(ctx context.Context, id uuid.UUID) (model.Secret, error) {
+	dbSecret, err := s.db.GetSecretByExtID(ctx, id)
 	if err != nil {
 		return model.Secret{}, err
 	}
 	return dbSecret, nil
 }
 
+// RevealSecret returns decrypted secret object, reveal is recorded to audit log.
+// Placeholder secret is downloaded first if fetcher is set.
+func (s *SecretService) RevealSecret(ctx context.Context, id int64) (interface{}, error) {
+	secret, err := s.fetchSecret(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.ReadFromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.audit, "REVEAL", secret.ID, secret.SecretID, "")
+
+	return obj, nil
+}
+
+// fetchSecret returns secret by local id, placeholder data is downloaded if fetcher is set
+func (s *SecretService) fetchSecret(ctx context.Context, id int64) (model.Secret, error) {
+	secret, err := s.db.GetSecret(ctx, id)
+	if err != nil {
+		return model.Secret{}, err
+	}
+	if secret.PayloadID == model.PayloadStates["LOCAL"] || s.fetcher == nil {
+		return secret, nil
+	}
+
+	if err := s.fetcher.FetchSecret(ctx, id); err != nil {
+		return model.Secret{}, err
+	}
+
+	return s.db.GetSecret(ctx, id)
+}
+
+// MarkCopied records to audit log that field of secret was copied
+func (s *SecretService) MarkCopied(ctx context.Context, id int64, field string) error {
+	secret, err := s.db.GetSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.audit, "COPY", secret.ID, secret.SecretID, "field "+field)
+
+	return nil
+}
+
 // ToSecret converts secret object to base secret
 func (s *SecretService) ToSecret(i interface{}) (model.Secret, error) {
 
@@ -190,20 +296,28 @@ func (s *SecretService) ToSecret(i interface{}) (model.Secret, error) {
 
 // ReadFromSecret reads secret object from base secret
 func (s *SecretService) ReadFromSecret(el model.Secret) (interface{}, error) {
+	if el.PayloadID != model.PayloadStates["LOCAL"] {
+		return nil, model.ErrorNotDownloaded
+	}
 
 	decData, err := pkg.Decode(el.SecretData, s.cfg.MasterKey)
 	if err != nil {
 		log.Fatal("error:", err)
 	}
 
-	switch el.Info.TypeID {
+	return objectFromData(el.Info, decData)
+}
+
+// objectFromData reads secret object of info type from decrypted json
+func objectFromData(info model.Info, decData []byte) (interface{}, error) {
+	switch info.TypeID {
 	case model.SecretTypes["CARD"]:
 		var card model.Card
 		if err := json.Unmarshal(decData, &card); err != nil {
 			return nil, errors.New("object is not Card type")
 		}
 
-		card.Info = el.Info
+		card.Info = info
 
 		return card, nil
 
@@ -213,7 +327,7 @@ func (s *SecretService) ReadFromSecret(el model.Secret) (interface{}, error) {
 			return nil, errors.New("object is not Auth type")
 		}
 
-		auth.Info = el.Info
+		auth.Info = info
 
 		return auth, nil
 
@@ -223,7 +337,7 @@ func (s *SecretService) ReadFromSecret(el model.Secret) (interface{}, error) {
 			return nil, errors.New("object is not Text type")
 		}
 
-		txt.Info = el.Info
+		txt.Info = info
 
 		return txt, nil
 
@@ -233,7 +347,7 @@ func (s *SecretService) ReadFromSecret(el model.Secret) (interface{}, error) {
 			return nil, errors.New("object is not Binary type")
 		}
 
-		bn.Info = el.Info
+		bn.Info = info
 
 		return bn, nil
 	}
@@ -241,23 +355,7 @@ func (s *SecretService) ReadFromSecret(el model.Secret) (interface{}, error) {
 	return nil, errors.New("wrong TypeID")
 }
 
-// DeleteSoftSecret soft deletes secret
-func (s *SecretService) DeleteSoftSecret(id int64) error {
-	dbSecret, err := s.db.GetSecret(id)
-	if err != nil {
-		//  if not found ok
-		if errors.Is(err, model.ErrorItemNotFound) {
-			return nil
-		}
-
-		return err
-	}
-
-	dbSecret.StatusID = model.SecretStatuses["DELETED"]
-	if err := s.db.UpdateSecret(dbSecret); err != nil {
-
-		return err
-	}
-
-	return nil
+// DeleteSoftSecret moves secret to trash, secret is deleted when trash retention expires or trash is emptied
+func (s *SecretService) DeleteSoftSecret(ctx context.Context, id int64) error {
+	return s.MoveToTrash(ctx, id)
 }
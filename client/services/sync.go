@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +23,13 @@ type SyncService struct {
 	provider provider.SecretProvider
 	cfg      *pkg.Config
 	limiter  *rate.Limiter
+	audit    Auditor
+	//  1 if server change events stream is connected
+	streamUp int32
+	events   *syncNotifier
+	exec     *syncExecutor
+	//  sync worker goroutines started by Run
+	running *sync.WaitGroup
 }
 
 func NewSyncService(db storage.Storage, provider provider.SecretProvider, cfg *pkg.Config) *SyncService {
@@ -28,91 +38,379 @@ func NewSyncService(db storage.Storage, provider provider.SecretProvider, cfg *p
 		provider: provider,
 		cfg:      cfg,
 		limiter:  rate.NewLimiter(rate.Limit(float64(cfg.RequestsPerMinute)/float64(60)), 1),
+		events:   &syncNotifier{subs: make(map[int]chan SyncEvent)},
+		exec:     newSyncExecutor(cfg.SyncWorkers),
+		running:  &sync.WaitGroup{},
 	}
 }
 
-// Run inits sync worker with timeout
-// sync inits if ping ok
+// SetAuditor sets auditor recording sync operations
+func (s *SyncService) SetAuditor(a Auditor) {
+	s.audit = a
+}
+
+// Run inits sync worker.
+// Local changes are uploaded on storage change notifications after debounce delay.
+// Remote changes are pushed by server change events, polling of server is used while events stream is down.
+// Polling slows down while nothing changes or server is unreachable.
+// Worker stops when context is done, Wait waits until it is stopped.
 func (s *SyncService) Run(ctx context.Context) error {
-	ticker := time.NewTicker(time.Second * time.Duration(s.cfg.SyncTimeoutSec))
+	notify := make(chan struct{}, 1)
+	changes, unsubscribe := s.db.SubscribeChanges()
+
+	s.running.Add(2)
+	go func() {
+		defer s.running.Done()
+		s.watchChanges(ctx, notify)
+	}()
 
 	go func() {
 		defer func() {
-			log.Println("defer")
-			ticker.Stop()
+			unsubscribe()
+			s.running.Done()
 		}()
-		for {
-			select {
-			case <-ticker.C:
-				if err := s.provider.PingAuth(); err != nil {
-					//log.Println(err.Error())
-					break
-				}
-
-				batch, err := s.GetSyncBatch()
-				if err != nil {
-					log.Printf("error synchronization, err:%s", err.Error())
-					break
-				}
-
-				if len(batch) == 0 {
-					break
-				}
-
-				s.tick(ctx, batch)
-			case <-ctx.Done():
-				log.Println("worker context done")
-				return
-			}
-		}
+		s.schedule(ctx, notify, changes)
 	}()
 	return nil
 }
 
-func (s *SyncService) tick(ctx context.Context, tasks []SyncTask) {
-	wg := sync.WaitGroup{}
-	wg.Add(len(tasks))
+// Wait waits until sync worker is stopped and started sync jobs are done
+func (s *SyncService) Wait() {
+	s.running.Wait()
+	s.exec.Wait()
+}
+
+// syncOnline runs sync cycle if ping ok, returns true if cycle processed tasks without error
+func (s *SyncService) syncOnline(ctx context.Context, cycle syncCycle) bool {
+	if err := s.provider.PingAuth(ctx); err != nil {
+		if errors.Is(err, model.ErrorNotAuthorized) {
+			s.setAuthLost(err)
+			return false
+		}
+		s.setOnline(false)
+		return false
+	}
+	s.setOnline(true)
+
+	sum, err := s.runCycle(ctx, cycle)
+	if err != nil {
+		log.Printf("error synchronization, err:%s", err.Error())
+		return false
+	}
+
+	return sum.tasks > 0
+}
+
+// watchChanges keeps subscription to server change events, notifies on every event and on subscription.
+// Broken subscription is renewed with timeout, sync falls back to polling meanwhile.
+func (s *SyncService) watchChanges(ctx context.Context, notify chan<- struct{}) {
+	retry := time.Second * time.Duration(s.cfg.SyncTimeoutSec)
+
+	for {
+		events, err := s.provider.SubscribeChanges(ctx)
+		if err == nil {
+			atomic.StoreInt32(&s.streamUp, 1)
+
+			//  changes made while stream was down are synced at once
+			signalSync(notify)
+			for range events {
+				signalSync(notify)
+			}
+
+			atomic.StoreInt32(&s.streamUp, 0)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// signalSync requests sync, requests made before sync started are merged
+func signalSync(notify chan<- struct{}) {
+	select {
+	case notify <- struct{}{}:
+	default:
+	}
+}
+
+// isStreamUp returns true if server change events stream is connected
+func (s *SyncService) isStreamUp() bool {
+	return atomic.LoadInt32(&s.streamUp) == 1
+}
+
+// hasLocalChanges returns true if local secrets have changes to sync
+func (s *SyncService) hasLocalChanges(ctx context.Context) (bool, error) {
+	list, err := s.db.GetMetaList(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, el := range list {
+		if el.StatusID != model.SecretStatuses["ACTUAL"] && el.StatusID != model.SecretStatuses["CONFLICT"] &&
+			el.StatusID != model.SecretStatuses["ORPHANED"] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Sync processes tasks of sync batch, change feed cursor is saved if no task failed.
+// Changes of failed tasks are received again with next sync.
+// Failed tasks are recorded to sync journal and retried with backoff, parked tasks wait for reset,
+// cursor is moved over deferred tasks, they are retried from journal.
+// Start and result of sync and of every task are published to subscribers.
+func (s *SyncService) Sync(ctx context.Context) error {
+	_, err := s.runCycle(ctx, s.sync)
+	return err
+}
+
+// runCycle runs sync cycle, start and result of cycle are published to subscribers
+func (s *SyncService) runCycle(ctx context.Context, cycle syncCycle) (syncSummary, error) {
+	s.publish(SyncEvent{TypeID: SyncEventTypes["CYCLE_STARTED"]}, func(status *SyncStatus) bool {
+		status.Syncing = true
+		return true
+	})
+
+	sum, err := cycle(ctx)
+
+	conflicts, cerr := s.db.GetConflicts(ctx)
+	if cerr != nil {
+		log.Printf("error get conflicts, err:%s", cerr.Error())
+	}
+
+	s.publish(SyncEvent{TypeID: SyncEventTypes["CYCLE_FINISHED"], Err: err}, func(status *SyncStatus) bool {
+		status.Syncing = false
+		status.LastSyncAt = pkg.MakeTimestamp()
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
+		}
+		status.Failed, status.Deferred = sum.failed, sum.deferred
+		if cerr == nil {
+			status.Conflicts = len(conflicts)
+		}
+		return true
+	})
+
+	return sum, err
+}
+
+// sync processes sync batch, change feed cursor is saved if no tasks failed.
+// Deferred tasks are retried from sync journal, so cursor is moved over their changes.
+func (s *SyncService) sync(ctx context.Context) (syncSummary, error) {
+	batch, cursor, err := s.GetSyncBatch(ctx)
+	if err != nil {
+		return syncSummary{}, err
+	}
+
+	sum, err := s.processTasks(ctx, batch, true)
+	if err != nil {
+		return sum, err
+	}
+
+	return sum, s.db.SetSyncCursor(ctx, cursor)
+}
+
+// processTasks processes tasks not deferred by sync journal, records failed tasks to journal.
+// Full batch has all tasks of sync, journal entries of tasks missing in it are deleted.
+func (s *SyncService) processTasks(ctx context.Context, batch []SyncTask, full bool) (syncSummary, error) {
+	sum := syncSummary{tasks: len(batch)}
+
+	now := time.Now()
+	ready, journaled, deferred, err := s.filterJournal(ctx, batch, full, now)
+	if err != nil {
+		return sum, err
+	}
+	sum.deferred = deferred
+
+	results := s.tick(ctx, ready)
+	s.publishResults(results)
+
+	sum.failed, err = s.recordJournal(ctx, results, journaled, now)
+	if err != nil {
+		return sum, err
+	}
+	if sum.failed > 0 {
+		return sum, fmt.Errorf("%v of %v sync tasks failed", sum.failed, len(batch))
+	}
 
-	for _, o := range tasks {
-		if err := s.limiter.Wait(ctx); err != nil {
+	return sum, nil
+}
+
+// tick processes tasks with rate limit, returns result of every task.
+// Uploads and downloads are grouped into batches, batch is processed with one request.
+// Batches are processed by executor workers, batches of same secret never overlap.
+// Batches not started before context is done fail with context error.
+func (s *SyncService) tick(ctx context.Context, tasks []SyncTask) []syncResult {
+	batches := batchTasks(tasks, syncBatchSize(s.cfg))
+	errs := make([][]error, len(batches))
+	dones := make([]<-chan struct{}, 0, len(batches))
+	for i, batch := range batches {
+		err := s.limiter.Wait(ctx)
+		if err == nil {
+			var done <-chan struct{}
+			i, batch := i, batch
+			done, err = s.exec.Submit(ctx, batchKeys(batch), func(ctx context.Context) {
+				errs[i] = s.processBatch(ctx, batch)
+			})
+			if err == nil {
+				dones = append(dones, done)
+			}
+		}
+		if err != nil {
 			log.Printf(err.Error())
+			for j := i; j < len(batches); j++ {
+				errs[j] = batchError(len(batches[j]), err)
+			}
 
 			break
 		}
+	}
 
-		o := o
-		go func() {
-			defer wg.Done()
+	for _, done := range dones {
+		<-done
+	}
 
-			if err := s.ProcessTask(o); err != nil {
-				log.Println(err.Error())
-			}
-		}()
+	results := make([]syncResult, 0, len(tasks))
+	for i, batch := range batches {
+		for j, task := range batch {
+			results = append(results, syncResult{Task: task, Err: errs[i][j]})
+		}
 	}
 
-	wg.Wait()
+	return results
 }
 
-// GetSyncBatch downloads remote list, gets local list, compares and returns list of tasks
-func (s *SyncService) GetSyncBatch() ([]SyncTask, error) {
+// GetSyncBatch gets remote changes after saved cursor, gets local list, compares and returns list of tasks
+// and change feed cursor of remote state. Full remote list is downloaded on first sync or if cursor expired.
+func (s *SyncService) GetSyncBatch(ctx context.Context) ([]SyncTask, int64, error) {
+	tasks, remList, _, err := s.getSyncBatch(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, remList.Cursor, nil
+}
+
+// getSyncBatch returns list of tasks, remote state and local list tasks are calculated from
+func (s *SyncService) getSyncBatch(ctx context.Context) ([]SyncTask, model.SyncList, []model.SecretMeta, error) {
 
 	// get loc secrets array
-	locList, err := s.db.GetMetaList()
+	locList, err := s.db.GetMetaList(ctx)
 	if err != nil {
-		return nil, err
+		return nil, model.SyncList{}, nil, err
 	}
 
 	// get remote list
-	remList, err := s.provider.GetSyncList()
+	remList, err := s.getRemoteList(ctx, locList)
+	if err != nil {
+		return nil, model.SyncList{}, nil, err
+	}
+
+	tasks, err := s.CalcSyncBatch(remList, locList)
+	if err != nil {
+		return nil, model.SyncList{}, nil, err
+	}
+
+	//  synced secret without tombstone missing remotely is anomaly, list can be partial,
+	//  full list is downloaded again before missing secrets are orphaned
+	if missing := countMissing(tasks); missing > 0 {
+		log.Printf("sync anomaly: %v synced secrets are unknown to server, full resync", missing)
+
+		if remList, err = s.provider.GetSyncList(ctx); err != nil {
+			return nil, model.SyncList{}, nil, err
+		}
+		if tasks, err = s.CalcSyncBatch(remList, locList); err != nil {
+			return nil, model.SyncList{}, nil, err
+		}
+	}
+
+	if tasks, err = s.resolveRepairs(ctx, tasks, remList, locList); err != nil {
+		return nil, model.SyncList{}, nil, err
+	}
+
+	return tasks, remList, locList, nil
+}
+
+// countMissing returns count of tasks orphaning secrets missing remotely without tombstone
+func countMissing(tasks []SyncTask) int {
+	count := 0
+	for _, el := range tasks {
+		if el.ActionID == SyncActions["ORPHAN"] {
+			count++
+		}
+	}
+
+	return count
+}
+
+// getRemoteList returns remote state of secrets,
+// remote state is built from local list and changes after saved cursor if cursor is valid
+func (s *SyncService) getRemoteList(ctx context.Context, loc []model.SecretMeta) (model.SyncList, error) {
+	cursor, err := s.db.GetSyncCursor(ctx)
 	if err != nil {
-		return nil, err
+		return model.SyncList{}, err
+	}
+
+	if cursor > 0 {
+		changes, err := s.provider.GetChanges(ctx, cursor)
+		if err == nil {
+			return applyChanges(loc, changes), nil
+		}
+		if !errors.Is(err, model.ErrorCursorExpired) {
+			return model.SyncList{}, err
+		}
+
+		log.Printf("sync cursor %v expired, full sync", cursor)
 	}
 
-	return s.CalcSyncBatch(remList, locList)
+	return s.provider.GetSyncList(ctx)
 }
 
-// GetSyncBatch compares local data and server meta info and returns list of tasks
-func (s SyncService) CalcSyncBatch(rm map[uuid.UUID]int, loc []model.SecretMeta) ([]SyncTask, error) {
+// applyChanges returns remote state of synced local secrets with remote changes applied,
+// not changed secrets have local version, deleted secrets are moved to tombstones
+func applyChanges(loc []model.SecretMeta, changes model.SyncChanges) model.SyncList {
+	res := model.SyncList{
+		List:       make(map[uuid.UUID]int, len(loc)),
+		Tombstones: make(map[uuid.UUID]model.Tombstone),
+		Cursor:     changes.Cursor,
+	}
+
+	for _, el := range loc {
+		if el.SecretID != uuid.Nil {
+			res.List[el.SecretID] = el.SecretVer
+		}
+	}
+
+	//  changes are ordered, last change of secret wins
+	for _, el := range changes.Changes {
+		if el.IsDeleted {
+			delete(res.List, el.SecretID)
+			res.Tombstones[el.SecretID] = model.Tombstone{
+				SecretID:  el.SecretID,
+				SecretVer: el.SecretVer,
+				DeletedAt: el.DeletedAt,
+				DeletedBy: el.DeletedBy,
+			}
+			continue
+		}
+
+		delete(res.Tombstones, el.SecretID)
+		res.List[el.SecretID] = el.SecretVer
+	}
+
+	return res
+}
+
+// GetSyncBatch compares local data and server meta info and returns list of tasks.
+// Synced secret is deleted locally only by remote tombstone.
+// Server keeps tombstones, so secret missing remotely is orphaned and waits for user decision,
+// it is not uploaded again not to restore secret deleted by other device.
+func (s SyncService) CalcSyncBatch(rm model.SyncList, loc []model.SecretMeta) ([]SyncTask, error) {
 	tasks := []SyncTask{}
 
 	// map for local server ids
@@ -130,7 +428,7 @@ func (s SyncService) CalcSyncBatch(rm map[uuid.UUID]int, loc []model.SecretMeta)
 
 			//  if status DELETED - delete locally
 			if el.StatusID == model.SecretStatuses["DELETED"] {
-				tasks = append(tasks, taskDeleteLocally(el))
+				tasks = append(tasks, taskDeleteLocally(el, model.Tombstone{}))
 				continue
 			}
 
@@ -141,12 +439,31 @@ func (s SyncService) CalcSyncBatch(rm map[uuid.UUID]int, loc []model.SecretMeta)
 		// secret id exist - add secret id to map
 		locListMap[el.SecretID] = struct{}{}
 
+		//  conflict and orphaned secret wait for resolution
+		if el.StatusID == model.SecretStatuses["CONFLICT"] || el.StatusID == model.SecretStatuses["ORPHANED"] {
+			continue
+		}
+
 		//  check remote version
-		remVer, remExist := rm[el.SecretID]
+		remVer, remExist := rm.List[el.SecretID]
 
 		if !remExist {
-			// if SecretID not nil and not exist remote - delete locally
-			tasks = append(tasks, taskDeleteLocally(el))
+			tomb, deleted := rm.Tombstones[el.SecretID]
+
+			switch {
+			//  deleted locally - nothing to keep
+			case el.StatusID == model.SecretStatuses["DELETED"]:
+				tasks = append(tasks, taskDeleteLocally(el, tomb))
+			//  deleted remotely and edited locally - local edit is kept as new secret
+			case deleted && el.StatusID == model.SecretStatuses["EDITED"]:
+				tasks = append(tasks, taskDetach(el, tomb))
+			//  deleted remotely - delete locally
+			case deleted:
+				tasks = append(tasks, taskDeleteLocally(el, tomb))
+			//  missing without tombstone - anomaly, secret is kept locally and not uploaded
+			default:
+				tasks = append(tasks, taskOrphan(el))
+			}
 			continue
 		}
 
@@ -179,10 +496,17 @@ func (s SyncService) CalcSyncBatch(rm map[uuid.UUID]int, loc []model.SecretMeta)
 			continue
 		}
 
+		//  local data differs from remote data of the same version - repair,
+		//  direction of repair is chosen by resolveRepairs
+		if remVer == el.SecretVer && isDamaged(el, rm.Hashes[el.SecretID]) {
+			tasks = append(tasks, taskRepair(el))
+			continue
+		}
+
 	}
 
 	// if exist in remote list and not exist in local - download
-	for k, _ := range rm {
+	for k, _ := range rm.List {
 		_, ok := locListMap[k]
 		if !ok {
 			tasks = append(tasks, taskDownloadNew(k))
@@ -191,6 +515,19 @@ func (s SyncService) CalcSyncBatch(rm map[uuid.UUID]int, loc []model.SecretMeta)
 	return tasks, nil
 }
 
+// isDamaged returns true if data of synced secret stored locally differs from remote data with hash.
+// Secrets without local data or without known hashes are not checked.
+func isDamaged(meta model.SecretMeta, remoteHash string) bool {
+	if meta.StatusID != model.SecretStatuses["ACTUAL"] || meta.PayloadID != model.PayloadStates["LOCAL"] {
+		return false
+	}
+	if remoteHash == "" || meta.DataHash == "" {
+		return false
+	}
+
+	return meta.DataHash != remoteHash
+}
+
 // DEleted - обновить secretID, и версию, пометить на удаление
 // EDITED - обновить secretID, и версию, статус edited
 
@@ -223,6 +560,7 @@ func taskDownload(meta model.SecretMeta) SyncTask {
 		SecretId:  meta.SecretID,
 		ActionID:  SyncActions["DOWNLOAD"],
 		TimeStamp: meta.TimeStamp,
+		PayloadID: meta.PayloadID,
 	}
 }
 
@@ -233,12 +571,52 @@ func taskDownloadNew(secretID uuid.UUID) SyncTask {
 	}
 }
 
+// local data of secret is damaged, remote data is downloaded
+func taskRepair(meta model.SecretMeta) SyncTask {
+	return SyncTask{
+		SecretId:  meta.SecretID,
+		ActionID:  SyncActions["REPAIR"],
+		TimeStamp: meta.TimeStamp,
+		PayloadID: meta.PayloadID,
+	}
+}
+
+// remote data of secret is damaged, local data is uploaded over it
+func taskRepairUpload(meta model.SecretMeta) SyncTask {
+	return SyncTask{
+		SecretId:  meta.SecretID,
+		Ver:       meta.SecretVer,
+		ActionID:  SyncActions["REPAIR_UPLOAD"],
+		TimeStamp: meta.TimeStamp,
+	}
+}
+
 // нет доступа т.к. deleted
-func taskDeleteLocally(meta model.SecretMeta) SyncTask {
+func taskDeleteLocally(meta model.SecretMeta, tomb model.Tombstone) SyncTask {
 	return SyncTask{
 		LocID:     meta.ID,
 		ActionID:  SyncActions["DELETE_LOCALLY"],
 		TimeStamp: meta.TimeStamp,
+		Tombstone: tomb,
+	}
+}
+
+// secret unknown to server or deleted remotely with local edit, uploaded as new
+func taskDetach(meta model.SecretMeta, tomb model.Tombstone) SyncTask {
+	return SyncTask{
+		LocID:     meta.ID,
+		SecretId:  meta.SecretID,
+		ActionID:  SyncActions["DETACH"],
+		TimeStamp: meta.TimeStamp,
+		Tombstone: tomb,
+	}
+}
+func taskOrphan(meta model.SecretMeta) SyncTask {
+	return SyncTask{
+		LocID:     meta.ID,
+		SecretId:  meta.SecretID,
+		ActionID:  SyncActions["ORPHAN"],
+		TimeStamp: meta.TimeStamp,
 	}
 }
 func taskDeleteRemote(meta model.SecretMeta) SyncTask {
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -14,160 +15,350 @@ import (
 // If UPLOAD_NEW - task exist only local, get by id
 // If UPLOAD - task was synch and have SecretID, get by secret id
 // If response 200, write secret meta data from response and set status ACTUAL
-func (s *SyncService) Upload(task SyncTask) error {
-	var secret model.Secret
-	var err error
-
-	if task.ActionID == SyncActions["UPLOAD_NEW"] {
-		secret, err = s.db.GetSecret(task.LocID)
-	}
-
-	if task.ActionID == SyncActions["UPLOAD"] {
-		secret, err = s.db.GetSecretByExtID(task.SecretId)
+func (s *SyncService) Upload(ctx context.Context, task SyncTask) error {
+	secret, err := s.uploadSecret(ctx, task)
+	if err != nil {
+		return err
 	}
 
+	meta, err := secret.Info.ToEncodedMeta(s.cfg.MasterKey)
 	if err != nil {
 		return err
 	}
 
-	id, ver, err := s.provider.UploadSecret(secret.SecretData, task.SecretId, task.Ver)
+	res, err := s.provider.UploadSecret(ctx, model.RemoteSecret{
+		SecretID:  task.SecretId,
+		SecretVer: task.Ver,
+		Data:      secret.SecretData,
+		Meta:      meta,
+		Vector:    secret.Vector,
+	})
 	if err != nil {
 		return err
 	}
 
-	if secret.SecretID != uuid.Nil && secret.SecretID != id {
+	return s.saveUploaded(ctx, secret, res)
+}
+
+// uploadSecret returns local secret of upload task
+func (s *SyncService) uploadSecret(ctx context.Context, task SyncTask) (model.Secret, error) {
+	if task.ActionID == SyncActions["UPLOAD_NEW"] {
+		return s.db.GetSecret(ctx, task.LocID)
+	}
+
+	return s.db.GetSecretByExtID(ctx, task.SecretId)
+}
+
+// saveUploaded saves server id, version and vector of uploaded secret
+func (s *SyncService) saveUploaded(ctx context.Context, secret model.Secret, remote model.RemoteSecret) error {
+	if secret.SecretID != uuid.Nil && secret.SecretID != remote.SecretID {
 		return errors.New("error upload sync: response secretID not equal local")
 	}
 
-	secret.SecretVer = ver
-	secret.SecretID = id
+	secret.SecretVer = remote.SecretVer
+	secret.SecretID = remote.SecretID
+	secret.Vector = remote.Vector
+	secret.BaseData = secret.SecretData
 	secret.StatusID = model.SecretStatuses["ACTUAL"]
 
-	if err := s.db.UpdateSecret(secret); err != nil {
+	if err := s.db.UpdateSecret(ctx, secret); err != nil {
 		return fmt.Errorf("error upload sync: error save secret meta info: %w", err)
 	}
 
+	recordAudit(ctx, s.audit, "SYNC_UPLOAD", secret.ID, secret.SecretID, fmt.Sprintf("version %v", remote.SecretVer))
+
 	return nil
 }
 
 // Download downloads secret from server
 // If response 200, updates local data and meta.
-func (s *SyncService) Download(task SyncTask) error {
-	id, ver, data, err := s.provider.DownloadSecret(task.SecretId)
+func (s *SyncService) Download(ctx context.Context, task SyncTask) error {
+	remote, err := s.provider.DownloadSecret(ctx, task.SecretId)
 	if err != nil {
 		return err
 	}
 
+	return s.saveDownloaded(ctx, remote)
+}
+
+// saveDownloaded updates local secret with downloaded version
+func (s *SyncService) saveDownloaded(ctx context.Context, remote model.RemoteSecret) error {
 	info := model.Info{}
-	if err := info.FromEncodedData(data, s.cfg.MasterKey); err != nil {
+	if err := info.FromEncodedData(remote.Data, s.cfg.MasterKey); err != nil {
 		return fmt.Errorf("error read info drom encoded secret data: %w", err)
 	}
 
-	dbSecret, err := s.db.GetSecretByExtID(id)
+	dbSecret, err := s.db.GetSecretByExtID(ctx, remote.SecretID)
 	if err != nil {
 		return fmt.Errorf("error save secret data to storage: %w", err)
 	}
 
+	//  placeholder has no data to keep in history
+	save := func(ctx context.Context, v model.Secret) error {
+		return s.db.UpdateSecretWithRevision(ctx, v, model.RevisionCauses["DOWNLOAD"], s.cfg.RevisionsKeep)
+	}
+	if dbSecret.PayloadID != model.PayloadStates["LOCAL"] {
+		save = s.db.UpdateSecret
+	}
+
 	dbSecret.Info = info
-	dbSecret.SecretVer = ver
+	dbSecret.SecretVer = remote.SecretVer
+	dbSecret.Vector = remote.Vector
 	dbSecret.StatusID = model.SecretStatuses["ACTUAL"]
-	dbSecret.SecretData = data
+	dbSecret.SecretData = remote.Data
+	dbSecret.BaseData = remote.Data
+	dbSecret.PayloadID = model.PayloadStates["LOCAL"]
+	dbSecret.Size = len(remote.Data)
 
-	if err := s.db.UpdateSecret(dbSecret); err != nil {
+	if err := save(ctx, dbSecret); err != nil {
 		return fmt.Errorf("error save secret data to storage: %w", err)
 	}
 
+	recordAudit(ctx, s.audit, "SYNC_DOWNLOAD", dbSecret.ID, remote.SecretID, fmt.Sprintf("version %v", remote.SecretVer))
+
 	return nil
 }
 
 // DownloadNew downloads secret from server
 // If response 200, creates new local data.
-func (s *SyncService) DownloadNew(task SyncTask) error {
-	id, ver, data, err := s.provider.DownloadSecret(task.SecretId)
+func (s *SyncService) DownloadNew(ctx context.Context, task SyncTask) error {
+	remote, err := s.provider.DownloadSecret(ctx, task.SecretId)
 	if err != nil {
 		return err
 	}
 
+	return s.saveDownloadedNew(ctx, remote)
+}
+
+// saveDownloadedNew adds downloaded secret to local storage
+func (s *SyncService) saveDownloadedNew(ctx context.Context, remote model.RemoteSecret) error {
 	info := model.Info{}
-	if err := info.FromEncodedData(data, s.cfg.MasterKey); err != nil {
+	if err := info.FromEncodedData(remote.Data, s.cfg.MasterKey); err != nil {
 		return fmt.Errorf("error read info drom encoded secret data: %w", err)
 	}
 
-	_, err = s.db.AddSecret(model.Secret{
+	locID, err := s.db.AddSecret(ctx, model.Secret{
 		Info:       info,
-		SecretID:   id,
-		SecretVer:  ver,
+		SecretID:   remote.SecretID,
+		SecretVer:  remote.SecretVer,
+		Vector:     remote.Vector,
 		StatusID:   model.SecretStatuses["ACTUAL"],
-		SecretData: data,
+		SecretData: remote.Data,
+		BaseData:   remote.Data,
+		Size:       len(remote.Data),
 	})
 
 	if err != nil {
 		return fmt.Errorf("error save secret data to storage: %w", err)
 	}
 
+	recordAudit(ctx, s.audit, "SYNC_DOWNLOAD", locID, remote.SecretID, fmt.Sprintf("new, version %v", remote.SecretVer))
+
 	return nil
 }
 
 // DeleteRemote deletes secret from server
 // If response 200, mark local secret status as DELETED.
-func (s *SyncService) DeleteRemote(task SyncTask) error {
-	secret, err := s.db.GetSecretByExtID(task.SecretId)
+func (s *SyncService) DeleteRemote(ctx context.Context, task SyncTask) error {
+	secret, err := s.db.GetSecretByExtID(ctx, task.SecretId)
 	if err != nil {
 		return err
 	}
 
-	if err := s.provider.DeleteSecret(task.SecretId); err != nil {
+	if err := s.provider.DeleteSecret(ctx, task.SecretId); err != nil {
 		return err
 	}
 
 	secret.StatusID = model.SecretStatuses["DELETED"]
 
-	err = s.db.UpdateSecret(secret)
+	err = s.db.UpdateSecret(ctx, secret)
 	if err != nil {
 		return err
 	}
 
+	recordAudit(ctx, s.audit, "SYNC_DELETE", secret.ID, secret.SecretID, "remote")
+
 	return nil
 }
 
 // DeleteLocally deletes secret from local database
-func (s *SyncService) DeleteLocally(task SyncTask) error {
-	return s.db.DeleteSecret(task.LocID)
+func (s *SyncService) DeleteLocally(ctx context.Context, task SyncTask) error {
+	if err := s.db.DeleteSecret(ctx, task.LocID); err != nil {
+		return err
+	}
+
+	details := "local"
+	if tomb := task.Tombstone; tomb.SecretID != uuid.Nil {
+		details = fmt.Sprintf("local, tombstone version %v, deleted by device %v", tomb.SecretVer, tomb.DeletedBy)
+	}
+	recordAudit(ctx, s.audit, "SYNC_DELETE", task.LocID, task.Tombstone.SecretID, details)
+
+	return nil
 }
 
-// DeleteLocally deletes secret from local database
-func (s *SyncService) AddCollision(task SyncTask) error {
-	// use external version - delete local, then ext version downloads on sync
-	// use local version - set loc ver as external
+// Detach unlinks local secret from remote secret, secret is uploaded as new with next sync.
+// Used for local edits of remotely deleted secrets, no local data is lost.
+func (s *SyncService) Detach(ctx context.Context, task SyncTask) error {
+	secret, err := s.db.GetSecret(ctx, task.LocID)
+	if err != nil {
+		return err
+	}
+
+	//  placeholder has no local data to keep
+	if secret.PayloadID != model.PayloadStates["LOCAL"] {
+		return s.DeleteLocally(ctx, SyncTask{LocID: task.LocID, Tombstone: task.Tombstone})
+	}
+
+	secret.SecretID = uuid.Nil
+	secret.SecretVer = 1
+	secret.Vector = nil
+	secret.BaseData = ""
+	secret.StatusID = model.SecretStatuses["NEW"]
+
+	if err := s.db.UpdateSecret(ctx, secret); err != nil {
+		return fmt.Errorf("error detach secret %v: %w", secret.ID, err)
+	}
+
+	details := fmt.Sprintf("edited after remote delete, tombstone version %v, deleted by device %v", task.Tombstone.SecretVer, task.Tombstone.DeletedBy)
+	recordAudit(ctx, s.audit, "SYNC_DETACH", secret.ID, task.SecretId, details)
+
+	return nil
+}
+
+// Orphan marks synced secret unknown to server as ORPHANED, secret is not synced until user keeps or deletes it.
+// Secret is not uploaded as new, so secret deleted by other device is not restored if its tombstone is lost.
+func (s *SyncService) Orphan(ctx context.Context, task SyncTask) error {
+	secret, err := s.db.GetSecret(ctx, task.LocID)
+	if err != nil {
+		return err
+	}
+
+	secret.StatusID = model.SecretStatuses["ORPHANED"]
+	if err := s.db.UpdateSecret(ctx, secret); err != nil {
+		return fmt.Errorf("error orphan secret %v: %w", secret.ID, err)
+	}
+
+	log.Printf("secret %v is unknown to server, marked orphaned", secret.ID)
+	recordAudit(ctx, s.audit, "SYNC_ORPHAN", secret.ID, task.SecretId, "unknown to server")
+	s.publish(SyncEvent{TypeID: SyncEventTypes["ORPHANED"], Task: task}, nil)
+
 	return nil
 }
 
-func (s *SyncService) ProcessTask(task SyncTask) error {
+// AddCollision downloads remote version of locally edited secret and merges it with local edit field by field.
+// If remote version is not newer than version local edit is based on, local edit is rebased on remote version.
+// If same field is changed on both sides, configured conflict strategy is applied,
+// with MANUAL strategy conflict is saved and secret is not synced until conflict is resolved.
+func (s *SyncService) AddCollision(ctx context.Context, task SyncTask) error {
+	remote, err := s.provider.DownloadSecret(ctx, task.SecretId)
+	if err != nil {
+		return err
+	}
+
+	secret, err := s.db.GetSecret(ctx, task.LocID)
+	if err != nil {
+		return err
+	}
+
+	if !isConcurrent(secret.Vector, remote.Vector) {
+		return s.rebase(ctx, secret, remote)
+	}
+
+	c := model.Conflict{
+		SecretLocID:  secret.ID,
+		SecretID:     remote.SecretID,
+		LocalVer:     secret.SecretVer,
+		RemoteVer:    remote.SecretVer,
+		RemoteData:   remote.Data,
+		RemoteVector: remote.Vector,
+	}
+
+	res, ok, err := mergeConflict(s.cfg, secret, c, false)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if len(res.Conflicts) == 0 {
+			return saveMerged(ctx, s.db, s.cfg, secret, c, res)
+		}
+		c.Fields = res.Conflicts
+	}
+
+	strategyID := conflictStrategy(s.cfg)
+	if strategyID == model.ConflictStrategies["MANUAL"] {
+		if _, err := s.db.AddConflict(ctx, c); err != nil {
+			return fmt.Errorf("error save conflict of secret %v: %w", secret.ID, err)
+		}
+		s.publish(SyncEvent{TypeID: SyncEventTypes["CONFLICT"], Task: task}, func(status *SyncStatus) bool {
+			status.Conflicts++
+			return true
+		})
+		return nil
+	}
+
+	return resolveConflict(ctx, s.db, s.cfg, c, strategyID)
+}
+
+// isConcurrent returns true if remote version has changes missing in version local edit is based on.
+// Without vectors every collision is concurrent.
+func isConcurrent(base model.VersionVector, remote model.VersionVector) bool {
+	if len(base) == 0 || len(remote) == 0 {
+		return true
+	}
+
+	order := remote.Compare(base)
+	return order == model.VectorOrders["DESCENDANT"] || order == model.VectorOrders["CONCURRENT"]
+}
+
+// rebase moves local edit on top of remote version, remote version is already included in edit
+func (s *SyncService) rebase(ctx context.Context, secret model.Secret, remote model.RemoteSecret) error {
+	secret.SecretVer = remote.SecretVer
+	secret.Vector = remote.Vector
+	secret.BaseData = remote.Data
+
+	if err := s.db.UpdateSecret(ctx, secret); err != nil {
+		return fmt.Errorf("error rebase secret %v: %w", secret.ID, err)
+	}
+
+	log.Printf("local edit of secret %v rebased on remote version %v", secret.ID, remote.SecretVer)
+
+	return nil
+}
+
+func (s *SyncService) ProcessTask(ctx context.Context, task SyncTask) error {
 	log.Printf("task started %+v", task)
 	switch task.ActionID {
 
-	case SyncActions["UPLOAD"], SyncActions["UPLOAD_NEW"]:
-		if err := s.Upload(task); err != nil {
+	case SyncActions["UPLOAD"], SyncActions["UPLOAD_NEW"], SyncActions["REPAIR_UPLOAD"]:
+		if err := s.Upload(ctx, task); err != nil {
 			return err
 		}
-	case SyncActions["DOWNLOAD"]:
-		if err := s.Download(task); err != nil {
+	case SyncActions["DOWNLOAD"], SyncActions["REPAIR"]:
+		if err := s.Download(ctx, task); err != nil {
 			return err
 		}
 	case SyncActions["DOWNLOAD_NEW"]:
-		if err := s.DownloadNew(task); err != nil {
+		if err := s.DownloadNew(ctx, task); err != nil {
 			return err
 		}
 	case SyncActions["SEND_DELETE"]:
-		if err := s.DeleteRemote(task); err != nil {
+		if err := s.DeleteRemote(ctx, task); err != nil {
 			return err
 		}
 	case SyncActions["DELETE_LOCALLY"]:
-		if err := s.DeleteLocally(task); err != nil {
+		if err := s.DeleteLocally(ctx, task); err != nil {
 			return err
 		}
 	case SyncActions["COLLISION"]:
-		if err := s.AddCollision(task); err != nil {
+		if err := s.AddCollision(ctx, task); err != nil {
+			return err
+		}
+	case SyncActions["DETACH"]:
+		if err := s.Detach(ctx, task); err != nil {
+			return err
+		}
+	case SyncActions["ORPHAN"]:
+		if err := s.Orphan(ctx, task); err != nil {
 			return err
 		}
 	}
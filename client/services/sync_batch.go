@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// defBatchSize is count of tasks in batch if not set in config
+const defBatchSize = 100
+
+// syncBatchSize returns max count of tasks in batch
+func syncBatchSize(cfg *pkg.Config) int {
+	if cfg.BatchSize > 0 {
+		return cfg.BatchSize
+	}
+
+	return defBatchSize
+}
+
+// batchTasks groups upload and download tasks into batches up to size, other tasks are single
+func batchTasks(tasks []SyncTask, size int) [][]SyncTask {
+	var uploads, downloads []SyncTask
+	res := make([][]SyncTask, 0)
+
+	for _, el := range tasks {
+		switch {
+		case isUploadTask(el):
+			uploads = append(uploads, el)
+		case isDownloadTask(el):
+			downloads = append(downloads, el)
+		default:
+			res = append(res, []SyncTask{el})
+		}
+	}
+
+	for _, list := range [][]SyncTask{uploads, downloads} {
+		for len(list) > 0 {
+			n := size
+			if len(list) < n {
+				n = len(list)
+			}
+			res = append(res, list[:n])
+			list = list[n:]
+		}
+	}
+
+	return res
+}
+
+// processBatch processes batch of tasks of same kind, returns error of every task, nil if task is done.
+// Tasks not started before context is done fail with context error.
+func (s *SyncService) processBatch(ctx context.Context, batch []SyncTask) []error {
+	if err := ctx.Err(); err != nil {
+		return batchError(len(batch), err)
+	}
+
+	switch {
+	case len(batch) > 1 && isUploadTask(batch[0]):
+		return s.UploadBatch(ctx, batch)
+	case isDownloadTask(batch[0]) && isSelective(s.cfg):
+		return s.DownloadSelective(ctx, batch)
+	case len(batch) > 1 && isDownloadTask(batch[0]):
+		return s.DownloadBatch(ctx, batch)
+	}
+
+	errs := make([]error, len(batch))
+	for i, el := range batch {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		if errs[i] = s.ProcessTask(ctx, el); errs[i] != nil {
+			log.Println(errs[i].Error())
+		}
+	}
+
+	return errs
+}
+
+// isUploadTask returns true if task uploads secret
+func isUploadTask(task SyncTask) bool {
+	return task.ActionID == SyncActions["UPLOAD"] || task.ActionID == SyncActions["UPLOAD_NEW"]
+}
+
+// isDownloadTask returns true if task downloads secret
+func isDownloadTask(task SyncTask) bool {
+	return task.ActionID == SyncActions["DOWNLOAD"] || task.ActionID == SyncActions["DOWNLOAD_NEW"] ||
+		task.ActionID == SyncActions["REPAIR"]
+}
+
+// UploadBatch uploads secrets of upload tasks with one request, returns error of every task
+func (s *SyncService) UploadBatch(ctx context.Context, tasks []SyncTask) []error {
+	log.Printf("upload batch started, tasks: %v", len(tasks))
+
+	errs := make([]error, len(tasks))
+	//  indexes of uploaded tasks
+	sent := make([]int, 0, len(tasks))
+	secrets := make([]model.Secret, 0, len(tasks))
+	items := make([]model.RemoteSecret, 0, len(tasks))
+	for i, task := range tasks {
+		secret, err := s.uploadSecret(ctx, task)
+		if err != nil {
+			log.Printf("error upload sync of task %+v: %v", task, err)
+			errs[i] = err
+			continue
+		}
+
+		meta, err := secret.Info.ToEncodedMeta(s.cfg.MasterKey)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		sent = append(sent, i)
+		secrets = append(secrets, secret)
+		items = append(items, model.RemoteSecret{
+			SecretID:  task.SecretId,
+			SecretVer: task.Ver,
+			Data:      secret.SecretData,
+			Meta:      meta,
+			Vector:    secret.Vector,
+		})
+	}
+
+	if len(items) == 0 {
+		return errs
+	}
+
+	res, err := s.provider.UploadSecrets(ctx, items)
+	if err != nil {
+		log.Println(err.Error())
+		return batchError(len(tasks), err)
+	}
+
+	for i, el := range res {
+		if el.Err == nil {
+			el.Err = s.saveUploaded(ctx, secrets[i], el)
+		}
+		if el.Err != nil {
+			log.Printf("error upload sync of secret %v: %v", secrets[i].ID, el.Err)
+			errs[sent[i]] = el.Err
+		}
+	}
+
+	log.Printf("upload batch processed, tasks: %v, failed: %v", len(tasks), countErrors(errs))
+
+	return errs
+}
+
+// DownloadBatch downloads secrets of download tasks with one request, returns error of every task
+func (s *SyncService) DownloadBatch(ctx context.Context, tasks []SyncTask) []error {
+	log.Printf("download batch started, tasks: %v", len(tasks))
+
+	ids := make([]uuid.UUID, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.SecretId)
+	}
+
+	res, err := s.provider.DownloadSecrets(ctx, ids)
+	if err != nil {
+		log.Println(err.Error())
+		return batchError(len(tasks), err)
+	}
+
+	errs := make([]error, len(tasks))
+	for i, el := range res {
+		if el.Err == nil {
+			if tasks[i].ActionID == SyncActions["DOWNLOAD_NEW"] {
+				el.Err = s.saveDownloadedNew(ctx, el)
+			} else {
+				el.Err = s.saveDownloaded(ctx, el)
+			}
+		}
+		if el.Err != nil {
+			log.Printf("error download sync of secret %v: %v", el.SecretID, el.Err)
+			errs[i] = el.Err
+		}
+	}
+
+	log.Printf("download batch processed, tasks: %v, failed: %v", len(tasks), countErrors(errs))
+
+	return errs
+}
+
+// batchError returns same error for every task of batch
+func batchError(count int, err error) []error {
+	errs := make([]error, count)
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// countErrors returns count of not nil errors
+func countErrors(errs []error) int {
+	count := 0
+	for _, err := range errs {
+		if err != nil {
+			count++
+		}
+	}
+
+	return count
+}
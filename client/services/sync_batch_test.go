@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSyncBatch_BatchTasks(t *testing.T) {
+	tasks := []SyncTask{
+		taskUploadNew(model.SecretMeta{ID: 1}),
+		taskDownloadNew(uuid.New()),
+		taskDeleteRemote(model.SecretMeta{ID: 2, SecretID: uuid.New()}),
+		taskUpload(model.SecretMeta{ID: 3, SecretID: uuid.New()}),
+		taskUploadNew(model.SecretMeta{ID: 4}),
+		taskDownload(model.SecretMeta{ID: 5, SecretID: uuid.New()}),
+	}
+
+	batches := batchTasks(tasks, 2)
+	require.Equal(t, [][]SyncTask{
+		{tasks[2]},
+		{tasks[0], tasks[3]},
+		{tasks[4]},
+		{tasks[1], tasks[5]},
+	}, batches)
+}
+
+func TestSyncBatch_Upload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	exist := model.Secret{ID: 1, SecretID: uuid.New(), SecretVer: 2, SecretData: "exist", StatusID: model.SecretStatuses["EDITED"]}
+	added := model.Secret{ID: 2, SecretVer: 1, SecretData: "new", StatusID: model.SecretStatuses["NEW"]}
+	failed := model.Secret{ID: 3, SecretVer: 1, SecretData: "failed", StatusID: model.SecretStatuses["NEW"]}
+	newID := uuid.New()
+
+	tasks := []SyncTask{
+		taskUpload(model.SecretMeta{SecretID: exist.SecretID, SecretVer: 2}),
+		taskUploadNew(model.SecretMeta{ID: added.ID, SecretVer: 1}),
+		taskUploadNew(model.SecretMeta{ID: failed.ID, SecretVer: 1}),
+		taskUploadNew(model.SecretMeta{ID: 4, SecretVer: 1}),
+	}
+
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), exist.SecretID).Return(exist, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), added.ID).Return(added, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), failed.ID).Return(failed, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), int64(4)).Return(model.Secret{}, model.ErrorItemNotFound)
+
+	meta, err := model.Info{}.ToEncodedMeta("testKey")
+	require.NoError(t, err)
+
+	provider.EXPECT().UploadSecrets(gomock.Any(), []model.RemoteSecret{
+		{SecretID: exist.SecretID, SecretVer: 2, Data: "exist", Meta: meta},
+		{SecretVer: 1, Data: "new", Meta: meta},
+		{SecretVer: 1, Data: "failed", Meta: meta},
+	}).Return([]model.RemoteSecret{
+		{SecretID: exist.SecretID, SecretVer: 3},
+		{SecretID: newID, SecretVer: 1},
+		{Err: errors.New("item response error")},
+	}, nil)
+
+	storage.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+		require.Equal(t, model.SecretStatuses["ACTUAL"], v.StatusID)
+		require.Equal(t, v.SecretData, v.BaseData)
+		if v.ID == exist.ID {
+			require.Equal(t, 3, v.SecretVer)
+			return
+		}
+		require.Equal(t, newID, v.SecretID)
+	}).Return(nil).Times(2)
+
+	errs := svc.UploadBatch(context.Background(), tasks)
+	require.Len(t, errs, len(tasks))
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.Error(t, errs[2])
+	require.ErrorIs(t, errs[3], model.ErrorItemNotFound)
+}
+
+func TestSyncBatch_Download(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svcSecret := GetTestSecretSvc(t, storage)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	remote, err := svcSecret.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+
+	exist := model.Secret{ID: 1, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"]}
+	newID := uuid.New()
+
+	tasks := []SyncTask{
+		taskDownload(model.SecretMeta{SecretID: exist.SecretID}),
+		taskDownloadNew(newID),
+		taskDownloadNew(uuid.New()),
+	}
+
+	provider.EXPECT().DownloadSecrets(gomock.Any(), []uuid.UUID{exist.SecretID, newID, tasks[2].SecretId}).Return([]model.RemoteSecret{
+		{SecretID: exist.SecretID, SecretVer: 2, Data: remote.SecretData},
+		{SecretID: newID, SecretVer: 1, Data: remote.SecretData},
+		{SecretID: tasks[2].SecretId, Err: errors.New("item response error")},
+	}, nil)
+
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), exist.SecretID).Return(exist, nil)
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["DOWNLOAD"], 0).Do(func(_ context.Context, v model.Secret, _ int, _ int) {
+		require.Equal(t, 2, v.SecretVer)
+		require.Equal(t, remote.SecretData, v.SecretData)
+	}).Return(nil)
+	storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+		require.Equal(t, newID, v.SecretID)
+		require.Equal(t, model.TestAuth.Title, v.Title)
+	}).Return(int64(2), nil)
+
+	require.Equal(t, 1, countErrors(svc.DownloadBatch(context.Background(), tasks)))
+
+	//  request error fails all tasks
+	provider.EXPECT().DownloadSecrets(gomock.Any(), gomock.Any()).Return(nil, errors.New("server error"))
+	require.Equal(t, 3, countErrors(svc.DownloadBatch(context.Background(), tasks)))
+}
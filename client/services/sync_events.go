@@ -0,0 +1,153 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// syncEventsBuffer is count of events kept for slow subscriber, next events are dropped
+const syncEventsBuffer = 64
+
+var (
+	SyncEventTypes = map[string]int{
+		"CYCLE_STARTED":  1,
+		"CYCLE_FINISHED": 2,
+		"TASK_DONE":      3,
+		"TASK_FAILED":    4,
+		"CONFLICT":       5,
+		"AUTH_LOST":      6,
+		"CONNECTIVITY":   7,
+		"ORPHANED":       8,
+	}
+)
+
+// SyncStatus is current state of sync
+type SyncStatus struct {
+	Online bool
+	//  time server became unreachable, 0 if online
+	OfflineSince int64
+	AuthLost     bool
+	Syncing      bool
+	//  time last sync cycle finished, 0 if not synced
+	LastSyncAt int64
+	//  error of last sync cycle, empty if cycle succeeded
+	LastError string
+	//  failed tasks of last sync cycle
+	Failed int
+	//  tasks waiting for retry or parked in sync journal
+	Deferred  int
+	Conflicts int
+}
+
+// SyncEvent is notification of sync state change.
+// Task is set for task, conflict and orphaned events, Err for failed task, failed cycle and lost auth,
+// Status is sync status after event.
+type SyncEvent struct {
+	TypeID    int
+	TimeStamp int64
+	Task      SyncTask
+	Err       error
+	Status    SyncStatus
+}
+
+// syncNotifier keeps sync status and sends events to subscribers
+type syncNotifier struct {
+	mu     sync.Mutex
+	subs   map[int]chan SyncEvent
+	lastID int
+	status SyncStatus
+}
+
+// Subscribe returns channel of sync events and function to unsubscribe.
+// Events are not queued for slow subscriber over buffer, Status returns actual state.
+func (s *SyncService) Subscribe() (<-chan SyncEvent, func()) {
+	n := s.events
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.lastID++
+	id := n.lastID
+	ch := make(chan SyncEvent, syncEventsBuffer)
+	n.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			delete(n.subs, id)
+			close(ch)
+		})
+	}
+}
+
+// Status returns current state of sync
+func (s *SyncService) Status() SyncStatus {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+
+	return s.events.status
+}
+
+// publish updates sync status and sends event to subscribers, event is not sent if update returns false
+func (s *SyncService) publish(event SyncEvent, update func(status *SyncStatus) bool) {
+	n := s.events
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if update != nil && !update(&n.status) {
+		return
+	}
+
+	event.TimeStamp = pkg.MakeTimestamp()
+	event.Status = n.status
+
+	for _, ch := range n.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// publishResults sends event of every processed task
+func (s *SyncService) publishResults(results []syncResult) {
+	for _, res := range results {
+		event := SyncEvent{TypeID: SyncEventTypes["TASK_DONE"], Task: res.Task}
+		if res.Err != nil {
+			event.TypeID, event.Err = SyncEventTypes["TASK_FAILED"], res.Err
+		}
+		s.publish(event, nil)
+	}
+}
+
+// setOnline updates server connectivity state, event is sent if state changed.
+// Server response with accepted auth restores lost auth.
+func (s *SyncService) setOnline(online bool) {
+	s.publish(SyncEvent{TypeID: SyncEventTypes["CONNECTIVITY"]}, func(status *SyncStatus) bool {
+		if online {
+			changed := !status.Online || status.AuthLost
+			status.Online, status.OfflineSince, status.AuthLost = true, 0, false
+			return changed
+		}
+
+		if !status.Online && status.OfflineSince != 0 {
+			return false
+		}
+		status.Online, status.OfflineSince = false, pkg.MakeTimestamp()
+		return true
+	})
+}
+
+// setAuthLost marks that server rejects client auth, event is sent once until auth is restored
+func (s *SyncService) setAuthLost(err error) {
+	s.publish(SyncEvent{TypeID: SyncEventTypes["AUTH_LOST"], Err: err}, func(status *SyncStatus) bool {
+		if status.AuthLost {
+			return false
+		}
+		status.AuthLost = true
+		return true
+	})
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+// readEvents returns types of events sent to subscriber
+func readEvents(events <-chan SyncEvent) []int {
+	res := make([]int, 0)
+	for {
+		select {
+		case ev := <-events:
+			res = append(res, ev.TypeID)
+		default:
+			return res
+		}
+	}
+}
+
+func TestSyncEvents_Sync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 6000})
+
+	events, unsubscribe := svc.Subscribe()
+	defer unsubscribe()
+
+	local := model.SecretMeta{ID: 1, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["DELETED"]}
+
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{local}, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+	provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(model.SyncChanges{Cursor: 12}, nil)
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{}, nil)
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), local.SecretID).Return(model.Secret{ID: 1, SecretID: local.SecretID}, nil)
+	provider.EXPECT().DeleteSecret(gomock.Any(), local.SecretID).Return(errors.New("server error"))
+	storage.EXPECT().SaveJournalEntry(gomock.Any(), gomock.Any()).Return(nil)
+	storage.EXPECT().GetConflicts(gomock.Any()).Return([]model.Conflict{{ID: 1}, {ID: 2}}, nil)
+
+	require.Error(t, svc.Sync(context.Background()))
+
+	require.Equal(t, []int{
+		SyncEventTypes["CYCLE_STARTED"],
+		SyncEventTypes["TASK_FAILED"],
+		SyncEventTypes["CYCLE_FINISHED"],
+	}, readEvents(events))
+
+	status := svc.Status()
+	require.False(t, status.Syncing)
+	require.NotZero(t, status.LastSyncAt)
+	require.NotEmpty(t, status.LastError)
+	require.Equal(t, 1, status.Failed)
+	require.Equal(t, 2, status.Conflicts)
+}
+
+func TestSyncEvents_Connectivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 6000})
+
+	events, unsubscribe := svc.Subscribe()
+
+	//  offline event is sent once
+	provider.EXPECT().PingAuth(gomock.Any()).Return(errors.New("connection refused")).Times(2)
+	svc.syncOnline(context.Background(), svc.sync)
+	svc.syncOnline(context.Background(), svc.sync)
+	require.Equal(t, []int{SyncEventTypes["CONNECTIVITY"]}, readEvents(events))
+	require.False(t, svc.Status().Online)
+	require.NotZero(t, svc.Status().OfflineSince)
+
+	//  auth lost event is sent once
+	provider.EXPECT().PingAuth(gomock.Any()).Return(fmt.Errorf("ping request error: %w", model.ErrorNotAuthorized)).Times(2)
+	svc.syncOnline(context.Background(), svc.sync)
+	svc.syncOnline(context.Background(), svc.sync)
+	require.Equal(t, []int{SyncEventTypes["AUTH_LOST"]}, readEvents(events))
+	require.True(t, svc.Status().AuthLost)
+
+	//  online again, sync started
+	provider.EXPECT().PingAuth(gomock.Any()).Return(nil)
+	storage.EXPECT().GetMetaList(gomock.Any()).Return(nil, errors.New("db error"))
+	storage.EXPECT().GetConflicts(gomock.Any()).Return([]model.Conflict{}, nil)
+	svc.syncOnline(context.Background(), svc.sync)
+	require.Equal(t, []int{
+		SyncEventTypes["CONNECTIVITY"],
+		SyncEventTypes["CYCLE_STARTED"],
+		SyncEventTypes["CYCLE_FINISHED"],
+	}, readEvents(events))
+
+	status := svc.Status()
+	require.True(t, status.Online)
+	require.Zero(t, status.OfflineSince)
+	require.False(t, status.AuthLost)
+
+	unsubscribe()
+	unsubscribe()
+	_, ok := <-events
+	require.False(t, ok)
+}
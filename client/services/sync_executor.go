@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// syncExecutor processes sync jobs with bounded count of workers.
+// Jobs touching same secret are serialized in order of submission,
+// jobs of different secrets run concurrently.
+type syncExecutor struct {
+	//  free worker slots
+	slots chan struct{}
+	mu    sync.Mutex
+	//  done channel of last submitted job of every secret key
+	tails map[string]chan struct{}
+	//  jobs in flight
+	wg sync.WaitGroup
+}
+
+// newSyncExecutor returns executor with workers count of config, jobs are processed one by one if it is not set
+func newSyncExecutor(workers int) *syncExecutor {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &syncExecutor{
+		slots: make(chan struct{}, workers),
+		tails: make(map[string]chan struct{}),
+	}
+}
+
+// secretKey returns key of secret task works with, synced secrets are keyed by server id
+func secretKey(task SyncTask) string {
+	if task.SecretId != uuid.Nil {
+		return task.SecretId.String()
+	}
+
+	return fmt.Sprintf("loc:%v", task.LocID)
+}
+
+// batchKeys returns unique keys of secrets of batch
+func batchKeys(batch []SyncTask) []string {
+	keys := make([]string, 0, len(batch))
+	seen := make(map[string]struct{}, len(batch))
+	for _, el := range batch {
+		key := secretKey(el)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Submit waits for free worker and starts job on it, job starts after previous jobs of same keys are done.
+// Returns error without starting job if context is done while waiting for worker.
+// Returned channel is closed when job is done.
+func (e *syncExecutor) Submit(ctx context.Context, keys []string, job func(ctx context.Context)) (<-chan struct{}, error) {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	//  keys are reserved in order of submission, job waits only for jobs submitted earlier,
+	//  every reserved job holds worker, so waiting chain always ends with running job
+	done := make(chan struct{})
+	prev := make([]chan struct{}, 0, len(keys))
+	e.mu.Lock()
+	for _, key := range keys {
+		if tail, ok := e.tails[key]; ok {
+			prev = append(prev, tail)
+		}
+		e.tails[key] = done
+	}
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer func() {
+			e.release(keys, done)
+			<-e.slots
+			e.wg.Done()
+		}()
+
+		//  previous job is waited even if context is done, jobs of secret never overlap
+		for _, el := range prev {
+			<-el
+		}
+
+		job(ctx)
+	}()
+
+	return done, nil
+}
+
+// Do submits job and waits until it is done
+func (e *syncExecutor) Do(ctx context.Context, keys []string, job func(ctx context.Context)) error {
+	done, err := e.Submit(ctx, keys, job)
+	if err != nil {
+		return err
+	}
+	<-done
+
+	return nil
+}
+
+// release frees keys reserved by job, keys reserved by later jobs are kept
+func (e *syncExecutor) release(keys []string, done chan struct{}) {
+	e.mu.Lock()
+	for _, key := range keys {
+		if e.tails[key] == done {
+			delete(e.tails, key)
+		}
+	}
+	e.mu.Unlock()
+
+	close(done)
+}
+
+// Wait waits until all submitted jobs are done
+func (e *syncExecutor) Wait() {
+	e.wg.Wait()
+}
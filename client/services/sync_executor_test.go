@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSyncExecutor_Submit(t *testing.T) {
+	exec := newSyncExecutor(2)
+	ctx := context.Background()
+
+	var running, maxRunning int32
+	mu := sync.Mutex{}
+	order := make(map[string][]int)
+
+	job := func(key string, n int) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			cur := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			mu.Lock()
+			if cur > maxRunning {
+				maxRunning = cur
+			}
+			order[key] = append(order[key], n)
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond * 5)
+		}
+	}
+
+	dones := make([]<-chan struct{}, 0)
+	for n := 0; n < 5; n++ {
+		for _, key := range []string{"a", "b", "c"} {
+			done, err := exec.Submit(ctx, []string{key}, job(key, n))
+			require.NoError(t, err)
+			dones = append(dones, done)
+		}
+	}
+	for _, done := range dones {
+		<-done
+	}
+
+	//  workers are bounded, jobs of key keep submission order
+	require.LessOrEqual(t, maxRunning, int32(2))
+	for _, key := range []string{"a", "b", "c"} {
+		require.Equal(t, []int{0, 1, 2, 3, 4}, order[key])
+	}
+	require.Empty(t, exec.tails)
+}
+
+func TestSyncExecutor_Cancel(t *testing.T) {
+	exec := newSyncExecutor(1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_, err := exec.Submit(ctx, []string{"a"}, func(ctx context.Context) {
+		close(started)
+		<-release
+	})
+	require.NoError(t, err)
+	<-started
+
+	//  no free worker, submit is interrupted by context
+	cancel()
+	_, err = exec.Submit(ctx, []string{"b"}, func(ctx context.Context) {})
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	exec.Wait()
+	require.Empty(t, exec.tails)
+}
+
+func TestSyncExecutor_TickCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewSyncService(mk.NewMockStorage(ctrl), pmk.NewMockSecretProvider(ctrl),
+		&pkg.Config{SyncTimeoutSec: 2, RequestsPerMinute: 60, SyncWorkers: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	//  nothing is processed after context is done
+	results := svc.tick(ctx, []SyncTask{
+		{SecretId: uuid.New(), ActionID: SyncActions["SEND_DELETE"]},
+		{SecretId: uuid.New(), ActionID: SyncActions["SEND_DELETE"]},
+	})
+	require.Len(t, results, 2)
+	for _, el := range results {
+		require.ErrorIs(t, el.Err, context.Canceled)
+	}
+	svc.Wait()
+}
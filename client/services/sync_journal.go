@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// maxRetryDelay is max delay of failed sync task retry
+const maxRetryDelay = time.Hour
+
+// syncResult is result of processed sync task, Err is nil if task is done
+type syncResult struct {
+	Task SyncTask
+	Err  error
+}
+
+// taskKey returns key of sync task in journal, same task of next sync has the same key
+func taskKey(task SyncTask) string {
+	return fmt.Sprintf("%v:%v:%v", task.ActionID, task.LocID, task.SecretId)
+}
+
+// retryDelay returns delay before next attempt of task failed attempts times,
+// delay starts from sync timeout and is doubled with every attempt up to maxRetryDelay
+func retryDelay(cfg *pkg.Config, attempts int) time.Duration {
+	delay := time.Second * time.Duration(cfg.SyncTimeoutSec)
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay
+}
+
+// filterJournal returns tasks ready to process, journal entries of ready tasks by task key and count of deferred tasks.
+// Task is deferred if it waits for retry or is parked.
+// If tasks are full sync batch, journal drives tasks applying remote changes missing in batch,
+// change feed cursor is moved over deferred tasks, so their changes are not received again.
+// Journal entries of tasks not needed anymore are deleted.
+func (s *SyncService) filterJournal(ctx context.Context, tasks []SyncTask, full bool, now time.Time) ([]SyncTask, map[string]model.SyncJournalEntry, int, error) {
+	list, err := s.db.GetJournalEntries(ctx)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	entries := make(map[string]model.SyncJournalEntry, len(list))
+	for _, el := range list {
+		entries[el.TaskKey] = el
+	}
+
+	ready := make([]SyncTask, 0, len(tasks))
+	journaled := make(map[string]model.SyncJournalEntry)
+	deferred := 0
+	addTask := func(task SyncTask, entry model.SyncJournalEntry, ok bool) {
+		if !ok {
+			ready = append(ready, task)
+			return
+		}
+		if entry.Parked || entry.NextRetryAt > now.UnixMilli() {
+			deferred++
+			return
+		}
+
+		ready = append(ready, task)
+		journaled[entry.TaskKey] = entry
+	}
+
+	touched := make(map[string]struct{}, len(tasks))
+	for _, task := range tasks {
+		key := taskKey(task)
+		entry, ok := entries[key]
+		delete(entries, key)
+		touched[secretKey(task)] = struct{}{}
+
+		addTask(task, entry, ok)
+	}
+
+	//  partial batch does not tell if secret is changed or synced by other task
+	if !full {
+		return ready, journaled, deferred, nil
+	}
+
+	for _, el := range list {
+		entry, ok := entries[el.TaskKey]
+		if !ok {
+			continue
+		}
+
+		//  remote change is received once, task applying it is kept by journal until it is done
+		task := SyncTask{LocID: entry.SecretLocID, SecretId: entry.SecretID, ActionID: entry.ActionID}
+		if _, ok := touched[secretKey(task)]; !ok && !isLocalChangeTask(task) {
+			addTask(task, entry, true)
+			continue
+		}
+
+		//  local change is calculated again, or secret has newer task
+		if err := s.db.DeleteJournalEntry(ctx, entry.TaskKey); err != nil && !errors.Is(err, model.ErrorItemNotFound) {
+			return nil, nil, 0, err
+		}
+	}
+
+	return ready, journaled, deferred, nil
+}
+
+// recordJournal saves results of processed tasks to journal, returns count of failed tasks.
+// Journal entry of done task is deleted. Failed task is retried with backoff,
+// task is parked if it is rejected by server or failed max attempts.
+// Tasks not processed because sync is canceled are not recorded.
+func (s *SyncService) recordJournal(ctx context.Context, results []syncResult, journaled map[string]model.SyncJournalEntry, now time.Time) (int, error) {
+	failed := 0
+	for _, res := range results {
+		key := taskKey(res.Task)
+		entry, ok := journaled[key]
+
+		if res.Err == nil {
+			if !ok {
+				continue
+			}
+			if err := s.db.DeleteJournalEntry(ctx, key); err != nil && !errors.Is(err, model.ErrorItemNotFound) {
+				return failed, err
+			}
+			continue
+		}
+
+		failed++
+		if errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded) {
+			continue
+		}
+
+		entry.TaskKey = key
+		entry.ActionID = res.Task.ActionID
+		entry.SecretLocID = res.Task.LocID
+		entry.SecretID = res.Task.SecretId
+		entry.Attempts++
+		entry.LastError = res.Err.Error()
+		entry.NextRetryAt = now.Add(retryDelay(s.cfg, entry.Attempts)).UnixMilli()
+		entry.Parked = errors.Is(res.Err, model.ErrorRequestRejected) || entry.Attempts >= s.cfg.SyncMaxAttempts
+
+		if err := s.db.SaveJournalEntry(ctx, entry); err != nil {
+			return failed, err
+		}
+	}
+
+	return failed, nil
+}
+
+type SyncJournalService struct {
+	db storage.Storage
+}
+
+// NewSyncJournalService returns new instance of sync journal service
+// Service lists failed sync tasks and resets them for retry
+func NewSyncJournalService(db storage.Storage) *SyncJournalService {
+	return &SyncJournalService{
+		db: db,
+	}
+}
+
+// Entries returns states of failed sync tasks
+func (s *SyncJournalService) Entries(ctx context.Context) ([]model.SyncJournalEntry, error) {
+	return s.db.GetJournalEntries(ctx)
+}
+
+// Retry resets failed task, task is retried with next sync
+func (s *SyncJournalService) Retry(ctx context.Context, taskKey string) error {
+	return s.db.DeleteJournalEntry(ctx, taskKey)
+}
+
+// RetryAll resets all failed tasks, returns count of reset tasks
+func (s *SyncJournalService) RetryAll(ctx context.Context) (int, error) {
+	list, err := s.db.GetJournalEntries(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, el := range list {
+		if err := s.db.DeleteJournalEntry(ctx, el.TaskKey); err != nil && !errors.Is(err, model.ErrorItemNotFound) {
+			return i, err
+		}
+	}
+
+	return len(list), nil
+}
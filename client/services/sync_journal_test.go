@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSyncJournal_RetryDelay(t *testing.T) {
+	cfg := &pkg.Config{SyncTimeoutSec: 2}
+
+	require.Equal(t, 2*time.Second, retryDelay(cfg, 1))
+	require.Equal(t, 4*time.Second, retryDelay(cfg, 2))
+	require.Equal(t, 16*time.Second, retryDelay(cfg, 4))
+	require.Equal(t, maxRetryDelay, retryDelay(cfg, 100))
+}
+
+func TestSyncJournal_Filter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := NewSyncService(storage, pmk.NewMockSecretProvider(ctrl), &pkg.Config{SyncTimeoutSec: 2, RequestsPerMinute: 60})
+
+	now := time.Now()
+	tasks := []SyncTask{
+		taskUploadNew(model.SecretMeta{ID: 1}),
+		taskDownloadNew(uuid.New()),
+		taskDownloadNew(uuid.New()),
+		taskDownloadNew(uuid.New()),
+	}
+
+	//  remote changes are not in batch after cursor is moved, tasks are driven by journal
+	retried := SyncTask{LocID: 5, SecretId: uuid.New(), ActionID: SyncActions["DOWNLOAD"]}
+	waiting := SyncTask{LocID: 6, SecretId: uuid.New(), ActionID: SyncActions["DOWNLOAD"]}
+	journalEntry := func(task SyncTask, entry model.SyncJournalEntry) model.SyncJournalEntry {
+		entry.TaskKey, entry.ActionID, entry.SecretLocID, entry.SecretID = taskKey(task), task.ActionID, task.LocID, task.SecretId
+		return entry
+	}
+
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{
+		//  retry time passed
+		journalEntry(tasks[1], model.SyncJournalEntry{Attempts: 1, NextRetryAt: now.Add(-time.Second).UnixMilli()}),
+		//  waits for retry
+		journalEntry(tasks[2], model.SyncJournalEntry{Attempts: 1, NextRetryAt: now.Add(time.Second).UnixMilli()}),
+		journalEntry(tasks[3], model.SyncJournalEntry{Attempts: 8, Parked: true}),
+		journalEntry(retried, model.SyncJournalEntry{Attempts: 1, NextRetryAt: now.Add(-time.Second).UnixMilli()}),
+		journalEntry(waiting, model.SyncJournalEntry{Attempts: 1, NextRetryAt: now.Add(time.Second).UnixMilli()}),
+		//  local change not made anymore
+		{TaskKey: "stale", ActionID: SyncActions["UPLOAD"], SecretLocID: 7, SecretID: uuid.New(), Attempts: 1},
+		//  secret has newer task
+		{TaskKey: "replaced", ActionID: SyncActions["DOWNLOAD"], SecretID: tasks[1].SecretId, Attempts: 1},
+	}, nil)
+	storage.EXPECT().DeleteJournalEntry(gomock.Any(), "stale").Return(nil)
+	storage.EXPECT().DeleteJournalEntry(gomock.Any(), "replaced").Return(nil)
+
+	ready, journaled, deferred, err := svc.filterJournal(context.Background(), tasks, true, now)
+	require.NoError(t, err)
+	require.Equal(t, append(tasks[:2:2], retried), ready)
+	require.Len(t, journaled, 2)
+	require.Contains(t, journaled, taskKey(tasks[1]))
+	require.Contains(t, journaled, taskKey(retried))
+	require.Equal(t, 3, deferred)
+}
+
+func TestSyncJournal_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := NewSyncService(storage, pmk.NewMockSecretProvider(ctrl), &pkg.Config{SyncTimeoutSec: 2, RequestsPerMinute: 60, SyncMaxAttempts: 3})
+
+	now := time.Now()
+	done := taskDownloadNew(uuid.New())
+	retried := taskDownloadNew(uuid.New())
+	failedFirst := taskUploadNew(model.SecretMeta{ID: 1})
+	rejected := taskUpload(model.SecretMeta{SecretID: uuid.New(), SecretVer: 2})
+	canceled := taskDownloadNew(uuid.New())
+
+	journaled := map[string]model.SyncJournalEntry{
+		taskKey(done):    {TaskKey: taskKey(done), Attempts: 1},
+		taskKey(retried): {TaskKey: taskKey(retried), Attempts: 2},
+	}
+
+	storage.EXPECT().DeleteJournalEntry(gomock.Any(), taskKey(done)).Return(nil)
+	storage.EXPECT().SaveJournalEntry(gomock.Any(), gomock.Any()).Do(func(_ context.Context, e model.SyncJournalEntry) {
+		switch e.TaskKey {
+		case taskKey(retried):
+			//  max attempts reached
+			require.Equal(t, 3, e.Attempts)
+			require.True(t, e.Parked)
+		case taskKey(failedFirst):
+			require.Equal(t, 1, e.Attempts)
+			require.False(t, e.Parked)
+			require.Equal(t, int64(1), e.SecretLocID)
+			require.Equal(t, now.Add(2*time.Second).UnixMilli(), e.NextRetryAt)
+			require.Equal(t, "server error", e.LastError)
+		case taskKey(rejected):
+			require.Equal(t, 1, e.Attempts)
+			require.True(t, e.Parked)
+			require.Equal(t, rejected.SecretId, e.SecretID)
+		default:
+			require.Failf(t, "unexpected journal entry", "%+v", e)
+		}
+	}).Return(nil).Times(3)
+
+	failed, err := svc.recordJournal(context.Background(), []syncResult{
+		{Task: done},
+		{Task: taskDownloadNew(uuid.New())},
+		{Task: retried, Err: errors.New("server error")},
+		{Task: failedFirst, Err: errors.New("server error")},
+		{Task: rejected, Err: fmt.Errorf("%w: version of data to low", model.ErrorRequestRejected)},
+		{Task: canceled, Err: context.Canceled},
+	}, journaled, now)
+	require.NoError(t, err)
+	require.Equal(t, 4, failed)
+}
+
+func TestSyncJournal_SyncSkipsDeferred(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 6000})
+	storage.EXPECT().GetConflicts(gomock.Any()).Return([]model.Conflict{}, nil).AnyTimes()
+
+	local := model.SecretMeta{ID: 1, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["DELETED"]}
+
+	//  parked task is not processed, cursor is moved, task is kept by journal
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{local}, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+	provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(model.SyncChanges{Cursor: 12}, nil)
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{
+		{TaskKey: taskKey(taskDeleteRemote(local)), Attempts: 8, Parked: true},
+	}, nil)
+	storage.EXPECT().SetSyncCursor(gomock.Any(), int64(12)).Return(nil)
+
+	require.NoError(t, svc.Sync(context.Background()))
+}
+
+func TestSyncJournal_Service(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := NewSyncJournalService(storage)
+
+	storage.EXPECT().DeleteJournalEntry(gomock.Any(), "missing").Return(model.ErrorItemNotFound)
+	require.ErrorIs(t, svc.Retry(context.Background(), "missing"), model.ErrorItemNotFound)
+
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{{TaskKey: "1"}, {TaskKey: "2"}}, nil)
+	storage.EXPECT().DeleteJournalEntry(gomock.Any(), "1").Return(nil)
+	storage.EXPECT().DeleteJournalEntry(gomock.Any(), "2").Return(nil)
+
+	count, err := svc.RetryAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
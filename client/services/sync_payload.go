@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// isSelective returns true if config has lazy or exclusion rules, secrets are downloaded by meta first
+func isSelective(cfg *pkg.Config) bool {
+	return cfg.LazySize > 0 || cfg.ExcludeSize > 0 || cfg.ExcludeTypes != "" || cfg.ExcludeTags != ""
+}
+
+// payloadState returns state of local copy of secret with description and data size by config rules.
+// Excluded secrets are not downloaded, binary secrets over lazy size are downloaded on first open.
+func payloadState(cfg *pkg.Config, info model.Info, size int) int {
+	if cfg.ExcludeSize > 0 && size > cfg.ExcludeSize {
+		return model.PayloadStates["EXCLUDED"]
+	}
+	for _, el := range pkg.SplitList(cfg.ExcludeTypes) {
+		if model.SecretTypes[el] == info.TypeID {
+			return model.PayloadStates["EXCLUDED"]
+		}
+	}
+	for _, el := range pkg.SplitList(cfg.ExcludeTags) {
+		for _, tag := range info.Tags {
+			if tag == el {
+				return model.PayloadStates["EXCLUDED"]
+			}
+		}
+	}
+
+	if cfg.LazySize > 0 && info.TypeID == model.SecretTypes["BINARY"] && size > cfg.LazySize {
+		return model.PayloadStates["PLACEHOLDER"]
+	}
+
+	return model.PayloadStates["LOCAL"]
+}
+
+// DownloadSelective downloads meta of secrets first, then data of secrets allowed by config rules.
+// Secrets not allowed are saved as placeholders, local secret with data is always updated with data.
+// Returns error of every task.
+func (s *SyncService) DownloadSelective(ctx context.Context, tasks []SyncTask) []error {
+	log.Printf("selective download started, tasks: %v", len(tasks))
+
+	errs := make([]error, len(tasks))
+	//  indexes of tasks downloaded with data and tasks checked by meta
+	full := make([]int, 0, len(tasks))
+	lazy := make([]int, 0, len(tasks))
+	ids := make([]uuid.UUID, 0, len(tasks))
+	for i, task := range tasks {
+		//  local data is kept up to date, it can be evicted manually
+		if task.ActionID != SyncActions["DOWNLOAD_NEW"] && task.PayloadID == model.PayloadStates["LOCAL"] {
+			full = append(full, i)
+			continue
+		}
+		lazy = append(lazy, i)
+		ids = append(ids, task.SecretId)
+	}
+
+	if len(ids) > 0 {
+		res, err := s.provider.DownloadSecretsMeta(ctx, ids)
+		if err != nil {
+			log.Println(err.Error())
+			return batchError(len(tasks), err)
+		}
+
+		for n, el := range res {
+			i := lazy[n]
+			if el.Err == nil {
+				var ok bool
+				if ok, el.Err = s.savePlaceholder(ctx, tasks[i], el); el.Err == nil && !ok {
+					full = append(full, i)
+				}
+			}
+			if el.Err != nil {
+				log.Printf("error download sync of secret %v: %v", el.SecretID, el.Err)
+				errs[i] = el.Err
+			}
+		}
+	}
+
+	if len(full) > 0 {
+		fullTasks := make([]SyncTask, 0, len(full))
+		for _, i := range full {
+			fullTasks = append(fullTasks, tasks[i])
+		}
+		for n, err := range s.DownloadBatch(ctx, fullTasks) {
+			errs[full[n]] = err
+		}
+	}
+
+	log.Printf("selective download processed, tasks: %v, with data: %v, failed: %v", len(tasks), len(full), countErrors(errs))
+
+	return errs
+}
+
+// savePlaceholder saves downloaded meta of secret as placeholder if secret data is not allowed on device.
+// Returns false if secret must be downloaded with data.
+func (s *SyncService) savePlaceholder(ctx context.Context, task SyncTask, remote model.RemoteSecret) (bool, error) {
+	//  uploaded without meta, rules can't be checked
+	if remote.Meta == "" {
+		return false, nil
+	}
+
+	info := model.Info{}
+	if err := info.FromEncodedMeta(remote.Meta, s.cfg.MasterKey); err != nil {
+		return false, fmt.Errorf("error read info from encoded secret meta: %w", err)
+	}
+
+	stateID := payloadState(s.cfg, info, remote.Size)
+	if stateID == model.PayloadStates["LOCAL"] {
+		return false, nil
+	}
+
+	details := fmt.Sprintf("placeholder, version %v", remote.SecretVer)
+	if stateID == model.PayloadStates["EXCLUDED"] {
+		details = fmt.Sprintf("excluded, version %v", remote.SecretVer)
+	}
+
+	secret := model.Secret{
+		Info:      info,
+		SecretID:  remote.SecretID,
+		SecretVer: remote.SecretVer,
+		Vector:    remote.Vector,
+		StatusID:  model.SecretStatuses["ACTUAL"],
+		PayloadID: stateID,
+		Size:      remote.Size,
+	}
+
+	if task.ActionID == SyncActions["DOWNLOAD_NEW"] {
+		locID, err := s.db.AddSecret(ctx, secret)
+		if err != nil {
+			return false, fmt.Errorf("error save secret meta to storage: %w", err)
+		}
+		recordAudit(ctx, s.audit, "SYNC_DOWNLOAD", locID, remote.SecretID, "new, "+details)
+
+		return true, nil
+	}
+
+	dbSecret, err := s.db.GetSecretByExtID(ctx, remote.SecretID)
+	if err != nil {
+		return false, fmt.Errorf("error save secret meta to storage: %w", err)
+	}
+
+	secret.ID, secret.TimeStamp = dbSecret.ID, dbSecret.TimeStamp
+	if err := s.db.UpdateSecret(ctx, secret); err != nil {
+		return false, fmt.Errorf("error save secret meta to storage: %w", err)
+	}
+	recordAudit(ctx, s.audit, "SYNC_DOWNLOAD", secret.ID, remote.SecretID, details)
+
+	return true, nil
+}
+
+// FetchSecret downloads data of placeholder secret, secret data is stored locally after it.
+// Fetch is serialized with sync jobs of secret.
+func (s *SyncService) FetchSecret(ctx context.Context, id int64) error {
+	secret, err := s.db.GetSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+	if secret.PayloadID == model.PayloadStates["LOCAL"] {
+		return nil
+	}
+
+	keys := []string{secretKey(SyncTask{SecretId: secret.SecretID, LocID: secret.ID})}
+	if derr := s.exec.Do(ctx, keys, func(ctx context.Context) {
+		var remote model.RemoteSecret
+		if remote, err = s.provider.DownloadSecret(ctx, secret.SecretID); err != nil {
+			err = fmt.Errorf("error fetch secret %v: %w", id, err)
+			return
+		}
+		err = s.saveDownloaded(ctx, remote)
+	}); derr != nil {
+		return derr
+	}
+
+	return err
+}
+
+// Evict removes local data of synced binary secrets over size, secrets stay as placeholders.
+// If size is 0, lazy size from config is used. Returns count of evicted secrets.
+func (s *SyncService) Evict(ctx context.Context, size int) (int, error) {
+	if size == 0 {
+		size = s.cfg.LazySize
+	}
+
+	list, err := s.db.GetSecretList(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, el := range list {
+		//  not synced or changed data can't be downloaded again
+		if el.TypeID != model.SecretTypes["BINARY"] || el.SecretID == uuid.Nil ||
+			el.StatusID != model.SecretStatuses["ACTUAL"] || el.PayloadID != model.PayloadStates["LOCAL"] ||
+			len(el.SecretData) <= size {
+			continue
+		}
+
+		el.Size = len(el.SecretData)
+		el.SecretData = ""
+		el.BaseData = ""
+		el.PayloadID = model.PayloadStates["PLACEHOLDER"]
+
+		if err := s.db.UpdateSecret(ctx, el); err != nil {
+			return count, fmt.Errorf("error evict secret %v: %w", el.ID, err)
+		}
+		recordAudit(ctx, s.audit, "EVICT", el.ID, el.SecretID, fmt.Sprintf("size %v", el.Size))
+
+		count++
+	}
+
+	return count, nil
+}
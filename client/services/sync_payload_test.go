@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSyncPayload_PayloadState(t *testing.T) {
+	cfg := &pkg.Config{LazySize: 100, ExcludeSize: 1000, ExcludeTypes: "CARD", ExcludeTags: "private, work"}
+	binary := model.Info{TypeID: model.SecretTypes["BINARY"]}
+
+	tests := []struct {
+		name  string
+		info  model.Info
+		size  int
+		state int
+	}{
+		{name: "small binary", info: binary, size: 100, state: model.PayloadStates["LOCAL"]},
+		{name: "large binary", info: binary, size: 101, state: model.PayloadStates["PLACEHOLDER"]},
+		{name: "large text", info: model.Info{TypeID: model.SecretTypes["TEXT"]}, size: 500, state: model.PayloadStates["LOCAL"]},
+		{name: "over exclude size", info: binary, size: 1001, state: model.PayloadStates["EXCLUDED"]},
+		{name: "excluded type", info: model.Info{TypeID: model.SecretTypes["CARD"]}, state: model.PayloadStates["EXCLUDED"]},
+		{name: "excluded tag", info: model.Info{TypeID: model.SecretTypes["AUTH"], Tags: []string{"home", "work"}}, state: model.PayloadStates["EXCLUDED"]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.state, payloadState(cfg, tt.info, tt.size))
+		})
+	}
+
+	require.False(t, isSelective(&pkg.Config{}))
+	require.True(t, isSelective(cfg))
+}
+
+func TestSyncPayload_DownloadSelective(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svcSecret := GetTestSecretSvc(t, storage)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60, LazySize: 100, ExcludeTags: "private"})
+
+	remote, err := svcSecret.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+	authMeta, err := model.TestAuth.Info.ToEncodedMeta("testKey")
+	require.NoError(t, err)
+	binaryMeta, err := model.Info{TypeID: model.SecretTypes["BINARY"], Title: "video"}.ToEncodedMeta("testKey")
+	require.NoError(t, err)
+	privateMeta, err := model.Info{TypeID: model.SecretTypes["TEXT"], Tags: []string{"private"}}.ToEncodedMeta("testKey")
+	require.NoError(t, err)
+
+	local := model.Secret{ID: 1, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"]}
+	placeholder := model.Secret{ID: 2, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"],
+		PayloadID: model.PayloadStates["PLACEHOLDER"], TimeStamp: 7}
+	binaryID, authID := uuid.New(), uuid.New()
+
+	tasks := []SyncTask{
+		taskDownload(model.SecretMeta{SecretID: local.SecretID}),
+		taskDownloadNew(binaryID),
+		taskDownloadNew(authID),
+		taskDownload(model.SecretMeta{SecretID: placeholder.SecretID, PayloadID: placeholder.PayloadID}),
+	}
+
+	//  local secret is not checked by meta
+	provider.EXPECT().DownloadSecretsMeta(gomock.Any(), []uuid.UUID{binaryID, authID, placeholder.SecretID}).Return([]model.RemoteSecret{
+		{SecretID: binaryID, SecretVer: 1, Meta: binaryMeta, Size: 1000},
+		{SecretID: authID, SecretVer: 1, Meta: authMeta, Size: len(remote.SecretData)},
+		{SecretID: placeholder.SecretID, SecretVer: 2, Meta: privateMeta, Size: 10},
+	}, nil)
+
+	storage.EXPECT().AddSecret(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, v model.Secret) (int64, error) {
+		if v.SecretID == binaryID {
+			require.Equal(t, model.PayloadStates["PLACEHOLDER"], v.PayloadID)
+			require.Equal(t, 1000, v.Size)
+			require.Equal(t, "video", v.Title)
+			require.Empty(t, v.SecretData)
+			return 3, nil
+		}
+		require.Equal(t, authID, v.SecretID)
+		require.Equal(t, model.PayloadStates["LOCAL"], v.PayloadID)
+		require.Equal(t, remote.SecretData, v.SecretData)
+		return 4, nil
+	}).Times(2)
+
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), placeholder.SecretID).Return(placeholder, nil)
+	storage.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+		require.Equal(t, placeholder.ID, v.ID)
+		require.Equal(t, placeholder.TimeStamp, v.TimeStamp)
+		require.Equal(t, model.PayloadStates["EXCLUDED"], v.PayloadID)
+		require.Equal(t, 2, v.SecretVer)
+	}).Return(nil)
+
+	provider.EXPECT().DownloadSecrets(gomock.Any(), []uuid.UUID{local.SecretID, authID}).Return([]model.RemoteSecret{
+		{SecretID: local.SecretID, SecretVer: 2, Data: remote.SecretData},
+		{SecretID: authID, SecretVer: 1, Data: remote.SecretData},
+	}, nil)
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), local.SecretID).Return(local, nil)
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["DOWNLOAD"], 0).Return(nil)
+
+	require.Equal(t, 0, countErrors(svc.processBatch(context.Background(), tasks)))
+}
+
+func TestSyncPayload_FetchAndEvict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svcSecret := GetTestSecretSvc(t, storage)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	remote, err := svcSecret.ToSecret(model.TestText)
+	require.NoError(t, err)
+
+	placeholder := model.Secret{Info: model.TestText.Info, ID: 1, SecretID: uuid.New(), SecretVer: 1,
+		StatusID: model.SecretStatuses["ACTUAL"], PayloadID: model.PayloadStates["PLACEHOLDER"]}
+	fetched := placeholder
+	fetched.SecretData, fetched.PayloadID = remote.SecretData, model.PayloadStates["LOCAL"]
+
+	//  without fetcher placeholder can't be revealed
+	storage.EXPECT().GetSecret(gomock.Any(), placeholder.ID).Return(placeholder, nil)
+	_, err = svcSecret.RevealSecret(context.Background(), placeholder.ID)
+	require.ErrorIs(t, err, model.ErrorNotDownloaded)
+
+	svcSecret.SetFetcher(svc)
+	gomock.InOrder(
+		storage.EXPECT().GetSecret(gomock.Any(), placeholder.ID).Return(placeholder, nil).Times(2),
+		storage.EXPECT().GetSecret(gomock.Any(), placeholder.ID).Return(fetched, nil),
+	)
+	provider.EXPECT().DownloadSecret(gomock.Any(), placeholder.SecretID).Return(model.RemoteSecret{SecretID: placeholder.SecretID, SecretVer: 1, Data: remote.SecretData}, nil)
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), placeholder.SecretID).Return(placeholder, nil)
+	storage.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+		require.Equal(t, model.PayloadStates["LOCAL"], v.PayloadID)
+		require.Equal(t, remote.SecretData, v.SecretData)
+		require.Equal(t, len(remote.SecretData), v.Size)
+	}).Return(nil)
+
+	obj, err := svcSecret.RevealSecret(context.Background(), placeholder.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.TestText, obj)
+
+	//  only synced binary secrets over size are evicted
+	large := model.Secret{Info: model.Info{TypeID: model.SecretTypes["BINARY"]}, ID: 2, SecretID: uuid.New(),
+		StatusID: model.SecretStatuses["ACTUAL"], SecretData: "large data", BaseData: "large data"}
+	small := large
+	small.ID, small.SecretData = 3, "data"
+	edited := large
+	edited.ID, edited.StatusID = 4, model.SecretStatuses["EDITED"]
+
+	storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{fetched, large, small, edited}, nil)
+	storage.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).Do(func(_ context.Context, v model.Secret) {
+		require.Equal(t, large.ID, v.ID)
+		require.Equal(t, model.PayloadStates["PLACEHOLDER"], v.PayloadID)
+		require.Equal(t, len(large.SecretData), v.Size)
+		require.Empty(t, v.SecretData)
+		require.Empty(t, v.BaseData)
+	}).Return(nil)
+
+	count, err := svc.Evict(context.Background(), 5)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
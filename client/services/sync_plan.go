@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// SyncPlanItem is sync task with local and remote state of secret
+type SyncPlanItem struct {
+	Task        SyncTask  `json:"-"`
+	Action      string    `json:"action"`
+	SecretLocID int64     `json:"secret_loc_id"`
+	SecretID    uuid.UUID `json:"secret_id"`
+	Title       string    `json:"title"`
+	LocalVer    int       `json:"local_ver"`
+	//  0 if secret is unknown to server
+	RemoteVer     int  `json:"remote_ver"`
+	RemoteDeleted bool `json:"remote_deleted,omitempty"`
+	//  error of reading remote secret title
+	Error string `json:"error,omitempty"`
+}
+
+// SyncPlan is list of tasks next sync would process, with count of tasks of every action
+type SyncPlan struct {
+	Items  []SyncPlanItem `json:"items"`
+	Totals map[string]int `json:"totals"`
+	Cursor int64          `json:"cursor"`
+}
+
+// Plan calculates sync tasks without processing them.
+// Titles of secrets not stored locally are read from downloaded data, nothing is saved.
+func (s *SyncService) Plan(ctx context.Context) (SyncPlan, error) {
+	tasks, remList, _, err := s.getSyncBatch(ctx)
+	if err != nil {
+		return SyncPlan{}, err
+	}
+
+	plan := SyncPlan{
+		Items:  make([]SyncPlanItem, 0, len(tasks)),
+		Totals: make(map[string]int),
+		Cursor: remList.Cursor,
+	}
+
+	//  indexes of items without local secret
+	remote := make([]int, 0)
+	for _, task := range tasks {
+		item := SyncPlanItem{
+			Task:     task,
+			Action:   syncActionName(task.ActionID),
+			SecretID: task.SecretId,
+		}
+
+		secret, err := s.planSecret(ctx, task)
+		switch {
+		case err == nil:
+			item.SecretLocID, item.Title, item.LocalVer = secret.ID, secret.Title, secret.SecretVer
+			if item.SecretID == uuid.Nil {
+				item.SecretID = secret.SecretID
+			}
+		case errors.Is(err, model.ErrorItemNotFound):
+			if item.SecretID != uuid.Nil {
+				remote = append(remote, len(plan.Items))
+			}
+		default:
+			return SyncPlan{}, err
+		}
+
+		if ver, ok := remList.List[item.SecretID]; ok && item.SecretID != uuid.Nil {
+			item.RemoteVer = ver
+		} else if tomb, ok := remList.Tombstones[item.SecretID]; ok {
+			item.RemoteVer, item.RemoteDeleted = tomb.SecretVer, true
+		}
+
+		plan.Items = append(plan.Items, item)
+		plan.Totals[item.Action]++
+	}
+
+	if err := s.readRemoteTitles(ctx, plan.Items, remote); err != nil {
+		return SyncPlan{}, err
+	}
+
+	return plan, nil
+}
+
+// ExecutePlan processes tasks of plan items with indexes, returns count of failed tasks.
+// Results are recorded to sync journal, change feed cursor is not moved.
+func (s *SyncService) ExecutePlan(ctx context.Context, plan SyncPlan, indexes []int) (int, error) {
+	tasks := make([]SyncTask, 0, len(indexes))
+	for _, i := range indexes {
+		if i < 0 || i >= len(plan.Items) {
+			return 0, fmt.Errorf("%w: plan item %v", model.ErrorParamNotValid, i+1)
+		}
+		tasks = append(tasks, plan.Items[i].Task)
+	}
+
+	list, err := s.db.GetJournalEntries(ctx)
+	if err != nil {
+		return 0, err
+	}
+	journaled := make(map[string]model.SyncJournalEntry, len(list))
+	for _, el := range list {
+		journaled[el.TaskKey] = el
+	}
+
+	results := s.tick(ctx, tasks)
+	s.publishResults(results)
+
+	failed, err := s.recordJournal(ctx, results, journaled, time.Now())
+	if err != nil {
+		return failed, err
+	}
+	if failed > 0 {
+		return failed, fmt.Errorf("%v of %v sync tasks failed", failed, len(tasks))
+	}
+
+	return 0, nil
+}
+
+// planSecret returns local secret of task
+func (s *SyncService) planSecret(ctx context.Context, task SyncTask) (model.Secret, error) {
+	if task.LocID != 0 {
+		return s.db.GetSecret(ctx, task.LocID)
+	}
+	if task.SecretId != uuid.Nil {
+		return s.db.GetSecretByExtID(ctx, task.SecretId)
+	}
+
+	return model.Secret{}, model.ErrorItemNotFound
+}
+
+// readRemoteTitles downloads secrets of items with indexes and sets titles decrypted with master key
+func (s *SyncService) readRemoteTitles(ctx context.Context, items []SyncPlanItem, indexes []int) error {
+	size := syncBatchSize(s.cfg)
+	for len(indexes) > 0 {
+		n := size
+		if len(indexes) < n {
+			n = len(indexes)
+		}
+
+		ids := make([]uuid.UUID, 0, n)
+		for _, i := range indexes[:n] {
+			ids = append(ids, items[i].SecretID)
+		}
+
+		res, err := s.provider.DownloadSecrets(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for j, el := range res {
+			item := &items[indexes[j]]
+			if el.Err != nil {
+				item.Error = el.Err.Error()
+				continue
+			}
+
+			info := model.Info{}
+			if err := info.FromEncodedData(el.Data, s.cfg.MasterKey); err != nil {
+				item.Error = err.Error()
+				continue
+			}
+			item.Title, item.RemoteVer = info.Title, el.SecretVer
+		}
+
+		indexes = indexes[n:]
+	}
+
+	return nil
+}
+
+// syncActionName returns sync action name by id
+func syncActionName(actionID int) string {
+	for name, id := range SyncActions {
+		if id == actionID {
+			return name
+		}
+	}
+
+	return strconv.Itoa(actionID)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSyncPlan_Plan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svcSecret := GetTestSecretSvc(t, storage)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 6000})
+
+	remote, err := svcSecret.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+
+	edited := model.Secret{ID: 1, SecretID: uuid.New(), SecretVer: 2, Info: model.Info{Title: "edited"}, StatusID: model.SecretStatuses["EDITED"]}
+	deleted := model.Secret{ID: 2, SecretID: uuid.New(), SecretVer: 1, Info: model.Info{Title: "deleted"}, StatusID: model.SecretStatuses["ACTUAL"]}
+	newID := uuid.New()
+
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{
+		{ID: edited.ID, SecretID: edited.SecretID, SecretVer: 2, StatusID: edited.StatusID},
+		{ID: deleted.ID, SecretID: deleted.SecretID, SecretVer: 1, StatusID: deleted.StatusID},
+	}, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+	provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(model.SyncChanges{
+		Changes: []model.SecretChange{
+			{SecretID: deleted.SecretID, SecretVer: 2, IsDeleted: true},
+			{SecretID: newID, SecretVer: 3},
+		},
+		Cursor: 12,
+	}, nil)
+
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), edited.SecretID).Return(edited, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), deleted.ID).Return(deleted, nil)
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), newID).Return(model.Secret{}, model.ErrorItemNotFound)
+	provider.EXPECT().DownloadSecrets(gomock.Any(), []uuid.UUID{newID}).Return([]model.RemoteSecret{
+		{SecretID: newID, SecretVer: 3, Data: remote.SecretData},
+	}, nil)
+
+	plan, err := svc.Plan(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 12, plan.Cursor)
+	require.Equal(t, map[string]int{"UPLOAD": 1, "DELETE_LOCALLY": 1, "DOWNLOAD_NEW": 1}, plan.Totals)
+
+	items := make(map[string]SyncPlanItem)
+	for _, el := range plan.Items {
+		items[el.Action] = el
+	}
+
+	require.Equal(t, "edited", items["UPLOAD"].Title)
+	require.Equal(t, 2, items["UPLOAD"].LocalVer)
+	require.Equal(t, 2, items["UPLOAD"].RemoteVer)
+
+	require.Equal(t, deleted.SecretID, items["DELETE_LOCALLY"].SecretID)
+	require.True(t, items["DELETE_LOCALLY"].RemoteDeleted)
+	require.Equal(t, 2, items["DELETE_LOCALLY"].RemoteVer)
+
+	require.Zero(t, items["DOWNLOAD_NEW"].SecretLocID)
+	require.Equal(t, model.TestAuth.Title, items["DOWNLOAD_NEW"].Title)
+	require.Equal(t, 3, items["DOWNLOAD_NEW"].RemoteVer)
+}
+
+func TestSyncPlan_Execute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 6000})
+
+	local := model.SecretMeta{ID: 1, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["DELETED"]}
+	plan := SyncPlan{Items: []SyncPlanItem{
+		{Task: taskDeleteRemote(local)},
+		{Task: taskDownloadNew(uuid.New())},
+	}}
+
+	_, err := svc.ExecutePlan(context.Background(), plan, []int{2})
+	require.ErrorIs(t, err, model.ErrorParamNotValid)
+
+	//  only selected task is processed
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{}, nil)
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), local.SecretID).Return(model.Secret{ID: 1, SecretID: local.SecretID}, nil)
+	provider.EXPECT().DeleteSecret(gomock.Any(), local.SecretID).Return(errors.New("server error"))
+	storage.EXPECT().SaveJournalEntry(gomock.Any(), gomock.Any()).Return(nil)
+
+	failed, err := svc.ExecutePlan(context.Background(), plan, []int{0})
+	require.Error(t, err)
+	require.Equal(t, 1, failed)
+}
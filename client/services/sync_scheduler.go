@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// syncSummary is result of sync cycle
+type syncSummary struct {
+	//  tasks of sync batch
+	tasks    int
+	failed   int
+	deferred int
+}
+
+// syncCycle is kind of sync, full sync or upload of local changes
+type syncCycle func(ctx context.Context) (syncSummary, error)
+
+// pollBackoff is interval of server polling, interval is doubled while sync is idle up to max
+type pollBackoff struct {
+	base time.Duration
+	max  time.Duration
+	cur  time.Duration
+}
+
+func newPollBackoff(cfg *pkg.Config) *pollBackoff {
+	base := time.Second * time.Duration(cfg.SyncTimeoutSec)
+	if base <= 0 {
+		base = time.Second
+	}
+	max := time.Second * time.Duration(cfg.SyncMaxTimeoutSec)
+
+	return &pollBackoff{base: base, max: max, cur: base}
+}
+
+// next returns interval before next poll, interval is reset if sync is active
+func (b *pollBackoff) next(active bool) time.Duration {
+	if active {
+		b.cur = b.base
+		return b.cur
+	}
+
+	b.cur *= 2
+	if b.cur > b.max {
+		b.cur = b.max
+	}
+
+	return b.cur
+}
+
+// schedule runs sync worker loop until context is done.
+// Local changes are uploaded when no other change is made for debounce delay,
+// remote changes are synced on server event or by polling.
+func (s *SyncService) schedule(ctx context.Context, notify <-chan struct{}, changes <-chan model.LocalChange) {
+	poll := newPollBackoff(s.cfg)
+	pollTimer := time.NewTimer(poll.cur)
+	defer pollTimer.Stop()
+
+	//  every next local change restarts debounce delay
+	debounceDelay := time.Millisecond * time.Duration(s.cfg.SyncDebounceMs)
+	debounce := time.NewTimer(debounceDelay)
+	stopTimer(debounce)
+	defer debounce.Stop()
+
+	for {
+		select {
+		case change := <-changes:
+			if isLocalChange(change) {
+				resetTimer(debounce, debounceDelay)
+			}
+		case <-debounce.C:
+			//  uploaded changes mean user is active, remote changes are polled often again
+			resetTimer(pollTimer, poll.next(s.syncOnline(ctx, s.syncLocal)))
+		case <-notify:
+			resetTimer(pollTimer, poll.next(s.syncOnline(ctx, s.sync)))
+		case <-pollTimer.C:
+			pollTimer.Reset(poll.next(s.poll(ctx)))
+		case <-ctx.Done():
+			log.Println("worker context done")
+			return
+		}
+	}
+}
+
+// poll syncs with server, returns true if sync processed tasks.
+// Remote changes are pushed while events stream is up, server is not polled without local changes.
+func (s *SyncService) poll(ctx context.Context) bool {
+	//  expired trash is marked deleted, and deleted with this sync
+	if _, err := purgeTrash(ctx, s.db, trashRetention(s.cfg)); err != nil {
+		log.Printf("error purge trash, err:%s", err.Error())
+	}
+
+	if s.isStreamUp() {
+		changed, err := s.hasLocalChanges(ctx)
+		if err != nil {
+			log.Printf("error synchronization, err:%s", err.Error())
+			return false
+		}
+		if !changed {
+			return false
+		}
+	}
+
+	return s.syncOnline(ctx, s.sync)
+}
+
+// syncLocal uploads local changes without request of remote changes, change feed cursor is not moved.
+// Upload of secret changed remotely fails and is resolved by next full sync.
+func (s *SyncService) syncLocal(ctx context.Context) (syncSummary, error) {
+	loc, err := s.db.GetMetaList(ctx)
+	if err != nil {
+		return syncSummary{}, err
+	}
+
+	//  remote state is assumed not changed since last sync
+	tasks, err := s.CalcSyncBatch(applyChanges(loc, model.SyncChanges{}), loc)
+	if err != nil {
+		return syncSummary{}, err
+	}
+
+	batch := make([]SyncTask, 0, len(tasks))
+	for _, el := range tasks {
+		if isLocalChangeTask(el) {
+			batch = append(batch, el)
+		}
+	}
+
+	return s.processTasks(ctx, batch, false)
+}
+
+// isLocalChangeTask returns true if task sends local change to server
+func isLocalChangeTask(task SyncTask) bool {
+	return isUploadTask(task) || task.ActionID == SyncActions["SEND_DELETE"]
+}
+
+// isLocalChange returns true if secret is written with change to sync, secrets written by sync are actual
+func isLocalChange(change model.LocalChange) bool {
+	return change.StatusID == model.SecretStatuses["NEW"] || change.StatusID == model.SecretStatuses["EDITED"] ||
+		change.StatusID == model.SecretStatuses["DELETED"]
+}
+
+// stopTimer stops timer and drains its channel
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// resetTimer restarts timer with new duration
+func resetTimer(t *time.Timer, d time.Duration) {
+	stopTimer(t)
+	t.Reset(d)
+}
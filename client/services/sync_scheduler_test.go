@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSync_PollBackoff(t *testing.T) {
+	poll := newPollBackoff(&pkg.Config{SyncTimeoutSec: 2, SyncMaxTimeoutSec: 10})
+
+	//  idle - interval grows up to max, activity resets it
+	require.Equal(t, time.Second*4, poll.next(false))
+	require.Equal(t, time.Second*8, poll.next(false))
+	require.Equal(t, time.Second*10, poll.next(false))
+	require.Equal(t, time.Second*10, poll.next(false))
+	require.Equal(t, time.Second*2, poll.next(true))
+}
+
+func TestSync_SyncLocal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	loc := []model.SecretMeta{
+		{ID: 1, StatusID: model.SecretStatuses["NEW"]},
+		{ID: 2, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["DELETED"]},
+		{ID: 3, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"]},
+	}
+	upload, del := taskUploadNew(loc[0]), taskDeleteRemote(loc[1])
+
+	//  only local changes are processed, server is not requested for changes,
+	//  journal entries of other tasks are kept
+	storage.EXPECT().GetMetaList(gomock.Any()).Return(loc, nil)
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{
+		{TaskKey: taskKey(upload), Parked: true},
+		{TaskKey: taskKey(del), Parked: true},
+		{TaskKey: taskKey(taskDownload(loc[2])), Parked: true},
+	}, nil)
+
+	sum, err := svc.syncLocal(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, syncSummary{tasks: 2, deferred: 2}, sum)
+}
+
+func TestSync_ScheduleDebounce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60,
+		SyncTimeoutSec: 60, SyncMaxTimeoutSec: 60, SyncDebounceMs: 20})
+
+	//  burst of local changes is uploaded with one sync
+	synced := make(chan struct{})
+	provider.EXPECT().PingAuth(gomock.Any()).Return(nil)
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{}, nil)
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{}, nil)
+	storage.EXPECT().GetConflicts(gomock.Any()).DoAndReturn(func(_ context.Context) ([]model.Conflict, error) {
+		close(synced)
+		return []model.Conflict{}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan model.LocalChange, 8)
+	stopped := make(chan struct{})
+	go func() {
+		svc.schedule(ctx, make(chan struct{}), changes)
+		close(stopped)
+	}()
+
+	//  secret written by sync is not local change
+	changes <- model.LocalChange{ID: 1, StatusID: model.SecretStatuses["ACTUAL"]}
+	for i := 0; i < 3; i++ {
+		changes <- model.LocalChange{ID: 2, StatusID: model.SecretStatuses["EDITED"]}
+		time.Sleep(time.Millisecond * 5)
+	}
+
+	select {
+	case <-synced:
+	case <-time.After(time.Second):
+		t.Fatal("local changes are not synced")
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	cancel()
+	<-stopped
+}
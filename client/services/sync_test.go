@@ -1,14 +1,20 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/Xrefullx/YanDip/client/model"
 	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
 )
 
 var (
@@ -18,6 +24,8 @@ var (
 type syncTest struct {
 	name   string
 	ext    map[uuid.UUID]int
+	tombs  map[uuid.UUID]model.Tombstone
+	hashes map[uuid.UUID]string
 	loc    []model.SecretMeta
 	reqErr require.ErrorAssertionFunc
 	result []SyncTask
@@ -30,6 +38,7 @@ func TestSync_CalcSync(t *testing.T) {
 	ver := rand.Intn(100) + 1
 
 	timeStamp := pkg.MakeTimestamp()
+	tomb := model.Tombstone{SecretID: secretID, SecretVer: ver + 1, DeletedAt: timeStamp, DeletedBy: uuid.New()}
 
 	tests := []syncTest{
 		//  new secretID == nil
@@ -48,27 +57,56 @@ func TestSync_CalcSync(t *testing.T) {
 		{
 			name:   "new status deleted - delete hard",
 			loc:    []model.SecretMeta{{SecretID: nilUUID, ID: locID, SecretVer: 1, StatusID: model.SecretStatuses["DELETED"], TimeStamp: timeStamp}},
-			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp})},
+			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp}, model.Tombstone{})},
 			reqErr: require.NoError,
 		},
 
-		//  exist secretID != nil, deleted not in ext list
+		//  exist secretID != nil, deleted - tombstone in ext list
 		{
-			name:   "exist edited/deleted - delete hard",
+			name:   "exist edited/deleted - detach",
 			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["EDITED"], TimeStamp: timeStamp}},
-			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp})},
+			tombs:  map[uuid.UUID]model.Tombstone{secretID: tomb},
+			result: []SyncTask{taskDetach(model.SecretMeta{ID: locID, SecretID: secretID, TimeStamp: timeStamp}, tomb)},
 			reqErr: require.NoError,
 		},
 		{
 			name:   "exist deleted/deleted - delete hard",
 			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["DELETED"], TimeStamp: timeStamp}},
-			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp})},
+			tombs:  map[uuid.UUID]model.Tombstone{secretID: tomb},
+			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp}, tomb)},
 			reqErr: require.NoError,
 		},
 		{
 			name:   "exist actual/deleted - delete hard",
 			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["ACTUAL"], TimeStamp: timeStamp}},
-			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp})},
+			tombs:  map[uuid.UUID]model.Tombstone{secretID: tomb},
+			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp}, tomb)},
+			reqErr: require.NoError,
+		},
+
+		//  exist secretID != nil, missing in ext list without tombstone
+		{
+			name:   "exist actual/missing - orphan",
+			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["ACTUAL"], TimeStamp: timeStamp}},
+			result: []SyncTask{taskOrphan(model.SecretMeta{ID: locID, SecretID: secretID, TimeStamp: timeStamp})},
+			reqErr: require.NoError,
+		},
+		{
+			name:   "exist edited/missing - orphan",
+			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["EDITED"], TimeStamp: timeStamp}},
+			result: []SyncTask{taskOrphan(model.SecretMeta{ID: locID, SecretID: secretID, TimeStamp: timeStamp})},
+			reqErr: require.NoError,
+		},
+		{
+			name:   "exist orphaned/missing - wait for user",
+			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["ORPHANED"], TimeStamp: timeStamp}},
+			result: []SyncTask{},
+			reqErr: require.NoError,
+		},
+		{
+			name:   "exist deleted/missing - delete hard",
+			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["DELETED"], TimeStamp: timeStamp}},
+			result: []SyncTask{taskDeleteLocally(model.SecretMeta{ID: locID, TimeStamp: timeStamp}, model.Tombstone{})},
 			reqErr: require.NoError,
 		},
 
@@ -96,6 +134,26 @@ func TestSync_CalcSync(t *testing.T) {
 			reqErr: require.NoError,
 		},
 
+		{
+			name: "exist actual/no changes, hash differs - repair",
+			loc: []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["ACTUAL"],
+				PayloadID: model.PayloadStates["LOCAL"], TimeStamp: timeStamp, DataHash: "local"}},
+			ext:    map[uuid.UUID]int{secretID: ver},
+			hashes: map[uuid.UUID]string{secretID: "remote"},
+			result: []SyncTask{taskRepair(model.SecretMeta{SecretID: secretID, PayloadID: model.PayloadStates["LOCAL"], TimeStamp: timeStamp})},
+			reqErr: require.NoError,
+		},
+
+		{
+			name: "exist actual/no changes, hash equal - nil",
+			loc: []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["ACTUAL"],
+				PayloadID: model.PayloadStates["LOCAL"], TimeStamp: timeStamp, DataHash: "remote"}},
+			ext:    map[uuid.UUID]int{secretID: ver},
+			hashes: map[uuid.UUID]string{secretID: "remote"},
+			result: []SyncTask{},
+			reqErr: require.NoError,
+		},
+
 		//  exist secretID != nil, ext changed ver.loc < ver.ext
 		{
 			name:   "exist edited/ changed - collision",
@@ -105,6 +163,14 @@ func TestSync_CalcSync(t *testing.T) {
 			reqErr: require.NoError,
 		},
 
+		{
+			name:   "exist conflict/ changed - nil",
+			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["CONFLICT"], TimeStamp: timeStamp}},
+			ext:    map[uuid.UUID]int{secretID: ver + 1},
+			result: []SyncTask{},
+			reqErr: require.NoError,
+		},
+
 		{
 			name:   "exist deleted/ changed - send delete",
 			loc:    []model.SecretMeta{{SecretID: secretID, ID: locID, SecretVer: ver, StatusID: model.SecretStatuses["DELETED"], TimeStamp: timeStamp}},
@@ -130,7 +196,7 @@ func TestSync_CalcSync(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			syncResult, err := SyncService{}.CalcSyncBatch(tt.ext, tt.loc)
+			syncResult, err := SyncService{}.CalcSyncBatch(model.SyncList{List: tt.ext, Tombstones: tt.tombs, Hashes: tt.hashes}, tt.loc)
 
 			tt.reqErr(t, err)
 			require.Equal(t, tt.result, syncResult)
@@ -138,3 +204,214 @@ func TestSync_CalcSync(t *testing.T) {
 
 	}
 }
+
+func TestSync_GetSyncBatchCursor(t *testing.T) {
+	synced, changed, deleted, created := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	device := uuid.New()
+	tomb := model.Tombstone{SecretID: deleted, SecretVer: 5, DeletedAt: 100, DeletedBy: device}
+	loc := []model.SecretMeta{
+		{ID: 1, SecretID: synced, SecretVer: 2, StatusID: model.SecretStatuses["ACTUAL"]},
+		{ID: 2, SecretID: changed, SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"]},
+		{ID: 3, SecretID: deleted, SecretVer: 4, StatusID: model.SecretStatuses["ACTUAL"]},
+	}
+	changes := model.SyncChanges{
+		Changes: []model.SecretChange{
+			{SecretID: changed, SecretVer: 2},
+			{SecretID: deleted, SecretVer: 5, IsDeleted: true, DeletedAt: 100, DeletedBy: device},
+			{SecretID: created, SecretVer: 1},
+		},
+		Cursor: 20,
+	}
+	expected := []SyncTask{taskDownload(loc[1]), taskDeleteLocally(loc[2], tomb), taskDownloadNew(created)}
+	fullList := func(cursor int64) model.SyncList {
+		return model.SyncList{
+			List:       map[uuid.UUID]int{synced: 2, changed: 2, created: 1},
+			Tombstones: map[uuid.UUID]model.Tombstone{deleted: tomb},
+			Cursor:     cursor,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		expect func(storage *mk.MockStorage, provider *pmk.MockSecretProvider)
+		cursor int64
+	}{
+		{
+			name: "first sync - full list",
+			expect: func(storage *mk.MockStorage, provider *pmk.MockSecretProvider) {
+				storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(0), nil)
+				provider.EXPECT().GetSyncList(gomock.Any()).Return(fullList(20), nil)
+			},
+			cursor: 20,
+		},
+		{
+			name: "cursor saved - only changes",
+			expect: func(storage *mk.MockStorage, provider *pmk.MockSecretProvider) {
+				storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+				provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(changes, nil)
+			},
+			cursor: 20,
+		},
+		{
+			name: "cursor expired - full list",
+			expect: func(storage *mk.MockStorage, provider *pmk.MockSecretProvider) {
+				storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+				provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(model.SyncChanges{}, model.ErrorCursorExpired)
+				provider.EXPECT().GetSyncList(gomock.Any()).Return(fullList(25), nil)
+			},
+			cursor: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := mk.NewMockStorage(ctrl)
+			provider := pmk.NewMockSecretProvider(ctrl)
+
+			storage.EXPECT().GetMetaList(gomock.Any()).Return(loc, nil)
+			tt.expect(storage, provider)
+
+			tasks, cursor, err := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60}).GetSyncBatch(context.Background())
+			require.NoError(t, err)
+			require.ElementsMatch(t, expected, tasks)
+			require.Equal(t, tt.cursor, cursor)
+		})
+	}
+}
+
+func TestSync_SyncSavesCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 6000})
+	storage.EXPECT().GetConflicts(gomock.Any()).Return([]model.Conflict{}, nil).AnyTimes()
+
+	local := model.SecretMeta{ID: 1, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["DELETED"]}
+
+	//  failed task - cursor not saved
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{local}, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+	provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(model.SyncChanges{Cursor: 12}, nil)
+	storage.EXPECT().GetSecretByExtID(gomock.Any(), local.SecretID).Return(model.Secret{ID: 1, SecretID: local.SecretID}, nil)
+	provider.EXPECT().DeleteSecret(gomock.Any(), local.SecretID).Return(errors.New("server error"))
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{}, nil)
+	storage.EXPECT().SaveJournalEntry(gomock.Any(), gomock.Any()).Return(nil)
+
+	require.Error(t, svc.Sync(context.Background()))
+
+	//  no changes - cursor moved
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{}, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(10), nil)
+	provider.EXPECT().GetChanges(gomock.Any(), int64(10)).Return(model.SyncChanges{Cursor: 12}, nil)
+	storage.EXPECT().GetJournalEntries(gomock.Any()).Return([]model.SyncJournalEntry{}, nil)
+	storage.EXPECT().SetSyncCursor(gomock.Any(), int64(12)).Return(nil)
+
+	require.NoError(t, svc.Sync(context.Background()))
+}
+
+func TestSync_MissingResync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	loc := []model.SecretMeta{
+		{ID: 1, SecretID: uuid.New(), SecretVer: 2, StatusID: model.SecretStatuses["ACTUAL"]},
+		{ID: 2, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"]},
+	}
+
+	//  partial list - missing secret is found with resync
+	storage.EXPECT().GetMetaList(gomock.Any()).Return(loc, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(0), nil)
+	gomock.InOrder(
+		provider.EXPECT().GetSyncList(gomock.Any()).Return(model.SyncList{List: map[uuid.UUID]int{loc[0].SecretID: 2}, Cursor: 7}, nil),
+		provider.EXPECT().GetSyncList(gomock.Any()).Return(model.SyncList{List: map[uuid.UUID]int{loc[0].SecretID: 2, loc[1].SecretID: 1}, Cursor: 8}, nil),
+	)
+
+	tasks, cursor, err := svc.GetSyncBatch(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+	require.EqualValues(t, 8, cursor)
+
+	//  missing after resync - secret is orphaned, not deleted and not uploaded
+	storage.EXPECT().GetMetaList(gomock.Any()).Return(loc, nil)
+	storage.EXPECT().GetSyncCursor(gomock.Any()).Return(int64(0), nil)
+	provider.EXPECT().GetSyncList(gomock.Any()).Return(model.SyncList{List: map[uuid.UUID]int{loc[0].SecretID: 2}, Cursor: 8}, nil).Times(2)
+
+	tasks, _, err = svc.GetSyncBatch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []SyncTask{taskOrphan(loc[1])}, tasks)
+
+	storage.EXPECT().GetSecret(gomock.Any(), int64(2)).Return(model.Secret{ID: 2, SecretID: loc[1].SecretID, SecretVer: 1, BaseData: "base"}, nil)
+	storage.EXPECT().UpdateSecret(gomock.Any(), model.Secret{ID: 2, SecretID: loc[1].SecretID, SecretVer: 1, BaseData: "base", StatusID: model.SecretStatuses["ORPHANED"]}).Return(nil)
+
+	require.NoError(t, svc.ProcessTask(context.Background(), tasks[0]))
+}
+
+func TestSync_WatchChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60, SyncTimeoutSec: 60})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan model.ChangeEvent)
+	provider.EXPECT().SubscribeChanges(gomock.Any()).Return(events, nil)
+
+	notify := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		svc.watchChanges(ctx, notify)
+		close(done)
+	}()
+
+	//  sync on subscription
+	<-notify
+	require.True(t, svc.isStreamUp())
+
+	//  sync on change event
+	events <- model.ChangeEvent{SecretID: uuid.New(), SecretVer: 2}
+	<-notify
+
+	//  stream down - polling
+	close(events)
+	require.Eventually(t, func() bool { return !svc.isStreamUp() }, time.Second, time.Millisecond*10)
+
+	cancel()
+	<-done
+}
+
+func TestSync_HasLocalChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := NewSyncService(storage, nil, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{
+		{ID: 1, StatusID: model.SecretStatuses["ACTUAL"]},
+		{ID: 2, StatusID: model.SecretStatuses["CONFLICT"]},
+	}, nil)
+	changed, err := svc.hasLocalChanges(context.Background())
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	storage.EXPECT().GetMetaList(gomock.Any()).Return([]model.SecretMeta{
+		{ID: 1, StatusID: model.SecretStatuses["ACTUAL"]},
+		{ID: 2, StatusID: model.SecretStatuses["EDITED"]},
+	}, nil)
+	changed, err = svc.hasLocalChanges(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// VerifyReport is result of full check of local and remote data of synced secrets
+type VerifyReport struct {
+	//  count of secrets compared with remote data
+	Checked int
+	//  count of secrets with local data changed since it was stored
+	LocalDamaged int
+	//  repair tasks, damaged data is replaced with data of other side
+	Tasks []SyncTask
+	//  secrets with both local and remote data damaged
+	Unrecoverable []uuid.UUID
+	//  count of failed repair tasks
+	Failed int
+}
+
+// Verify compares data of synced secrets with remote data of the same version by hash stored on server.
+// Remote data is downloaded, so damage of both local and remote copy is found.
+// Damaged local data is downloaded again, damaged remote data is replaced by upload of local data.
+// Repair tasks are processed if repair is true.
+func (s *SyncService) Verify(ctx context.Context, repair bool) (VerifyReport, error) {
+	remList, err := s.provider.GetSyncList(ctx)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+
+	list, err := s.db.GetSecretList(ctx)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+
+	//  only synced secrets with local data of remote version are compared
+	local := make(map[uuid.UUID]model.Secret)
+	ids := make([]uuid.UUID, 0, len(list))
+	for _, el := range list {
+		if el.SecretID == uuid.Nil || el.StatusID != model.SecretStatuses["ACTUAL"] ||
+			el.PayloadID != model.PayloadStates["LOCAL"] {
+			continue
+		}
+		if ver, ok := remList.List[el.SecretID]; !ok || ver != el.SecretVer || remList.Hashes[el.SecretID] == "" {
+			continue
+		}
+
+		local[el.SecretID] = el
+		ids = append(ids, el.SecretID)
+	}
+
+	report := VerifyReport{Tasks: make([]SyncTask, 0), Unrecoverable: make([]uuid.UUID, 0)}
+	if err := s.checkSecrets(ctx, &report, local, ids, remList.Hashes); err != nil {
+		return report, err
+	}
+
+	log.Printf("verify processed, checked: %v, to repair: %v, unrecoverable: %v",
+		report.Checked, len(report.Tasks), len(report.Unrecoverable))
+
+	if !repair || len(report.Tasks) == 0 {
+		return report, nil
+	}
+
+	results := s.tick(ctx, report.Tasks)
+	s.publishResults(results)
+	for _, el := range results {
+		if el.Err != nil {
+			report.Failed++
+		}
+	}
+	if report.Failed > 0 {
+		return report, fmt.Errorf("%v of %v repair tasks failed", report.Failed, len(report.Tasks))
+	}
+
+	return report, nil
+}
+
+// checkSecrets downloads remote data of local secrets with ids by batches,
+// compares local and remote data with hashes stored on server and adds result to report
+func (s *SyncService) checkSecrets(ctx context.Context, report *VerifyReport, local map[uuid.UUID]model.Secret,
+	ids []uuid.UUID, hashes map[uuid.UUID]string) error {
+	size := syncBatchSize(s.cfg)
+	for len(ids) > 0 {
+		n := size
+		if len(ids) < n {
+			n = len(ids)
+		}
+
+		res, err := s.provider.DownloadSecrets(ctx, ids[:n])
+		if err != nil {
+			return fmt.Errorf("error download secrets to verify: %w", err)
+		}
+		ids = ids[n:]
+
+		for _, remote := range res {
+			secret := local[remote.SecretID]
+			//  changed while checked, compared with next check
+			if remote.Err != nil || remote.SecretVer != secret.SecretVer {
+				continue
+			}
+
+			report.Checked++
+			verifySecret(report, secret, remote, hashes[remote.SecretID])
+		}
+	}
+
+	return nil
+}
+
+// resolveRepairs chooses direction of repair tasks of sync batch the same way Verify does.
+// Hash of meta only shows local and remote data differ, so remote data is downloaded
+// and data of both sides is compared with hash stored on server.
+// Repair of secret changed while checked or damaged on both sides is dropped.
+func (s *SyncService) resolveRepairs(ctx context.Context, tasks []SyncTask, remList model.SyncList,
+	loc []model.SecretMeta) ([]SyncTask, error) {
+	locIDs := make(map[uuid.UUID]int64, len(loc))
+	for _, el := range loc {
+		if el.SecretID != uuid.Nil {
+			locIDs[el.SecretID] = el.ID
+		}
+	}
+
+	res := make([]SyncTask, 0, len(tasks))
+	local := make(map[uuid.UUID]model.Secret)
+	ids := make([]uuid.UUID, 0)
+	for _, el := range tasks {
+		if el.ActionID != SyncActions["REPAIR"] {
+			res = append(res, el)
+			continue
+		}
+
+		secret, err := s.db.GetSecret(ctx, locIDs[el.SecretId])
+		if err != nil {
+			return nil, err
+		}
+		local[el.SecretId] = secret
+		ids = append(ids, el.SecretId)
+	}
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	report := VerifyReport{Tasks: make([]SyncTask, 0), Unrecoverable: make([]uuid.UUID, 0)}
+	if err := s.checkSecrets(ctx, &report, local, ids, remList.Hashes); err != nil {
+		return nil, err
+	}
+
+	return append(res, report.Tasks...), nil
+}
+
+// verifySecret compares hashes of local and remote data with hash stored on server, adds result to report
+func verifySecret(report *VerifyReport, secret model.Secret, remote model.RemoteSecret, hash string) {
+	localHash, remoteHash := pkg.DataHash(secret.SecretData), pkg.DataHash(remote.Data)
+	if secret.DataHash != "" && secret.DataHash != localHash {
+		report.LocalDamaged++
+	}
+
+	meta := model.SecretMeta{
+		ID:        secret.ID,
+		SecretID:  secret.SecretID,
+		SecretVer: secret.SecretVer,
+		StatusID:  secret.StatusID,
+		PayloadID: secret.PayloadID,
+		TimeStamp: secret.TimeStamp,
+		DataHash:  localHash,
+	}
+
+	switch {
+	case localHash == hash && remoteHash == hash:
+	case remoteHash == hash:
+		report.Tasks = append(report.Tasks, taskRepair(meta))
+	case localHash == hash:
+		report.Tasks = append(report.Tasks, taskRepairUpload(meta))
+	default:
+		log.Printf("verify: local and remote data of secret %v are damaged", secret.ID)
+		report.Unrecoverable = append(report.Unrecoverable, secret.SecretID)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	pmk "github.com/Xrefullx/YanDip/client/provider/mock"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestSync_Verify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	secret := func(id int64, data string) model.Secret {
+		return model.Secret{ID: id, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"],
+			PayloadID: model.PayloadStates["LOCAL"], SecretData: data, DataHash: pkg.DataHash(data)}
+	}
+	ok, locDamaged, remDamaged, bothDamaged := secret(1, "ok"), secret(2, "local"), secret(3, "remote"), secret(4, "both")
+	locDamaged.SecretData = "damaged"
+	bothDamaged.SecretData = "damaged"
+	//  not synced secret is skipped
+	newSecret := model.Secret{ID: 5, StatusID: model.SecretStatuses["NEW"]}
+
+	remList := model.SyncList{List: make(map[uuid.UUID]int), Hashes: make(map[uuid.UUID]string)}
+	for _, el := range []model.Secret{ok, locDamaged, remDamaged, bothDamaged} {
+		remList.List[el.SecretID] = el.SecretVer
+		remList.Hashes[el.SecretID] = el.DataHash
+	}
+
+	provider.EXPECT().GetSyncList(gomock.Any()).Return(remList, nil)
+	storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{ok, locDamaged, remDamaged, bothDamaged, newSecret}, nil)
+	provider.EXPECT().DownloadSecrets(gomock.Any(), gomock.Len(4)).Return([]model.RemoteSecret{
+		{SecretID: ok.SecretID, SecretVer: 1, Data: "ok"},
+		{SecretID: locDamaged.SecretID, SecretVer: 1, Data: "local"},
+		{SecretID: remDamaged.SecretID, SecretVer: 1, Data: "damaged"},
+		{SecretID: bothDamaged.SecretID, SecretVer: 1, Data: "damaged"},
+	}, nil)
+
+	report, err := svc.Verify(context.Background(), false)
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Checked)
+	require.Equal(t, 2, report.LocalDamaged)
+	require.Equal(t, []uuid.UUID{bothDamaged.SecretID}, report.Unrecoverable)
+
+	require.Len(t, report.Tasks, 2)
+	require.Equal(t, SyncActions["REPAIR"], report.Tasks[0].ActionID)
+	require.Equal(t, locDamaged.SecretID, report.Tasks[0].SecretId)
+	require.Equal(t, SyncActions["REPAIR_UPLOAD"], report.Tasks[1].ActionID)
+	require.Equal(t, remDamaged.SecretID, report.Tasks[1].SecretId)
+}
+
+func TestSync_ResolveRepairs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	provider := pmk.NewMockSecretProvider(ctrl)
+	svc := NewSyncService(storage, provider, &pkg.Config{MasterKey: "testKey", RequestsPerMinute: 60})
+
+	secret := func(id int64, data string) model.Secret {
+		return model.Secret{ID: id, SecretID: uuid.New(), SecretVer: 1, StatusID: model.SecretStatuses["ACTUAL"],
+			PayloadID: model.PayloadStates["LOCAL"], SecretData: data, DataHash: pkg.DataHash(data)}
+	}
+	locDamaged, remDamaged := secret(1, "local"), secret(2, "remote")
+	locDamaged.SecretData = "damaged"
+	download := taskDownload(model.SecretMeta{ID: 3, SecretID: uuid.New(), SecretVer: 1})
+
+	remList := model.SyncList{List: make(map[uuid.UUID]int), Hashes: make(map[uuid.UUID]string)}
+	loc := make([]model.SecretMeta, 0, 2)
+	for _, el := range []model.Secret{locDamaged, remDamaged} {
+		remList.List[el.SecretID] = el.SecretVer
+		remList.Hashes[el.SecretID] = el.DataHash
+		loc = append(loc, model.SecretMeta{ID: el.ID, SecretID: el.SecretID, SecretVer: el.SecretVer})
+	}
+
+	//  repair of sync batch is directed by data of both sides as in verify
+	storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(locDamaged, nil)
+	storage.EXPECT().GetSecret(gomock.Any(), int64(2)).Return(remDamaged, nil)
+	provider.EXPECT().DownloadSecrets(gomock.Any(), gomock.Len(2)).Return([]model.RemoteSecret{
+		{SecretID: locDamaged.SecretID, SecretVer: 1, Data: "local"},
+		{SecretID: remDamaged.SecretID, SecretVer: 1, Data: "damaged"},
+	}, nil)
+
+	tasks, err := svc.resolveRepairs(context.Background(), []SyncTask{
+		taskRepair(loc[0]), download, taskRepair(loc[1]),
+	}, remList, loc)
+	require.NoError(t, err)
+
+	require.Len(t, tasks, 3)
+	require.Equal(t, download, tasks[0])
+	require.Equal(t, SyncActions["REPAIR"], tasks[1].ActionID)
+	require.Equal(t, locDamaged.SecretID, tasks[1].SecretId)
+	require.Equal(t, SyncActions["REPAIR_UPLOAD"], tasks[2].ActionID)
+	require.Equal(t, remDamaged.SecretID, tasks[2].SecretId)
+}
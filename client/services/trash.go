@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// MoveToTrash marks secret as trashed.
+// Trashed state is a part of secret data, so it is synced to other devices as usual edit.
+func (s *SecretService) MoveToTrash(ctx context.Context, id int64) error {
+	return s.setTrashedAt(ctx, id, pkg.MakeTimestamp(), "TRASH")
+}
+
+// RestoreFromTrash returns secret from trash
+func (s *SecretService) RestoreFromTrash(ctx context.Context, id int64) error {
+	return s.setTrashedAt(ctx, id, 0, "RESTORE")
+}
+
+// Trash returns list of trashed secrets
+func (s *SecretService) Trash(ctx context.Context) ([]model.Secret, error) {
+	list, err := s.db.GetSecretList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]model.Secret, 0)
+	for _, el := range list {
+		if el.TrashedAt != 0 {
+			res = append(res, el)
+		}
+	}
+
+	return res, nil
+}
+
+// EmptyTrash deletes all trashed secrets, returns count of deleted
+func (s *SecretService) EmptyTrash(ctx context.Context) (int, error) {
+	return purgeTrash(ctx, s.db, 0)
+}
+
+// setTrashedAt sets trash time of secret object and saves it as local edit
+func (s *SecretService) setTrashedAt(ctx context.Context, id int64, trashedAt int64, operation string) error {
+	secret, err := s.fetchSecret(ctx, id)
+	if err != nil {
+		//  if not found ok
+		if errors.Is(err, model.ErrorItemNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if secret.TrashedAt == trashedAt {
+		return nil
+	}
+
+	obj, err := s.ReadFromSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	info, err := itemInfo(obj)
+	if err != nil {
+		return err
+	}
+	info.TrashedAt = trashedAt
+
+	obj, err = withInfo(obj, info)
+	if err != nil {
+		return err
+	}
+
+	return s.editSecret(ctx, id, obj, operation)
+}
+
+// trashRetention returns retention period of trash
+func trashRetention(cfg *pkg.Config) time.Duration {
+	return time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+}
+
+// purgeTrash marks as DELETED secrets trashed longer than retention, returns count of marked.
+// Expired trash is purged by sync scheduler, DELETED secrets are deleted on server and locally by sync
+func purgeTrash(ctx context.Context, db storage.Storage, retention time.Duration) (int, error) {
+	list, err := db.GetSecretList(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := pkg.MakeTimestamp() - retention.Milliseconds()
+	count := 0
+	for _, el := range list {
+		if el.TrashedAt == 0 || el.TrashedAt > expired {
+			continue
+		}
+
+		el.StatusID = model.SecretStatuses["DELETED"]
+		if err := db.UpdateSecret(ctx, el); err != nil {
+			return count, fmt.Errorf("error purge secret %v from trash: %w", el.ID, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// withInfo returns secret object with replaced info
+func withInfo(obj interface{}, info model.Info) (interface{}, error) {
+	switch el := obj.(type) {
+	case model.Auth:
+		el.Info = info
+		return el, nil
+	case model.Card:
+		el.Info = info
+		return el, nil
+	case model.Text:
+		el.Info = info
+		return el, nil
+	case model.Binary:
+		el.Info = info
+		return el, nil
+	}
+
+	return nil, fmt.Errorf("%w: wrong secret type %T", model.ErrorParamNotValid, obj)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+func TestTrash_MoveRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	svc := GetTestSecretSvc(t, storage)
+
+	secret, err := svc.ToSecret(model.TestAuth)
+	require.NoError(t, err)
+	secret.ID, secret.SecretID, secret.StatusID = 1, uuid.New(), model.SecretStatuses["ACTUAL"]
+
+	var saved model.Secret
+	storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(secret, nil).Times(2)
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["LOCAL_EDIT"], 0).
+		Do(func(_ context.Context, v model.Secret, _ int, _ int) { saved = v }).Return(nil)
+
+	require.NoError(t, svc.DeleteSoftSecret(context.Background(), 1))
+
+	//  trashed state is edit of secret data, uploaded by sync
+	require.Equal(t, model.SecretStatuses["EDITED"], saved.StatusID)
+	require.NotZero(t, saved.TrashedAt)
+	info := model.Info{}
+	require.NoError(t, info.FromEncodedData(saved.SecretData, cfg.MasterKey))
+	require.Equal(t, saved.TrashedAt, info.TrashedAt)
+
+	//  restore from trash clears trashed state
+	storage.EXPECT().GetSecret(gomock.Any(), int64(1)).Return(saved, nil).Times(2)
+	storage.EXPECT().UpdateSecretWithRevision(gomock.Any(), gomock.Any(), model.RevisionCauses["LOCAL_EDIT"], 0).
+		Do(func(_ context.Context, v model.Secret, _ int, _ int) { require.Zero(t, v.TrashedAt) }).Return(nil)
+
+	require.NoError(t, svc.RestoreFromTrash(context.Background(), 1))
+}
+
+func TestTrash_Purge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := mk.NewMockStorage(ctrl)
+	trashCfg := &pkg.Config{MasterKey: "testKey", TrashRetentionDays: 30}
+	svc := NewSecret(trashCfg, storage)
+
+	now := pkg.MakeTimestamp()
+	expired := model.Secret{ID: 1, Info: model.Info{TrashedAt: now - (31 * 24 * time.Hour).Milliseconds()}}
+	fresh := model.Secret{ID: 2, Info: model.Info{TrashedAt: now - time.Hour.Milliseconds()}}
+	active := model.Secret{ID: 3}
+
+	storage.EXPECT().GetSecretList(gomock.Any()).Return([]model.Secret{expired, fresh, active}, nil).Times(3)
+
+	trash, err := svc.Trash(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []model.Secret{expired, fresh}, trash)
+
+	deleted := expired
+	deleted.StatusID = model.SecretStatuses["DELETED"]
+	storage.EXPECT().UpdateSecret(gomock.Any(), deleted).Return(nil)
+
+	//  scheduler purges trash expired by retention
+	count, err := purgeTrash(context.Background(), storage, trashRetention(trashCfg))
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	deletedFresh := fresh
+	deletedFresh.StatusID = model.SecretStatuses["DELETED"]
+	storage.EXPECT().UpdateSecret(gomock.Any(), deleted).Return(nil)
+	storage.EXPECT().UpdateSecret(gomock.Any(), deletedFresh).Return(nil)
+
+	count, err = svc.EmptyTrash(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
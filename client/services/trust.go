@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// pinTimeout limits storage requests made while TLS handshake
+const pinTimeout = time.Second * 5
+
+type TrustService struct {
+	cfg *pkg.Config
+	db  storage.Storage
+}
+
+// NewTrustService returns new instance of server trust service
+// Server certificate is verified by system roots or CA bundle of config.
+// Server key is checked against pin of config, or against pin stored in vault on first connection to host.
+func NewTrustService(cfg *pkg.Config, db storage.Storage) *TrustService {
+	return &TrustService{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+// TLSConfig returns TLS config of connections to server host, server key is checked after certificate chain is verified.
+// Key is pinned for host name without port, the same key is expected on all ports of host.
+// Device certificate is presented if server requests it.
+func (s *TrustService) TLSConfig(host string) (*tls.Config, error) {
+	cfg, err := s.verifyConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.VerifyConnection = func(state tls.ConnectionState) error {
+		return s.verifyConnection(host, state)
+	}
+	cfg.GetClientCertificate = s.clientCertificate
+
+	return cfg, nil
+}
+
+// ServerPin connects to server address host:port and returns pin of server key.
+// Certificate chain is verified, key is not checked against pinned key.
+func (s *TrustService) ServerPin(ctx context.Context, addr string) (string, error) {
+	cfg, err := s.verifyConfig()
+	if err != nil {
+		return "", err
+	}
+
+	dialer := tls.Dialer{Config: cfg}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("error connect to server: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return "", errors.New("server has not presented certificate")
+	}
+
+	return pkg.SPKIPin(state.PeerCertificates[0]), nil
+}
+
+// Repin replaces pinned key of server with key presented by server now, returns previous and new pin.
+// If expected pin is set, presented key must match it, so new key is confirmed out of band.
+func (s *TrustService) Repin(ctx context.Context, addr string, expected string) (string, string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: wrong server address: %v", model.ErrorParamNotValid, err)
+	}
+
+	pin, err := s.ServerPin(ctx, addr)
+	if err != nil {
+		return "", "", err
+	}
+	if expected != "" && pin != expected {
+		return "", "", fmt.Errorf("%w: server presented key %v, expected %v", model.ErrorServerKeyChanged, pin, expected)
+	}
+
+	prev, err := s.db.GetServerPin(ctx, host)
+	if err != nil && !errors.Is(err, model.ErrorItemNotFound) {
+		return "", "", err
+	}
+	if err := s.db.SaveServerPin(ctx, model.ServerPin{Host: host, Pin: pin}); err != nil {
+		return "", "", err
+	}
+
+	return prev.Pin, pin, nil
+}
+
+// verifyConfig returns TLS config verifying server certificate chain with roots of config
+func (s *TrustService) verifyConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.cfg.CAFile == "" {
+		return cfg, nil
+	}
+
+	bundle, err := os.ReadFile(s.cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("error read CA bundle: %w", err)
+	}
+
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("CA bundle %v has no PEM certificates", s.cfg.CAFile)
+	}
+
+	return cfg, nil
+}
+
+// verifyConnection checks key of verified server certificate against pinned key of host
+func (s *TrustService) verifyConnection(host string, state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server has not presented certificate")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pinTimeout)
+	defer cancel()
+
+	return s.checkPin(ctx, host, pkg.SPKIPin(state.PeerCertificates[0]))
+}
+
+// checkPin checks pin of key presented by host.
+// Pin of config is checked first, with TOFU mode key is pinned on first connection to host.
+func (s *TrustService) checkPin(ctx context.Context, host string, pin string) error {
+	if s.cfg.ServerPin != "" {
+		if pin != s.cfg.ServerPin {
+			return fmt.Errorf("%w: host %v presented key %v, configured pin is %v",
+				model.ErrorServerKeyChanged, host, pin, s.cfg.ServerPin)
+		}
+		return nil
+	}
+
+	if s.cfg.PinMode != "TOFU" {
+		return nil
+	}
+
+	stored, err := s.db.GetServerPin(ctx, host)
+	if errors.Is(err, model.ErrorItemNotFound) {
+		log.Printf("server key of %v is pinned on first use: %v", host, pin)
+		return s.db.SaveServerPin(ctx, model.ServerPin{Host: host, Pin: pin})
+	}
+	if err != nil {
+		return fmt.Errorf("error read pinned server key: %w", err)
+	}
+
+	if stored.Pin != pin {
+		return fmt.Errorf("%w: host %v presented key %v, pinned key is %v; "+
+			"if server key was replaced, check new key and pin it with repin command",
+			model.ErrorServerKeyChanged, host, pin, stored.Pin)
+	}
+
+	return nil
+}
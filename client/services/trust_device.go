@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/provider"
+)
+
+var _ provider.DeviceIdentity = (*TrustService)(nil)
+
+// DeviceID returns id of vault device, new id if device identity is not created
+func (s *TrustService) DeviceID(ctx context.Context) (uuid.UUID, error) {
+	identity, err := s.db.GetDeviceIdentity(ctx)
+	if errors.Is(err, model.ErrorItemNotFound) {
+		return uuid.New(), nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return identity.DeviceID, nil
+}
+
+// CertificateRequest returns PEM certificate request of device, nil if device has certificate.
+// Device key is generated on first request and stored in vault encrypted with master key,
+// identity of other device is replaced.
+func (s *TrustService) CertificateRequest(ctx context.Context, deviceID uuid.UUID) ([]byte, error) {
+	identity, err := s.db.GetDeviceIdentity(ctx)
+	if err != nil && !errors.Is(err, model.ErrorItemNotFound) {
+		return nil, err
+	}
+
+	if identity.DeviceID == deviceID && identity.Certificate != "" {
+		return nil, nil
+	}
+
+	var key *ecdsa.PrivateKey
+	if identity.DeviceID == deviceID {
+		if key, err = s.deviceKey(identity); err != nil {
+			return nil, err
+		}
+	} else {
+		if identity.DeviceID != uuid.Nil {
+			log.Printf("identity of device %v is replaced by device %v", identity.DeviceID, deviceID)
+		}
+		if key, err = s.newDeviceKey(ctx, deviceID); err != nil {
+			return nil, err
+		}
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{Subject: pkix.Name{CommonName: deviceID.String()}}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// SaveCertificate saves PEM certificate issued to device, certificate must be issued for key of device
+func (s *TrustService) SaveCertificate(ctx context.Context, deviceID uuid.UUID, certPEM []byte) error {
+	identity, err := s.db.GetDeviceIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if identity.DeviceID != deviceID {
+		return fmt.Errorf("%w: certificate is issued for device %v, vault device is %v",
+			model.ErrorParamNotValid, deviceID, identity.DeviceID)
+	}
+
+	identity.Certificate = string(certPEM)
+	if _, err := s.tlsCertificate(identity); err != nil {
+		return fmt.Errorf("%w: wrong device certificate: %v", model.ErrorParamNotValid, err)
+	}
+
+	return s.db.SaveDeviceIdentity(ctx, identity)
+}
+
+// clientCertificate returns certificate of device, server requests it in TLS handshake.
+// Empty certificate is returned if device has no certificate, connection is made without it.
+func (s *TrustService) clientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pinTimeout)
+	defer cancel()
+
+	identity, err := s.db.GetDeviceIdentity(ctx)
+	if errors.Is(err, model.ErrorItemNotFound) || (err == nil && identity.Certificate == "") {
+		return &tls.Certificate{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error read device identity: %w", err)
+	}
+
+	return s.tlsCertificate(identity)
+}
+
+// tlsCertificate returns certificate of device with decrypted key
+func (s *TrustService) tlsCertificate(identity model.DeviceIdentity) (*tls.Certificate, error) {
+	keyPEM, err := pkg.Decode(identity.Key, s.cfg.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decode device key: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair([]byte(identity.Certificate), keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cert, nil
+}
+
+// newDeviceKey generates key of device and saves it as vault device identity
+func (s *TrustService) newDeviceKey(ctx context.Context, deviceID uuid.UUID) (*ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := pkg.Encode(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), s.cfg.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SaveDeviceIdentity(ctx, model.DeviceIdentity{DeviceID: deviceID, Key: encrypted}); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// deviceKey returns decrypted key of device identity
+func (s *TrustService) deviceKey(identity model.DeviceIdentity) (*ecdsa.PrivateKey, error) {
+	keyPEM, err := pkg.Decode(identity.Key, s.cfg.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decode device key: %w", err)
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("device key is not PEM encoded")
+	}
+
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	mk "github.com/Xrefullx/YanDip/client/storage/mock"
+)
+
+// writeCABundle writes certificate of test server to PEM bundle, returns file name
+func writeCABundle(t *testing.T, server *httptest.Server) string {
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(file, bundle, 0600))
+
+	return file
+}
+
+// getTrusted makes request to test server with TLS config of trust service
+func getTrusted(t *testing.T, svc *TrustService, server *httptest.Server) error {
+	tlsConfig, err := svc.TLSConfig("127.0.0.1")
+	require.NoError(t, err)
+
+	client := http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func TestTrust_TLSConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	pin := pkg.SPKIPin(server.Certificate())
+	caFile := writeCABundle(t, server)
+
+	t.Run("server not trusted by system roots", func(t *testing.T) {
+		svc := NewTrustService(&pkg.Config{PinMode: "TOFU"}, mk.NewMockStorage(ctrl))
+		require.Error(t, getTrusted(t, svc, server))
+	})
+
+	t.Run("key pinned on first use", func(t *testing.T) {
+		db := mk.NewMockStorage(ctrl)
+		db.EXPECT().GetServerPin(gomock.Any(), "127.0.0.1").Return(model.ServerPin{}, model.ErrorItemNotFound)
+		db.EXPECT().SaveServerPin(gomock.Any(), model.ServerPin{Host: "127.0.0.1", Pin: pin}).Return(nil)
+
+		svc := NewTrustService(&pkg.Config{CAFile: caFile, PinMode: "TOFU"}, db)
+		require.NoError(t, getTrusted(t, svc, server))
+	})
+
+	t.Run("changed key rejected", func(t *testing.T) {
+		db := mk.NewMockStorage(ctrl)
+		db.EXPECT().GetServerPin(gomock.Any(), "127.0.0.1").Return(model.ServerPin{Host: "127.0.0.1", Pin: "sha256/old"}, nil)
+
+		svc := NewTrustService(&pkg.Config{CAFile: caFile, PinMode: "TOFU"}, db)
+		err := getTrusted(t, svc, server)
+		require.ErrorIs(t, err, model.ErrorServerKeyChanged)
+		require.Contains(t, err.Error(), pin)
+	})
+
+	t.Run("configured pin overrides vault pin", func(t *testing.T) {
+		svc := NewTrustService(&pkg.Config{CAFile: caFile, PinMode: "TOFU", ServerPin: pin}, mk.NewMockStorage(ctrl))
+		require.NoError(t, getTrusted(t, svc, server))
+
+		svc = NewTrustService(&pkg.Config{CAFile: caFile, ServerPin: "sha256/other"}, mk.NewMockStorage(ctrl))
+		require.ErrorIs(t, getTrusted(t, svc, server), model.ErrorServerKeyChanged)
+	})
+
+	t.Run("pinning disabled", func(t *testing.T) {
+		svc := NewTrustService(&pkg.Config{CAFile: caFile, PinMode: "OFF"}, mk.NewMockStorage(ctrl))
+		require.NoError(t, getTrusted(t, svc, server))
+	})
+}
+
+func TestTrust_Repin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+	pin := pkg.SPKIPin(server.Certificate())
+
+	db := mk.NewMockStorage(ctrl)
+	db.EXPECT().GetServerPin(gomock.Any(), "127.0.0.1").Return(model.ServerPin{Host: "127.0.0.1", Pin: "sha256/old"}, nil)
+	db.EXPECT().SaveServerPin(gomock.Any(), model.ServerPin{Host: "127.0.0.1", Pin: pin}).Return(nil)
+
+	svc := NewTrustService(&pkg.Config{CAFile: writeCABundle(t, server), PinMode: "TOFU"}, db)
+	ctx := context.Background()
+
+	//  key not matching expected pin is not pinned
+	_, _, err := svc.Repin(ctx, addr, "sha256/other")
+	require.ErrorIs(t, err, model.ErrorServerKeyChanged)
+
+	prev, cur, err := svc.Repin(ctx, addr, pin)
+	require.NoError(t, err)
+	require.Equal(t, "sha256/old", prev)
+	require.Equal(t, pin, cur)
+
+	//  certificate chain is verified before key is pinned
+	svc = NewTrustService(&pkg.Config{PinMode: "TOFU"}, mk.NewMockStorage(ctrl))
+	_, _, err = svc.Repin(ctx, addr, "")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, model.ErrorServerKeyChanged)
+}
+
+func TestTrust_SPKIPin(t *testing.T) {
+	server := httptest.NewUnstartedServer(nil)
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	pin := pkg.SPKIPin(server.Certificate())
+	require.True(t, pkg.IsSPKIPin(pin))
+	require.False(t, pkg.IsSPKIPin("sha256/short"))
+	require.False(t, pkg.IsSPKIPin(strings.TrimPrefix(pin, "sha256/")))
+}
+
+// signTestCSR signs PEM certificate request with self-signed test CA, returns PEM certificate
+func signTestCSR(t *testing.T, csrPEM []byte) []byte {
+	block, _ := pem.Decode(csrPEM)
+	require.NotNil(t, block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      csr.Subject,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, csr.PublicKey, caKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestTrust_DeviceIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//  storage keeps saved identity
+	var stored *model.DeviceIdentity
+	db := mk.NewMockStorage(ctrl)
+	db.EXPECT().GetDeviceIdentity(gomock.Any()).DoAndReturn(func(_ context.Context) (model.DeviceIdentity, error) {
+		if stored == nil {
+			return model.DeviceIdentity{}, model.ErrorItemNotFound
+		}
+		return *stored, nil
+	}).AnyTimes()
+	db.EXPECT().SaveDeviceIdentity(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, d model.DeviceIdentity) error {
+		stored = &d
+		return nil
+	}).AnyTimes()
+
+	svc := NewTrustService(&pkg.Config{MasterKey: "masterkey"}, db)
+	ctx := context.Background()
+
+	//  new device id is used until identity is created
+	deviceID, err := svc.DeviceID(ctx)
+	require.NoError(t, err)
+
+	cert, err := svc.clientCertificate(nil)
+	require.NoError(t, err)
+	require.Empty(t, cert.Certificate)
+
+	csr, err := svc.CertificateRequest(ctx, deviceID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	require.NotContains(t, stored.Key, "PRIVATE KEY")
+
+	//  the same key is requested again while certificate is not issued
+	again, err := svc.CertificateRequest(ctx, deviceID)
+	require.NoError(t, err)
+	require.NotEqual(t, csr, again)
+	key := stored.Key
+
+	certPEM := signTestCSR(t, again)
+	require.ErrorIs(t, svc.SaveCertificate(ctx, uuid.New(), certPEM), model.ErrorParamNotValid)
+	require.NoError(t, svc.SaveCertificate(ctx, deviceID, certPEM))
+	require.Equal(t, key, stored.Key)
+
+	id, err := svc.DeviceID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, deviceID, id)
+
+	//  certificate is not requested by enrolled device
+	csr, err = svc.CertificateRequest(ctx, deviceID)
+	require.NoError(t, err)
+	require.Nil(t, csr)
+
+	cert, err = svc.clientCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	require.Equal(t, deviceID.String(), leaf.Subject.CommonName)
+}
@@ -1,19 +1,72 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 
 	"github.com/Xrefullx/YanDip/client/model"
 )
 
 type Storage interface {
-	AddSecret(v model.Secret) (int64, error)
-	GetSecret(id int64) (model.Secret, error)
-	GetSecretByExtID(extID uuid.UUID) (model.Secret, error)
-	GetMetaList() ([]model.SecretMeta, error)
+	AddSecret(ctx context.Context, v model.Secret) (int64, error)
+	GetSecret(ctx context.Context, id int64) (model.Secret, error)
+	GetSecretByExtID(ctx context.Context, extID uuid.UUID) (model.Secret, error)
+	GetMetaList(ctx context.Context) ([]model.SecretMeta, error)
+	GetSecretList(ctx context.Context) ([]model.Secret, error)
 
 	//UpdateSecretBySecretID(v model.Secret) error
-	UpdateSecret(v model.Secret) error
-	DeleteSecret(id int64) error
+	UpdateSecret(ctx context.Context, v model.Secret) error
+	DeleteSecret(ctx context.Context, id int64) error
+
+	// UpdateSecretWithRevision saves current state of secret as revision with cause, then updates secret.
+	// Only keep newest revisions of secret are kept.
+	UpdateSecretWithRevision(ctx context.Context, v model.Secret, causeID int, keep int) error
+	GetRevisions(ctx context.Context, secretLocID int64) ([]model.Revision, error)
+	GetRevision(ctx context.Context, id int64) (model.Revision, error)
+
+	// AddAuditEntry appends entry to audit log and moves log head to it, headMAC authenticates new head
+	AddAuditEntry(ctx context.Context, e model.AuditEntry, headMAC string) error
+	// GetAuditHead returns head of audit log, empty head if log is empty
+	GetAuditHead(ctx context.Context) (model.AuditHead, error)
+	GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+
+	// AddConflict saves conflict of secret and marks secret as CONFLICT, conflict of same secret is replaced
+	AddConflict(ctx context.Context, c model.Conflict) (int64, error)
+	GetConflicts(ctx context.Context) ([]model.Conflict, error)
+	GetConflict(ctx context.Context, id int64) (model.Conflict, error)
+	DeleteConflict(ctx context.Context, id int64) error
+
+	// GetSyncCursor returns server change feed cursor of last successful sync, 0 if vault was not synced
+	GetSyncCursor(ctx context.Context) (int64, error)
+	SetSyncCursor(ctx context.Context, cursor int64) error
+
+	// SaveJournalEntry saves state of failed sync task, entry of same task is replaced
+	SaveJournalEntry(ctx context.Context, e model.SyncJournalEntry) error
+	GetJournalEntries(ctx context.Context) ([]model.SyncJournalEntry, error)
+	GetJournalEntry(ctx context.Context, taskKey string) (model.SyncJournalEntry, error)
+	DeleteJournalEntry(ctx context.Context, taskKey string) error
+
+	// SaveServerPin saves pinned key of server host, pin of same host is replaced
+	SaveServerPin(ctx context.Context, p model.ServerPin) error
+	// GetServerPin returns pinned key of server host, ErrorItemNotFound if host is not pinned
+	GetServerPin(ctx context.Context, host string) (model.ServerPin, error)
+	DeleteServerPin(ctx context.Context, host string) error
+
+	// SaveDeviceIdentity saves key and certificate of vault device, vault has one device identity
+	SaveDeviceIdentity(ctx context.Context, d model.DeviceIdentity) error
+	// GetDeviceIdentity returns device identity of vault, ErrorItemNotFound if it is not created
+	GetDeviceIdentity(ctx context.Context) (model.DeviceIdentity, error)
+	DeleteDeviceIdentity(ctx context.Context) error
+
+	AddImportBatch(ctx context.Context, b model.ImportBatch, ids []int64) (int64, error)
+	GetImportBatches(ctx context.Context) ([]model.ImportBatch, error)
+	GetImportBatchItems(ctx context.Context, batchID int64) ([]int64, error)
+	DeleteImportBatch(ctx context.Context, batchID int64) error
+
+	// SubscribeChanges returns channel of notifications of added, updated and deleted secrets
+	// and function to unsubscribe. Notifications are dropped for slow subscriber over buffer.
+	SubscribeChanges() (<-chan model.LocalChange, func())
+
 	Close()
 }
@@ -5,9 +5,10 @@
 package mock
 
 import (
-	model "github.com/Xrefullx/YanDip/client/model"
+	context "context"
 	reflect "reflect"
 
+	model "github.com/Xrefullx/YanDip/client/model"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 )
@@ -35,19 +36,63 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddAuditEntry mocks base method.
+func (m *MockStorage) AddAuditEntry(ctx context.Context, e model.AuditEntry, headMAC string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuditEntry", ctx, e, headMAC)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuditEntry indicates an expected call of AddAuditEntry.
+func (mr *MockStorageMockRecorder) AddAuditEntry(ctx, e, headMAC interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuditEntry", reflect.TypeOf((*MockStorage)(nil).AddAuditEntry), ctx, e, headMAC)
+}
+
+// AddConflict mocks base method.
+func (m *MockStorage) AddConflict(ctx context.Context, c model.Conflict) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddConflict", ctx, c)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddConflict indicates an expected call of AddConflict.
+func (mr *MockStorageMockRecorder) AddConflict(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddConflict", reflect.TypeOf((*MockStorage)(nil).AddConflict), ctx, c)
+}
+
+// AddImportBatch mocks base method.
+func (m *MockStorage) AddImportBatch(ctx context.Context, b model.ImportBatch, ids []int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImportBatch", ctx, b, ids)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddImportBatch indicates an expected call of AddImportBatch.
+func (mr *MockStorageMockRecorder) AddImportBatch(ctx, b, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImportBatch", reflect.TypeOf((*MockStorage)(nil).AddImportBatch), ctx, b, ids)
+}
+
 // AddSecret mocks base method.
-func (m *MockStorage) AddSecret(v model.Secret) (int64, error) {
+func (m *MockStorage) AddSecret(ctx context.Context, v model.Secret) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddSecret", v)
+	ret := m.ctrl.Call(m, "AddSecret", ctx, v)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddSecret indicates an expected call of AddSecret.
-func (mr *MockStorageMockRecorder) AddSecret(v interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) AddSecret(ctx, v interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSecret", reflect.TypeOf((*MockStorage)(nil).AddSecret), v)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSecret", reflect.TypeOf((*MockStorage)(nil).AddSecret), ctx, v)
 }
 
 // Close mocks base method.
@@ -62,75 +107,440 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
+// DeleteConflict mocks base method.
+func (m *MockStorage) DeleteConflict(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConflict", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConflict indicates an expected call of DeleteConflict.
+func (mr *MockStorageMockRecorder) DeleteConflict(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConflict", reflect.TypeOf((*MockStorage)(nil).DeleteConflict), ctx, id)
+}
+
+// DeleteDeviceIdentity mocks base method.
+func (m *MockStorage) DeleteDeviceIdentity(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceIdentity", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeviceIdentity indicates an expected call of DeleteDeviceIdentity.
+func (mr *MockStorageMockRecorder) DeleteDeviceIdentity(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceIdentity", reflect.TypeOf((*MockStorage)(nil).DeleteDeviceIdentity), ctx)
+}
+
+// DeleteImportBatch mocks base method.
+func (m *MockStorage) DeleteImportBatch(ctx context.Context, batchID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteImportBatch", ctx, batchID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteImportBatch indicates an expected call of DeleteImportBatch.
+func (mr *MockStorageMockRecorder) DeleteImportBatch(ctx, batchID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteImportBatch", reflect.TypeOf((*MockStorage)(nil).DeleteImportBatch), ctx, batchID)
+}
+
+// DeleteJournalEntry mocks base method.
+func (m *MockStorage) DeleteJournalEntry(ctx context.Context, taskKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteJournalEntry", ctx, taskKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteJournalEntry indicates an expected call of DeleteJournalEntry.
+func (mr *MockStorageMockRecorder) DeleteJournalEntry(ctx, taskKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJournalEntry", reflect.TypeOf((*MockStorage)(nil).DeleteJournalEntry), ctx, taskKey)
+}
+
 // DeleteSecret mocks base method.
-func (m *MockStorage) DeleteSecret(id int64) error {
+func (m *MockStorage) DeleteSecret(ctx context.Context, id int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSecret", id)
+	ret := m.ctrl.Call(m, "DeleteSecret", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteSecret indicates an expected call of DeleteSecret.
-func (mr *MockStorageMockRecorder) DeleteSecret(id interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) DeleteSecret(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockStorage)(nil).DeleteSecret), ctx, id)
+}
+
+// DeleteServerPin mocks base method.
+func (m *MockStorage) DeleteServerPin(ctx context.Context, host string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteServerPin", ctx, host)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteServerPin indicates an expected call of DeleteServerPin.
+func (mr *MockStorageMockRecorder) DeleteServerPin(ctx, host interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServerPin", reflect.TypeOf((*MockStorage)(nil).DeleteServerPin), ctx, host)
+}
+
+// GetAuditEntries mocks base method.
+func (m *MockStorage) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEntries", ctx, filter)
+	ret0, _ := ret[0].([]model.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEntries indicates an expected call of GetAuditEntries.
+func (mr *MockStorageMockRecorder) GetAuditEntries(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEntries", reflect.TypeOf((*MockStorage)(nil).GetAuditEntries), ctx, filter)
+}
+
+// GetAuditHead mocks base method.
+func (m *MockStorage) GetAuditHead(ctx context.Context) (model.AuditHead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditHead", ctx)
+	ret0, _ := ret[0].(model.AuditHead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditHead indicates an expected call of GetAuditHead.
+func (mr *MockStorageMockRecorder) GetAuditHead(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditHead", reflect.TypeOf((*MockStorage)(nil).GetAuditHead), ctx)
+}
+
+// GetConflict mocks base method.
+func (m *MockStorage) GetConflict(ctx context.Context, id int64) (model.Conflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConflict", ctx, id)
+	ret0, _ := ret[0].(model.Conflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConflict indicates an expected call of GetConflict.
+func (mr *MockStorageMockRecorder) GetConflict(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConflict", reflect.TypeOf((*MockStorage)(nil).GetConflict), ctx, id)
+}
+
+// GetConflicts mocks base method.
+func (m *MockStorage) GetConflicts(ctx context.Context) ([]model.Conflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConflicts", ctx)
+	ret0, _ := ret[0].([]model.Conflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConflicts indicates an expected call of GetConflicts.
+func (mr *MockStorageMockRecorder) GetConflicts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConflicts", reflect.TypeOf((*MockStorage)(nil).GetConflicts), ctx)
+}
+
+// GetDeviceIdentity mocks base method.
+func (m *MockStorage) GetDeviceIdentity(ctx context.Context) (model.DeviceIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceIdentity", ctx)
+	ret0, _ := ret[0].(model.DeviceIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceIdentity indicates an expected call of GetDeviceIdentity.
+func (mr *MockStorageMockRecorder) GetDeviceIdentity(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceIdentity", reflect.TypeOf((*MockStorage)(nil).GetDeviceIdentity), ctx)
+}
+
+// GetImportBatchItems mocks base method.
+func (m *MockStorage) GetImportBatchItems(ctx context.Context, batchID int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportBatchItems", ctx, batchID)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImportBatchItems indicates an expected call of GetImportBatchItems.
+func (mr *MockStorageMockRecorder) GetImportBatchItems(ctx, batchID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockStorage)(nil).DeleteSecret), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportBatchItems", reflect.TypeOf((*MockStorage)(nil).GetImportBatchItems), ctx, batchID)
+}
+
+// GetImportBatches mocks base method.
+func (m *MockStorage) GetImportBatches(ctx context.Context) ([]model.ImportBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportBatches", ctx)
+	ret0, _ := ret[0].([]model.ImportBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImportBatches indicates an expected call of GetImportBatches.
+func (mr *MockStorageMockRecorder) GetImportBatches(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportBatches", reflect.TypeOf((*MockStorage)(nil).GetImportBatches), ctx)
+}
+
+// GetJournalEntries mocks base method.
+func (m *MockStorage) GetJournalEntries(ctx context.Context) ([]model.SyncJournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJournalEntries", ctx)
+	ret0, _ := ret[0].([]model.SyncJournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJournalEntries indicates an expected call of GetJournalEntries.
+func (mr *MockStorageMockRecorder) GetJournalEntries(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJournalEntries", reflect.TypeOf((*MockStorage)(nil).GetJournalEntries), ctx)
+}
+
+// GetJournalEntry mocks base method.
+func (m *MockStorage) GetJournalEntry(ctx context.Context, taskKey string) (model.SyncJournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJournalEntry", ctx, taskKey)
+	ret0, _ := ret[0].(model.SyncJournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJournalEntry indicates an expected call of GetJournalEntry.
+func (mr *MockStorageMockRecorder) GetJournalEntry(ctx, taskKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJournalEntry", reflect.TypeOf((*MockStorage)(nil).GetJournalEntry), ctx, taskKey)
 }
 
 // GetMetaList mocks base method.
-func (m *MockStorage) GetMetaList() ([]model.SecretMeta, error) {
+func (m *MockStorage) GetMetaList(ctx context.Context) ([]model.SecretMeta, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMetaList")
+	ret := m.ctrl.Call(m, "GetMetaList", ctx)
 	ret0, _ := ret[0].([]model.SecretMeta)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMetaList indicates an expected call of GetMetaList.
-func (mr *MockStorageMockRecorder) GetMetaList() *gomock.Call {
+func (mr *MockStorageMockRecorder) GetMetaList(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetaList", reflect.TypeOf((*MockStorage)(nil).GetMetaList))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetaList", reflect.TypeOf((*MockStorage)(nil).GetMetaList), ctx)
+}
+
+// GetRevision mocks base method.
+func (m *MockStorage) GetRevision(ctx context.Context, id int64) (model.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, id)
+	ret0, _ := ret[0].(model.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockStorageMockRecorder) GetRevision(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockStorage)(nil).GetRevision), ctx, id)
+}
+
+// GetRevisions mocks base method.
+func (m *MockStorage) GetRevisions(ctx context.Context, secretLocID int64) ([]model.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", ctx, secretLocID)
+	ret0, _ := ret[0].([]model.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockStorageMockRecorder) GetRevisions(ctx, secretLocID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockStorage)(nil).GetRevisions), ctx, secretLocID)
 }
 
 // GetSecret mocks base method.
-func (m *MockStorage) GetSecret(id int64) (model.Secret, error) {
+func (m *MockStorage) GetSecret(ctx context.Context, id int64) (model.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSecret", id)
+	ret := m.ctrl.Call(m, "GetSecret", ctx, id)
 	ret0, _ := ret[0].(model.Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSecret indicates an expected call of GetSecret.
-func (mr *MockStorageMockRecorder) GetSecret(id interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetSecret(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockStorage)(nil).GetSecret), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockStorage)(nil).GetSecret), ctx, id)
 }
 
 // GetSecretByExtID mocks base method.
-func (m *MockStorage) GetSecretByExtID(extID uuid.UUID) (model.Secret, error) {
+func (m *MockStorage) GetSecretByExtID(ctx context.Context, extID uuid.UUID) (model.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSecretByExtID", extID)
+	ret := m.ctrl.Call(m, "GetSecretByExtID", ctx, extID)
 	ret0, _ := ret[0].(model.Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSecretByExtID indicates an expected call of GetSecretByExtID.
-func (mr *MockStorageMockRecorder) GetSecretByExtID(extID interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetSecretByExtID(ctx, extID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretByExtID", reflect.TypeOf((*MockStorage)(nil).GetSecretByExtID), ctx, extID)
+}
+
+// GetSecretList mocks base method.
+func (m *MockStorage) GetSecretList(ctx context.Context) ([]model.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretList", ctx)
+	ret0, _ := ret[0].([]model.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretList indicates an expected call of GetSecretList.
+func (mr *MockStorageMockRecorder) GetSecretList(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretList", reflect.TypeOf((*MockStorage)(nil).GetSecretList), ctx)
+}
+
+// GetServerPin mocks base method.
+func (m *MockStorage) GetServerPin(ctx context.Context, host string) (model.ServerPin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServerPin", ctx, host)
+	ret0, _ := ret[0].(model.ServerPin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServerPin indicates an expected call of GetServerPin.
+func (mr *MockStorageMockRecorder) GetServerPin(ctx, host interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretByExtID", reflect.TypeOf((*MockStorage)(nil).GetSecretByExtID), extID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServerPin", reflect.TypeOf((*MockStorage)(nil).GetServerPin), ctx, host)
+}
+
+// GetSyncCursor mocks base method.
+func (m *MockStorage) GetSyncCursor(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSyncCursor", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSyncCursor indicates an expected call of GetSyncCursor.
+func (mr *MockStorageMockRecorder) GetSyncCursor(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSyncCursor", reflect.TypeOf((*MockStorage)(nil).GetSyncCursor), ctx)
+}
+
+// SaveDeviceIdentity mocks base method.
+func (m *MockStorage) SaveDeviceIdentity(ctx context.Context, d model.DeviceIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDeviceIdentity", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDeviceIdentity indicates an expected call of SaveDeviceIdentity.
+func (mr *MockStorageMockRecorder) SaveDeviceIdentity(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDeviceIdentity", reflect.TypeOf((*MockStorage)(nil).SaveDeviceIdentity), ctx, d)
+}
+
+// SaveJournalEntry mocks base method.
+func (m *MockStorage) SaveJournalEntry(ctx context.Context, e model.SyncJournalEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveJournalEntry", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveJournalEntry indicates an expected call of SaveJournalEntry.
+func (mr *MockStorageMockRecorder) SaveJournalEntry(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveJournalEntry", reflect.TypeOf((*MockStorage)(nil).SaveJournalEntry), ctx, e)
+}
+
+// SaveServerPin mocks base method.
+func (m *MockStorage) SaveServerPin(ctx context.Context, p model.ServerPin) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveServerPin", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveServerPin indicates an expected call of SaveServerPin.
+func (mr *MockStorageMockRecorder) SaveServerPin(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveServerPin", reflect.TypeOf((*MockStorage)(nil).SaveServerPin), ctx, p)
+}
+
+// SetSyncCursor mocks base method.
+func (m *MockStorage) SetSyncCursor(ctx context.Context, cursor int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSyncCursor", ctx, cursor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSyncCursor indicates an expected call of SetSyncCursor.
+func (mr *MockStorageMockRecorder) SetSyncCursor(ctx, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSyncCursor", reflect.TypeOf((*MockStorage)(nil).SetSyncCursor), ctx, cursor)
+}
+
+// SubscribeChanges mocks base method.
+func (m *MockStorage) SubscribeChanges() (<-chan model.LocalChange, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeChanges")
+	ret0, _ := ret[0].(<-chan model.LocalChange)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// SubscribeChanges indicates an expected call of SubscribeChanges.
+func (mr *MockStorageMockRecorder) SubscribeChanges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeChanges", reflect.TypeOf((*MockStorage)(nil).SubscribeChanges))
 }
 
 // UpdateSecret mocks base method.
-func (m *MockStorage) UpdateSecret(v model.Secret) error {
+func (m *MockStorage) UpdateSecret(ctx context.Context, v model.Secret) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSecret", v)
+	ret := m.ctrl.Call(m, "UpdateSecret", ctx, v)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateSecret indicates an expected call of UpdateSecret.
-func (mr *MockStorageMockRecorder) UpdateSecret(v interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) UpdateSecret(ctx, v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecret", reflect.TypeOf((*MockStorage)(nil).UpdateSecret), ctx, v)
+}
+
+// UpdateSecretWithRevision mocks base method.
+func (m *MockStorage) UpdateSecretWithRevision(ctx context.Context, v model.Secret, causeID, keep int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSecretWithRevision", ctx, v, causeID, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSecretWithRevision indicates an expected call of UpdateSecretWithRevision.
+func (mr *MockStorageMockRecorder) UpdateSecretWithRevision(ctx, v, causeID, keep interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecret", reflect.TypeOf((*MockStorage)(nil).UpdateSecret), v)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSecretWithRevision", reflect.TypeOf((*MockStorage)(nil).UpdateSecretWithRevision), ctx, v, causeID, keep)
 }
@@ -1,8 +1,11 @@
 package sqllte
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
@@ -28,8 +31,115 @@ CREATE TABLE IF NOT EXISTS secrets (
 	time_stamp INTEGER NOT NULL
   );`
 
+// migrations stores schema changes over secretsTbl,
+// migration with index i moves schema to version i+1, applied version is kept in user_version pragma.
+var migrations = []string{
+	`ALTER TABLE secrets ADD COLUMN folder TEXT NOT NULL DEFAULT '';
+	ALTER TABLE secrets ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+	CREATE TABLE IF NOT EXISTS import_batches (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		format TEXT NOT NULL,
+		source TEXT NOT NULL,
+		count INT NOT NULL,
+		time_stamp INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS import_items (
+		batch_id INTEGER NOT NULL,
+		secret_loc_id INTEGER NOT NULL,
+		PRIMARY KEY (batch_id, secret_loc_id)
+	);`,
+	`CREATE TABLE IF NOT EXISTS secret_revisions (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		secret_loc_id INTEGER NOT NULL,
+		cause_id INT NOT NULL,
+		type_id INT,
+		title TEXT NOT NULL,
+		description TEXT,
+		folder TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '',
+		secret_ver INT,
+		secret_data TEXT NOT NULL,
+		time_stamp INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS secret_revisions_secret ON secret_revisions (secret_loc_id);`,
+	`ALTER TABLE secrets ADD COLUMN trashed_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE secret_revisions ADD COLUMN trashed_at INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		seq INTEGER NOT NULL PRIMARY KEY,
+		time_stamp INTEGER NOT NULL,
+		operation_id INT NOT NULL,
+		secret_loc_id INTEGER NOT NULL,
+		secret_id UUID NOT NULL,
+		source TEXT NOT NULL,
+		details TEXT NOT NULL,
+		prev_hash TEXT NOT NULL UNIQUE,
+		hash TEXT NOT NULL
+	);
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+	CREATE TABLE IF NOT EXISTS audit_head (
+		id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+		seq INTEGER NOT NULL,
+		hash TEXT NOT NULL
+	);`,
+	`CREATE TABLE IF NOT EXISTS conflicts (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		secret_loc_id INTEGER NOT NULL UNIQUE,
+		secret_id UUID NOT NULL,
+		local_ver INT NOT NULL,
+		remote_ver INT NOT NULL,
+		remote_data TEXT NOT NULL,
+		time_stamp INTEGER NOT NULL
+	);`,
+	`ALTER TABLE secrets ADD COLUMN base_data TEXT NOT NULL DEFAULT '';
+	ALTER TABLE conflicts ADD COLUMN fields TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE IF NOT EXISTS sync_state (
+		id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+		cursor INTEGER NOT NULL
+	);`,
+	`CREATE TABLE IF NOT EXISTS sync_journal (
+		task_key TEXT NOT NULL PRIMARY KEY,
+		action_id INT NOT NULL,
+		secret_loc_id INTEGER NOT NULL,
+		secret_id UUID NOT NULL,
+		attempts INT NOT NULL,
+		last_error TEXT NOT NULL,
+		next_retry_at INTEGER NOT NULL,
+		parked INT NOT NULL,
+		time_stamp INTEGER NOT NULL
+	);`,
+	`ALTER TABLE secrets ADD COLUMN payload_id INT NOT NULL DEFAULT 0;
+	ALTER TABLE secrets ADD COLUMN size INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE secrets ADD COLUMN vector TEXT NOT NULL DEFAULT '';
+	ALTER TABLE conflicts ADD COLUMN remote_vector TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE secrets ADD COLUMN data_hash TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE IF NOT EXISTS server_pins (
+		host TEXT NOT NULL PRIMARY KEY,
+		pin TEXT NOT NULL,
+		time_stamp INTEGER NOT NULL
+	);`,
+	`CREATE TABLE IF NOT EXISTS device_identity (
+		id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+		device_id UUID NOT NULL,
+		key TEXT NOT NULL,
+		cert TEXT NOT NULL,
+		time_stamp INTEGER NOT NULL
+	);`,
+	`ALTER TABLE audit_head ADD COLUMN mac TEXT NOT NULL DEFAULT '';`,
+}
+
+// secretColumns is list of secrets table columns, in scanSecret order
+const secretColumns = "id, status_id, type_id, title, description, folder, tags, trashed_at, secret_id, secret_ver, secret_data, base_data, payload_id, size, vector, data_hash, time_stamp"
+
 type Storage struct {
-	db *sql.DB
+	db      *sql.DB
+	changes *changeNotifier
 }
 
 // NewStorage inits new connection to psql storage.
@@ -41,18 +151,49 @@ func NewStorage(file string) (*Storage, error) {
 	if _, err = db.Exec(secretsTbl); err != nil {
 		return nil, err
 	}
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
+	return &Storage{db: db, changes: newChangeNotifier()}, nil
+}
+
+// migrate applies not applied migrations
+func migrate(db *sql.DB) error {
+	var ver int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&ver); err != nil {
+		return err
+	}
+
+	for i := ver; i < len(migrations); i++ {
+		if _, err := db.Exec(migrations[i]); err != nil {
+			return fmt.Errorf("error apply storage migration %v: %w", i+1, err)
+		}
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			return err
+		}
+	}
 
-	return &Storage{db: db}, nil
+	return nil
 }
 
 // AddSecret adds new secret to storage
-func (s *Storage) AddSecret(v model.Secret) (int64, error) {
-	stmt, err := s.db.Prepare("INSERT INTO secrets(status_id, type_id, title, description, secret_id, secret_ver, secret_data, time_stamp) VALUES(?,?,?,?,?,?,?,?)")
+func (s *Storage) AddSecret(ctx context.Context, v model.Secret) (int64, error) {
+	tags, err := encodeTags(v.Tags)
+	if err != nil {
+		return 0, err
+	}
+	vector, err := encodeVector(v.Vector)
 	if err != nil {
 		return 0, err
 	}
 
-	r, err := stmt.Exec(v.StatusID, v.TypeID, v.Title, v.Description, v.SecretID, v.SecretVer, v.SecretData, pkg.MakeTimestamp())
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO secrets(status_id, type_id, title, description, folder, tags, trashed_at, secret_id, secret_ver, secret_data, base_data, payload_id, size, vector, data_hash, time_stamp) VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := stmt.ExecContext(ctx, v.StatusID, v.TypeID, v.Title, v.Description, v.Folder, tags, v.TrashedAt, v.SecretID, v.SecretVer, v.SecretData, v.BaseData, v.PayloadID, v.Size, vector, pkg.DataHash(v.SecretData), pkg.MakeTimestamp())
 	if err != nil {
 		return 0, err
 	}
@@ -61,24 +202,44 @@ func (s *Storage) AddSecret(v model.Secret) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	s.changes.publish(model.LocalChange{ID: id, StatusID: v.StatusID})
 
 	return id, nil
 }
 
 // UpdateSecret adds new secret to storage
-func (s *Storage) UpdateSecret(v model.Secret) error {
+func (s *Storage) UpdateSecret(ctx context.Context, v model.Secret) error {
+	if err := updateSecret(ctx, s.db, v); err != nil {
+		return err
+	}
+	s.changes.publish(model.LocalChange{ID: v.ID, StatusID: v.StatusID})
+
+	return nil
+}
+
+// execer is common interface of sql.DB and sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// updateSecret updates secret if it was not changed since read
+func updateSecret(ctx context.Context, db execer, v model.Secret) error {
 	query := `
 		UPDATE secrets
-		SET status_id = ?, type_id = ?, title=?, description=?, secret_id=?, secret_ver=?, secret_data=?,time_stamp=?
+		SET status_id = ?, type_id = ?, title=?, description=?, folder=?, tags=?, trashed_at=?, secret_id=?, secret_ver=?, secret_data=?, base_data=?, payload_id=?, size=?, vector=?, data_hash=?, time_stamp=?
 		WHERE id = ? AND time_stamp = ?;
 `
 
-	stmt, err := s.db.Prepare(query)
+	tags, err := encodeTags(v.Tags)
+	if err != nil {
+		return err
+	}
+	vector, err := encodeVector(v.Vector)
 	if err != nil {
 		return err
 	}
 
-	res, err := stmt.Exec(v.StatusID, v.TypeID, v.Title, v.Description, v.SecretID, v.SecretVer, v.SecretData, pkg.MakeTimestamp(), v.ID, v.TimeStamp)
+	res, err := db.ExecContext(ctx, query, v.StatusID, v.TypeID, v.Title, v.Description, v.Folder, tags, v.TrashedAt, v.SecretID, v.SecretVer, v.SecretData, v.BaseData, v.PayloadID, v.Size, vector, pkg.DataHash(v.SecretData), pkg.MakeTimestamp(), v.ID, v.TimeStamp)
 	if err != nil {
 		return err
 	}
@@ -93,15 +254,15 @@ func (s *Storage) UpdateSecret(v model.Secret) error {
 }
 
 // UpdateSecret adds new secret to storage
-func (s *Storage) DeleteSecret(id int64) error {
+func (s *Storage) DeleteSecret(ctx context.Context, id int64) error {
 	query := `DELETE FROM secrets WHERE id = ?`
 
-	stmt, err := s.db.Prepare(query)
+	stmt, err := s.db.PrepareContext(ctx, query)
 	if err != nil {
 		return err
 	}
 
-	res, err := stmt.Exec(id)
+	res, err := stmt.ExecContext(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -112,65 +273,71 @@ func (s *Storage) DeleteSecret(id int64) error {
 		return model.ErrorItemNotFound
 	}
 
+	//  revisions and conflicts are kept only for existing secrets
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM secret_revisions WHERE secret_loc_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM conflicts WHERE secret_loc_id = ?", id); err != nil {
+		return err
+	}
+	s.changes.publish(model.LocalChange{ID: id})
+
 	return nil
 }
 
 // GetSecret returns secret from storage
-func (s *Storage) GetSecret(id int64) (model.Secret, error) {
-	res := model.Secret{Info: model.Info{}}
-	if err := s.db.QueryRow(
-		"SELECT id, status_id, type_id, title, description, secret_id, secret_ver, secret_data, time_stamp FROM secrets WHERE id=@id",
+func (s *Storage) GetSecret(ctx context.Context, id int64) (model.Secret, error) {
+	return scanSecret(s.db.QueryRowContext(ctx,
+		"SELECT "+secretColumns+" FROM secrets WHERE id=@id",
 		sql.Named("id", id),
-	).Scan(
-		&res.ID,
-		&res.StatusID,
-		&res.TypeID,
-		&res.Title,
-		&res.Description,
-		&res.SecretID,
-		&res.SecretVer,
-		&res.SecretData,
-		&res.TimeStamp); err != nil {
-		if errors.Is(sql.ErrNoRows, err) {
-			return model.Secret{}, model.ErrorItemNotFound
-		}
-		return model.Secret{}, err
-	}
-
-	return res, nil
+	))
 }
 
-// GetSecret returns secret from storage
-func (s *Storage) GetSecretByExtID(extID uuid.UUID) (model.Secret, error) {
-	res := model.Secret{}
-	if err := s.db.QueryRow(
-		"SELECT id, status_id, type_id, title, description, secret_id, secret_ver, secret_data, time_stamp FROM secrets WHERE secret_id=@secret_id",
+// GetSecretByExtID returns secret from storage by server id
+func (s *Storage) GetSecretByExtID(ctx context.Context, extID uuid.UUID) (model.Secret, error) {
+	return scanSecret(s.db.QueryRowContext(ctx,
+		"SELECT "+secretColumns+" FROM secrets WHERE secret_id=@secret_id",
 		sql.Named("secret_id", extID),
-	).Scan(
-		&res.ID,
-		&res.StatusID,
-		&res.TypeID,
-		&res.Title,
-		&res.Description,
-		&res.SecretID,
-		&res.SecretVer,
-		&res.SecretData,
-		&res.TimeStamp); err != nil {
-		if errors.Is(sql.ErrNoRows, err) {
-			return model.Secret{}, model.ErrorItemNotFound
+	))
+}
+
+// GetSecretList returns all secrets, except deleted
+func (s *Storage) GetSecretList(ctx context.Context) ([]model.Secret, error) {
+	list := make([]model.Secret, 0)
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+secretColumns+" FROM secrets WHERE status_id <> ? ORDER BY id", model.SecretStatuses["DELETED"])
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
 		}
-		return model.Secret{}, err
+	}()
+
+	for rows.Next() {
+		el, err := scanSecret(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, el)
 	}
 
-	return res, nil
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
 }
 
 // GetMetaList returns array of meta info secrets
-func (s *Storage) GetMetaList() ([]model.SecretMeta, error) {
+func (s *Storage) GetMetaList(ctx context.Context) ([]model.SecretMeta, error) {
 	var list []model.SecretMeta
 
-	rows, err := s.db.Query(
-		"SELECT id, status_id, secret_id, secret_ver, time_stamp FROM secrets")
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, status_id, secret_id, secret_ver, payload_id, data_hash, time_stamp FROM secrets")
 
 	if err != nil {
 		return nil, err
@@ -183,7 +350,7 @@ func (s *Storage) GetMetaList() ([]model.SecretMeta, error) {
 
 	for rows.Next() {
 		var el model.SecretMeta
-		err = rows.Scan(&el.ID, &el.StatusID, &el.SecretID, &el.SecretVer, &el.TimeStamp)
+		err = rows.Scan(&el.ID, &el.StatusID, &el.SecretID, &el.SecretVer, &el.PayloadID, &el.DataHash, &el.TimeStamp)
 		if err != nil {
 			return nil, err
 		}
@@ -202,6 +369,96 @@ func (s *Storage) GetMetaList() ([]model.SecretMeta, error) {
 	return list, nil
 }
 
+// rowScanner is common interface of sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSecret reads secret selected with secretColumns
+func scanSecret(row rowScanner) (model.Secret, error) {
+	res := model.Secret{Info: model.Info{}}
+	var tags, vector string
+
+	if err := row.Scan(
+		&res.ID,
+		&res.StatusID,
+		&res.TypeID,
+		&res.Title,
+		&res.Description,
+		&res.Folder,
+		&tags,
+		&res.TrashedAt,
+		&res.SecretID,
+		&res.SecretVer,
+		&res.SecretData,
+		&res.BaseData,
+		&res.PayloadID,
+		&res.Size,
+		&vector,
+		&res.DataHash,
+		&res.TimeStamp); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Secret{}, model.ErrorItemNotFound
+		}
+		return model.Secret{}, err
+	}
+
+	if len(tags) > 0 {
+		if err := json.Unmarshal([]byte(tags), &res.Tags); err != nil {
+			return model.Secret{}, err
+		}
+	}
+	v, err := decodeVector(vector)
+	if err != nil {
+		return model.Secret{}, err
+	}
+	res.Vector = v
+
+	return res, nil
+}
+
+// encodeTags encodes tags or other string list to column value
+func encodeTags(tags []string) (string, error) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// encodeVector encodes version vector to column value, empty if secret has no vector
+func encodeVector(v model.VersionVector) (string, error) {
+	if len(v) == 0 {
+		return "", nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// decodeVector decodes version vector from column value
+func decodeVector(s string) (model.VersionVector, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	var v model.VersionVector
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
 // Close  closes database connection.
 func (s *Storage) Close() {
 	if s.db == nil {
@@ -0,0 +1,118 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// AddAuditEntry appends entry to audit log and moves log head to it, headMAC authenticates new head.
+// Entry must follow current head, seq is primary key and prev_hash is unique, so log can not fork.
+func (s *Storage) AddAuditEntry(ctx context.Context, e model.AuditEntry, headMAC string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Println(err.Error())
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log(seq, time_stamp, operation_id, secret_loc_id, secret_id, source, details, prev_hash, hash)
+		VALUES(?,?,?,?,?,?,?,?,?)`,
+		e.Seq, e.TimeStamp, e.OperationID, e.SecretLocID, e.SecretID, e.Source, e.Details, e.PrevHash, e.Hash)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO audit_head(id, seq, hash, mac) VALUES(1, ?, ?, ?)", e.Seq, e.Hash, headMAC); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAuditHead returns head of audit log, empty head if log is empty
+func (s *Storage) GetAuditHead(ctx context.Context) (model.AuditHead, error) {
+	var head model.AuditHead
+
+	err := s.db.QueryRowContext(ctx, "SELECT seq, hash, mac FROM audit_head WHERE id = 1").Scan(&head.Seq, &head.Hash, &head.MAC)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.AuditHead{}, nil
+	}
+	if err != nil {
+		return model.AuditHead{}, err
+	}
+
+	return head, nil
+}
+
+// GetAuditEntries returns audit entries matching filter, ordered by sequence number
+func (s *Storage) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	list := make([]model.AuditEntry, 0)
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	if filter.SecretLocID != 0 {
+		where = append(where, "secret_loc_id = ?")
+		args = append(args, filter.SecretLocID)
+	}
+	if filter.SecretID != uuid.Nil {
+		where = append(where, "secret_id = ?")
+		args = append(args, filter.SecretID)
+	}
+	if filter.OperationID != 0 {
+		where = append(where, "operation_id = ?")
+		args = append(args, filter.OperationID)
+	}
+	if filter.From != 0 {
+		where = append(where, "time_stamp >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To != 0 {
+		where = append(where, "time_stamp < ?")
+		args = append(args, filter.To)
+	}
+
+	query := "SELECT seq, time_stamp, operation_id, secret_loc_id, secret_id, source, details, prev_hash, hash FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY seq"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var el model.AuditEntry
+		if err := rows.Scan(&el.Seq, &el.TimeStamp, &el.OperationID, &el.SecretLocID, &el.SecretID,
+			&el.Source, &el.Details, &el.PrevHash, &el.Hash); err != nil {
+			return nil, err
+		}
+
+		list = append(list, el)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
+}
@@ -0,0 +1,57 @@
+package sqllte
+
+import (
+	"sync"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// changesBuffer is count of notifications kept for slow subscriber, next notifications are dropped
+const changesBuffer = 64
+
+// changeNotifier sends notifications of written secrets to subscribers
+type changeNotifier struct {
+	mu     sync.Mutex
+	subs   map[int]chan model.LocalChange
+	lastID int
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{subs: make(map[int]chan model.LocalChange)}
+}
+
+// SubscribeChanges returns channel of notifications of written secrets and function to unsubscribe
+func (s *Storage) SubscribeChanges() (<-chan model.LocalChange, func()) {
+	n := s.changes
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.lastID++
+	id := n.lastID
+	ch := make(chan model.LocalChange, changesBuffer)
+	n.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			delete(n.subs, id)
+			close(ch)
+		})
+	}
+}
+
+// publish sends notification to subscribers, notification is dropped for subscriber with full buffer
+func (n *changeNotifier) publish(change model.LocalChange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, ch := range n.subs {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
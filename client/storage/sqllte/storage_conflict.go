@@ -0,0 +1,161 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// conflictColumns is list of conflicts table columns, in scanConflict order
+const conflictColumns = "id, secret_loc_id, secret_id, local_ver, remote_ver, remote_data, remote_vector, fields, time_stamp"
+
+// AddConflict saves conflict of secret and marks secret as CONFLICT.
+// Secret has one conflict, new conflict of secret replaces saved one.
+func (s *Storage) AddConflict(ctx context.Context, c model.Conflict) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Println(err.Error())
+		}
+	}()
+
+	fields, err := encodeTags(c.Fields)
+	if err != nil {
+		return 0, err
+	}
+	vector, err := encodeVector(c.RemoteVector)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO conflicts(secret_loc_id, secret_id, local_ver, remote_ver, remote_data, remote_vector, fields, time_stamp)
+		VALUES(?,?,?,?,?,?,?,?)
+		ON CONFLICT(secret_loc_id) DO UPDATE SET
+			secret_id = excluded.secret_id,
+			local_ver = excluded.local_ver,
+			remote_ver = excluded.remote_ver,
+			remote_data = excluded.remote_data,
+			remote_vector = excluded.remote_vector,
+			fields = excluded.fields,
+			time_stamp = excluded.time_stamp`,
+		c.SecretLocID, c.SecretID, c.LocalVer, c.RemoteVer, c.RemoteData, vector, fields, pkg.MakeTimestamp())
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM conflicts WHERE secret_loc_id = ?", c.SecretLocID).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE secrets SET status_id = ? WHERE id = ?", model.SecretStatuses["CONFLICT"], c.SecretLocID)
+	if err != nil {
+		return 0, err
+	}
+	exists, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, model.ErrorItemNotFound
+	}
+
+	return id, tx.Commit()
+}
+
+// GetConflicts returns list of unresolved conflicts, oldest first
+func (s *Storage) GetConflicts(ctx context.Context) ([]model.Conflict, error) {
+	list := make([]model.Conflict, 0)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+conflictColumns+" FROM conflicts ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		el, err := scanConflict(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, el)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
+}
+
+// GetConflict returns conflict by id
+func (s *Storage) GetConflict(ctx context.Context, id int64) (model.Conflict, error) {
+	return scanConflict(s.db.QueryRowContext(ctx, "SELECT "+conflictColumns+" FROM conflicts WHERE id = ?", id))
+}
+
+// DeleteConflict deletes resolved conflict
+func (s *Storage) DeleteConflict(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM conflicts WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	exists, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return model.ErrorItemNotFound
+	}
+
+	return nil
+}
+
+// scanConflict reads conflict selected with conflictColumns
+func scanConflict(row rowScanner) (model.Conflict, error) {
+	res := model.Conflict{}
+	var fields, vector string
+
+	if err := row.Scan(
+		&res.ID,
+		&res.SecretLocID,
+		&res.SecretID,
+		&res.LocalVer,
+		&res.RemoteVer,
+		&res.RemoteData,
+		&vector,
+		&fields,
+		&res.TimeStamp); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Conflict{}, model.ErrorItemNotFound
+		}
+		return model.Conflict{}, err
+	}
+
+	if len(fields) > 0 {
+		if err := json.Unmarshal([]byte(fields), &res.Fields); err != nil {
+			return model.Conflict{}, err
+		}
+	}
+	v, err := decodeVector(vector)
+	if err != nil {
+		return model.Conflict{}, err
+	}
+	res.RemoteVector = v
+
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// SaveDeviceIdentity saves key and certificate of vault device, previous identity is replaced
+func (s *Storage) SaveDeviceIdentity(ctx context.Context, d model.DeviceIdentity) error {
+	if d.DeviceID == uuid.Nil || d.Key == "" {
+		return model.ErrorParamNotValid
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO device_identity(id, device_id, key, cert, time_stamp) VALUES(1,?,?,?,?)
+		ON CONFLICT(id) DO UPDATE SET device_id = excluded.device_id, key = excluded.key,
+			cert = excluded.cert, time_stamp = excluded.time_stamp`,
+		d.DeviceID, d.Key, d.Certificate, pkg.MakeTimestamp())
+
+	return err
+}
+
+// GetDeviceIdentity returns device identity of vault
+func (s *Storage) GetDeviceIdentity(ctx context.Context) (model.DeviceIdentity, error) {
+	res := model.DeviceIdentity{}
+
+	err := s.db.QueryRowContext(ctx, "SELECT device_id, key, cert, time_stamp FROM device_identity WHERE id = 1").
+		Scan(&res.DeviceID, &res.Key, &res.Certificate, &res.TimeStamp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.DeviceIdentity{}, model.ErrorItemNotFound
+	}
+	if err != nil {
+		return model.DeviceIdentity{}, err
+	}
+
+	return res, nil
+}
+
+// DeleteDeviceIdentity deletes device identity of vault
+func (s *Storage) DeleteDeviceIdentity(ctx context.Context) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM device_identity WHERE id = 1")
+	if err != nil {
+		return err
+	}
+
+	exists, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return model.ErrorItemNotFound
+	}
+
+	return nil
+}
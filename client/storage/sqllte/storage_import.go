@@ -0,0 +1,135 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// AddImportBatch adds import batch record with list of added secrets local ids
+func (s *Storage) AddImportBatch(ctx context.Context, b model.ImportBatch, ids []int64) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Println(err.Error())
+		}
+	}()
+
+	r, err := tx.ExecContext(ctx, "INSERT INTO import_batches(format, source, count, time_stamp) VALUES(?,?,?,?)",
+		b.Format, b.Source, len(ids), pkg.MakeTimestamp())
+	if err != nil {
+		return 0, err
+	}
+
+	batchID, err := r.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO import_items(batch_id, secret_loc_id) VALUES(?,?)")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if _, err := stmt.ExecContext(ctx, batchID, id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return batchID, nil
+}
+
+// GetImportBatches returns list of import batches
+func (s *Storage) GetImportBatches(ctx context.Context) ([]model.ImportBatch, error) {
+	list := make([]model.ImportBatch, 0)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, format, source, count, time_stamp FROM import_batches ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var el model.ImportBatch
+		if err := rows.Scan(&el.ID, &el.Format, &el.Source, &el.Count, &el.TimeStamp); err != nil {
+			return nil, err
+		}
+
+		list = append(list, el)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
+}
+
+// GetImportBatchItems returns local ids of secrets added by import batch
+func (s *Storage) GetImportBatchItems(ctx context.Context, batchID int64) ([]int64, error) {
+	list := make([]int64, 0)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT secret_loc_id FROM import_items WHERE batch_id = ? ORDER BY secret_loc_id", batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		list = append(list, id)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
+}
+
+// DeleteImportBatch deletes import batch record and its items
+func (s *Storage) DeleteImportBatch(ctx context.Context, batchID int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM import_batches WHERE id = ?", batchID)
+	if err != nil {
+		return err
+	}
+
+	exists, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if exists == 0 {
+		return model.ErrorItemNotFound
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM import_items WHERE batch_id = ?", batchID); err != nil {
+		return err
+	}
+
+	return nil
+}
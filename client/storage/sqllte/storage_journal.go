@@ -0,0 +1,113 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// journalColumns is list of sync_journal table columns, in scanJournalEntry order
+const journalColumns = "task_key, action_id, secret_loc_id, secret_id, attempts, last_error, next_retry_at, parked, time_stamp"
+
+// SaveJournalEntry saves state of failed sync task, entry of same task is replaced
+func (s *Storage) SaveJournalEntry(ctx context.Context, e model.SyncJournalEntry) error {
+	if e.TaskKey == "" {
+		return model.ErrorParamNotValid
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sync_journal(`+journalColumns+`)
+		VALUES(?,?,?,?,?,?,?,?,?)
+		ON CONFLICT(task_key) DO UPDATE SET
+			action_id = excluded.action_id,
+			secret_loc_id = excluded.secret_loc_id,
+			secret_id = excluded.secret_id,
+			attempts = excluded.attempts,
+			last_error = excluded.last_error,
+			next_retry_at = excluded.next_retry_at,
+			parked = excluded.parked,
+			time_stamp = excluded.time_stamp`,
+		e.TaskKey, e.ActionID, e.SecretLocID, e.SecretID, e.Attempts, e.LastError, e.NextRetryAt, e.Parked, pkg.MakeTimestamp())
+
+	return err
+}
+
+// GetJournalEntries returns states of failed sync tasks, oldest first
+func (s *Storage) GetJournalEntries(ctx context.Context) ([]model.SyncJournalEntry, error) {
+	list := make([]model.SyncJournalEntry, 0)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+journalColumns+" FROM sync_journal ORDER BY time_stamp, task_key")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		el, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, el)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
+}
+
+// GetJournalEntry returns state of failed sync task by task key
+func (s *Storage) GetJournalEntry(ctx context.Context, taskKey string) (model.SyncJournalEntry, error) {
+	return scanJournalEntry(s.db.QueryRowContext(ctx, "SELECT "+journalColumns+" FROM sync_journal WHERE task_key = ?", taskKey))
+}
+
+// DeleteJournalEntry deletes state of sync task
+func (s *Storage) DeleteJournalEntry(ctx context.Context, taskKey string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM sync_journal WHERE task_key = ?", taskKey)
+	if err != nil {
+		return err
+	}
+
+	exists, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return model.ErrorItemNotFound
+	}
+
+	return nil
+}
+
+// scanJournalEntry reads journal entry selected with journalColumns
+func scanJournalEntry(row rowScanner) (model.SyncJournalEntry, error) {
+	res := model.SyncJournalEntry{}
+
+	if err := row.Scan(
+		&res.TaskKey,
+		&res.ActionID,
+		&res.SecretLocID,
+		&res.SecretID,
+		&res.Attempts,
+		&res.LastError,
+		&res.NextRetryAt,
+		&res.Parked,
+		&res.TimeStamp); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.SyncJournalEntry{}, model.ErrorItemNotFound
+		}
+		return model.SyncJournalEntry{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+)
+
+// SaveServerPin saves pinned key of server host, pin of same host is replaced
+func (s *Storage) SaveServerPin(ctx context.Context, p model.ServerPin) error {
+	if p.Host == "" || p.Pin == "" {
+		return model.ErrorParamNotValid
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO server_pins(host, pin, time_stamp) VALUES(?,?,?)
+		ON CONFLICT(host) DO UPDATE SET pin = excluded.pin, time_stamp = excluded.time_stamp`,
+		p.Host, p.Pin, pkg.MakeTimestamp())
+
+	return err
+}
+
+// GetServerPin returns pinned key of server host
+func (s *Storage) GetServerPin(ctx context.Context, host string) (model.ServerPin, error) {
+	res := model.ServerPin{}
+
+	err := s.db.QueryRowContext(ctx, "SELECT host, pin, time_stamp FROM server_pins WHERE host = ?", host).
+		Scan(&res.Host, &res.Pin, &res.TimeStamp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ServerPin{}, model.ErrorItemNotFound
+	}
+	if err != nil {
+		return model.ServerPin{}, err
+	}
+
+	return res, nil
+}
+
+// DeleteServerPin deletes pinned key of server host
+func (s *Storage) DeleteServerPin(ctx context.Context, host string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM server_pins WHERE host = ?", host)
+	if err != nil {
+		return err
+	}
+
+	exists, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return model.ErrorItemNotFound
+	}
+
+	return nil
+}
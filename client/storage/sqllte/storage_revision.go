@@ -0,0 +1,128 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/Xrefullx/YanDip/client/model"
+)
+
+// revisionColumns is list of secret_revisions table columns, in scanRevision order
+const revisionColumns = "id, secret_loc_id, cause_id, type_id, title, description, folder, tags, trashed_at, secret_ver, secret_data, time_stamp"
+
+// UpdateSecretWithRevision saves current state of secret as revision, updates secret and removes old revisions.
+// Revision is not saved if secret data is not changed or keep is 0.
+func (s *Storage) UpdateSecretWithRevision(ctx context.Context, v model.Secret, causeID int, keep int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Println(err.Error())
+		}
+	}()
+
+	if keep > 0 {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO secret_revisions(secret_loc_id, cause_id, type_id, title, description, folder, tags, trashed_at, secret_ver, secret_data, time_stamp)
+			SELECT id, ?, type_id, title, description, folder, tags, trashed_at, secret_ver, secret_data, time_stamp
+			FROM secrets
+			WHERE id = ? AND time_stamp = ? AND (secret_data <> ? OR title <> ? OR description <> ? OR folder <> ?)`,
+			causeID, v.ID, v.TimeStamp, v.SecretData, v.Title, v.Description, v.Folder)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := updateSecret(ctx, tx, v); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM secret_revisions
+		WHERE secret_loc_id = ? AND id NOT IN (
+			SELECT id FROM secret_revisions WHERE secret_loc_id = ? ORDER BY id DESC LIMIT ?
+		)`, v.ID, v.ID, keep); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.changes.publish(model.LocalChange{ID: v.ID, StatusID: v.StatusID})
+
+	return nil
+}
+
+// GetRevisions returns revisions of secret, newest first
+func (s *Storage) GetRevisions(ctx context.Context, secretLocID int64) ([]model.Revision, error) {
+	list := make([]model.Revision, 0)
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM secret_revisions WHERE secret_loc_id = ? ORDER BY id DESC", secretLocID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		el, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, el)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return list, nil
+}
+
+// GetRevision returns revision by id
+func (s *Storage) GetRevision(ctx context.Context, id int64) (model.Revision, error) {
+	return scanRevision(s.db.QueryRowContext(ctx, "SELECT "+revisionColumns+" FROM secret_revisions WHERE id = ?", id))
+}
+
+// scanRevision reads revision selected with revisionColumns
+func scanRevision(row rowScanner) (model.Revision, error) {
+	res := model.Revision{}
+	var tags string
+
+	if err := row.Scan(
+		&res.ID,
+		&res.SecretLocID,
+		&res.CauseID,
+		&res.TypeID,
+		&res.Title,
+		&res.Description,
+		&res.Folder,
+		&tags,
+		&res.TrashedAt,
+		&res.SecretVer,
+		&res.SecretData,
+		&res.TimeStamp); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Revision{}, model.ErrorItemNotFound
+		}
+		return model.Revision{}, err
+	}
+
+	if len(tags) > 0 {
+		if err := json.Unmarshal([]byte(tags), &res.Tags); err != nil {
+			return model.Revision{}, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,31 @@
+package sqllte
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetSyncCursor returns server change feed cursor of last successful sync, 0 if not saved
+func (s *Storage) GetSyncCursor(ctx context.Context) (int64, error) {
+	var cursor int64
+
+	err := s.db.QueryRowContext(ctx, "SELECT cursor FROM sync_state WHERE id = 1").Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return cursor, nil
+}
+
+// SetSyncCursor saves server change feed cursor of successful sync
+func (s *Storage) SetSyncCursor(ctx context.Context, cursor int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sync_state(id, cursor) VALUES(1, ?)
+		ON CONFLICT(id) DO UPDATE SET cursor = excluded.cursor`, cursor)
+
+	return err
+}
@@ -1,7 +1,9 @@
 package sqllte
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/icrowley/fake"
@@ -16,11 +18,11 @@ func (s *TestSuite) TestStorage_UpdateSecret() {
 		toAdd := getMockSecret()
 
 		// add to storage
-		id, err := s.storage.AddSecret(toAdd)
+		id, err := s.storage.AddSecret(context.Background(), toAdd)
 		s.Require().NoError(err)
 
 		// read added
-		toAdd, err = s.storage.GetSecret(id)
+		toAdd, err = s.storage.GetSecret(context.Background(), id)
 		s.Require().NoError(err)
 
 		//  update field, except id and timestamp
@@ -29,11 +31,11 @@ func (s *TestSuite) TestStorage_UpdateSecret() {
 		toUpdate.TimeStamp = toAdd.TimeStamp
 
 		//  update
-		err = s.storage.UpdateSecret(toUpdate)
+		err = s.storage.UpdateSecret(context.Background(), toUpdate)
 		s.Require().NoError(err)
 
 		// read updated
-		dbUpdated, err := s.storage.GetSecret(id)
+		dbUpdated, err := s.storage.GetSecret(context.Background(), id)
 		s.Require().NoError(err)
 
 		s.Assert().EqualValues(dbUpdated.ID, toUpdate.ID)
@@ -51,11 +53,11 @@ func (s *TestSuite) TestStorage_UpdateSecret() {
 		toAdd := getMockSecret()
 
 		// add to storage
-		id, err := s.storage.AddSecret(toAdd)
+		id, err := s.storage.AddSecret(context.Background(), toAdd)
 		s.Require().NoError(err)
 
 		// read added
-		toAdd, err = s.storage.GetSecret(id)
+		toAdd, err = s.storage.GetSecret(context.Background(), id)
 		s.Require().NoError(err)
 
 		//  update field, except id and timestamp
@@ -64,7 +66,7 @@ func (s *TestSuite) TestStorage_UpdateSecret() {
 		toUpdate.TimeStamp = pkg.MakeTimestamp()
 
 		//  update
-		err = s.storage.UpdateSecret(toUpdate)
+		err = s.storage.UpdateSecret(context.Background(), toUpdate)
 		s.Require().Error(err)
 	})
 
@@ -72,7 +74,7 @@ func (s *TestSuite) TestStorage_UpdateSecret() {
 		secret1 := getMockSecret()
 		secret1.ID = 201
 
-		err := s.storage.UpdateSecret(secret1)
+		err := s.storage.UpdateSecret(context.Background(), secret1)
 		s.Require().Error(err)
 	})
 }
@@ -85,7 +87,7 @@ func (s *TestSuite) TestStorage_GetInfoList() {
 		for i := 0; i < count; i++ {
 			secret := getMockSecret()
 
-			id, err := s.storage.AddSecret(secret)
+			id, err := s.storage.AddSecret(context.Background(), secret)
 			s.Require().NoError(err)
 
 			secret.ID = id
@@ -93,7 +95,7 @@ func (s *TestSuite) TestStorage_GetInfoList() {
 			arrSecrets[i] = secret
 		}
 
-		infoArr, err := s.storage.GetMetaList()
+		infoArr, err := s.storage.GetMetaList(context.Background())
 		s.Require().NoError(err)
 
 		for i, el := range infoArr {
@@ -101,6 +103,7 @@ func (s *TestSuite) TestStorage_GetInfoList() {
 			s.Assert().EqualValues(arrSecrets[i].SecretID, el.SecretID)
 			s.Assert().EqualValues(arrSecrets[i].SecretVer, el.SecretVer)
 			s.Assert().EqualValues(arrSecrets[i].StatusID, el.StatusID)
+			s.Assert().EqualValues(pkg.DataHash(arrSecrets[i].SecretData), el.DataHash)
 			s.Assert().NotEmpty(el.TimeStamp)
 		}
 	})
@@ -110,10 +113,10 @@ func (s *TestSuite) TestStorage_Add_Get() {
 	s.runDropSecrets("Add and read secret", func() {
 		testSecret := getMockSecret()
 
-		id, err := s.storage.AddSecret(testSecret)
+		id, err := s.storage.AddSecret(context.Background(), testSecret)
 		s.Require().NoError(err)
 
-		dbSecret, err := s.storage.GetSecret(id)
+		dbSecret, err := s.storage.GetSecret(context.Background(), id)
 
 		s.Assert().EqualValues(testSecret.TypeID, dbSecret.TypeID)
 		s.Assert().EqualValues(testSecret.Title, dbSecret.Title)
@@ -122,12 +125,39 @@ func (s *TestSuite) TestStorage_Add_Get() {
 		s.Assert().EqualValues(testSecret.SecretVer, dbSecret.SecretVer)
 		s.Assert().EqualValues(testSecret.StatusID, dbSecret.StatusID)
 		s.Assert().EqualValues(testSecret.SecretData, dbSecret.SecretData)
+		s.Assert().EqualValues(testSecret.Folder, dbSecret.Folder)
+		s.Assert().EqualValues(testSecret.Tags, dbSecret.Tags)
+		s.Assert().EqualValues(testSecret.PayloadID, dbSecret.PayloadID)
+		s.Assert().EqualValues(testSecret.Size, dbSecret.Size)
+		s.Assert().EqualValues(testSecret.Vector, dbSecret.Vector)
+		s.Assert().EqualValues(pkg.DataHash(testSecret.SecretData), dbSecret.DataHash)
 
 		s.Assert().NotEmpty(dbSecret.TimeStamp)
 	})
 
+	s.runDropSecrets("Add placeholder and read meta", func() {
+		testSecret := getMockSecret()
+		testSecret.SecretData, testSecret.BaseData = "", ""
+		testSecret.PayloadID = model.PayloadStates["PLACEHOLDER"]
+		testSecret.Size = 1 << 20
+
+		id, err := s.storage.AddSecret(context.Background(), testSecret)
+		s.Require().NoError(err)
+
+		dbSecret, err := s.storage.GetSecret(context.Background(), id)
+		s.Require().NoError(err)
+		s.Assert().EqualValues(testSecret.PayloadID, dbSecret.PayloadID)
+		s.Assert().EqualValues(testSecret.Size, dbSecret.Size)
+		s.Assert().Empty(dbSecret.SecretData)
+
+		list, err := s.storage.GetMetaList(context.Background())
+		s.Require().NoError(err)
+		s.Require().Len(list, 1)
+		s.Assert().EqualValues(testSecret.PayloadID, list[0].PayloadID)
+	})
+
 	s.runDropSecrets("Get not exist", func() {
-		_, err := s.storage.GetSecret(564)
+		_, err := s.storage.GetSecret(context.Background(), 564)
 		s.Require().Error(err)
 		s.Require().True(errors.Is(err, model.ErrorItemNotFound))
 	})
@@ -139,8 +169,10 @@ func (s *TestSuite) runDropSecrets(name string, subtest func()) bool {
 }
 
 func (s *TestSuite) dropSecretsTable() {
-	_, err := s.storage.db.Exec("DELETE FROM Secrets")
-	s.Require().NoError(err)
+	for _, tbl := range []string{"secrets", "import_batches", "import_items", "secret_revisions", "conflicts"} {
+		_, err := s.storage.db.Exec("DELETE FROM " + tbl)
+		s.Require().NoError(err)
+	}
 }
 
 func getMockSecret() model.Secret {
@@ -149,10 +181,365 @@ func getMockSecret() model.Secret {
 			TypeID:      model.SecretTypes["CARD"],
 			Title:       fake.Company(),
 			Description: fake.CharactersN(200),
+			Folder:      fake.Word(),
+			Tags:        []string{fake.Word(), fake.Word()},
 		},
 		SecretID:   uuid.New(),
 		SecretVer:  1,
 		StatusID:   3,
 		SecretData: fake.CharactersN(2000),
+		BaseData:   fake.CharactersN(2000),
+		Size:       2000,
+		Vector:     model.VersionVector{uuid.NewString(): 2},
 	}
 }
+
+func (s *TestSuite) TestStorage_ImportBatch() {
+	s.runDropSecrets("Add, get and delete import batch", func() {
+		ids := []int64{3, 1, 2}
+
+		batchID, err := s.storage.AddImportBatch(context.Background(), model.ImportBatch{Format: "chrome", Source: "passwords.csv"}, ids)
+		s.Require().NoError(err)
+
+		list, err := s.storage.GetImportBatches(context.Background())
+		s.Require().NoError(err)
+		s.Require().Len(list, 1)
+		s.Assert().EqualValues(batchID, list[0].ID)
+		s.Assert().EqualValues("chrome", list[0].Format)
+		s.Assert().EqualValues("passwords.csv", list[0].Source)
+		s.Assert().EqualValues(len(ids), list[0].Count)
+		s.Assert().NotEmpty(list[0].TimeStamp)
+
+		items, err := s.storage.GetImportBatchItems(context.Background(), batchID)
+		s.Require().NoError(err)
+		s.Assert().Equal([]int64{1, 2, 3}, items)
+
+		s.Require().NoError(s.storage.DeleteImportBatch(context.Background(), batchID))
+
+		items, err = s.storage.GetImportBatchItems(context.Background(), batchID)
+		s.Require().NoError(err)
+		s.Assert().Empty(items)
+
+		err = s.storage.DeleteImportBatch(context.Background(), batchID)
+		s.Require().True(errors.Is(err, model.ErrorItemNotFound))
+	})
+}
+
+func (s *TestSuite) TestStorage_Revisions() {
+	s.runDropSecrets("Update with revision keeps last revisions", func() {
+		toAdd := getMockSecret()
+		id, err := s.storage.AddSecret(context.Background(), toAdd)
+		s.Require().NoError(err)
+
+		for i := 1; i <= 3; i++ {
+			secret, err := s.storage.GetSecret(context.Background(), id)
+			s.Require().NoError(err)
+
+			secret.SecretData = fmt.Sprintf("data %v", i)
+			s.Require().NoError(s.storage.UpdateSecretWithRevision(context.Background(), secret, model.RevisionCauses["LOCAL_EDIT"], 2))
+		}
+
+		//  same data, revision not saved
+		secret, err := s.storage.GetSecret(context.Background(), id)
+		s.Require().NoError(err)
+		s.Require().NoError(s.storage.UpdateSecretWithRevision(context.Background(), secret, model.RevisionCauses["DOWNLOAD"], 2))
+
+		list, err := s.storage.GetRevisions(context.Background(), id)
+		s.Require().NoError(err)
+		s.Require().Len(list, 2)
+		s.Assert().Equal("data 2", list[0].SecretData)
+		s.Assert().Equal("data 1", list[1].SecretData)
+		s.Assert().Equal(model.RevisionCauses["LOCAL_EDIT"], list[0].CauseID)
+		s.Assert().Equal(id, list[0].SecretLocID)
+		s.Assert().Equal(toAdd.Tags, list[0].Tags)
+
+		rev, err := s.storage.GetRevision(context.Background(), list[0].ID)
+		s.Require().NoError(err)
+		s.Assert().Equal(list[0], rev)
+
+		//  revisions are removed with secret
+		s.Require().NoError(s.storage.DeleteSecret(context.Background(), id))
+		list, err = s.storage.GetRevisions(context.Background(), id)
+		s.Require().NoError(err)
+		s.Assert().Empty(list)
+
+		_, err = s.storage.GetRevision(context.Background(), rev.ID)
+		s.Require().True(errors.Is(err, model.ErrorItemNotFound))
+	})
+
+	s.runDropSecrets("Update with revision wrong timestamp", func() {
+		id, err := s.storage.AddSecret(context.Background(), getMockSecret())
+		s.Require().NoError(err)
+
+		secret, err := s.storage.GetSecret(context.Background(), id)
+		s.Require().NoError(err)
+		secret.TimeStamp--
+		secret.SecretData = "changed"
+
+		err = s.storage.UpdateSecretWithRevision(context.Background(), secret, model.RevisionCauses["LOCAL_EDIT"], 2)
+		s.Require().True(errors.Is(err, model.ErrorItemNotFound))
+
+		list, err := s.storage.GetRevisions(context.Background(), id)
+		s.Require().NoError(err)
+		s.Assert().Empty(list)
+	})
+}
+
+func (s *TestSuite) TestStorage_AuditLog() {
+	s.Run("Append, query and protect audit log", func() {
+		head, err := s.storage.GetAuditHead(context.Background())
+		s.Require().NoError(err)
+		s.Require().Equal(model.AuditHead{}, head)
+
+		secretID := uuid.New()
+		entries := []model.AuditEntry{
+			{Seq: 1, TimeStamp: 100, OperationID: model.AuditOperations["ADD"], SecretLocID: 1, Source: "tui", PrevHash: "", Hash: "h1"},
+			{Seq: 2, TimeStamp: 200, OperationID: model.AuditOperations["SYNC_UPLOAD"], SecretLocID: 1, SecretID: secretID, Source: "tui", PrevHash: "h1", Hash: "h2"},
+			{Seq: 3, TimeStamp: 300, OperationID: model.AuditOperations["EXPORT"], Source: "backup", Details: "items: 1", PrevHash: "h2", Hash: "h3"},
+		}
+		for _, e := range entries {
+			s.Require().NoError(s.storage.AddAuditEntry(context.Background(), e, "mac"+e.Hash))
+		}
+
+		head, err = s.storage.GetAuditHead(context.Background())
+		s.Require().NoError(err)
+		s.Assert().Equal(model.AuditHead{Seq: 3, Hash: "h3", MAC: "mach3"}, head)
+
+		list, err := s.storage.GetAuditEntries(context.Background(), model.AuditFilter{})
+		s.Require().NoError(err)
+		s.Assert().Equal(entries, list)
+
+		list, err = s.storage.GetAuditEntries(context.Background(), model.AuditFilter{SecretLocID: 1, From: 150})
+		s.Require().NoError(err)
+		s.Assert().Equal(entries[1:2], list)
+
+		list, err = s.storage.GetAuditEntries(context.Background(), model.AuditFilter{OperationID: model.AuditOperations["EXPORT"], To: 1000})
+		s.Require().NoError(err)
+		s.Assert().Equal(entries[2:], list)
+
+		//  log can not fork, be changed or truncated
+		s.Require().Error(s.storage.AddAuditEntry(context.Background(), model.AuditEntry{Seq: 4, PrevHash: "h2", Hash: "x"}, ""))
+		s.Require().Error(s.storage.AddAuditEntry(context.Background(), model.AuditEntry{Seq: 3, PrevHash: "h3", Hash: "x"}, ""))
+
+		_, err = s.storage.db.Exec("UPDATE audit_log SET details = 'changed' WHERE seq = 1")
+		s.Require().Error(err)
+		_, err = s.storage.db.Exec("DELETE FROM audit_log WHERE seq = 3")
+		s.Require().Error(err)
+
+		head, err = s.storage.GetAuditHead(context.Background())
+		s.Require().NoError(err)
+		s.Assert().EqualValues(3, head.Seq)
+	})
+}
+
+func (s *TestSuite) TestStorage_Conflicts() {
+	s.runDropSecrets("Add, replace and delete conflict", func() {
+		toAdd := getMockSecret()
+		id, err := s.storage.AddSecret(context.Background(), toAdd)
+		s.Require().NoError(err)
+
+		c := model.Conflict{SecretLocID: id, SecretID: toAdd.SecretID, LocalVer: 1, RemoteVer: 2, RemoteData: "remote 2", Fields: []string{"password"}}
+		conflictID, err := s.storage.AddConflict(context.Background(), c)
+		s.Require().NoError(err)
+
+		secret, err := s.storage.GetSecret(context.Background(), id)
+		s.Require().NoError(err)
+		s.Assert().Equal(model.SecretStatuses["CONFLICT"], secret.StatusID)
+
+		//  new conflict of secret replaces saved
+		c.RemoteVer, c.RemoteData, c.RemoteVector = 3, "remote 3", model.VersionVector{"device": 3}
+		replacedID, err := s.storage.AddConflict(context.Background(), c)
+		s.Require().NoError(err)
+		s.Assert().Equal(conflictID, replacedID)
+
+		list, err := s.storage.GetConflicts(context.Background())
+		s.Require().NoError(err)
+		s.Require().Len(list, 1)
+		s.Assert().Equal(3, list[0].RemoteVer)
+		s.Assert().Equal("remote 3", list[0].RemoteData)
+		s.Assert().Equal(toAdd.SecretID, list[0].SecretID)
+		s.Assert().Equal([]string{"password"}, list[0].Fields)
+		s.Assert().Equal(c.RemoteVector, list[0].RemoteVector)
+
+		got, err := s.storage.GetConflict(context.Background(), conflictID)
+		s.Require().NoError(err)
+		s.Assert().Equal(list[0], got)
+
+		s.Require().NoError(s.storage.DeleteConflict(context.Background(), conflictID))
+		s.Require().True(errors.Is(s.storage.DeleteConflict(context.Background(), conflictID), model.ErrorItemNotFound))
+		_, err = s.storage.GetConflict(context.Background(), conflictID)
+		s.Require().True(errors.Is(err, model.ErrorItemNotFound))
+	})
+
+	s.runDropSecrets("Conflict of missing secret", func() {
+		_, err := s.storage.AddConflict(context.Background(), model.Conflict{SecretLocID: 100, SecretID: uuid.New(), RemoteData: "remote"})
+		s.Require().True(errors.Is(err, model.ErrorItemNotFound))
+
+		list, err := s.storage.GetConflicts(context.Background())
+		s.Require().NoError(err)
+		s.Assert().Empty(list)
+	})
+
+	s.runDropSecrets("Conflicts are removed with secret", func() {
+		id, err := s.storage.AddSecret(context.Background(), getMockSecret())
+		s.Require().NoError(err)
+
+		_, err = s.storage.AddConflict(context.Background(), model.Conflict{SecretLocID: id, SecretID: uuid.New(), RemoteData: "remote"})
+		s.Require().NoError(err)
+		s.Require().NoError(s.storage.DeleteSecret(context.Background(), id))
+
+		list, err := s.storage.GetConflicts(context.Background())
+		s.Require().NoError(err)
+		s.Assert().Empty(list)
+	})
+}
+
+func (s *TestSuite) TestStorage_SyncCursor() {
+	s.Run("Save and replace sync cursor", func() {
+		cursor, err := s.storage.GetSyncCursor(context.Background())
+		s.Require().NoError(err)
+		s.Require().Zero(cursor)
+
+		s.Require().NoError(s.storage.SetSyncCursor(context.Background(), 15))
+		s.Require().NoError(s.storage.SetSyncCursor(context.Background(), 27))
+
+		cursor, err = s.storage.GetSyncCursor(context.Background())
+		s.Require().NoError(err)
+		s.Assert().EqualValues(27, cursor)
+
+		//  cursor reset forces full sync
+		s.Require().NoError(s.storage.SetSyncCursor(context.Background(), 0))
+		cursor, err = s.storage.GetSyncCursor(context.Background())
+		s.Require().NoError(err)
+		s.Assert().Zero(cursor)
+	})
+}
+
+func (s *TestSuite) TestStorage_ServerPin() {
+	s.Run("Save, replace and delete server pin", func() {
+		ctx := context.Background()
+
+		_, err := s.storage.GetServerPin(ctx, "localhost")
+		s.Require().ErrorIs(err, model.ErrorItemNotFound)
+
+		s.Require().NoError(s.storage.SaveServerPin(ctx, model.ServerPin{Host: "localhost", Pin: "sha256/old"}))
+		s.Require().NoError(s.storage.SaveServerPin(ctx, model.ServerPin{Host: "localhost", Pin: "sha256/new"}))
+		s.Require().ErrorIs(s.storage.SaveServerPin(ctx, model.ServerPin{Host: "localhost"}), model.ErrorParamNotValid)
+
+		pin, err := s.storage.GetServerPin(ctx, "localhost")
+		s.Require().NoError(err)
+		s.Assert().Equal("sha256/new", pin.Pin)
+		s.Assert().NotZero(pin.TimeStamp)
+
+		s.Require().NoError(s.storage.DeleteServerPin(ctx, "localhost"))
+		s.Require().ErrorIs(s.storage.DeleteServerPin(ctx, "localhost"), model.ErrorItemNotFound)
+	})
+}
+
+func (s *TestSuite) TestStorage_DeviceIdentity() {
+	s.Run("Save, replace and delete device identity", func() {
+		ctx := context.Background()
+
+		_, err := s.storage.GetDeviceIdentity(ctx)
+		s.Require().ErrorIs(err, model.ErrorItemNotFound)
+
+		identity := model.DeviceIdentity{DeviceID: uuid.New(), Key: "key"}
+		s.Require().NoError(s.storage.SaveDeviceIdentity(ctx, identity))
+		s.Require().ErrorIs(s.storage.SaveDeviceIdentity(ctx, model.DeviceIdentity{DeviceID: identity.DeviceID}), model.ErrorParamNotValid)
+
+		//  certificate is saved to identity after it is issued
+		identity.Certificate = "cert"
+		s.Require().NoError(s.storage.SaveDeviceIdentity(ctx, identity))
+
+		stored, err := s.storage.GetDeviceIdentity(ctx)
+		s.Require().NoError(err)
+		s.Assert().Equal(identity.DeviceID, stored.DeviceID)
+		s.Assert().Equal("key", stored.Key)
+		s.Assert().Equal("cert", stored.Certificate)
+		s.Assert().NotZero(stored.TimeStamp)
+
+		s.Require().NoError(s.storage.DeleteDeviceIdentity(ctx))
+		s.Require().ErrorIs(s.storage.DeleteDeviceIdentity(ctx), model.ErrorItemNotFound)
+	})
+}
+
+func (s *TestSuite) TestStorage_SyncJournal() {
+	s.Run("Save, replace and delete journal entries", func() {
+		list, err := s.storage.GetJournalEntries(context.Background())
+		s.Require().NoError(err)
+		s.Require().Empty(list)
+
+		entry := model.SyncJournalEntry{
+			TaskKey:     "2:0:" + uuid.New().String(),
+			ActionID:    2,
+			SecretID:    uuid.New(),
+			Attempts:    1,
+			LastError:   "server error",
+			NextRetryAt: 100,
+		}
+		s.Require().NoError(s.storage.SaveJournalEntry(context.Background(), entry))
+
+		entry.Attempts, entry.Parked = 2, true
+		s.Require().NoError(s.storage.SaveJournalEntry(context.Background(), entry))
+
+		saved, err := s.storage.GetJournalEntry(context.Background(), entry.TaskKey)
+		s.Require().NoError(err)
+		s.Assert().NotZero(saved.TimeStamp)
+		entry.TimeStamp = saved.TimeStamp
+		s.Assert().Equal(entry, saved)
+
+		list, err = s.storage.GetJournalEntries(context.Background())
+		s.Require().NoError(err)
+		s.Assert().Len(list, 1)
+
+		s.Require().NoError(s.storage.DeleteJournalEntry(context.Background(), entry.TaskKey))
+		s.Assert().ErrorIs(s.storage.DeleteJournalEntry(context.Background(), entry.TaskKey), model.ErrorItemNotFound)
+		_, err = s.storage.GetJournalEntry(context.Background(), entry.TaskKey)
+		s.Assert().ErrorIs(err, model.ErrorItemNotFound)
+	})
+}
+
+func (s *TestSuite) TestStorage_Cancelled() {
+	s.runDropSecrets("Cancelled context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := s.storage.AddSecret(ctx, getMockSecret())
+		s.Require().ErrorIs(err, context.Canceled)
+
+		err = s.storage.UpdateSecretWithRevision(ctx, getMockSecret(), model.RevisionCauses["LOCAL_EDIT"], 1)
+		s.Require().ErrorIs(err, context.Canceled)
+
+		list, err := s.storage.GetSecretList(context.Background())
+		s.Require().NoError(err)
+		s.Require().Empty(list)
+	})
+}
+
+func (s *TestSuite) TestStorage_SubscribeChanges() {
+	s.runDropSecrets("Changes of secrets are published", func() {
+		changes, unsubscribe := s.storage.SubscribeChanges()
+		defer unsubscribe()
+
+		secret := getMockSecret()
+		id, err := s.storage.AddSecret(context.Background(), secret)
+		s.Require().NoError(err)
+
+		secret, err = s.storage.GetSecret(context.Background(), id)
+		s.Require().NoError(err)
+		secret.StatusID = model.SecretStatuses["EDITED"]
+		s.Require().NoError(s.storage.UpdateSecret(context.Background(), secret))
+
+		//  failed update is not published
+		s.Require().Error(s.storage.UpdateSecret(context.Background(), secret))
+
+		s.Require().NoError(s.storage.DeleteSecret(context.Background(), id))
+
+		s.Require().Equal([]model.LocalChange{
+			{ID: id, StatusID: getMockSecret().StatusID},
+			{ID: id, StatusID: model.SecretStatuses["EDITED"]},
+			{ID: id},
+		}, []model.LocalChange{<-changes, <-changes, <-changes})
+		s.Require().Empty(changes)
+	})
+}
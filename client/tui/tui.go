@@ -1,24 +1,37 @@
 package tui
 
 import (
+	"context"
+	"time"
+
 	"github.com/Xrefullx/YanDip/client/model"
 	"github.com/Xrefullx/YanDip/client/services"
 	"github.com/rivo/tview"
 )
 
+// operationTimeout limits time of every vault operation started from interface
+const operationTimeout = 10 * time.Second
+
 type TUI struct {
+	ctx           context.Context
 	app           *tview.Application
 	secretService services.SecretService
 }
 
-// NewTUI creates a new TUI instance
-func NewTUI(app *tview.Application, secretService services.SecretService) *TUI {
+// NewTUI creates a new TUI instance, operations are cancelled when ctx is done
+func NewTUI(ctx context.Context, app *tview.Application, secretService services.SecretService) *TUI {
 	return &TUI{
+		ctx:           ctx,
 		app:           app,
 		secretService: secretService,
 	}
 }
 
+// operation returns context of single vault operation
+func (t *TUI) operation() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(t.ctx, operationTimeout)
+}
+
 // SetQ sets up the TUI layout and starts the event loop
 func (t *TUI) SetQ() error {
 	hello := tview.NewTextView().SetText("Hello, world!")
@@ -32,30 +45,48 @@ func (t *TUI) SetQ() error {
 
 // AddAuth adds a new auth secret using the SecretService
 func (t *TUI) AddAuth(auth model.Auth) (int64, error) {
-	return t.secretService.AddAuth(auth)
+	ctx, cancel := t.operation()
+	defer cancel()
+
+	return t.secretService.AddAuth(ctx, auth)
 }
 
 // AddCard adds a new card secret using the SecretService
 func (t *TUI) AddCard(card model.Card) (int64, error) {
-	return t.secretService.AddCard(card)
+	ctx, cancel := t.operation()
+	defer cancel()
+
+	return t.secretService.AddCard(ctx, card)
 }
 
 // AddBinary adds a new binary secret using the SecretService
 func (t *TUI) AddBinary(filePath string, title string, description string) (int64, error) {
-	return t.secretService.AddBinary(filePath, title, description)
+	ctx, cancel := t.operation()
+	defer cancel()
+
+	return t.secretService.AddBinary(ctx, filePath, title, description)
 }
 
 // UpdateSecret updates a secret using the SecretService
 func (t *TUI) UpdateSecret(secret model.Secret) error {
-	return t.secretService.UpdateSecret(secret)
+	ctx, cancel := t.operation()
+	defer cancel()
+
+	return t.secretService.UpdateSecret(ctx, secret)
 }
 
 // DeleteSoftSecret soft deletes a secret using the SecretService
 func (t *TUI) DeleteSoftSecret(id int64) error {
-	return t.secretService.DeleteSoftSecret(id)
+	ctx, cancel := t.operation()
+	defer cancel()
+
+	return t.secretService.DeleteSoftSecret(ctx, id)
 }
 
 // GetSecret gets a secret using the SecretService
 func (t *TUI) GetSecret(id int64) (model.Secret, error) {
-	return t.secretService.GetSecret(id)
+	ctx, cancel := t.operation()
+	defer cancel()
+
+	return t.secretService.GetSecret(ctx, id)
 }
@@ -0,0 +1,982 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/client/importer"
+	"github.com/Xrefullx/YanDip/client/model"
+	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/provider"
+	"github.com/Xrefullx/YanDip/client/services"
+	"github.com/Xrefullx/YanDip/client/storage"
+)
+
+// commandEnv stores dependencies of client commands
+type commandEnv struct {
+	cfg      *pkg.Config
+	db       storage.Storage
+	secrets  *services.SecretService
+	audit    *services.AuditService
+	trust    *services.TrustService
+	provider provider.SecretProvider
+}
+
+// errUsage returned by command if arguments are wrong
+var errUsage = errors.New("wrong command arguments")
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env commandEnv, args []string) error
+}
+
+var commands = map[string]command{
+	"audit": {
+		usage: "audit [-secret id] [-op REVEAL|COPY|ADD|EDIT|TRASH|RESTORE|EXPORT|IMPORT|SYNC_UPLOAD|SYNC_DOWNLOAD|SYNC_DELETE] [-from RFC3339] [-to RFC3339]",
+		run:   cmdAudit,
+	},
+	"audit-verify": {
+		usage: "audit-verify",
+		run:   cmdAuditVerify,
+	},
+	"backup": {
+		usage: "backup -passphrase pass <file>",
+		run:   cmdBackup,
+	},
+	"backup-verify": {
+		usage: "backup-verify -passphrase pass <file>",
+		run:   cmdBackupVerify,
+	},
+	"trash": {
+		usage: "trash",
+		run:   cmdTrash,
+	},
+	"trash-restore": {
+		usage: "trash-restore <secret id>",
+		run:   cmdTrashRestore,
+	},
+	"trash-empty": {
+		usage: "trash-empty",
+		run:   cmdTrashEmpty,
+	},
+	"show": {
+		usage: "show [-reveal] <secret id>",
+		run:   cmdShow,
+	},
+	"restore": {
+		usage: "restore -passphrase pass [-mode new|merge] <file>",
+		run:   cmdRestore,
+	},
+	"conflicts": {
+		usage: "conflicts",
+		run:   cmdConflicts,
+	},
+	"conflict-resolve": {
+		usage: "conflict-resolve <conflict id> KEEP_LOCAL|KEEP_REMOTE|KEEP_BOTH",
+		run:   cmdConflictResolve,
+	},
+	"orphans": {
+		usage: "orphans",
+		run:   cmdOrphans,
+	},
+	"orphan-resolve": {
+		usage: "orphan-resolve <secret id> KEEP|DELETE",
+		run:   cmdOrphanResolve,
+	},
+	"sync-plan": {
+		usage: "sync-plan -login login -password pass [-device id] [-json] [-execute 1,2,...]",
+		run:   cmdSyncPlan,
+	},
+	"verify": {
+		usage: "verify -login login -password pass [-device id] [-dry-run]",
+		run:   cmdVerify,
+	},
+	"sync-journal": {
+		usage: "sync-journal",
+		run:   cmdSyncJournal,
+	},
+	"sync-retry": {
+		usage: "sync-retry -all | <task key>",
+		run:   cmdSyncRetry,
+	},
+	"repin": {
+		usage: "repin [-addr host:port] [-pin sha256/<base64>]",
+		run:   cmdRepin,
+	},
+	"devices": {
+		usage: "devices -login login -password pass [-revoke id]",
+		run:   cmdDevices,
+	},
+	"evict": {
+		usage: "evict [-size bytes]",
+		run:   cmdEvict,
+	},
+	"history": {
+		usage: "history <secret id>",
+		run:   cmdHistory,
+	},
+	"history-diff": {
+		usage: "history-diff [-reveal] <secret id> <from revision> [to revision, current if not set]",
+		run:   cmdHistoryDiff,
+	},
+	"history-restore": {
+		usage: "history-restore <secret id> <revision>",
+		run:   cmdHistoryRestore,
+	},
+	"import": {
+		usage: "import [-format auto|bitwarden|1password|1pux|chrome|firefox|edge|keepass] [-password pass] [-key-file file] [-dry-run] [-skip-duplicates] <file>",
+		run:   cmdImport,
+	},
+	"import-list": {
+		usage: "import-list",
+		run:   cmdImportList,
+	},
+	"import-rollback": {
+		usage: "import-rollback <batch id>",
+		run:   cmdImportRollback,
+	},
+}
+
+// runCommand runs client command, args[0] is command name
+func runCommand(ctx context.Context, env commandEnv, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage()
+		return fmt.Errorf("unknown command: %v", args[0])
+	}
+
+	if err := cmd.run(ctx, env, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return fmt.Errorf("%w, usage: %v", err, cmd.usage)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("commands:")
+	for _, name := range names {
+		fmt.Println("  " + commands[name].usage)
+	}
+}
+
+func cmdImport(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", string(importer.FormatAuto), "export file format")
+	dryRun := fs.Bool("dry-run", false, "show records and duplicates without import")
+	skipDuplicates := fs.Bool("skip-duplicates", false, "do not import duplicates")
+	password := fs.String("password", "", "password of encrypted database")
+	keyFile := fs.String("key-file", "", "key file of encrypted database")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	opts := importer.Options{
+		Format:   importer.Format(*format),
+		Password: *password,
+	}
+	if *keyFile != "" {
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return fmt.Errorf("error read key file: %w", err)
+		}
+		opts.KeyFile = data
+	}
+
+	svc := services.NewImportService(env.db, env.secrets)
+
+	if *dryRun {
+		preview, err := svc.Preview(ctx, fs.Arg(0), opts)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tTYPE\tTITLE\tFOLDER\tHISTORY\tDUPLICATE")
+		for _, el := range preview.Items {
+			dup := ""
+			if el.Duplicate {
+				dup = "yes"
+				if el.DuplicateOf != 0 {
+					dup = fmt.Sprintf("local id %v", el.DuplicateOf)
+				}
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", el.Index+1, typeName(el.TypeID), el.Title, el.Folder, el.History, dup)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("format: %v, records: %v, duplicates: %v\n", preview.Format, len(preview.Items), preview.Duplicates)
+		return nil
+	}
+
+	res, err := svc.Import(ctx, fs.Arg(0), opts, *skipDuplicates)
+	fmt.Printf("format: %v, added: %v, skipped: %v, batch: %v\n", res.Format, res.Added, res.Skipped, res.BatchID)
+
+	return err
+}
+
+func cmdImportList(ctx context.Context, env commandEnv, _ []string) error {
+	list, err := services.NewImportService(env.db, env.secrets).Batches(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "BATCH\tFORMAT\tSOURCE\tCOUNT\tTIME")
+	for _, el := range list {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", el.ID, el.Format, el.Source, el.Count, formatTimestamp(el.TimeStamp))
+	}
+
+	return w.Flush()
+}
+
+func cmdImportRollback(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	batchID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("wrong batch id: %w", err)
+	}
+
+	return services.NewImportService(env.db, env.secrets).Rollback(ctx, batchID)
+}
+
+func cmdBackup(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	passphrase := fs.String("passphrase", "", "backup passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *passphrase == "" {
+		return errUsage
+	}
+
+	count, err := services.NewBackupService(env.db, env.secrets).Export(ctx, fs.Arg(0), *passphrase)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("backup saved: %v, items: %v\n", fs.Arg(0), count)
+	return nil
+}
+
+func cmdBackupVerify(_ context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("backup-verify", flag.ContinueOnError)
+	passphrase := fs.String("passphrase", "", "backup passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *passphrase == "" {
+		return errUsage
+	}
+
+	bundle, err := services.NewBackupService(env.db, env.secrets).Verify(fs.Arg(0), *passphrase)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("backup is valid, version: %v, created: %v, client: %v, items: %v\n",
+		bundle.Version, formatTimestamp(bundle.CreatedAt), bundle.Client, len(bundle.Items))
+	return nil
+}
+
+func cmdRestore(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	passphrase := fs.String("passphrase", "", "backup passphrase")
+	mode := fs.String("mode", string(services.RestoreMerge), "restore mode, new adds all items, merge skips existing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *passphrase == "" {
+		return errUsage
+	}
+
+	res, err := services.NewBackupService(env.db, env.secrets).Restore(ctx, fs.Arg(0), *passphrase, services.RestoreMode(*mode))
+	fmt.Printf("added: %v, updated: %v, skipped: %v\n", res.Added, res.Updated, res.Skipped)
+
+	return err
+}
+
+func cmdHistory(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	list, err := env.secrets.Revisions(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tCAUSE\tTITLE\tVERSION\tTIME")
+	for _, el := range list {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", el.ID, causeName(el.CauseID), el.Title, el.SecretVer, formatTimestamp(el.TimeStamp))
+	}
+
+	return w.Flush()
+}
+
+func cmdHistoryDiff(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("history-diff", flag.ContinueOnError)
+	reveal := fs.Bool("reveal", false, "show field values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 && fs.NArg() != 3 {
+		return errUsage
+	}
+
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+	ids = append(ids, 0)
+
+	changes, err := env.secrets.DiffRevisions(ctx, ids[0], ids[1], ids[2])
+	if err != nil {
+		return err
+	}
+	if *reveal {
+		if err := env.audit.Record(ctx, model.AuditOperations["REVEAL"], ids[0], uuid.Nil, "history diff"); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tOLD\tNEW")
+	for _, el := range changes {
+		if !*reveal {
+			el.Old, el.New = maskValue(el.Old), maskValue(el.New)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\n", el.Field, el.Old, el.New)
+	}
+
+	return w.Flush()
+}
+
+func cmdHistoryRestore(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	return env.secrets.RestoreRevision(ctx, ids[0], ids[1])
+}
+
+func cmdConflicts(ctx context.Context, env commandEnv, _ []string) error {
+	list, err := services.NewConflictService(env.cfg, env.db).Conflicts(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSECRET\tTITLE\tLOCAL VERSION\tREMOTE VERSION\tFIELDS\tTIME")
+	for _, el := range list {
+		title := ""
+		if secret, err := env.secrets.GetSecret(ctx, el.SecretLocID); err == nil {
+			title = secret.Title
+		}
+		fields := strings.Join(el.Fields, ", ")
+		if fields == "" {
+			fields = "all"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", el.ID, el.SecretLocID, title, el.LocalVer, el.RemoteVer, fields, formatTimestamp(el.TimeStamp))
+	}
+
+	return w.Flush()
+}
+
+func cmdConflictResolve(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	ids, err := parseIDs(args[:1])
+	if err != nil {
+		return err
+	}
+
+	return services.NewConflictService(env.cfg, env.db).Resolve(ctx, ids[0], args[1])
+}
+
+func cmdOrphans(ctx context.Context, env commandEnv, _ []string) error {
+	list, err := env.secrets.Orphans(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tTITLE\tREMOTE ID\tVERSION")
+	for _, el := range list {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", el.ID, typeName(el.TypeID), el.Title, el.SecretID, el.SecretVer)
+	}
+
+	return w.Flush()
+}
+
+func cmdOrphanResolve(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 2 || (args[1] != "KEEP" && args[1] != "DELETE") {
+		return errUsage
+	}
+
+	ids, err := parseIDs(args[:1])
+	if err != nil {
+		return err
+	}
+
+	return env.secrets.ResolveOrphan(ctx, ids[0], args[1] == "KEEP")
+}
+
+func cmdSyncPlan(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("sync-plan", flag.ContinueOnError)
+	login := fs.String("login", "", "server login")
+	password := fs.String("password", "", "server password")
+	device := fs.String("device", "", "device id, new if not set")
+	asJSON := fs.Bool("json", false, "print plan as json")
+	execute := fs.String("execute", "", "comma separated numbers of plan items to sync")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *login == "" || *password == "" {
+		return errUsage
+	}
+
+	if err := authorise(ctx, env, *login, *password, *device); err != nil {
+		return err
+	}
+
+	svc := services.NewSyncService(env.db, env.provider, env.cfg)
+	svc.SetAuditor(env.audit)
+
+	plan, err := svc.Plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *execute != "" {
+		nums, err := parseIDs(strings.Split(*execute, ","))
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+
+		indexes := make([]int, 0, len(nums))
+		for _, el := range nums {
+			indexes = append(indexes, int(el)-1)
+		}
+
+		failed, err := svc.ExecutePlan(ctx, plan, indexes)
+		fmt.Printf("processed: %v, failed: %v\n", len(indexes), failed)
+
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tACTION\tSECRET\tTITLE\tLOCAL VERSION\tREMOTE VERSION")
+	for i, el := range plan.Items {
+		remote := strconv.Itoa(el.RemoteVer)
+		if el.RemoteDeleted {
+			remote += " deleted"
+		}
+		title := el.Title
+		if el.Error != "" {
+			title = "error: " + el.Error
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", i+1, el.Action, el.SecretLocID, title, el.LocalVer, remote)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(plan.Totals))
+	for name := range plan.Totals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	totals := make([]string, 0, len(names))
+	for _, name := range names {
+		totals = append(totals, fmt.Sprintf("%v: %v", name, plan.Totals[name]))
+	}
+	fmt.Printf("tasks: %v\n", len(plan.Items))
+	if len(totals) > 0 {
+		fmt.Println(strings.Join(totals, ", "))
+	}
+
+	return nil
+}
+
+// authorise authorises provider as device, vault device is used if device is not set
+func authorise(ctx context.Context, env commandEnv, login string, password string, device string) error {
+	deviceID, err := env.trust.DeviceID(ctx)
+	if err != nil {
+		return err
+	}
+	if device != "" {
+		id, err := uuid.Parse(device)
+		if err != nil {
+			return fmt.Errorf("%w: wrong device id: %v", errUsage, err)
+		}
+		deviceID = id
+	}
+
+	return env.provider.Authorise(ctx, login, password, pkg.MasterHash(env.cfg.MasterKey), deviceID)
+}
+
+func cmdVerify(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	login := fs.String("login", "", "server login")
+	password := fs.String("password", "", "server password")
+	device := fs.String("device", "", "device id, new if not set")
+	dryRun := fs.Bool("dry-run", false, "only report damaged secrets")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *login == "" || *password == "" {
+		return errUsage
+	}
+
+	if err := authorise(ctx, env, *login, *password, *device); err != nil {
+		return err
+	}
+
+	svc := services.NewSyncService(env.db, env.provider, env.cfg)
+	svc.SetAuditor(env.audit)
+
+	report, verr := svc.Verify(ctx, !*dryRun)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tSECRET")
+	for _, el := range report.Tasks {
+		fmt.Fprintf(w, "%v\t%v\n", actionName(el.ActionID), el.SecretId)
+	}
+	for _, el := range report.Unrecoverable {
+		fmt.Fprintf(w, "%v\t%v\n", "UNRECOVERABLE", el)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("checked: %v, local damaged: %v, to repair: %v, failed: %v, unrecoverable: %v\n",
+		report.Checked, report.LocalDamaged, len(report.Tasks), report.Failed, len(report.Unrecoverable))
+
+	return verr
+}
+
+func cmdSyncJournal(ctx context.Context, env commandEnv, _ []string) error {
+	list, err := services.NewSyncJournalService(env.db).Entries(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TASK\tACTION\tSECRET\tATTEMPTS\tSTATE\tNEXT RETRY\tERROR")
+	for _, el := range list {
+		state, next := "retry", formatTimestamp(el.NextRetryAt)
+		if el.Parked {
+			state, next = "parked", ""
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", el.TaskKey, actionName(el.ActionID), el.SecretLocID, el.Attempts, state, next, el.LastError)
+	}
+
+	return w.Flush()
+}
+
+func cmdSyncRetry(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("sync-retry", flag.ContinueOnError)
+	all := fs.Bool("all", false, "reset all failed tasks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	svc := services.NewSyncJournalService(env.db)
+	switch {
+	case *all && fs.NArg() == 0:
+		count, err := svc.RetryAll(ctx)
+		fmt.Printf("reset: %v\n", count)
+		return err
+	case !*all && fs.NArg() == 1:
+		return svc.Retry(ctx, fs.Arg(0))
+	}
+
+	return errUsage
+}
+
+func cmdRepin(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("repin", flag.ContinueOnError)
+	addr := fs.String("addr", "", "server address host:port, server of config if not set")
+	expected := fs.String("pin", "", "expected key pin, confirmed out of band")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || (*expected != "" && !pkg.IsSPKIPin(*expected)) {
+		return errUsage
+	}
+
+	if *addr == "" {
+		var err error
+		if *addr, err = serverAddr(env.cfg); err != nil {
+			return err
+		}
+	}
+
+	prev, pin, err := env.trust.Repin(ctx, *addr, *expected)
+	if err != nil {
+		return err
+	}
+	if prev == "" {
+		prev = "none"
+	}
+
+	fmt.Printf("server: %v\nprevious key: %v\npinned key: %v\n", *addr, prev, pin)
+	if *expected == "" {
+		fmt.Println("key is not confirmed, compare it with key of server administrator")
+	}
+
+	return nil
+}
+
+func cmdDevices(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("devices", flag.ContinueOnError)
+	login := fs.String("login", "", "server login")
+	password := fs.String("password", "", "server password")
+	revoke := fs.String("revoke", "", "id of device to revoke")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *login == "" || *password == "" {
+		return errUsage
+	}
+
+	if err := authorise(ctx, env, *login, *password, ""); err != nil {
+		return err
+	}
+
+	if *revoke != "" {
+		id, err := uuid.Parse(*revoke)
+		if err != nil {
+			return fmt.Errorf("%w: wrong device id: %v", errUsage, err)
+		}
+		if err := env.provider.RevokeDevice(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("device %v revoked\n", id)
+	}
+
+	list, err := env.provider.GetDevices(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tENROLLED\tREVOKED\t")
+	for _, el := range list {
+		revoked := "-"
+		if el.RevokedAt != 0 {
+			revoked = time.UnixMilli(el.RevokedAt).Format(time.RFC3339)
+		}
+		id := el.ID.String()
+		if el.Current {
+			id += " (this)"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t\n", id, time.UnixMilli(el.CreatedAt).Format(time.RFC3339), revoked)
+	}
+
+	return w.Flush()
+}
+
+// serverAddr returns host:port of server of config transport, port of url is default port of its scheme if not set
+func serverAddr(cfg *pkg.Config) (string, error) {
+	if cfg.Transport == "GRPC" {
+		return cfg.GRPCAddress, nil
+	}
+
+	u, err := url.Parse(cfg.ServerURL)
+	if err != nil {
+		return "", fmt.Errorf("wrong server url: %w", err)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+
+	//  default port of url scheme
+	switch u.Scheme {
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	case "http":
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	default:
+		return "", fmt.Errorf("wrong server url: unsupported scheme %q", u.Scheme)
+	}
+}
+
+func cmdEvict(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("evict", flag.ContinueOnError)
+	size := fs.Int("size", 0, "binary secrets over size in bytes are evicted, lazy size from config if not set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *size < 0 {
+		return errUsage
+	}
+
+	svc := services.NewSyncService(env.db, env.provider, env.cfg)
+	svc.SetAuditor(env.audit)
+
+	count, err := svc.Evict(ctx, *size)
+	fmt.Printf("evicted: %v\n", count)
+
+	return err
+}
+
+func cmdTrash(ctx context.Context, env commandEnv, _ []string) error {
+	list, err := env.secrets.Trash(ctx)
+	if err != nil {
+		return err
+	}
+
+	retention := time.Duration(env.cfg.TrashRetentionDays) * 24 * time.Hour
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tTITLE\tTRASHED\tDELETED AFTER")
+	for _, el := range list {
+		expires := time.UnixMilli(el.TrashedAt).Add(retention).Format(time.RFC3339)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", el.ID, typeName(el.TypeID), el.Title, formatTimestamp(el.TrashedAt), expires)
+	}
+
+	return w.Flush()
+}
+
+func cmdTrashRestore(ctx context.Context, env commandEnv, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	return env.secrets.RestoreFromTrash(ctx, ids[0])
+}
+
+func cmdTrashEmpty(ctx context.Context, env commandEnv, _ []string) error {
+	count, err := env.secrets.EmptyTrash(ctx)
+	fmt.Printf("deleted: %v\n", count)
+
+	return err
+}
+
+func cmdShow(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	reveal := fs.Bool("reveal", false, "show field values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	secret, err := env.secrets.GetSecret(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("id: %v\ntype: %v\ntitle: %v\ndescription: %v\nfolder: %v\n",
+		secret.ID, typeName(secret.TypeID), secret.Title, secret.Description, secret.Folder)
+	if !*reveal {
+		return nil
+	}
+
+	obj, err := env.secrets.RevealSecret(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+
+	return nil
+}
+
+func cmdAudit(ctx context.Context, env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	secretID := fs.Int64("secret", 0, "local secret id")
+	op := fs.String("op", "", "operation name")
+	from := fs.String("from", "", "entries from time, RFC3339")
+	to := fs.String("to", "", "entries before time, RFC3339")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	filter := model.AuditFilter{SecretLocID: *secretID}
+	if *op != "" {
+		opID, ok := model.AuditOperations[*op]
+		if !ok {
+			return fmt.Errorf("%w: unknown operation %v", errUsage, *op)
+		}
+		filter.OperationID = opID
+	}
+	for _, el := range []struct {
+		value string
+		res   *int64
+	}{{*from, &filter.From}, {*to, &filter.To}} {
+		if el.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, el.value)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+		*el.res = t.UnixMilli()
+	}
+
+	list, err := env.audit.Query(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SEQ\tTIME\tOPERATION\tSECRET\tSOURCE\tDETAILS")
+	for _, el := range list {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			el.Seq, formatTimestamp(el.TimeStamp), operationName(el.OperationID), el.SecretLocID, el.Source, el.Details)
+	}
+
+	return w.Flush()
+}
+
+func cmdAuditVerify(ctx context.Context, env commandEnv, _ []string) error {
+	count, err := env.audit.Verify(ctx)
+	fmt.Printf("verified entries: %v\n", count)
+
+	return err
+}
+
+// parseIDs parses list of ids
+func parseIDs(args []string) ([]int64, error) {
+	res := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong id %q: %w", arg, err)
+		}
+		res = append(res, id)
+	}
+
+	return res, nil
+}
+
+// maskValue hides value, keeps only mark of it existence
+func maskValue(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	return "******"
+}
+
+// causeName returns revision cause name by id
+func causeName(causeID int) string {
+	for name, id := range model.RevisionCauses {
+		if id == causeID {
+			return name
+		}
+	}
+
+	return strconv.Itoa(causeID)
+}
+
+// operationName returns audit operation name by id
+func operationName(operationID int) string {
+	for name, id := range model.AuditOperations {
+		if id == operationID {
+			return name
+		}
+	}
+
+	return strconv.Itoa(operationID)
+}
+
+// actionName returns sync action name by id
+func actionName(actionID int) string {
+	for name, id := range services.SyncActions {
+		if id == actionID {
+			return name
+		}
+	}
+
+	return strconv.Itoa(actionID)
+}
+
+// typeName returns secret type name by id
+func typeName(typeID int) string {
+	for name, id := range model.SecretTypes {
+		if id == typeID {
+			return name
+		}
+	}
+
+	return strconv.Itoa(typeID)
+}
+
+// formatTimestamp formats timestamp in milliseconds
+func formatTimestamp(ts int64) string {
+	return time.UnixMilli(ts).Format(time.RFC3339)
+}
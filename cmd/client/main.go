@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/rivo/tview"
 
 	"github.com/Xrefullx/YanDip/client/model"
 	"github.com/Xrefullx/YanDip/client/pkg"
+	"github.com/Xrefullx/YanDip/client/provider"
+	"github.com/Xrefullx/YanDip/client/provider/grpc"
 	"github.com/Xrefullx/YanDip/client/provider/http"
 	"github.com/Xrefullx/YanDip/client/services"
 	"github.com/Xrefullx/YanDip/client/storage/sqllte"
@@ -28,35 +34,124 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	provCfg := http.HTTPConfig{
-		AuthURL:     "/api/user/login",
-		RegisterURL: "/api/user/register",
-		SecretURL:   "/api/secret",
-		SyncListURL: "/api/sync",
-		PingURL:     "/api/ping",
-		BaseURL:     cfg.ServerURL,
-		Timeout:     time.Millisecond * 500,
-	}
 	db, err := sqllte.NewStorage(cfg.StorageFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
-	provider := http.NewHTTPProvider(provCfg)
+	secretService := services.NewSecret(cfg, db)
+
+	//  audit entries are marked with command name, or tui for interface
+	auditSource := "tui"
+	if flag.NArg() > 0 {
+		auditSource = flag.Arg(0)
+	}
+	svcAudit := services.NewAuditService(cfg, db, auditSource)
+	secretService.SetAuditor(svcAudit)
+
+	svcTrust := services.NewTrustService(cfg, db)
+	addr, err := serverAddr(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tlsConfig, err := svcTrust.TLSConfig(host)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	provider, closeProvider, err := newProvider(cfg, tlsConfig, svcTrust)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeProvider()
+
+	//  interrupt cancels running command or stops sync and interface
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	//  run command if set, instead of interface
+	if flag.NArg() > 0 {
+		env := commandEnv{cfg: cfg, db: db, secrets: &secretService, audit: svcAudit, trust: svcTrust, provider: provider}
+		if err := runCommand(ctx, env, flag.Args()); err != nil {
+			db.Close()
+			log.Fatal(err)
+		}
+		return
+	}
+
 	svcSync := services.NewSyncService(db, provider, cfg)
-	if err := svcSync.Run(context.Background()); err != nil {
+	svcSync.SetAuditor(svcAudit)
+	secretService.SetFetcher(svcSync)
+	if err := svcSync.Run(ctx); err != nil {
 		log.Fatal(err)
 	}
-	secretService := services.NewSecret(cfg, db)
 
 	app := tview.NewApplication()
-	tui := tui.NewTUI(app, secretService)
+	tui := tui.NewTUI(ctx, app, secretService)
 
 	if err := tui.SetQ(); err != nil {
 		log.Fatalf("Failed to set queue: %v", err)
 	}
 
-	sigc := make(chan os.Signal, 1)
-	signal.Notify(sigc, os.Interrupt)
-	<-sigc
+	<-ctx.Done()
+
+	//  running sync jobs are finished before storage is closed
+	svcSync.Wait()
+}
+
+// newProvider returns provider of transport set in config, returned func closes provider connection.
+// Device certificate is requested on login of device with identity, certificates are used only with TLS.
+func newProvider(cfg *pkg.Config, tlsConfig *tls.Config, identity provider.DeviceIdentity) (provider.SecretProvider, func(), error) {
+	//  server serves gRPC with TLS if https is enabled
+	enableTLS := strings.HasPrefix(cfg.ServerURL, "https://")
+
+	if cfg.Transport == "GRPC" {
+		p, err := grpc.NewGRPCProvider(grpc.GRPCConfig{
+			Address:   cfg.GRPCAddress,
+			EnableTLS: enableTLS,
+			TLSConfig: tlsConfig,
+			Timeout:   time.Millisecond * 500,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if enableTLS {
+			p.SetIdentity(identity)
+		}
+
+		return p, func() {
+			if err := p.Close(); err != nil {
+				log.Printf("error close grpc connection: %v", err)
+			}
+		}, nil
+	}
+
+	provCfg := http.HTTPConfig{
+		AuthURL:          "/api/user/login",
+		RegisterURL:      "/api/user/register",
+		RefreshURL:       "/api/user/refresh",
+		SecretURL:        "/api/secret",
+		BatchUploadURL:   "/api/secrets/upload",
+		BatchDownloadURL: "/api/secrets/download",
+		SyncListURL:      "/api/sync",
+		ChangesURL:       "/api/sync/changes",
+		EventsURL:        "/api/sync/events",
+		PingURL:          "/api/ping",
+		DevicesURL:       "/api/devices",
+		DeviceURL:        "/api/device",
+		BaseURL:          cfg.ServerURL,
+		Timeout:          time.Millisecond * 500,
+		TLSConfig:        tlsConfig,
+	}
+
+	p := http.NewHTTPProvider(provCfg)
+	if enableTLS {
+		p.SetIdentity(identity)
+	}
+
+	return p, func() {}, nil
 }
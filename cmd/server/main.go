@@ -15,8 +15,11 @@ import (
 	"github.com/Xrefullx/YanDip/server/api"
 	"github.com/Xrefullx/YanDip/server/pkg"
 	"github.com/Xrefullx/YanDip/server/services/auth"
+	"github.com/Xrefullx/YanDip/server/services/device"
+	"github.com/Xrefullx/YanDip/server/services/events"
 	"github.com/Xrefullx/YanDip/server/services/logpkg"
 	"github.com/Xrefullx/YanDip/server/services/secret"
+	"github.com/Xrefullx/YanDip/server/services/session"
 	"github.com/Xrefullx/YanDip/server/storage/psql"
 	"github.com/Xrefullx/YanDip/server/storage/psql/migrations"
 )
@@ -55,11 +58,35 @@ func main() {
 		log.Fatalf("error starting secret service:%v", err.Error())
 	}
 
-	server, err := api.NewServer(cfg, svcAuth, svcSecret, jwtAuth)
+	//  client certificates are sent only with tls, devices are not checked without it
+	var svcDevice device.DeviceManager
+	if cfg.EnableHTTPS {
+		caCert, caKey, err := pkg.GetDeviceCA(cfg.DeviceCACert, cfg.DeviceCAKey)
+		if err != nil {
+			log.Fatalf("error loading device CA:%v", err.Error())
+		}
+		svcDevice, err = device.NewDevice(db.DeviceRepo, caCert, caKey, cfg.DeviceAllowLegacy)
+		if err != nil {
+			log.Fatalf("error starting device service:%v", err.Error())
+		}
+	}
+
+	svcSession, err := session.NewSession(db.TokenRepo, jwtAuth, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	if err != nil {
+		log.Fatalf("error starting session service:%v", err.Error())
+	}
+
+	broker := events.NewBroker()
+
+	server, err := api.NewServer(cfg, svcAuth, svcSecret, svcDevice, svcSession, broker, jwtAuth)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
+	ctxPurge, cancelPurge := context.WithCancel(context.Background())
+	defer cancelPurge()
+	go runPurge(ctxPurge, svcSecret, svcSession, cfg.DeletedRetention)
+
 	go func() {
 		if err := server.Run(); err != nil {
 			if !errors.Is(err, http.ErrServerClosed) {
@@ -82,3 +109,30 @@ func main() {
 	defer logpkg.CloseLogger()
 
 }
+
+// runPurge removes expired deleted secrets and expired refresh tokens once an hour
+func runPurge(ctx context.Context, svc *secret.Secret, sessions *session.Session, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			count, err := svc.PurgeDeleted(ctx, retention)
+			if err != nil {
+				log.Printf("error purge deleted secrets: %v", err)
+			} else if count > 0 {
+				log.Printf("purged deleted secrets: %v", count)
+			}
+
+			count, err = sessions.PurgeExpired(ctx)
+			if err != nil {
+				log.Printf("error purge expired refresh tokens: %v", err)
+			} else if count > 0 {
+				log.Printf("purged expired refresh tokens: %v", count)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package secretpb is gRPC API of secret storage service, shared by server and client.
+package secretpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative proto/secretpb/secret.proto
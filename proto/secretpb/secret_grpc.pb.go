@@ -0,0 +1,624 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: proto/secretpb/secret.proto
+
+package secretpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SecretsClient is the client API for Secrets service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SecretsClient interface {
+	Register(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// Refresh exchanges refresh token for new token and refresh token of the same device,
+	// UNAUTHENTICATED if refresh token is revoked, expired or reused.
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// SyncList returns versions and data hashes of user secrets, tombstones of deleted secrets
+	// and change feed cursor at the moment list was read.
+	SyncList(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SyncListResponse, error)
+	// SyncChanges returns page of changes after cursor, OUT_OF_RANGE if cursor expired.
+	SyncChanges(ctx context.Context, in *SyncChangesRequest, opts ...grpc.CallOption) (*SyncChangesResponse, error)
+	// SyncEvents streams changes of user secrets made by other devices until client disconnects.
+	SyncEvents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Secrets_SyncEventsClient, error)
+	// UploadSecret adds secret if id is empty, else updates secret based on version vector,
+	// ABORTED if update is not based on stored version.
+	UploadSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*Secret, error)
+	GetSecret(ctx context.Context, in *SecretID, opts ...grpc.CallOption) (*Secret, error)
+	DeleteSecret(ctx context.Context, in *SecretID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// UploadSecrets and DownloadSecrets process every item separately, results are in request order.
+	UploadSecrets(ctx context.Context, in *BatchUploadRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	DownloadSecrets(ctx context.Context, in *BatchDownloadRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// ListDevices returns devices of user, UNIMPLEMENTED if device certificates are not used.
+	ListDevices(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DeviceList, error)
+	// RevokeDevice revokes user device, token and certificate of device are not accepted after.
+	RevokeDevice(ctx context.Context, in *DeviceID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type secretsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSecretsClient(cc grpc.ClientConnInterface) SecretsClient {
+	return &secretsClient{cc}
+}
+
+func (c *secretsClient) Register(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/Login", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/Refresh", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) SyncList(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SyncListResponse, error) {
+	out := new(SyncListResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/SyncList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) SyncChanges(ctx context.Context, in *SyncChangesRequest, opts ...grpc.CallOption) (*SyncChangesResponse, error) {
+	out := new(SyncChangesResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/SyncChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) SyncEvents(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Secrets_SyncEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Secrets_ServiceDesc.Streams[0], "/secret.v1.Secrets/SyncEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &secretsSyncEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Secrets_SyncEventsClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type secretsSyncEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *secretsSyncEventsClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *secretsClient) UploadSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*Secret, error) {
+	out := new(Secret)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/UploadSecret", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) GetSecret(ctx context.Context, in *SecretID, opts ...grpc.CallOption) (*Secret, error) {
+	out := new(Secret)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/GetSecret", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) DeleteSecret(ctx context.Context, in *SecretID, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/DeleteSecret", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) UploadSecrets(ctx context.Context, in *BatchUploadRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/UploadSecrets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) DownloadSecrets(ctx context.Context, in *BatchDownloadRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/DownloadSecrets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) ListDevices(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DeviceList, error) {
+	out := new(DeviceList)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/ListDevices", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretsClient) RevokeDevice(ctx context.Context, in *DeviceID, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/secret.v1.Secrets/RevokeDevice", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SecretsServer is the server API for Secrets service.
+// All implementations must embed UnimplementedSecretsServer
+// for forward compatibility
+type SecretsServer interface {
+	Register(context.Context, *LoginRequest) (*LoginResponse, error)
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// Refresh exchanges refresh token for new token and refresh token of the same device,
+	// UNAUTHENTICATED if refresh token is revoked, expired or reused.
+	Refresh(context.Context, *RefreshRequest) (*LoginResponse, error)
+	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// SyncList returns versions and data hashes of user secrets, tombstones of deleted secrets
+	// and change feed cursor at the moment list was read.
+	SyncList(context.Context, *emptypb.Empty) (*SyncListResponse, error)
+	// SyncChanges returns page of changes after cursor, OUT_OF_RANGE if cursor expired.
+	SyncChanges(context.Context, *SyncChangesRequest) (*SyncChangesResponse, error)
+	// SyncEvents streams changes of user secrets made by other devices until client disconnects.
+	SyncEvents(*emptypb.Empty, Secrets_SyncEventsServer) error
+	// UploadSecret adds secret if id is empty, else updates secret based on version vector,
+	// ABORTED if update is not based on stored version.
+	UploadSecret(context.Context, *Secret) (*Secret, error)
+	GetSecret(context.Context, *SecretID) (*Secret, error)
+	DeleteSecret(context.Context, *SecretID) (*emptypb.Empty, error)
+	// UploadSecrets and DownloadSecrets process every item separately, results are in request order.
+	UploadSecrets(context.Context, *BatchUploadRequest) (*BatchResponse, error)
+	DownloadSecrets(context.Context, *BatchDownloadRequest) (*BatchResponse, error)
+	// ListDevices returns devices of user, UNIMPLEMENTED if device certificates are not used.
+	ListDevices(context.Context, *emptypb.Empty) (*DeviceList, error)
+	// RevokeDevice revokes user device, token and certificate of device are not accepted after.
+	RevokeDevice(context.Context, *DeviceID) (*emptypb.Empty, error)
+	mustEmbedUnimplementedSecretsServer()
+}
+
+// UnimplementedSecretsServer must be embedded to have forward compatible implementations.
+type UnimplementedSecretsServer struct {
+}
+
+func (UnimplementedSecretsServer) Register(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedSecretsServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedSecretsServer) Refresh(context.Context, *RefreshRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedSecretsServer) Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedSecretsServer) SyncList(context.Context, *emptypb.Empty) (*SyncListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncList not implemented")
+}
+func (UnimplementedSecretsServer) SyncChanges(context.Context, *SyncChangesRequest) (*SyncChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncChanges not implemented")
+}
+func (UnimplementedSecretsServer) SyncEvents(*emptypb.Empty, Secrets_SyncEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SyncEvents not implemented")
+}
+func (UnimplementedSecretsServer) UploadSecret(context.Context, *Secret) (*Secret, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadSecret not implemented")
+}
+func (UnimplementedSecretsServer) GetSecret(context.Context, *SecretID) (*Secret, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSecret not implemented")
+}
+func (UnimplementedSecretsServer) DeleteSecret(context.Context, *SecretID) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSecret not implemented")
+}
+func (UnimplementedSecretsServer) UploadSecrets(context.Context, *BatchUploadRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadSecrets not implemented")
+}
+func (UnimplementedSecretsServer) DownloadSecrets(context.Context, *BatchDownloadRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DownloadSecrets not implemented")
+}
+func (UnimplementedSecretsServer) ListDevices(context.Context, *emptypb.Empty) (*DeviceList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDevices not implemented")
+}
+func (UnimplementedSecretsServer) RevokeDevice(context.Context, *DeviceID) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeDevice not implemented")
+}
+func (UnimplementedSecretsServer) mustEmbedUnimplementedSecretsServer() {}
+
+// UnsafeSecretsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SecretsServer will
+// result in compilation errors.
+type UnsafeSecretsServer interface {
+	mustEmbedUnimplementedSecretsServer()
+}
+
+func RegisterSecretsServer(s grpc.ServiceRegistrar, srv SecretsServer) {
+	s.RegisterService(&Secrets_ServiceDesc, srv)
+}
+
+func _Secrets_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Register(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/Login",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/Refresh",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Ping(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_SyncList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).SyncList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/SyncList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).SyncList(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_SyncChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).SyncChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/SyncChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).SyncChanges(ctx, req.(*SyncChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_SyncEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SecretsServer).SyncEvents(m, &secretsSyncEventsServer{stream})
+}
+
+type Secrets_SyncEventsServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type secretsSyncEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *secretsSyncEventsServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Secrets_UploadSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Secret)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).UploadSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/UploadSecret",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).UploadSecret(ctx, req.(*Secret))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_GetSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).GetSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/GetSecret",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).GetSecret(ctx, req.(*SecretID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_DeleteSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).DeleteSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/DeleteSecret",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).DeleteSecret(ctx, req.(*SecretID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_UploadSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).UploadSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/UploadSecrets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).UploadSecrets(ctx, req.(*BatchUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_DownloadSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDownloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).DownloadSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/DownloadSecrets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).DownloadSecrets(ctx, req.(*BatchDownloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_ListDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).ListDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/ListDevices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).ListDevices(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Secrets_RevokeDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeviceID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).RevokeDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/secret.v1.Secrets/RevokeDevice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).RevokeDevice(ctx, req.(*DeviceID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Secrets_ServiceDesc is the grpc.ServiceDesc for Secrets service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Secrets_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "secret.v1.Secrets",
+	HandlerType: (*SecretsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _Secrets_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _Secrets_Login_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _Secrets_Refresh_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _Secrets_Ping_Handler,
+		},
+		{
+			MethodName: "SyncList",
+			Handler:    _Secrets_SyncList_Handler,
+		},
+		{
+			MethodName: "SyncChanges",
+			Handler:    _Secrets_SyncChanges_Handler,
+		},
+		{
+			MethodName: "UploadSecret",
+			Handler:    _Secrets_UploadSecret_Handler,
+		},
+		{
+			MethodName: "GetSecret",
+			Handler:    _Secrets_GetSecret_Handler,
+		},
+		{
+			MethodName: "DeleteSecret",
+			Handler:    _Secrets_DeleteSecret_Handler,
+		},
+		{
+			MethodName: "UploadSecrets",
+			Handler:    _Secrets_UploadSecrets_Handler,
+		},
+		{
+			MethodName: "DownloadSecrets",
+			Handler:    _Secrets_DownloadSecrets_Handler,
+		},
+		{
+			MethodName: "ListDevices",
+			Handler:    _Secrets_ListDevices_Handler,
+		},
+		{
+			MethodName: "RevokeDevice",
+			Handler:    _Secrets_RevokeDevice_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SyncEvents",
+			Handler:       _Secrets_SyncEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/secretpb/secret.proto",
+}
@@ -9,22 +9,34 @@ import (
 
 	apimiddleware "github.com/Xrefullx/YanDip/server/api/middleware"
 	"github.com/Xrefullx/YanDip/server/services/auth"
+	"github.com/Xrefullx/YanDip/server/services/device"
+	"github.com/Xrefullx/YanDip/server/services/events"
 	"github.com/Xrefullx/YanDip/server/services/secret"
+	"github.com/Xrefullx/YanDip/server/services/session"
 )
 
 type Handler struct {
-	svcAuth   auth.Authenticator
-	svcSecret secret.SecretManager
-	jwtAuth   *jwtauth.JWTAuth
+	svcAuth    auth.Authenticator
+	svcSecret  secret.SecretManager
+	svcDevice  device.DeviceManager
+	svcSession session.SessionManager
+	broker     events.EventBroker
+	jwtAuth    *jwtauth.JWTAuth
 }
 
 // NewHandler Return new handler
-func NewHandler(auth auth.Authenticator, secret secret.SecretManager, jwtAuth *jwtauth.JWTAuth) (*Handler, error) {
+// if broker is nil, changes are not published
+// if device service is nil, device certificates are not issued and checked
+// session service issues tokens, it is required
+func NewHandler(auth auth.Authenticator, secret secret.SecretManager, device device.DeviceManager, session session.SessionManager, broker events.EventBroker, jwtAuth *jwtauth.JWTAuth) (*Handler, error) {
 
 	return &Handler{
-		svcAuth:   auth,
-		svcSecret: secret,
-		jwtAuth:   jwtAuth,
+		svcAuth:    auth,
+		svcSecret:  secret,
+		svcDevice:  device,
+		svcSession: session,
+		broker:     broker,
+		jwtAuth:    jwtAuth,
 	}, nil
 }
 
@@ -38,13 +50,19 @@ func GetRouter(handler *Handler) *chi.Mux {
 		r.Use(middleware.AllowContentType("application/json"))
 		r.Post("/api/user/register", handler.Register)
 		r.Post("/api/user/login", handler.Login)
+		r.Post("/api/user/refresh", handler.Refresh)
 	})
 
 	r.Group(func(r chi.Router) {
 		r.Use(jwtauth.Verifier(tokenAuth))
 		r.Use(apimiddleware.MiddlewareAuth)
+		if handler.svcDevice != nil {
+			r.Use(apimiddleware.MiddlewareDevice(handler.svcDevice))
+		}
 
 		r.Get("/api/sync", handler.SyncList)
+		r.Get("/api/sync/changes", handler.SyncChanges)
+		r.Get("/api/sync/events", handler.SyncEvents)
 		r.Get("/api/ping", handler.Ping)
 
 		// Secret processing
@@ -52,6 +70,13 @@ func GetRouter(handler *Handler) *chi.Mux {
 		r.Get("/api/secret", handler.SecretGet)
 		r.Delete("/api/secret", handler.SecretDelete)
 
+		// Batch secret processing
+		r.Post("/api/secrets/upload", handler.SecretsUpload)
+		r.Post("/api/secrets/download", handler.SecretsDownload)
+
+		// Device processing
+		r.Get("/api/devices", handler.DeviceList)
+		r.Delete("/api/device", handler.DeviceRevoke)
 	})
 
 	return r
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +16,8 @@ import (
 )
 
 // Register registers user, sets cookie with jwt token.
+// Device certificate is issued for certificate request, request is required if device certificates are used.
+// Refresh token is issued if sessions are used.
 // 200 — user registered;
 // 400 — wrong request format;
 // 409 — user exist;
@@ -24,6 +28,14 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//  certificate request is checked before user is created
+	if h.svcDevice != nil {
+		if err := h.svcDevice.ValidateRequest(loginData.DeviceID, []byte(loginData.CSR)); err != nil {
+			h.writeDeviceError(w, err)
+			return
+		}
+	}
+
 	//  authenticate and get user.
 	user, err := h.svcAuth.CreateUser(r.Context(), loginData.Login, loginData.Password, loginData.MasterHash)
 	if err != nil {
@@ -38,16 +50,30 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//  issue device certificate
+	var cert []byte
+	if h.svcDevice != nil {
+		cert, err = h.svcDevice.EnrollLogin(r.Context(), user.ID, loginData.DeviceID, []byte(loginData.CSR))
+		if err != nil {
+			h.writeDeviceError(w, err)
+			return
+		}
+	}
+
 	//  set token to response
-	if err := h.setToken(w, user.ID, loginData.DeviceID); err != nil {
+	refresh, err := h.setToken(r.Context(), w, user.ID, loginData.DeviceID)
+	if err != nil {
 		return
 	}
+	h.writeLoginResponse(w, apimodel.LoginResponse{Certificate: string(cert), RefreshToken: refresh})
 }
 
 // Login authenticates user, sets jwt token.
+// Certificate is issued to device not enrolled yet, certificate request is required for it if device certificates are used.
+// Refresh token is issued if sessions are used.
 // 200 — user authenticated;
 // 400 — wrong request format;
-// 401 — wrong login/password;
+// 401 — wrong login/password, device is revoked;
 // 500 — internal server error.
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	loginData, err := h.readLoginRequest(w, r)
@@ -69,10 +95,22 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	//  issue device certificate
+	var cert []byte
+	if h.svcDevice != nil {
+		cert, err = h.svcDevice.EnrollLogin(r.Context(), user.ID, loginData.DeviceID, []byte(loginData.CSR))
+		if err != nil {
+			h.writeDeviceError(w, err)
+			return
+		}
+	}
+
 	//  set token to response
-	if err := h.setToken(w, user.ID, loginData.DeviceID); err != nil {
+	refresh, err := h.setToken(r.Context(), w, user.ID, loginData.DeviceID)
+	if err != nil {
 		return
 	}
+	h.writeLoginResponse(w, apimodel.LoginResponse{Certificate: string(cert), RefreshToken: refresh})
 }
 
 // readLoginRequest reads login data from request.
@@ -95,15 +133,81 @@ func (h Handler) readLoginRequest(w http.ResponseWriter, r *http.Request) (apimo
 	return loginData, nil
 }
 
-// setToken sets jwt token with user_id claim to response.
-func (h Handler) setToken(w http.ResponseWriter, userID uuid.UUID, deviceID uuid.UUID) error {
-	//  encode token with user_id claim.
-	token, err := h.svcAuth.EncodeTokenUserID(userID, deviceID, h.jwtAuth)
+// Refresh exchanges refresh token for new access token and refresh token of the same device.
+// Refresh token is accepted once, request must be made with certificate of token device if certificates are used.
+// 200 — token refreshed;
+// 400 — wrong request format;
+// 401 — refresh token is revoked, expired or reused, device is revoked;
+// 500 — internal server error.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req apimodel.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.Println("Error closing request body:", err)
+		}
+	}()
+
+	if req.RefreshToken == "" {
+		http.Error(w, "refresh token is empty", http.StatusBadRequest)
+		return
+	}
+
+	used, err := h.svcSession.Lookup(r.Context(), req.RefreshToken)
+	if err != nil {
+		h.writeSessionError(w, err)
+		return
+	}
+
+	//  token must be used by its device, token is not consumed by another device
+	if h.svcDevice != nil {
+		var certs []*x509.Certificate
+		if r.TLS != nil {
+			certs = r.TLS.PeerCertificates
+		}
+		if err := h.svcDevice.CheckDevice(r.Context(), used.UserID, used.DeviceID, certs); err != nil {
+			h.writeDeviceError(w, err)
+			return
+		}
+	}
+
+	pair, err := h.svcSession.Rotate(r.Context(), used)
+	if err != nil {
+		h.writeSessionError(w, err)
+		return
+	}
+
+	h.writeToken(w, pair.AccessToken)
+	h.writeLoginResponse(w, apimodel.LoginResponse{RefreshToken: pair.RefreshToken})
+}
+
+// setToken sets short-lived jwt token with user_id and device_id claims to response, returns refresh token.
+func (h Handler) setToken(ctx context.Context, w http.ResponseWriter, userID uuid.UUID, deviceID uuid.UUID) (string, error) {
+	pair, err := h.svcSession.Issue(ctx, userID, deviceID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return err
+		return "", err
+	}
+	h.writeToken(w, pair.AccessToken)
+
+	return pair.RefreshToken, nil
+}
+
+// writeSessionError writes 401 if refresh token is not accepted, 500 otherwise
+func (h Handler) writeSessionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, model.ErrorTokenRevoked) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
 
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// writeToken sets jwt token cookie and header, writes status 200.
+func (h Handler) writeToken(w http.ResponseWriter, token string) {
 	//  set cookie.
 	cookie := http.Cookie{
 		Name:  "jwt",
@@ -115,6 +219,32 @@ func (h Handler) setToken(w http.ResponseWriter, userID uuid.UUID, deviceID uuid
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Authorization", "Bearer "+token)
 	w.WriteHeader(http.StatusOK)
+}
+
+// writeDeviceError writes error of device enrollment
+func (h Handler) writeDeviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrorDeviceRevoked), errors.Is(err, model.ErrorDeviceCertMismatch):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, model.ErrorParamNotValid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
 
-	return nil
+// writeLoginResponse writes issued device certificate and refresh token to response body, headers are already written
+func (h Handler) writeLoginResponse(w http.ResponseWriter, result apimodel.LoginResponse) {
+	if result.Certificate == "" && result.RefreshToken == "" {
+		return
+	}
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("error encode login response: %v", err)
+		return
+	}
+	if _, err := w.Write(resp); err != nil {
+		log.Printf("error write login response: %v", err)
+	}
 }
@@ -15,6 +15,8 @@ import (
 	apimodel "github.com/Xrefullx/YanDip/server/api/model"
 	"github.com/Xrefullx/YanDip/server/model"
 	mk "github.com/Xrefullx/YanDip/server/services/auth/mock"
+	dmk "github.com/Xrefullx/YanDip/server/services/device/mock"
+	ssmk "github.com/Xrefullx/YanDip/server/services/session/mock"
 )
 
 var (
@@ -31,6 +33,8 @@ var (
 		MasterHash: fake.CharactersN(32),
 	}
 
+	mockTokens = model.TokenPair{UserID: mockUser.ID, DeviceID: mockLogin.DeviceID, AccessToken: "tokentoken"}
+
 	reqAuth = mustMarshalLogin(mockLogin)
 )
 
@@ -48,12 +52,13 @@ func TestHandler_Login(t *testing.T) {
 	defer ctrl.Finish()
 	tests := []TestRoute{
 		{
-			name:    "return 200 if authenticated",
-			method:  http.MethodPost,
-			url:     "/api/user/login",
-			svcAuth: authOk(ctrl),
-			headers: map[string]string{"Content-Type": "application/json"},
-			body:    reqAuth,
+			name:       "return 200 if authenticated",
+			method:     http.MethodPost,
+			url:        "/api/user/login",
+			svcAuth:    authOk(ctrl),
+			svcSession: sessionIssueOk(ctrl, mockTokens),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       reqAuth,
 
 			expectedHeaders: map[string]string{
 				"Content-Type":  "application/json",
@@ -140,12 +145,13 @@ func TestHandler_Register(t *testing.T) {
 	defer ctrl.Finish()
 	tests := []TestRoute{
 		{
-			name:    "return 200 if registered",
-			method:  http.MethodPost,
-			url:     "/api/user/register",
-			svcAuth: registerOk(ctrl),
-			headers: map[string]string{"Content-Type": "application/json"},
-			body:    reqAuth,
+			name:       "return 200 if registered",
+			method:     http.MethodPost,
+			url:        "/api/user/register",
+			svcAuth:    registerOk(ctrl),
+			svcSession: sessionIssueOk(ctrl, mockTokens),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       reqAuth,
 
 			expectedHeaders: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer tokentoken"},
 			expectedCode:    200,
@@ -214,6 +220,110 @@ func TestHandler_Register(t *testing.T) {
 
 }
 
+// TestHandler_Refresh tests issue and refresh of refresh tokens
+func TestHandler_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pair := model.TokenPair{UserID: mockUser.ID, DeviceID: mockLogin.DeviceID, AccessToken: "tokentoken", RefreshToken: "refresh"}
+	used := model.RefreshToken{ID: uuid.New(), UserID: mockUser.ID, DeviceID: mockLogin.DeviceID}
+
+	otherDevice := dmk.NewMockDeviceManager(ctrl)
+	otherDevice.EXPECT().CheckDevice(gomock.Any(), used.UserID, used.DeviceID, gomock.Any()).Return(model.ErrorDeviceCertMismatch)
+	tests := []TestRoute{
+		{
+			name:       "return 200 and refresh token on login",
+			method:     http.MethodPost,
+			url:        "/api/user/login",
+			svcAuth:    authOk(ctrl),
+			svcSession: sessionIssueOk(ctrl, pair),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       reqAuth,
+
+			expectedHeaders: map[string]string{"Authorization": "Bearer tokentoken"},
+			expectedBody:    `{"refresh_token":"refresh"}`,
+			expectedCode:    200,
+		},
+		{
+			name:       "return 200 and new tokens if refreshed",
+			method:     http.MethodPost,
+			url:        "/api/user/refresh",
+			svcSession: sessionRefresh(ctrl, used, model.TokenPair{AccessToken: "newtoken", RefreshToken: "rotated"}),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       `{"refresh_token":"refresh"}`,
+
+			expectedHeaders: map[string]string{"Authorization": "Bearer newtoken"},
+			expectedBody:    `{"refresh_token":"rotated"}`,
+			expectedCode:    200,
+		},
+		{
+			name:         "return 401 if refresh token is revoked",
+			method:       http.MethodPost,
+			url:          "/api/user/refresh",
+			svcSession:   sessionLookup(ctrl, model.RefreshToken{}, model.ErrorTokenRevoked),
+			headers:      map[string]string{"Content-Type": "application/json"},
+			body:         `{"refresh_token":"refresh"}`,
+			expectedCode: 401,
+		},
+		{
+			name:         "return 401 without token rotated if certificate of other device",
+			method:       http.MethodPost,
+			url:          "/api/user/refresh",
+			svcDevice:    otherDevice,
+			svcSession:   sessionLookup(ctrl, used, nil),
+			headers:      map[string]string{"Content-Type": "application/json"},
+			body:         `{"refresh_token":"refresh"}`,
+			expectedCode: 401,
+		},
+		{
+			name:         "return 401 if refresh token is used meanwhile",
+			method:       http.MethodPost,
+			url:          "/api/user/refresh",
+			svcSession:   sessionRotateErr(ctrl, used, model.ErrorTokenRevoked),
+			headers:      map[string]string{"Content-Type": "application/json"},
+			body:         `{"refresh_token":"refresh"}`,
+			expectedCode: 401,
+		},
+		{
+			name:         "return 400 if refresh token is empty",
+			method:       http.MethodPost,
+			url:          "/api/user/refresh",
+			svcSession:   ssmk.NewMockSessionManager(ctrl),
+			headers:      map[string]string{"Content-Type": "application/json"},
+			body:         `{}`,
+			expectedCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.CheckTest(t)
+		})
+	}
+}
+
+/* Mocks for refresh handler */
+func sessionIssueOk(ctrl *gomock.Controller, pair model.TokenPair) *ssmk.MockSessionManager {
+	sessionMock := ssmk.NewMockSessionManager(ctrl)
+	sessionMock.EXPECT().Issue(gomock.Any(), pair.UserID, pair.DeviceID).Return(pair, nil)
+	return sessionMock
+}
+func sessionLookup(ctrl *gomock.Controller, used model.RefreshToken, err error) *ssmk.MockSessionManager {
+	sessionMock := ssmk.NewMockSessionManager(ctrl)
+	sessionMock.EXPECT().Lookup(gomock.Any(), "refresh").Return(used, err)
+	return sessionMock
+}
+func sessionRefresh(ctrl *gomock.Controller, used model.RefreshToken, pair model.TokenPair) *ssmk.MockSessionManager {
+	sessionMock := sessionLookup(ctrl, used, nil)
+	sessionMock.EXPECT().Rotate(gomock.Any(), used).Return(pair, nil)
+	return sessionMock
+}
+func sessionRotateErr(ctrl *gomock.Controller, used model.RefreshToken, err error) *ssmk.MockSessionManager {
+	sessionMock := sessionLookup(ctrl, used, nil)
+	sessionMock.EXPECT().Rotate(gomock.Any(), used).Return(model.TokenPair{}, err)
+	return sessionMock
+}
+
 /*  Auth mocks  */
 func authEmpty(ctrl *gomock.Controller) *mk.MockAuthenticator {
 	authMock := mk.NewMockAuthenticator(ctrl)
@@ -224,7 +334,6 @@ func authEmpty(ctrl *gomock.Controller) *mk.MockAuthenticator {
 func authOk(ctrl *gomock.Controller) *mk.MockAuthenticator {
 	authMock := mk.NewMockAuthenticator(ctrl)
 	authMock.EXPECT().Authenticate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockUser, nil)
-	authMock.EXPECT().EncodeTokenUserID(mockUser.ID, mockLogin.DeviceID, gomock.Any()).Return("tokentoken", nil)
 	return authMock
 }
 func authErrWrongPass(ctrl *gomock.Controller) *mk.MockAuthenticator {
@@ -242,7 +351,6 @@ func authErrServer(ctrl *gomock.Controller) *mk.MockAuthenticator {
 func registerOk(ctrl *gomock.Controller) *mk.MockAuthenticator {
 	authMock := mk.NewMockAuthenticator(ctrl)
 	authMock.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockUser, nil)
-	authMock.EXPECT().EncodeTokenUserID(mockUser.ID, mockLogin.DeviceID, gomock.Any()).Return("tokentoken", nil)
 	return authMock
 }
 func registerErrServer(ctrl *gomock.Controller) *mk.MockAuthenticator {
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+// maxBatchSize is max count of items in batch request
+const maxBatchSize = 500
+
+// SecretsUpload adds or updates many secrets, every item is processed separately
+// 200 - items processed, item status is 200 if saved, 409 if not based on stored version, 422 if not valid or deleted,
+// 500 - internal error
+// 422 - too many items
+// 400 - if cant parse request
+func (h *Handler) SecretsUpload(w http.ResponseWriter, r *http.Request) {
+	user := h.getUserDataFromContext(r)
+
+	var req apimodel.BatchUploadRequest
+	if !h.isBatchBodyRead(w, r, &req) {
+		return
+	}
+	if len(req.Items) > maxBatchSize {
+		h.writeError(w, fmt.Errorf("%w: batch size over %v", model.ErrorParamNotValid, maxBatchSize))
+		return
+	}
+
+	resp := apimodel.BatchResponse{Items: make([]apimodel.BatchItem, 0, len(req.Items))}
+	for _, el := range req.Items {
+		saved, err := h.uploadSecret(r, user, model.Secret{
+			ID:     el.ID,
+			UserID: user.UserID,
+			Ver:    el.Ver,
+			Data:   el.Data,
+			Meta:   el.Meta,
+			Vector: el.Vector,
+		})
+
+		resp.Items = append(resp.Items, batchItem(apimodel.BatchItem{ID: saved.ID, Ver: saved.Ver, Vector: saved.Vector}, err))
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+// SecretsDownload returns many secrets, every item is processed separately.
+// With meta only request only meta and size of data are returned.
+// 200 - items processed, item status is 200 if found, 422 if not found or deleted, 500 - internal error
+// 422 - too many items
+// 400 - if cant parse request
+func (h *Handler) SecretsDownload(w http.ResponseWriter, r *http.Request) {
+	user := h.getUserDataFromContext(r)
+
+	var req apimodel.BatchDownloadRequest
+	if !h.isBatchBodyRead(w, r, &req) {
+		return
+	}
+	if len(req.IDs) > maxBatchSize {
+		h.writeError(w, fmt.Errorf("%w: batch size over %v", model.ErrorParamNotValid, maxBatchSize))
+		return
+	}
+
+	resp := apimodel.BatchResponse{Items: make([]apimodel.BatchItem, 0, len(req.IDs))}
+	for _, id := range req.IDs {
+		secret, err := h.svcSecret.Get(r.Context(), id, user.UserID)
+		if err == nil && secret.IsDeleted {
+			err = model.ErrorItemIsDeleted
+		}
+
+		item := apimodel.BatchItem{ID: id}
+		if err == nil {
+			item.Ver, item.Meta, item.Vector, item.Size = secret.Ver, secret.Meta, secret.Vector, len(secret.Data)
+			if !req.MetaOnly {
+				item.Data = secret.Data
+			}
+		}
+		resp.Items = append(resp.Items, batchItem(item, err))
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+// batchItem sets status of batch item by error
+func batchItem(item apimodel.BatchItem, err error) apimodel.BatchItem {
+	if err != nil {
+		item.Status = errorStatus(err)
+		item.Error = err.Error()
+		return item
+	}
+
+	item.Status = http.StatusOK
+	return item
+}
+
+func (h *Handler) isBatchBodyRead(w http.ResponseWriter, r *http.Request, data interface{}) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	if err := json.Unmarshal(body, data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
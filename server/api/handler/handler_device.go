@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+)
+
+// DeviceList returns devices of user.
+// 200 — list of devices;
+// 501 — device certificates are not used;
+// 500 — internal server error.
+func (h *Handler) DeviceList(w http.ResponseWriter, r *http.Request) {
+	if !h.isDeviceEnabled(w) {
+		return
+	}
+	user := h.getUserDataFromContext(r)
+
+	devices, err := h.svcDevice.GetUserDevices(r.Context(), user.UserID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	result := make([]apimodel.DeviceItem, 0, len(devices))
+	for _, el := range devices {
+		result = append(result, apimodel.DeviceItem{
+			ID:        el.ID,
+			CreatedAt: el.CreatedAt,
+			RevokedAt: el.RevokedAt,
+			Current:   el.ID == user.DeviceID,
+		})
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// DeviceRevoke revokes user device, token, refresh tokens and certificate of device are not accepted after.
+// 200 — device revoked;
+// 400 — wrong request format;
+// 422 — device not found;
+// 501 — device certificates are not used;
+// 500 — internal server error.
+func (h *Handler) DeviceRevoke(w http.ResponseWriter, r *http.Request) {
+	if !h.isDeviceEnabled(w) {
+		return
+	}
+	user := h.getUserDataFromContext(r)
+
+	var req apimodel.DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.Println("Error closing request body:", err)
+		}
+	}()
+
+	if err := h.svcDevice.Revoke(r.Context(), user.UserID, req.ID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	//  refresh tokens of revoked device are not accepted
+	if err := h.svcSession.RevokeDevice(r.Context(), user.UserID, req.ID); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, nil)
+}
+
+// isDeviceEnabled writes 501 and returns false if device service is not set
+func (h *Handler) isDeviceEnabled(w http.ResponseWriter) bool {
+	if h.svcDevice == nil {
+		http.Error(w, "device certificates are not used", http.StatusNotImplemented)
+		return false
+	}
+
+	return true
+}
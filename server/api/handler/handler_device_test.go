@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/services/auth"
+	dmk "github.com/Xrefullx/YanDip/server/services/device/mock"
+	ssmk "github.com/Xrefullx/YanDip/server/services/session/mock"
+)
+
+// TestHandler_LoginDevice tests issue of device certificate on login
+func TestHandler_LoginDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	login := mockLogin
+	login.CSR = "csr"
+
+	enrolled := dmk.NewMockDeviceManager(ctrl)
+	enrolled.EXPECT().EnrollLogin(gomock.Any(), mockUser.ID, login.DeviceID, []byte("csr")).Return(nil, nil)
+
+	revoked := dmk.NewMockDeviceManager(ctrl)
+	revoked.EXPECT().EnrollLogin(gomock.Any(), mockUser.ID, login.DeviceID, []byte("csr")).Return(nil, model.ErrorDeviceRevoked)
+
+	tests := []TestRoute{
+		{
+			name:       "return 200 and certificate if device is new",
+			method:     http.MethodPost,
+			url:        "/api/user/login",
+			svcAuth:    authOk(ctrl),
+			svcDevice:  enrollOk(ctrl, login),
+			svcSession: sessionIssueOk(ctrl, mockTokens),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       mustMarshalLogin(login),
+
+			expectedHeaders: map[string]string{"Authorization": "Bearer tokentoken"},
+			expectedBody:    `{"certificate":"cert"}`,
+			expectedCode:    200,
+		},
+		{
+			name:       "return 200 without certificate if device is enrolled",
+			method:     http.MethodPost,
+			url:        "/api/user/login",
+			svcAuth:    authOk(ctrl),
+			svcDevice:  enrolled,
+			svcSession: sessionIssueOk(ctrl, mockTokens),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       mustMarshalLogin(login),
+
+			expectedHeaders: map[string]string{"Authorization": "Bearer tokentoken"},
+			expectedCode:    200,
+		},
+		{
+			name:         "return 401 if device is revoked",
+			method:       http.MethodPost,
+			url:          "/api/user/login",
+			svcAuth:      authOk(ctrl),
+			svcDevice:    revoked,
+			headers:      map[string]string{"Content-Type": "application/json"},
+			body:         mustMarshalLogin(login),
+			expectedCode: 401,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.CheckTest(t)
+		})
+	}
+}
+
+// TestHandler_RegisterDevice tests that certificate request is checked before user is created
+func TestHandler_RegisterDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	login := mockLogin
+	login.CSR = "csr"
+
+	invalid := dmk.NewMockDeviceManager(ctrl)
+	invalid.EXPECT().ValidateRequest(login.DeviceID, []byte("csr")).Return(model.ErrorParamNotValid)
+
+	valid := dmk.NewMockDeviceManager(ctrl)
+	valid.EXPECT().ValidateRequest(login.DeviceID, []byte("csr")).Return(nil)
+	valid.EXPECT().EnrollLogin(gomock.Any(), mockUser.ID, login.DeviceID, []byte("csr")).Return([]byte("cert"), nil)
+
+	tests := []TestRoute{
+		{
+			name:       "return 200 and certificate if registered",
+			method:     http.MethodPost,
+			url:        "/api/user/register",
+			svcAuth:    registerOk(ctrl),
+			svcDevice:  valid,
+			svcSession: sessionIssueOk(ctrl, mockTokens),
+			headers:    map[string]string{"Content-Type": "application/json"},
+			body:       mustMarshalLogin(login),
+
+			expectedBody: `{"certificate":"cert"}`,
+			expectedCode: 200,
+		},
+		{
+			name:         "return 400 without user created if certificate request is not valid",
+			method:       http.MethodPost,
+			url:          "/api/user/register",
+			svcAuth:      authEmpty(ctrl),
+			svcDevice:    invalid,
+			headers:      map[string]string{"Content-Type": "application/json"},
+			body:         mustMarshalLogin(login),
+			expectedCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.CheckTest(t)
+		})
+	}
+}
+
+// TestHandler_Devices tests list and revoke of user devices
+func TestHandler_Devices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID, otherID := uuid.New(), uuid.New(), uuid.New()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	token, err := auth.Auth{}.EncodeTokenUserID(userID, deviceID, jwtauth.New("HS256", []byte("secret"), nil))
+	require.NoError(t, err)
+	headers := map[string]string{"Content-Type": "application/json", "Authorization": "Bearer " + token}
+
+	devices := dmk.NewMockDeviceManager(ctrl)
+	devices.EXPECT().CheckDevice(gomock.Any(), userID, deviceID, gomock.Any()).Return(nil).AnyTimes()
+	devices.EXPECT().GetUserDevices(gomock.Any(), userID).Return([]model.Device{
+		{ID: deviceID, UserID: userID, CreatedAt: created},
+	}, nil)
+	devices.EXPECT().Revoke(gomock.Any(), userID, otherID).Return(nil)
+	devices.EXPECT().Revoke(gomock.Any(), userID, deviceID).Return(model.ErrorItemNotFound)
+
+	sessions := ssmk.NewMockSessionManager(ctrl)
+	sessions.EXPECT().RevokeDevice(gomock.Any(), userID, otherID).Return(nil)
+
+	tests := []TestRoute{
+		{
+			name:      "return 200 and list of devices",
+			method:    http.MethodGet,
+			url:       "/api/devices",
+			svcDevice: devices,
+			headers:   headers,
+
+			expectedBody: mustMarshal(t, []apimodel.DeviceItem{{ID: deviceID, CreatedAt: created, Current: true}}),
+			expectedCode: 200,
+		},
+		{
+			name:         "return 200 if device revoked",
+			method:       http.MethodDelete,
+			url:          "/api/device",
+			svcDevice:    devices,
+			svcSession:   sessions,
+			headers:      headers,
+			body:         mustMarshal(t, apimodel.DeviceRequest{ID: otherID}),
+			expectedCode: 200,
+		},
+		{
+			name:         "return 422 if device not found",
+			method:       http.MethodDelete,
+			url:          "/api/device",
+			svcDevice:    devices,
+			headers:      headers,
+			body:         mustMarshal(t, apimodel.DeviceRequest{ID: deviceID}),
+			expectedCode: 422,
+		},
+		{
+			name:         "return 501 if devices are not used",
+			method:       http.MethodGet,
+			url:          "/api/devices",
+			headers:      headers,
+			expectedCode: 501,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.CheckTest(t)
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	res, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(res)
+}
+
+/* Mocks for device handlers */
+func enrollOk(ctrl *gomock.Controller, login apimodel.LoginRequest) *dmk.MockDeviceManager {
+	deviceMock := dmk.NewMockDeviceManager(ctrl)
+	deviceMock.EXPECT().EnrollLogin(gomock.Any(), mockUser.ID, login.DeviceID, []byte(login.CSR)).Return([]byte("cert"), nil)
+	return deviceMock
+}
@@ -13,7 +13,8 @@ import (
 )
 
 // 200 - if secret addedd or updated succefully
-// 422 - if secret not founded, is deleted, low version to update, request data not valid
+// 409 - if update is not based on stored version
+// 422 - if secret not founded, is deleted, request data not valid
 // 400 - if cant parse request
 // 500 - internal error
 func (h *Handler) SecretUpload(w http.ResponseWriter, r *http.Request) {
@@ -29,32 +30,21 @@ func (h *Handler) SecretUpload(w http.ResponseWriter, r *http.Request) {
 		UserID:    user.UserID,
 		Ver:       req.Ver,
 		Data:      req.Data,
+		Meta:      req.Meta,
+		Vector:    req.Vector,
 		IsDeleted: false,
 	}
 
-	var id uuid.UUID
-	var ver int
-	var err error
-
-	if secret.ID == uuid.Nil {
-		// Если ID не установлен, это новый секрет
-		id, ver, err = h.svcSecret.Add(r.Context(), secret)
-		if err != nil {
-			h.writeError(w, err)
-			return
-		}
-	} else {
-		// Если ID установлен, это обновление существующего секрета
-		id, ver, err = h.svcSecret.Update(r.Context(), secret)
-		if err != nil {
-			h.writeError(w, err)
-			return
-		}
+	saved, err := h.uploadSecret(r, user, secret)
+	if err != nil {
+		h.writeError(w, err)
+		return
 	}
 
 	resp := apimodel.SecretRequest{
-		ID:  id,
-		Ver: ver,
+		ID:     saved.ID,
+		Ver:    saved.Ver,
+		Vector: saved.Vector,
 	}
 
 	h.writeJSONResponse(w, http.StatusOK, resp)
@@ -74,12 +64,14 @@ func (h *Handler) SecretDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.svcSecret.Delete(r.Context(), req.ID, user.UserID)
+	err := h.svcSecret.Delete(r.Context(), req.ID, user.UserID, user.DeviceID)
 	if err != nil {
 		h.writeError(w, err)
 		return
 	}
 
+	h.publishChange(user, model.ChangeEvent{SecretID: req.ID, IsDeleted: true})
+
 	h.writeJSONResponse(w, http.StatusOK, nil)
 }
 
@@ -106,6 +98,27 @@ func (h *Handler) SecretGet(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, secret)
 }
 
+// uploadSecret adds or updates secret by request device and publishes change
+func (h *Handler) uploadSecret(r *http.Request, user apimodel.UserContextData, secret model.Secret) (model.Secret, error) {
+	var saved model.Secret
+	var err error
+
+	if secret.ID == uuid.Nil {
+		// Если ID не установлен, это новый секрет
+		saved, err = h.svcSecret.Add(r.Context(), secret, user.DeviceID)
+	} else {
+		// Если ID установлен, это обновление существующего секрета
+		saved, err = h.svcSecret.Update(r.Context(), secret, user.DeviceID)
+	}
+	if err != nil {
+		return model.Secret{}, err
+	}
+
+	h.publishChange(user, model.ChangeEvent{SecretID: saved.ID, Ver: saved.Ver})
+
+	return saved, nil
+}
+
 func (h *Handler) isSecretBodyRead(w http.ResponseWriter, r *http.Request, data *apimodel.SecretRequest) bool {
 	// read withdraw from request
 	body, err := io.ReadAll(r.Body)
@@ -1,22 +1,186 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
 
 	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+const (
+	//  default and max count of changes in change feed response
+	defChangesLimit = 500
+	maxChangesLimit = 1000
+
+	//  period of heartbeat comments in event stream, keeps proxies from closing idle stream
+	eventsHeartbeat = 30 * time.Second
 )
 
 func (h *Handler) SyncList(w http.ResponseWriter, r *http.Request) {
 	user := h.getUserDataFromContext(r)
 
+	//  cursor is read before list, changes made between are returned by change feed again
+	cursor, err := h.svcSecret.GetUserCursor(r.Context(), user.UserID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
 	list, err := h.svcSecret.GetUserSyncList(r.Context(), user.UserID)
 	if err != nil {
 		h.writeError(w, err)
+		return
+	}
+
+	tombstones, err := h.svcSecret.GetUserTombstones(r.Context(), user.UserID)
+	if err != nil {
+		h.writeError(w, err)
+		return
 	}
 
 	result := apimodel.SyncResponse{
-		List: list,
+		List:       make(map[uuid.UUID]int, len(list)),
+		Hashes:     make(map[uuid.UUID]string, len(list)),
+		Tombstones: make([]apimodel.TombstoneItem, 0, len(tombstones)),
+		Cursor:     cursor,
+	}
+	for id, el := range list {
+		result.List[id] = el.Ver
+		if el.Hash != "" {
+			result.Hashes[id] = el.Hash
+		}
+	}
+	for _, el := range tombstones {
+		result.Tombstones = append(result.Tombstones, apimodel.TombstoneItem{
+			ID:        el.ID,
+			Ver:       el.Ver,
+			DeletedAt: el.DeletedAt,
+			DeletedBy: el.DeletedBy,
+		})
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// SyncChanges returns changes of user secrets after cursor
+// 200 - page of changes, deleted secrets included
+// 410 - cursor expired, client must get full sync list
+// 422 - cursor or limit not valid
+// 500 - internal error
+func (h *Handler) SyncChanges(w http.ResponseWriter, r *http.Request) {
+	user := h.getUserDataFromContext(r)
+
+	cursor, err := strconv.ParseInt(r.URL.Query().Get("cursor"), 10, 64)
+	if err != nil {
+		h.writeError(w, model.ErrorParamNotValid)
+		return
+	}
+
+	limit := defChangesLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			h.writeError(w, model.ErrorParamNotValid)
+			return
+		}
+	}
+	if limit > maxChangesLimit {
+		limit = maxChangesLimit
+	}
+
+	feed, err := h.svcSecret.GetChanges(r.Context(), user.UserID, cursor, limit)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	result := apimodel.ChangesResponse{
+		Changes: make([]apimodel.ChangeItem, 0, len(feed.Changes)),
+		Cursor:  feed.Cursor,
+		HasMore: feed.HasMore,
+	}
+	for _, el := range feed.Changes {
+		item := apimodel.ChangeItem{
+			ID:        el.ID,
+			Ver:       el.Ver,
+			IsDeleted: el.IsDeleted,
+			Seq:       el.Seq,
+		}
+		if el.IsDeleted {
+			deletedAt, deletedBy := el.DeletedAt, el.DeletedBy
+			item.DeletedAt, item.DeletedBy = &deletedAt, &deletedBy
+		}
+
+		result.Changes = append(result.Changes, item)
 	}
 
 	h.writeJSONResponse(w, http.StatusOK, result)
 }
+
+// SyncEvents streams changes of user secrets made by other devices as server-sent events.
+// Stream is kept open until client disconnects, comment line is sent as heartbeat.
+// 200 - event stream
+// 500 - streaming not supported
+func (h *Handler) SyncEvents(w http.ResponseWriter, r *http.Request) {
+	user := h.getUserDataFromContext(r)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || h.broker == nil {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	changes, unsubscribe := h.broker.Subscribe(user.UserID, user.DeviceID)
+	defer unsubscribe()
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	//  client knows subscription is active
+	if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-changes:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(apimodel.ChangeEvent{
+				ID:        e.SecretID,
+				Ver:       e.Ver,
+				IsDeleted: e.IsDeleted,
+			})
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: change\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	apimodel "github.com/Xrefullx/YanDip/server/api/model"
@@ -9,15 +10,32 @@ import (
 )
 
 func (h *Handler) writeError(w http.ResponseWriter, err error) {
-	switch err {
+	http.Error(w, err.Error(), errorStatus(err))
+}
 
-	case model.ErrorParamNotValid, model.ErrorItemNotFound, model.ErrorVersionToLow, model.ErrorItemNotFound:
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
-		return
+// errorStatus returns http status of error
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrorParamNotValid), errors.Is(err, model.ErrorItemNotFound),
+		errors.Is(err, model.ErrorItemIsDeleted):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, model.ErrorVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrorCursorExpired):
+		return http.StatusGone
 	default:
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+}
+
+// publishChange publishes change of user secret made by request device
+func (h *Handler) publishChange(user apimodel.UserContextData, e model.ChangeEvent) {
+	if h.broker == nil {
 		return
 	}
+
+	e.UserID, e.DeviceID = user.UserID, user.DeviceID
+	h.broker.Publish(e)
 }
 
 func (h *Handler) getUserDataFromContext(r *http.Request) apimodel.UserContextData {
@@ -12,14 +12,20 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/Xrefullx/YanDip/server/services/auth"
+	"github.com/Xrefullx/YanDip/server/services/device"
+	"github.com/Xrefullx/YanDip/server/services/events"
 	"github.com/Xrefullx/YanDip/server/services/secret"
+	"github.com/Xrefullx/YanDip/server/services/session"
 )
 
 // HandlerTest stores test data and expected response params.
 type TestRoute struct {
-	svcAuth   auth.Authenticator //  authentication service
-	svcSecret secret.SecretManager
-	jwtAuth   *jwtauth.JWTAuth
+	svcAuth    auth.Authenticator //  authentication service
+	svcSecret  secret.SecretManager
+	svcDevice  device.DeviceManager
+	svcSession session.SessionManager
+	broker     events.EventBroker
+	jwtAuth    *jwtauth.JWTAuth
 
 	name    string            //  test name
 	method  string            //  http method
@@ -35,7 +41,7 @@ type TestRoute struct {
 // CheckTest runs handler, builds request and checks response values
 func (tt *TestRoute) CheckTest(t *testing.T) {
 	//  new handler with mock services
-	h, err := NewHandler(tt.svcAuth, tt.svcSecret, tt.jwtAuth)
+	h, err := NewHandler(tt.svcAuth, tt.svcSecret, tt.svcDevice, tt.svcSession, tt.broker, tt.jwtAuth)
 	require.NoError(t, err)
 
 	//  new router with handler
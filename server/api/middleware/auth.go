@@ -46,6 +46,21 @@ func MiddlewareAuth(next http.Handler) http.Handler {
 	})
 }
 
+// UserFromToken verifies token and returns user data from its claims
+func UserFromToken(ja *jwtauth.JWTAuth, tokenString string) (model.UserContextData, error) {
+	token, err := jwtauth.VerifyToken(ja, tokenString)
+	if err != nil {
+		return model.UserContextData{}, err
+	}
+
+	claims, err := token.AsMap(context.Background())
+	if err != nil {
+		return model.UserContextData{}, err
+	}
+
+	return readClaims(claims)
+}
+
 func readClaims(claims map[string]interface{}) (model.UserContextData, error) {
 	objUserID, ok := claims["user_id"]
 	if !ok {
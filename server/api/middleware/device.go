@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	"github.com/Xrefullx/YanDip/server/api/model"
+	svcmodel "github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/services/device"
+)
+
+// MiddlewareDevice checks that request is made with client certificate of token device.
+// Must be used after MiddlewareAuth, user data is read from context.
+func MiddlewareDevice(devices device.DeviceManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userData, ok := r.Context().Value(model.ContextKeyUserID).(model.UserContextData)
+			if !ok {
+				http.Error(w, "user data is empty", http.StatusUnauthorized)
+				return
+			}
+
+			var certs []*x509.Certificate
+			if r.TLS != nil {
+				certs = r.TLS.PeerCertificates
+			}
+
+			if err := devices.CheckDevice(r.Context(), userData.UserID, userData.DeviceID, certs); err != nil {
+				if errors.Is(err, svcmodel.ErrorDeviceRevoked) || errors.Is(err, svcmodel.ErrorDeviceCertMismatch) {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	svcmodel "github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/services/auth"
+	dmk "github.com/Xrefullx/YanDip/server/services/device/mock"
+)
+
+// TestMiddlewareDevice tests check of token device
+func TestMiddlewareDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID, revokedID, failedID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	token := func(deviceID uuid.UUID) string {
+		tokenString, err := auth.Auth{}.EncodeTokenUserID(userID, deviceID, tokenAuth)
+		require.NoError(t, err)
+		return "Bearer " + tokenString
+	}
+
+	//  requests of test recorder are made without tls
+	devices := dmk.NewMockDeviceManager(ctrl)
+	devices.EXPECT().CheckDevice(gomock.Any(), userID, deviceID, nil).Return(nil)
+	devices.EXPECT().CheckDevice(gomock.Any(), userID, revokedID, nil).Return(svcmodel.ErrorDeviceRevoked)
+	devices.EXPECT().CheckDevice(gomock.Any(), userID, failedID, nil).Return(errors.New("db error"))
+
+	middlewares := []Middleware{MiddlewareDevice(devices), MiddlewareAuth, jwtauth.Verifier(tokenAuth)}
+	toBodyHandler := writeUserIDToBody{t: t}
+
+	tests := []TestMiddleware{
+		{
+			name:           "device ok",
+			middlewareFunc: middlewares,
+			nextHandler:    toBodyHandler,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": token(deviceID)},
+
+			expectedCode: 200,
+		},
+		{
+			name:           "device revoked - 401",
+			middlewareFunc: middlewares,
+			nextHandler:    toBodyHandler,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": token(revokedID)},
+
+			expectedCode: 401,
+		},
+		{
+			name:           "device check failed - 500",
+			middlewareFunc: middlewares,
+			nextHandler:    toBodyHandler,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": token(failedID)},
+
+			expectedCode: 500,
+		},
+		{
+			name:           "no auth middleware - 401",
+			middlewareFunc: []Middleware{MiddlewareDevice(devices)},
+			nextHandler:    toBodyHandler,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": token(deviceID)},
+
+			expectedCode: 401,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.CheckTest(t)
+		})
+	}
+}
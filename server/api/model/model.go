@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,11 +15,71 @@ type (
 		MasterHash string    `json:"master_hash"`
 		Password   string    `json:"password"`
 		DeviceID   uuid.UUID `json:"device_id"`
+		//  PEM certificate request of new device, device certificate is issued on first login
+		CSR string `json:"csr,omitempty"`
 	}
+
+	// LoginResponse is body of login response, set if device certificate or refresh token is issued
+	LoginResponse struct {
+		//  PEM device certificate
+		Certificate string `json:"certificate,omitempty"`
+		//  refresh token of device, exchanged for new access token when it expires
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+
+	RefreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	DeviceRequest struct {
+		ID uuid.UUID `json:"id"`
+	}
+
+	DeviceItem struct {
+		ID        uuid.UUID  `json:"id"`
+		CreatedAt time.Time  `json:"created_at"`
+		RevokedAt *time.Time `json:"revoked_at,omitempty"`
+		//  device of request
+		Current bool `json:"current,omitempty"`
+	}
+
 	SecretRequest struct {
 		Data string    `json:"data,omitempty"`
 		ID   uuid.UUID `json:"id,omitempty"`
 		Ver  int       `json:"ver,omitempty"`
+		//  encrypted secret description, optional
+		Meta string `json:"meta,omitempty"`
+		//  version vector, in upload request it is vector of version edit is based on
+		Vector map[string]int `json:"vector,omitempty"`
+	}
+
+	BatchUploadRequest struct {
+		Items []SecretRequest `json:"items"`
+	}
+
+	BatchDownloadRequest struct {
+		IDs []uuid.UUID `json:"ids"`
+		//  only meta and size of secrets are returned, without data
+		MetaOnly bool `json:"meta_only,omitempty"`
+	}
+
+	// BatchItem is result of batch request item, status is http status of item
+	BatchItem struct {
+		ID   uuid.UUID `json:"id"`
+		Ver  int       `json:"ver,omitempty"`
+		Data string    `json:"data,omitempty"`
+		Meta string    `json:"meta,omitempty"`
+		//  version vector of secret
+		Vector map[string]int `json:"vector,omitempty"`
+		//  size of secret data
+		Size   int    `json:"size,omitempty"`
+		Status int    `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// BatchResponse is results of batch request items in request order
+	BatchResponse struct {
+		Items []BatchItem `json:"items"`
 	}
 
 	UserContextData struct {
@@ -28,6 +89,40 @@ type (
 
 	SyncResponse struct {
 		List map[uuid.UUID]int `json:"list"`
+		//  hashes of secrets data, secret stored before hashes were added has no hash
+		Hashes map[uuid.UUID]string `json:"hashes,omitempty"`
+		//  deleted secrets, secret missing in list and tombstones is unknown to server
+		Tombstones []TombstoneItem `json:"tombstones"`
+		//  change feed cursor at the moment list was read
+		Cursor int64 `json:"cursor"`
+	}
+
+	TombstoneItem struct {
+		ID        uuid.UUID `json:"id"`
+		Ver       int       `json:"ver"`
+		DeletedAt time.Time `json:"deleted_at"`
+		DeletedBy uuid.UUID `json:"deleted_by"`
+	}
+
+	ChangeItem struct {
+		ID        uuid.UUID  `json:"id"`
+		Ver       int        `json:"ver"`
+		IsDeleted bool       `json:"is_deleted,omitempty"`
+		Seq       int64      `json:"seq"`
+		DeletedAt *time.Time `json:"deleted_at,omitempty"`
+		DeletedBy *uuid.UUID `json:"deleted_by,omitempty"`
+	}
+
+	ChangeEvent struct {
+		ID        uuid.UUID `json:"id"`
+		Ver       int       `json:"ver,omitempty"`
+		IsDeleted bool      `json:"is_deleted,omitempty"`
+	}
+
+	ChangesResponse struct {
+		Changes []ChangeItem `json:"changes"`
+		Cursor  int64        `json:"cursor"`
+		HasMore bool         `json:"has_more"`
 	}
 )
 
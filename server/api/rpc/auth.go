@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+)
+
+// Register registers user, returns token.
+// Device certificate is issued for certificate request, request is required if device certificates are used.
+// Refresh token is issued if sessions are used.
+// INVALID_ARGUMENT - wrong request;
+// ALREADY_EXISTS - user exist.
+func (s *Server) Register(ctx context.Context, req *secretpb.LoginRequest) (*secretpb.LoginResponse, error) {
+	loginData, err := readLoginRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	//  certificate request is checked before user is created
+	if s.svcDevice != nil {
+		if err := s.svcDevice.ValidateRequest(loginData.DeviceID, []byte(loginData.CSR)); err != nil {
+			return nil, statusError(err)
+		}
+	}
+
+	user, err := s.svcAuth.CreateUser(ctx, loginData.Login, loginData.Password, loginData.MasterHash)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	//  issue device certificate
+	var cert []byte
+	if s.svcDevice != nil {
+		cert, err = s.svcDevice.EnrollLogin(ctx, user.ID, loginData.DeviceID, []byte(loginData.CSR))
+		if err != nil {
+			return nil, statusError(err)
+		}
+	}
+
+	return s.tokenResponse(ctx, user.ID, loginData.DeviceID, cert)
+}
+
+// Login authenticates user, returns token.
+// Certificate is issued to device not enrolled yet, certificate request is required for it if device certificates are used.
+// Refresh token is issued if sessions are used.
+// INVALID_ARGUMENT - wrong request;
+// UNAUTHENTICATED - wrong login/password, device is revoked.
+func (s *Server) Login(ctx context.Context, req *secretpb.LoginRequest) (*secretpb.LoginResponse, error) {
+	loginData, err := readLoginRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.svcAuth.Authenticate(ctx, loginData.Login, loginData.Password, loginData.MasterHash)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	//  issue device certificate
+	var cert []byte
+	if s.svcDevice != nil {
+		cert, err = s.svcDevice.EnrollLogin(ctx, user.ID, loginData.DeviceID, []byte(loginData.CSR))
+		if err != nil {
+			return nil, statusError(err)
+		}
+	}
+
+	return s.tokenResponse(ctx, user.ID, loginData.DeviceID, cert)
+}
+
+// Refresh exchanges refresh token for new token and refresh token of the same device.
+// Refresh token is accepted once, call must be made with certificate of token device if certificates are used.
+// INVALID_ARGUMENT - refresh token is empty;
+// UNAUTHENTICATED - refresh token is revoked, expired or reused, device is revoked.
+func (s *Server) Refresh(ctx context.Context, req *secretpb.RefreshRequest) (*secretpb.LoginResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is empty")
+	}
+
+	used, err := s.svcSession.Lookup(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	//  token must be used by its device, token is not consumed by another device
+	if err := s.checkDevice(ctx, apimodel.UserContextData{UserID: used.UserID, DeviceID: used.DeviceID}); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.svcSession.Rotate(ctx, used)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &secretpb.LoginResponse{Token: pair.AccessToken, RefreshToken: pair.RefreshToken}, nil
+}
+
+// Ping returns empty response if token is valid
+func (s *Server) Ping(_ context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+// readLoginRequest reads and validates login data
+func readLoginRequest(req *secretpb.LoginRequest) (apimodel.LoginRequest, error) {
+	deviceID, err := uuid.Parse(req.GetDeviceId())
+	if err != nil {
+		return apimodel.LoginRequest{}, status.Errorf(codes.InvalidArgument, "wrong device id: %v", err)
+	}
+
+	loginData := apimodel.LoginRequest{
+		Login:      req.GetLogin(),
+		Password:   req.GetPassword(),
+		MasterHash: req.GetMasterHash(),
+		DeviceID:   deviceID,
+		CSR:        req.GetCsr(),
+	}
+	if err := loginData.Validate(); err != nil {
+		return apimodel.LoginRequest{}, status.Errorf(codes.InvalidArgument, "invalid login data: %v", err)
+	}
+
+	return loginData, nil
+}
+
+// tokenResponse returns short-lived token with user_id and device_id claims, refresh token and issued device certificate
+func (s *Server) tokenResponse(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, cert []byte) (*secretpb.LoginResponse, error) {
+	pair, err := s.svcSession.Issue(ctx, userID, deviceID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &secretpb.LoginResponse{Token: pair.AccessToken, RefreshToken: pair.RefreshToken, Certificate: string(cert)}, nil
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+// maxBatchSize is max count of items in batch request
+const maxBatchSize = 500
+
+// UploadSecrets adds or updates many secrets, every item is processed separately
+// item code is OK if saved, ABORTED if not based on stored version, other code if not valid or deleted
+// INVALID_ARGUMENT - too many items
+func (s *Server) UploadSecrets(ctx context.Context, req *secretpb.BatchUploadRequest) (*secretpb.BatchResponse, error) {
+	user := getUserDataFromContext(ctx)
+
+	if len(req.GetItems()) > maxBatchSize {
+		return nil, statusError(fmt.Errorf("%w: batch size over %v", model.ErrorParamNotValid, maxBatchSize))
+	}
+
+	resp := &secretpb.BatchResponse{Items: make([]*secretpb.BatchItem, 0, len(req.GetItems()))}
+	for _, el := range req.GetItems() {
+		secret, err := readSecret(el, user)
+		if err == nil {
+			secret, err = s.uploadSecret(ctx, user, secret)
+		}
+
+		item := &secretpb.BatchItem{Secret: &secretpb.Secret{Id: el.GetId()}}
+		if err == nil {
+			item.Secret = &secretpb.Secret{Id: secret.ID.String(), Ver: int32(secret.Ver), Vector: encodeVector(secret.Vector)}
+		}
+		resp.Items = append(resp.Items, batchItem(item, err))
+	}
+
+	return resp, nil
+}
+
+// DownloadSecrets returns many secrets, every item is processed separately.
+// With meta only request only meta and size of data are returned.
+// item code is OK if found, NOT_FOUND or FAILED_PRECONDITION if not found or deleted
+// INVALID_ARGUMENT - too many items
+func (s *Server) DownloadSecrets(ctx context.Context, req *secretpb.BatchDownloadRequest) (*secretpb.BatchResponse, error) {
+	user := getUserDataFromContext(ctx)
+
+	if len(req.GetIds()) > maxBatchSize {
+		return nil, statusError(fmt.Errorf("%w: batch size over %v", model.ErrorParamNotValid, maxBatchSize))
+	}
+
+	resp := &secretpb.BatchResponse{Items: make([]*secretpb.BatchItem, 0, len(req.GetIds()))}
+	for _, el := range req.GetIds() {
+		item := &secretpb.BatchItem{Secret: &secretpb.Secret{Id: el}}
+
+		id, err := parseID(el)
+		var secret model.Secret
+		if err == nil {
+			secret, err = s.svcSecret.Get(ctx, id, user.UserID)
+		}
+		if err == nil && secret.IsDeleted {
+			err = model.ErrorItemIsDeleted
+		}
+		if err == nil {
+			var res *secretpb.Secret
+			if res, err = encodeSecret(secret, req.GetMetaOnly()); err == nil {
+				item.Secret = res
+			}
+		}
+
+		resp.Items = append(resp.Items, batchItem(item, err))
+	}
+
+	return resp, nil
+}
+
+// batchItem sets status code of batch item by error
+func batchItem(item *secretpb.BatchItem, err error) *secretpb.BatchItem {
+	if err == nil {
+		item.Code = uint32(codes.OK)
+		return item
+	}
+
+	if st, ok := status.FromError(err); ok {
+		item.Code, item.Error = uint32(st.Code()), st.Message()
+		return item
+	}
+
+	item.Code, item.Error = uint32(errorCode(err)), err.Error()
+	return item
+}
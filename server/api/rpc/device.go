@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+// ListDevices returns devices of user.
+// UNIMPLEMENTED - device certificates are not used.
+func (s *Server) ListDevices(ctx context.Context, _ *emptypb.Empty) (*secretpb.DeviceList, error) {
+	if s.svcDevice == nil {
+		return nil, status.Error(codes.Unimplemented, "device certificates are not used")
+	}
+	user := getUserDataFromContext(ctx)
+
+	devices, err := s.svcDevice.GetUserDevices(ctx, user.UserID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	result := &secretpb.DeviceList{Devices: make([]*secretpb.Device, 0, len(devices))}
+	for _, el := range devices {
+		item := &secretpb.Device{
+			Id:        el.ID.String(),
+			CreatedAt: el.CreatedAt.UnixMilli(),
+			Current:   el.ID == user.DeviceID,
+		}
+		if el.RevokedAt != nil {
+			item.RevokedAt = el.RevokedAt.UnixMilli()
+		}
+		result.Devices = append(result.Devices, item)
+	}
+
+	return result, nil
+}
+
+// RevokeDevice revokes user device.
+// INVALID_ARGUMENT - wrong device id;
+// NOT_FOUND - device not found;
+// UNIMPLEMENTED - device certificates are not used.
+func (s *Server) RevokeDevice(ctx context.Context, req *secretpb.DeviceID) (*emptypb.Empty, error) {
+	if s.svcDevice == nil {
+		return nil, status.Error(codes.Unimplemented, "device certificates are not used")
+	}
+	user := getUserDataFromContext(ctx)
+
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "wrong device id: %v", err)
+	}
+
+	if err := s.svcDevice.Revoke(ctx, user.UserID, id); err != nil {
+		return nil, statusError(err)
+	}
+
+	//  refresh tokens of revoked device are not accepted
+	if err := s.svcSession.RevokeDevice(ctx, user.UserID, id); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
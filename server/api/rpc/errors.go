@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+// errorCode returns grpc status code of error
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, model.ErrorParamNotValid):
+		return codes.InvalidArgument
+	case errors.Is(err, model.ErrorItemNotFound):
+		return codes.NotFound
+	case errors.Is(err, model.ErrorItemIsDeleted):
+		return codes.FailedPrecondition
+	case errors.Is(err, model.ErrorVersionConflict):
+		return codes.Aborted
+	case errors.Is(err, model.ErrorCursorExpired):
+		return codes.OutOfRange
+	case errors.Is(err, model.ErrorWrongAuthData), errors.Is(err, model.ErrorDeviceRevoked),
+		errors.Is(err, model.ErrorDeviceCertMismatch), errors.Is(err, model.ErrorTokenRevoked):
+		return codes.Unauthenticated
+	case errors.Is(err, model.ErrorConflictSaveUser):
+		return codes.AlreadyExists
+	default:
+		return codes.Internal
+	}
+}
+
+// statusError returns error as grpc status error
+func statusError(err error) error {
+	return status.Error(errorCode(err), err.Error())
+}
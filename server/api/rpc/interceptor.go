@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	apimiddleware "github.com/Xrefullx/YanDip/server/api/middleware"
+	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+)
+
+// publicMethods are called without token
+var publicMethods = map[string]struct{}{
+	"/secret.v1.Secrets/Register": {},
+	"/secret.v1.Secrets/Login":    {},
+	"/secret.v1.Secrets/Refresh":  {},
+}
+
+// unaryAuth checks token of call and sets user data to context
+func (s *Server) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if _, ok := publicMethods[info.FullMethod]; ok {
+		return handler(ctx, req)
+	}
+
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// streamAuth checks token of stream and sets user data to stream context
+func (s *Server) streamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate reads token from authorization metadata, returns context with user data
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "token is empty")
+	}
+
+	token := values[0]
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = token[7:]
+	}
+
+	user, err := apimiddleware.UserFromToken(s.jwtAuth, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if err := s.checkDevice(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(ctx, apimodel.ContextKeyUserID, user), nil
+}
+
+// checkDevice checks that call of token device is made with device client certificate
+func (s *Server) checkDevice(ctx context.Context, user apimodel.UserContextData) error {
+	if s.svcDevice == nil {
+		return nil
+	}
+
+	var certs []*x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			certs = info.State.PeerCertificates
+		}
+	}
+
+	if err := s.svcDevice.CheckDevice(ctx, user.UserID, user.DeviceID, certs); err != nil {
+		return statusError(err)
+	}
+
+	return nil
+}
+
+// authStream is server stream with authenticated context
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func getUserDataFromContext(ctx context.Context) apimodel.UserContextData {
+	return ctx.Value(apimodel.ContextKeyUserID).(apimodel.UserContextData)
+}
@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+	apimodel "github.com/Xrefullx/YanDip/server/api/model"
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+// UploadSecret adds or updates secret, returns id, version and vector of stored secret
+// ABORTED - update is not based on stored version
+// INVALID_ARGUMENT, NOT_FOUND, FAILED_PRECONDITION - secret not valid, not found or deleted
+func (s *Server) UploadSecret(ctx context.Context, req *secretpb.Secret) (*secretpb.Secret, error) {
+	user := getUserDataFromContext(ctx)
+
+	secret, err := readSecret(req, user)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	saved, err := s.uploadSecret(ctx, user, secret)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &secretpb.Secret{Id: saved.ID.String(), Ver: int32(saved.Ver), Vector: encodeVector(saved.Vector)}, nil
+}
+
+// GetSecret returns secret
+// NOT_FOUND - secret not found
+func (s *Server) GetSecret(ctx context.Context, req *secretpb.SecretID) (*secretpb.Secret, error) {
+	user := getUserDataFromContext(ctx)
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	secret, err := s.svcSecret.Get(ctx, id, user.UserID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return encodeSecret(secret, false)
+}
+
+// DeleteSecret deletes secret
+// NOT_FOUND - secret not found
+func (s *Server) DeleteSecret(ctx context.Context, req *secretpb.SecretID) (*emptypb.Empty, error) {
+	user := getUserDataFromContext(ctx)
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	if err := s.svcSecret.Delete(ctx, id, user.UserID, user.DeviceID); err != nil {
+		return nil, statusError(err)
+	}
+
+	s.publishChange(user, model.ChangeEvent{SecretID: id, IsDeleted: true})
+
+	return &emptypb.Empty{}, nil
+}
+
+// uploadSecret adds or updates secret by request device and publishes change
+func (s *Server) uploadSecret(ctx context.Context, user apimodel.UserContextData, secret model.Secret) (model.Secret, error) {
+	var saved model.Secret
+	var err error
+
+	if secret.ID == uuid.Nil {
+		saved, err = s.svcSecret.Add(ctx, secret, user.DeviceID)
+	} else {
+		saved, err = s.svcSecret.Update(ctx, secret, user.DeviceID)
+	}
+	if err != nil {
+		return model.Secret{}, err
+	}
+
+	s.publishChange(user, model.ChangeEvent{SecretID: saved.ID, Ver: saved.Ver})
+
+	return saved, nil
+}
+
+// publishChange publishes change of user secret made by request device
+func (s *Server) publishChange(user apimodel.UserContextData, e model.ChangeEvent) {
+	if s.broker == nil {
+		return
+	}
+
+	e.UserID, e.DeviceID = user.UserID, user.DeviceID
+	s.broker.Publish(e)
+}
+
+// readSecret returns secret of request, id is nil for new secret.
+// Data is stored base64 encoded, the same as data uploaded with http api.
+func readSecret(req *secretpb.Secret, user apimodel.UserContextData) (model.Secret, error) {
+	id := uuid.Nil
+	if req.GetId() != "" {
+		var err error
+		if id, err = parseID(req.GetId()); err != nil {
+			return model.Secret{}, err
+		}
+	}
+
+	secret := model.Secret{
+		ID:     id,
+		UserID: user.UserID,
+		Ver:    int(req.GetVer()),
+		Meta:   req.GetMeta(),
+		Vector: make(model.VersionVector, len(req.GetVector())),
+	}
+	if len(req.GetData()) > 0 {
+		secret.Data = base64.StdEncoding.EncodeToString(req.GetData())
+	}
+	for device, count := range req.GetVector() {
+		secret.Vector[device] = int(count)
+	}
+
+	return secret, nil
+}
+
+// encodeSecret returns secret as response, with meta only size of data is returned without data
+func encodeSecret(secret model.Secret, metaOnly bool) (*secretpb.Secret, error) {
+	resp := &secretpb.Secret{
+		Id:     secret.ID.String(),
+		Ver:    int32(secret.Ver),
+		Meta:   secret.Meta,
+		Vector: encodeVector(secret.Vector),
+		Size:   int32(len(secret.Data)),
+	}
+	if metaOnly || secret.Data == "" {
+		return resp, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(secret.Data)
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "stored data is not base64 encoded: %v", err)
+	}
+	resp.Data = data
+
+	return resp, nil
+}
+
+// encodeVector returns version vector as response
+func encodeVector(v model.VersionVector) map[string]int32 {
+	res := make(map[string]int32, len(v))
+	for device, count := range v {
+		res[device] = int32(count)
+	}
+
+	return res
+}
+
+// parseID parses secret id of request
+func parseID(id string) (uuid.UUID, error) {
+	res, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: wrong secret id: %v", model.ErrorParamNotValid, err)
+	}
+
+	return res, nil
+}
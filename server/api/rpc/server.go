@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"github.com/go-chi/jwtauth/v5"
+	"google.golang.org/grpc"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+	"github.com/Xrefullx/YanDip/server/services/auth"
+	"github.com/Xrefullx/YanDip/server/services/device"
+	"github.com/Xrefullx/YanDip/server/services/events"
+	"github.com/Xrefullx/YanDip/server/services/secret"
+	"github.com/Xrefullx/YanDip/server/services/session"
+)
+
+var _ secretpb.SecretsServer = (*Server)(nil)
+
+// Server implements gRPC secrets service with the same services as http handler
+type Server struct {
+	secretpb.UnimplementedSecretsServer
+
+	svcAuth    auth.Authenticator
+	svcSecret  secret.SecretManager
+	svcDevice  device.DeviceManager
+	svcSession session.SessionManager
+	broker     events.EventBroker
+	jwtAuth    *jwtauth.JWTAuth
+}
+
+// NewServer returns new gRPC secrets service
+// if broker is nil, changes are not published
+// if device service is nil, device certificates are not issued and checked
+// session service issues tokens, it is required
+func NewServer(auth auth.Authenticator, secret secret.SecretManager, device device.DeviceManager, session session.SessionManager, broker events.EventBroker, jwtAuth *jwtauth.JWTAuth) *Server {
+	return &Server{
+		svcAuth:    auth,
+		svcSecret:  secret,
+		svcDevice:  device,
+		svcSession: session,
+		broker:     broker,
+		jwtAuth:    jwtAuth,
+	}
+}
+
+// NewGRPCServer returns gRPC server with secrets service registered, calls are authenticated by token
+func NewGRPCServer(s *Server, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.UnaryInterceptor(s.unaryAuth),
+		grpc.StreamInterceptor(s.streamAuth),
+	)
+
+	srv := grpc.NewServer(opts...)
+	secretpb.RegisterSecretsServer(srv, s)
+
+	return srv
+}
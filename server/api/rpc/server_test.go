@@ -0,0 +1,302 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/services/auth"
+	amk "github.com/Xrefullx/YanDip/server/services/auth/mock"
+	dmk "github.com/Xrefullx/YanDip/server/services/device/mock"
+	"github.com/Xrefullx/YanDip/server/services/events"
+	smk "github.com/Xrefullx/YanDip/server/services/secret/mock"
+	ssmk "github.com/Xrefullx/YanDip/server/services/session/mock"
+)
+
+var testJWT = jwtauth.New("HS256", []byte("secret"), nil)
+
+// startTestServer runs service on in-memory listener, returns client of service
+func startTestServer(t *testing.T, s *Server) secretpb.SecretsClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := NewGRPCServer(s)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return secretpb.NewSecretsClient(conn)
+}
+
+// withUser returns context with token of user device
+func withUser(t *testing.T, userID uuid.UUID, deviceID uuid.UUID) context.Context {
+	token, err := auth.Auth{}.EncodeTokenUserID(userID, deviceID, testJWT)
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// issueTokens returns token pair of session mock with access token of user device
+func issueTokens(_ context.Context, userID uuid.UUID, deviceID uuid.UUID) (model.TokenPair, error) {
+	token, err := auth.Auth{}.EncodeTokenUserID(userID, deviceID, testJWT)
+	return model.TokenPair{UserID: userID, DeviceID: deviceID, AccessToken: token}, err
+}
+
+func TestServer_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := model.User{ID: uuid.New(), Login: "login"}
+	req := &secretpb.LoginRequest{Login: "login", Password: "password", MasterHash: "hash", DeviceId: uuid.New().String()}
+
+	svcAuth := amk.NewMockAuthenticator(ctrl)
+	svcAuth.EXPECT().Authenticate(gomock.Any(), "login", "password", "hash").Return(user, nil)
+	svcAuth.EXPECT().Authenticate(gomock.Any(), "login", "wrong", "hash").Return(model.User{}, model.ErrorWrongAuthData)
+
+	svcSession := ssmk.NewMockSessionManager(ctrl)
+	svcSession.EXPECT().Issue(gomock.Any(), user.ID, gomock.Any()).DoAndReturn(issueTokens)
+
+	client := startTestServer(t, NewServer(svcAuth, smk.NewMockSecretManager(ctrl), nil, svcSession, nil, testJWT))
+	ctx := context.Background()
+
+	resp, err := client.Login(ctx, req)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.GetToken())
+
+	//  token of login authenticates calls
+	_, err = client.Ping(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+resp.GetToken()), &emptypb.Empty{})
+	require.NoError(t, err)
+
+	_, err = client.Ping(ctx, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Login(ctx, &secretpb.LoginRequest{Login: "login", Password: "wrong", MasterHash: "hash", DeviceId: req.DeviceId})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Login(ctx, &secretpb.LoginRequest{Login: "login", Password: "password", MasterHash: "hash"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_Device(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := model.User{ID: uuid.New(), Login: "login"}
+	deviceID, revokedID := uuid.New(), uuid.New()
+	created := time.Now()
+
+	svcAuth := amk.NewMockAuthenticator(ctrl)
+	svcAuth.EXPECT().Authenticate(gomock.Any(), "login", "password", "hash").Return(user, nil).Times(2)
+
+	svcSession := ssmk.NewMockSessionManager(ctrl)
+	svcSession.EXPECT().Issue(gomock.Any(), user.ID, deviceID).DoAndReturn(issueTokens)
+
+	svcDevice := dmk.NewMockDeviceManager(ctrl)
+	svcDevice.EXPECT().EnrollLogin(gomock.Any(), user.ID, deviceID, []byte("csr")).Return([]byte("cert"), nil)
+	svcDevice.EXPECT().EnrollLogin(gomock.Any(), user.ID, revokedID, []byte("csr")).Return(nil, model.ErrorDeviceRevoked)
+	svcDevice.EXPECT().CheckDevice(gomock.Any(), user.ID, deviceID, gomock.Any()).Return(nil)
+	svcDevice.EXPECT().CheckDevice(gomock.Any(), user.ID, revokedID, gomock.Any()).Return(model.ErrorDeviceRevoked)
+	svcDevice.EXPECT().GetUserDevices(gomock.Any(), user.ID).Return([]model.Device{
+		{ID: deviceID, UserID: user.ID, CreatedAt: created},
+		{ID: revokedID, UserID: user.ID, CreatedAt: created, RevokedAt: &created},
+	}, nil)
+
+	//  user is not created with invalid certificate request
+	svcDevice.EXPECT().ValidateRequest(deviceID, []byte("invalid")).Return(model.ErrorParamNotValid)
+
+	client := startTestServer(t, NewServer(svcAuth, smk.NewMockSecretManager(ctrl), svcDevice, svcSession, nil, testJWT))
+
+	_, err := client.Register(context.Background(),
+		&secretpb.LoginRequest{Login: "login", Password: "password", MasterHash: "hash", DeviceId: deviceID.String(), Csr: "invalid"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	//  certificate is issued to new device
+	resp, err := client.Login(context.Background(),
+		&secretpb.LoginRequest{Login: "login", Password: "password", MasterHash: "hash", DeviceId: deviceID.String(), Csr: "csr"})
+	require.NoError(t, err)
+	require.Equal(t, "cert", resp.GetCertificate())
+
+	_, err = client.Login(context.Background(),
+		&secretpb.LoginRequest{Login: "login", Password: "password", MasterHash: "hash", DeviceId: revokedID.String(), Csr: "csr"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	list, err := client.ListDevices(withUser(t, user.ID, deviceID), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, list.GetDevices(), 2)
+	require.True(t, list.GetDevices()[0].GetCurrent())
+	require.Equal(t, created.UnixMilli(), list.GetDevices()[1].GetRevokedAt())
+
+	//  token of revoked device is not accepted
+	_, err = client.Ping(withUser(t, user.ID, revokedID), &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := model.User{ID: uuid.New(), Login: "login"}
+	deviceID := uuid.New()
+
+	svcAuth := amk.NewMockAuthenticator(ctrl)
+	svcAuth.EXPECT().Authenticate(gomock.Any(), "login", "password", "hash").Return(user, nil)
+
+	access, err := auth.Auth{}.EncodeTokenUserID(user.ID, deviceID, testJWT)
+	require.NoError(t, err)
+
+	used := model.RefreshToken{ID: uuid.New(), UserID: user.ID, DeviceID: deviceID}
+
+	svcSession := ssmk.NewMockSessionManager(ctrl)
+	svcSession.EXPECT().Issue(gomock.Any(), user.ID, deviceID).
+		Return(model.TokenPair{UserID: user.ID, DeviceID: deviceID, AccessToken: access, RefreshToken: "refresh"}, nil)
+	svcSession.EXPECT().Lookup(gomock.Any(), "refresh").Return(used, nil).Times(2)
+	svcSession.EXPECT().Lookup(gomock.Any(), "reused").Return(model.RefreshToken{}, model.ErrorTokenRevoked)
+	//  token is rotated once, only after device is checked
+	svcSession.EXPECT().Rotate(gomock.Any(), used).
+		Return(model.TokenPair{UserID: user.ID, DeviceID: deviceID, AccessToken: access, RefreshToken: "rotated"}, nil)
+
+	svcDevice := dmk.NewMockDeviceManager(ctrl)
+	svcDevice.EXPECT().EnrollLogin(gomock.Any(), user.ID, deviceID, []byte{}).Return(nil, nil)
+	gomock.InOrder(
+		svcDevice.EXPECT().CheckDevice(gomock.Any(), user.ID, deviceID, gomock.Any()).Return(nil),
+		svcDevice.EXPECT().CheckDevice(gomock.Any(), user.ID, deviceID, gomock.Any()).Return(model.ErrorDeviceCertMismatch),
+	)
+
+	client := startTestServer(t, NewServer(svcAuth, smk.NewMockSecretManager(ctrl), svcDevice, svcSession, nil, testJWT))
+	ctx := context.Background()
+
+	//  refresh token is issued on login
+	resp, err := client.Login(ctx, &secretpb.LoginRequest{Login: "login", Password: "password", MasterHash: "hash", DeviceId: deviceID.String()})
+	require.NoError(t, err)
+	require.Equal(t, access, resp.GetToken())
+	require.Equal(t, "refresh", resp.GetRefreshToken())
+
+	//  refresh is called without token
+	resp, err = client.Refresh(ctx, &secretpb.RefreshRequest{RefreshToken: "refresh"})
+	require.NoError(t, err)
+	require.Equal(t, "rotated", resp.GetRefreshToken())
+
+	//  token is not rotated by other device
+	_, err = client.Refresh(ctx, &secretpb.RefreshRequest{RefreshToken: "refresh"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Refresh(ctx, &secretpb.RefreshRequest{RefreshToken: "reused"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.Refresh(ctx, &secretpb.RefreshRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_Secret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID := uuid.New(), uuid.New()
+	stored := model.Secret{
+		ID:     uuid.New(),
+		UserID: userID,
+		Ver:    2,
+		Data:   "ZGF0YQ==",
+		Meta:   "meta",
+		Vector: model.VersionVector{deviceID.String(): 2},
+	}
+
+	svcSecret := smk.NewMockSecretManager(ctrl)
+	svcSecret.EXPECT().Update(gomock.Any(), gomock.Any(), deviceID).DoAndReturn(
+		func(_ context.Context, secret model.Secret, _ uuid.UUID) (model.Secret, error) {
+			//  raw data is stored base64 encoded
+			require.Equal(t, stored.Data, secret.Data)
+			require.Equal(t, userID, secret.UserID)
+			return stored, nil
+		})
+	svcSecret.EXPECT().Update(gomock.Any(), gomock.Any(), deviceID).Return(model.Secret{}, model.ErrorVersionConflict)
+	svcSecret.EXPECT().Get(gomock.Any(), stored.ID, userID).Return(stored, nil)
+	svcSecret.EXPECT().Get(gomock.Any(), gomock.Any(), userID).Return(model.Secret{}, model.ErrorItemNotFound)
+
+	broker := events.NewBroker()
+	defer broker.Close()
+	otherDevice, unsubscribe := broker.Subscribe(userID, uuid.New())
+	defer unsubscribe()
+
+	client := startTestServer(t, NewServer(amk.NewMockAuthenticator(ctrl), svcSecret, nil, nil, broker, testJWT))
+	ctx := withUser(t, userID, deviceID)
+
+	upload := &secretpb.Secret{Id: stored.ID.String(), Ver: 1, Data: []byte("data"), Vector: map[string]int32{deviceID.String(): 1}}
+	saved, err := client.UploadSecret(ctx, upload)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), saved.GetVer())
+	require.Equal(t, map[string]int32{deviceID.String(): 2}, saved.GetVector())
+
+	//  other devices of user are notified
+	select {
+	case e := <-otherDevice:
+		require.Equal(t, stored.ID, e.SecretID)
+		require.Equal(t, stored.Ver, e.Ver)
+	case <-time.After(time.Second):
+		t.Fatal("change event is not published")
+	}
+
+	_, err = client.UploadSecret(ctx, upload)
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	secret, err := client.GetSecret(ctx, &secretpb.SecretID{Id: stored.ID.String()})
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), secret.GetData())
+	require.Equal(t, "meta", secret.GetMeta())
+
+	_, err = client.GetSecret(ctx, &secretpb.SecretID{Id: uuid.New().String()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetSecret(ctx, &secretpb.SecretID{Id: "wrong"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_SyncEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	broker := events.NewBroker()
+	defer broker.Close()
+
+	client := startTestServer(t, NewServer(amk.NewMockAuthenticator(ctrl), smk.NewMockSecretManager(ctrl), nil, nil, broker, testJWT))
+	ctx, cancel := context.WithCancel(withUser(t, userID, uuid.New()))
+	defer cancel()
+
+	stream, err := client.SyncEvents(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	//  header is sent when subscription is active
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	id := uuid.New()
+	broker.Publish(model.ChangeEvent{UserID: userID, DeviceID: uuid.New(), SecretID: id, Ver: 3})
+
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, id.String(), e.GetId())
+	require.Equal(t, int32(3), e.GetVer())
+}
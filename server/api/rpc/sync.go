@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Xrefullx/YanDip/proto/secretpb"
+)
+
+const (
+	//  default and max count of changes in change feed response
+	defChangesLimit = 500
+	maxChangesLimit = 1000
+)
+
+// SyncList returns versions and hashes of user secrets, tombstones and change feed cursor
+func (s *Server) SyncList(ctx context.Context, _ *emptypb.Empty) (*secretpb.SyncListResponse, error) {
+	user := getUserDataFromContext(ctx)
+
+	//  cursor is read before list, changes made between are returned by change feed again
+	cursor, err := s.svcSecret.GetUserCursor(ctx, user.UserID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	list, err := s.svcSecret.GetUserSyncList(ctx, user.UserID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	tombstones, err := s.svcSecret.GetUserTombstones(ctx, user.UserID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &secretpb.SyncListResponse{
+		List:       make(map[string]int32, len(list)),
+		Hashes:     make(map[string]string, len(list)),
+		Tombstones: make([]*secretpb.Tombstone, 0, len(tombstones)),
+		Cursor:     cursor,
+	}
+	for id, el := range list {
+		resp.List[id.String()] = int32(el.Ver)
+		if el.Hash != "" {
+			resp.Hashes[id.String()] = el.Hash
+		}
+	}
+	for _, el := range tombstones {
+		resp.Tombstones = append(resp.Tombstones, &secretpb.Tombstone{
+			Id:        el.ID.String(),
+			Ver:       int32(el.Ver),
+			DeletedAt: el.DeletedAt.UnixMilli(),
+			DeletedBy: el.DeletedBy.String(),
+		})
+	}
+
+	return resp, nil
+}
+
+// SyncChanges returns changes of user secrets after cursor
+// OUT_OF_RANGE - cursor expired, client must get full sync list
+// INVALID_ARGUMENT - cursor or limit not valid
+func (s *Server) SyncChanges(ctx context.Context, req *secretpb.SyncChangesRequest) (*secretpb.SyncChangesResponse, error) {
+	user := getUserDataFromContext(ctx)
+
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit is negative")
+	}
+	if limit == 0 {
+		limit = defChangesLimit
+	}
+	if limit > maxChangesLimit {
+		limit = maxChangesLimit
+	}
+
+	feed, err := s.svcSecret.GetChanges(ctx, user.UserID, req.GetCursor(), limit)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &secretpb.SyncChangesResponse{
+		Changes: make([]*secretpb.Change, 0, len(feed.Changes)),
+		Cursor:  feed.Cursor,
+		HasMore: feed.HasMore,
+	}
+	for _, el := range feed.Changes {
+		change := &secretpb.Change{
+			Id:        el.ID.String(),
+			Ver:       int32(el.Ver),
+			IsDeleted: el.IsDeleted,
+			Seq:       el.Seq,
+		}
+		if el.IsDeleted {
+			change.DeletedAt, change.DeletedBy = el.DeletedAt.UnixMilli(), el.DeletedBy.String()
+		}
+
+		resp.Changes = append(resp.Changes, change)
+	}
+
+	return resp, nil
+}
+
+// SyncEvents streams changes of user secrets made by other devices until client disconnects
+// UNAVAILABLE - streaming not supported
+func (s *Server) SyncEvents(_ *emptypb.Empty, stream secretpb.Secrets_SyncEventsServer) error {
+	user := getUserDataFromContext(stream.Context())
+
+	if s.broker == nil {
+		return status.Error(codes.Unavailable, "streaming not supported")
+	}
+
+	changes, unsubscribe := s.broker.Subscribe(user.UserID, user.DeviceID)
+	defer unsubscribe()
+
+	//  client knows subscription is active
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case e, ok := <-changes:
+			if !ok {
+				return nil
+			}
+
+			if err := stream.Send(&secretpb.ChangeEvent{
+				Id:        e.SecretID.String(),
+				Ver:       int32(e.Ver),
+				IsDeleted: e.IsDeleted,
+			}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -2,56 +2,136 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/jwtauth/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/Xrefullx/YanDip/server/api/handler"
+	"github.com/Xrefullx/YanDip/server/api/rpc"
 	"github.com/Xrefullx/YanDip/server/pkg"
 	"github.com/Xrefullx/YanDip/server/services/auth"
+	"github.com/Xrefullx/YanDip/server/services/device"
+	"github.com/Xrefullx/YanDip/server/services/events"
 	"github.com/Xrefullx/YanDip/server/services/secret"
+	"github.com/Xrefullx/YanDip/server/services/session"
 )
 
 type Server struct {
 	httpServer http.Server
+	grpcServer *grpc.Server
 	cfg        *pkg.Config
 }
 
-func NewServer(cfg *pkg.Config, a auth.Authenticator, secret secret.SecretManager, jwtAuth *jwtauth.JWTAuth) (*Server, error) {
+// NewServer returns http and gRPC servers.
+// if device service is set, client certificates of devices are verified with device CA
+func NewServer(cfg *pkg.Config, a auth.Authenticator, secret secret.SecretManager, device device.DeviceManager, session session.SessionManager, broker events.EventBroker, jwtAuth *jwtauth.JWTAuth) (*Server, error) {
 
-	h, err := handler.NewHandler(a, secret, jwtAuth)
+	h, err := handler.NewHandler(a, secret, device, session, broker, jwtAuth)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запуска server:%w", err)
 	}
 
-	return &Server{
+	//  gRPC server uses the same certificate as http server
+	var opts []grpc.ServerOption
+	var tlsConfig *tls.Config
+	if cfg.EnableHTTPS {
+		tlsConfig, err = serverTLSConfig(device)
+		if err != nil {
+			return nil, fmt.Errorf("error serve ssl:%w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := &Server{
 		httpServer: http.Server{
-			Addr:    cfg.ServerPort,
-			Handler: handler.GetRouter(h),
+			Addr:      cfg.ServerPort,
+			Handler:   handler.GetRouter(h),
+			TLSConfig: tlsConfig,
 		},
-		cfg: cfg,
-	}, nil
+		grpcServer: rpc.NewGRPCServer(rpc.NewServer(a, secret, device, session, broker, jwtAuth), opts...),
+		cfg:        cfg,
+	}
+
+	//  event streams are closed on shutdown, else shutdown waits for them
+	s.httpServer.RegisterOnShutdown(broker.Close)
+
+	return s, nil
 
 }
 
 // Run Start server
+// http and gRPC servers are started, returns when one of them is stopped
 func (s *Server) Run() error {
+	errc := make(chan error, 2)
+	go func() {
+		errc <- s.runGRPC()
+	}()
+	go func() {
+		errc <- s.runHTTP()
+	}()
+
+	return <-errc
+}
+
+// runHTTP starts http server
+func (s *Server) runHTTP() error {
 	log.Printf("starting HTTP server on %v", s.cfg.ServerPort)
 
 	if s.cfg.EnableHTTPS {
-		certPath, keyPath, err := pkg.GetCertX509Files()
-		if err != nil {
-			return fmt.Errorf("error serve ssl:%w", err)
-		}
-		return handleServerCloseErr(s.httpServer.ListenAndServeTLS(certPath, keyPath))
+		//  certificate is set in server tls config
+		return handleServerCloseErr(s.httpServer.ListenAndServeTLS("", ""))
 	}
 
 	return handleServerCloseErr(s.httpServer.ListenAndServe())
 }
 
+// runGRPC starts gRPC server
+func (s *Server) runGRPC() error {
+	log.Printf("starting gRPC server on %v", s.cfg.GRPCPort)
+
+	listener, err := net.Listen("tcp", s.cfg.GRPCPort)
+	if err != nil {
+		return fmt.Errorf("gRPC server listen error: %w", err)
+	}
+
+	if err := s.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("gRPC server closed with: %w", err)
+	}
+
+	return nil
+}
+
+// serverTLSConfig returns tls config with server certificate.
+// Client certificate is verified with device CA if it is sent, requests without it are checked by device service.
+func serverTLSConfig(device device.DeviceManager) (*tls.Config, error) {
+	certPath, keyPath, err := pkg.GetCertX509Files()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if device != nil {
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		cfg.ClientCAs = device.ClientCAs()
+	}
+
+	return cfg, nil
+}
+
 // returns error if error is not http.ErrServerClosed
 func handleServerCloseErr(err error) error {
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -67,5 +147,19 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		return errors.New("http server not runned")
 	}
 
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+
+	//  event streams are closed by http server shutdown, running calls are waited until ctx is done
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+
+	return err
 }
@@ -4,12 +4,24 @@ import "errors"
 
 var (
 	ErrorConflictSaveUser = errors.New("user already exist")
-	ErrorItemNotFound     = errors.New("item not found")
-	ErrorWrongAuthData    = errors.New("incorrect login or password")
+	//  device id is enrolled already, new certificate is not issued for it
+	ErrorConflictSaveDevice = errors.New("device already enrolled")
+	ErrorItemNotFound       = errors.New("item not found")
+	ErrorWrongAuthData      = errors.New("incorrect login or password")
 
-	ErrorVersionToLow  = errors.New("version of data to low")
-	ErrorItemIsDeleted = errors.New("element is deleted")
-	ErrorParamNotValid = errors.New("incoming parameter not valid")
+	//  update is not based on stored version, client must merge stored version first
+	ErrorVersionConflict = errors.New("version conflict, update is not based on stored version")
+	ErrorItemIsDeleted   = errors.New("element is deleted")
+	ErrorParamNotValid   = errors.New("incoming parameter not valid")
+	ErrorCursorExpired   = errors.New("change feed cursor expired")
+
+	//  device is revoked, its token and certificate are not accepted
+	ErrorDeviceRevoked = errors.New("device is revoked")
+	//  request is not made with certificate of token device
+	ErrorDeviceCertMismatch = errors.New("client certificate does not match token device")
+
+	//  refresh token is unknown, expired, used or revoked, device must login again
+	ErrorTokenRevoked = errors.New("refresh token is revoked")
 
 	ErrAddingUser         = errors.New("ошибка добавления пользователя")
 	ErrAuthenticatingUser = errors.New("ошибка авторизации пользователя")
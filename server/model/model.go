@@ -1,7 +1,11 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
@@ -21,11 +25,95 @@ type (
 	}
 
 	Secret struct {
-		ID        uuid.UUID `validate:"required"`
-		Ver       int       `validate:"required,min=1"`
-		UserID    uuid.UUID `validate:"required"`
-		Data      string    `validate:"required_without=IsDeleted"`
+		ID     uuid.UUID `validate:"required"`
+		Ver    int       `validate:"required,min=1"`
+		UserID uuid.UUID `validate:"required"`
+		Data   string    `validate:"required_without=IsDeleted"`
+		//  encrypted secret description, downloaded without data
+		Meta string
+		//  changes of secret by devices, for update it is vector of version edit is based on
+		Vector VersionVector
+		//  hash of data, calculated by server when data is stored
+		Hash      string
+		IsDeleted bool
+	}
+
+	// SecretVersion is version and data hash of stored secret
+	SecretVersion struct {
+		Ver  int
+		Hash string
+	}
+
+	// SecretChange is change feed record, last change of secret
+	SecretChange struct {
+		ID        uuid.UUID
+		Ver       int
 		IsDeleted bool
+		//  change sequence number, grows with every change
+		Seq int64
+		//  deletion time and device, set if deleted
+		DeletedAt time.Time
+		DeletedBy uuid.UUID
+	}
+
+	// ChangeEvent is change of user secret, published to other devices of user
+	ChangeEvent struct {
+		UserID uuid.UUID
+		//  originating device, not notified
+		DeviceID  uuid.UUID
+		SecretID  uuid.UUID
+		Ver       int
+		IsDeleted bool
+	}
+
+	// Tombstone is record of deleted secret, deleted secret version is greater than last version
+	Tombstone struct {
+		ID        uuid.UUID
+		Ver       int
+		DeletedAt time.Time
+		DeletedBy uuid.UUID
+	}
+
+	// Device is user device enrolled with client certificate signed by device CA
+	Device struct {
+		ID     uuid.UUID
+		UserID uuid.UUID
+		//  serial number of device certificate, hex encoded
+		CertSerial string
+		CreatedAt  time.Time
+		//  set if device is revoked, certificate of revoked device is not accepted
+		RevokedAt *time.Time
+	}
+
+	// RefreshToken is record of refresh token issued to user device, only hash of token is stored.
+	// Token is used once, new token is issued on refresh.
+	RefreshToken struct {
+		ID        uuid.UUID
+		UserID    uuid.UUID
+		DeviceID  uuid.UUID
+		TokenHash string
+		ExpiresAt time.Time
+		CreatedAt time.Time
+		//  set when token is exchanged for new token
+		UsedAt *time.Time
+		//  set when token is revoked
+		RevokedAt *time.Time
+	}
+
+	// TokenPair is access token with refresh token issued to user device
+	TokenPair struct {
+		UserID       uuid.UUID
+		DeviceID     uuid.UUID
+		AccessToken  string
+		RefreshToken string
+	}
+
+	// ChangeFeed is page of changes after cursor
+	ChangeFeed struct {
+		Changes []SecretChange
+		//  cursor to request next changes
+		Cursor  int64
+		HasMore bool
 	}
 )
 
@@ -55,6 +143,13 @@ func (s *Secret) ValidateUpdate() error {
 	return nil
 }
 
+// DataHash returns hex encoded sha256 of secret data
+func DataHash(data string) string {
+	sum := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(sum[:])
+}
+
 func (u *User) ValidateLogin() error {
 	err := validate.Var(u.Login, "required,min=3,max=60")
 	if err != nil {
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+)
+
+// deviceCAValidity is lifetime of generated device CA
+const deviceCAValidity = 10 * 365 * 24 * time.Hour
+
+// GetDeviceCA returns device CA certificate and key from files.
+// If files not exist, generates new CA, so device certificates are signed by the same CA after restart.
+func GetDeviceCA(certFile string, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	certExist, err := filesExist(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyExist, err := filesExist(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !certExist && !keyExist {
+		if err := genDeviceCAFiles(certFile, keyFile); err != nil {
+			return nil, nil, fmt.Errorf("error generate device CA: %w", err)
+		}
+	}
+
+	return readDeviceCA(certFile, keyFile)
+}
+
+// genDeviceCAFiles generates device CA certificate and key files, key file is readable by owner only
+func genDeviceCAFiles(certFile string, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Secrets"},
+			CommonName:   "Secrets device CA",
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(deviceCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}), 0644)
+}
+
+// readDeviceCA reads device CA certificate and key PEM files
+func readDeviceCA(certFile string, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("device CA file %v has no certificate", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cert.IsCA {
+		return nil, nil, fmt.Errorf("device CA certificate %v is not CA", certFile)
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("device CA file %v has no key", keyFile)
+	}
+	key, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// parsePrivateKey parses EC or PKCS8 key
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "EC PRIVATE KEY" {
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("device CA key is not signing key")
+	}
+
+	return signer, nil
+}
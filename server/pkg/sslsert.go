@@ -29,7 +29,8 @@ var cert = &x509.Certificate{
 		Organization: []string{"Secrets"},
 		Country:      []string{"RU"},
 	},
-	// разрешаем использование сертификата для 127.0.0.1 и ::1
+	// разрешаем использование сертификата для localhost, 127.0.0.1 и ::1
+	DNSNames:    []string{"localhost"},
 	IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
 	// сертификат верен, начиная со времени создания
 	NotBefore: time.Now(),
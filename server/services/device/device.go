@@ -0,0 +1,240 @@
+package device
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/storage"
+)
+
+var _ DeviceManager = (*Device)(nil)
+
+// certValidity is lifetime of device certificate
+const certValidity = 365 * 24 * time.Hour
+
+type Device struct {
+	storage storage.DeviceRepository
+	caCert  *x509.Certificate
+	caKey   crypto.Signer
+	pool    *x509.CertPool
+	//  devices not enrolled are accepted without certificate
+	allowLegacy bool
+}
+
+// NewDevice returns device service, device certificates are signed by CA.
+// If allowLegacy is set, devices logged in before certificates were issued are accepted without certificate.
+func NewDevice(repo storage.DeviceRepository, caCert *x509.Certificate, caKey crypto.Signer, allowLegacy bool) (*Device, error) {
+	if caCert == nil || caKey == nil {
+		return nil, errors.New("device CA is not set")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return &Device{
+		storage: repo,
+		caCert:  caCert,
+		caKey:   caKey,
+		pool:    pool,
+
+		allowLegacy: allowLegacy,
+	}, nil
+}
+
+// ValidateRequest checks certificate request of device, request is required unless legacy devices are accepted.
+// Common name of request must be device id.
+func (d *Device) ValidateRequest(deviceID uuid.UUID, csrPEM []byte) error {
+	_, err := d.parseRequest(deviceID, csrPEM)
+
+	return err
+}
+
+// Enroll signs certificate request of new device.
+// Common name of request must be device id, certificate is bound to device id and user.
+// Certificate is issued once for device id, ErrorConflictSaveDevice returned if device is enrolled.
+// Without request device must be enrolled by user, ErrorParamNotValid returned for new device unless legacy devices are accepted.
+func (d *Device) Enroll(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, csrPEM []byte) ([]byte, error) {
+	//  enrolled device logs in without request
+	if len(csrPEM) == 0 {
+		err := d.checkEnrolled(ctx, userID, deviceID)
+		if err == nil && !d.allowLegacy {
+			return nil, fmt.Errorf("%w: certificate request of new device is required", model.ErrorParamNotValid)
+		}
+		return nil, err
+	}
+
+	csr, err := d.parseRequest(deviceID, csrPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.checkEnrolled(ctx, userID, deviceID); err != nil {
+		return nil, err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         deviceID.String(),
+			OrganizationalUnit: []string{userID.String()},
+		},
+		NotBefore:   time.Now().Add(-time.Minute),
+		NotAfter:    time.Now().Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, tmpl, d.caCert, csr.PublicKey, d.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.storage.Add(ctx, model.Device{ID: deviceID, UserID: userID, CertSerial: serial.Text(16)}); err != nil {
+		return nil, err
+	}
+	log.Printf("device %v of user %v enrolled", deviceID, userID)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}), nil
+}
+
+// EnrollLogin issues certificate to device of login or registration.
+// Certificate is not issued again to enrolled device, empty certificate is returned for it.
+// New device without request is rejected unless legacy devices are accepted.
+func (d *Device) EnrollLogin(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, csrPEM []byte) ([]byte, error) {
+	cert, err := d.Enroll(ctx, userID, deviceID, csrPEM)
+	if errors.Is(err, model.ErrorConflictSaveDevice) {
+		return nil, nil
+	}
+
+	return cert, err
+}
+
+// CheckDevice checks that request of token device is made with certificate issued for device.
+// Device not enrolled is rejected, if legacy devices are accepted its requests without certificate are accepted.
+func (d *Device) CheckDevice(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, certs []*x509.Certificate) error {
+	device, err := d.storage.Get(ctx, deviceID)
+	if errors.Is(err, model.ErrorItemNotFound) {
+		if d.allowLegacy && len(certs) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%w: device %v is not enrolled", model.ErrorDeviceCertMismatch, deviceID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if device.UserID != userID {
+		return fmt.Errorf("%w: device %v is enrolled by other user", model.ErrorDeviceCertMismatch, deviceID)
+	}
+	if device.RevokedAt != nil {
+		return model.ErrorDeviceRevoked
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("%w: certificate of device %v is required", model.ErrorDeviceCertMismatch, deviceID)
+	}
+
+	cert := certs[0]
+	if cert.Subject.CommonName != deviceID.String() || cert.SerialNumber.Text(16) != device.CertSerial {
+		return fmt.Errorf("%w: certificate of %v presented", model.ErrorDeviceCertMismatch, cert.Subject.CommonName)
+	}
+
+	return nil
+}
+
+// Revoke revokes user device
+func (d *Device) Revoke(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error {
+	if err := d.storage.Revoke(ctx, deviceID, userID); err != nil {
+		return err
+	}
+	log.Printf("device %v of user %v revoked", deviceID, userID)
+
+	return nil
+}
+
+// GetUserDevices returns devices of user
+func (d *Device) GetUserDevices(ctx context.Context, userID uuid.UUID) ([]model.Device, error) {
+	return d.storage.GetUserDevices(ctx, userID)
+}
+
+// ClientCAs returns pool of device CA
+func (d *Device) ClientCAs() *x509.CertPool {
+	return d.pool
+}
+
+// checkEnrolled returns error if device id can not be enrolled
+func (d *Device) checkEnrolled(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error {
+	device, err := d.storage.Get(ctx, deviceID)
+	if errors.Is(err, model.ErrorItemNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if device.UserID == userID && device.RevokedAt != nil {
+		return model.ErrorDeviceRevoked
+	}
+
+	return model.ErrorConflictSaveDevice
+}
+
+// newSerial returns random 128 bit certificate serial number
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// parseRequest parses certificate request of device.
+// Empty request is not valid, nil is returned for it if legacy devices are accepted.
+func (d *Device) parseRequest(deviceID uuid.UUID, csrPEM []byte) (*x509.CertificateRequest, error) {
+	if len(csrPEM) == 0 {
+		if d.allowLegacy {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: certificate request of device is required", model.ErrorParamNotValid)
+	}
+
+	csr, err := parseCSR(csrPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrorParamNotValid, err)
+	}
+	if csr.Subject.CommonName != deviceID.String() {
+		return nil, fmt.Errorf("%w: certificate request is not made for device %v", model.ErrorParamNotValid, deviceID)
+	}
+
+	return csr, nil
+}
+
+// parseCSR parses PEM certificate request and checks its signature
+func parseCSR(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("certificate request is not PEM encoded")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	return csr, nil
+}
@@ -0,0 +1,245 @@
+package device
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/pkg"
+	"github.com/Xrefullx/YanDip/server/storage/mock"
+)
+
+// newTestDevice returns device service with CA generated in temp dir
+func newTestDevice(t *testing.T, repo *mock.MockDeviceRepository, allowLegacy bool) *Device {
+	dir := t.TempDir()
+	caCert, caKey, err := pkg.GetDeviceCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	require.NoError(t, err)
+
+	svc, err := NewDevice(repo, caCert, caKey, allowLegacy)
+	require.NoError(t, err)
+
+	return svc
+}
+
+// randSerial returns random certificate serial number
+func randSerial(t *testing.T) *big.Int {
+	serial, err := newSerial()
+	require.NoError(t, err)
+
+	return serial
+}
+
+// testCSR returns PEM certificate request with common name
+func testCSR(t *testing.T, cn string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestDevice_Enroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID := uuid.New(), uuid.New()
+	revokedAt := time.Now()
+
+	var stored model.Device
+	repo := mock.NewMockDeviceRepository(ctrl)
+	repo.EXPECT().Get(gomock.Any(), deviceID).Return(model.Device{}, model.ErrorItemNotFound)
+	repo.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, device model.Device) error {
+		stored = device
+		return nil
+	})
+	repo.EXPECT().Get(gomock.Any(), deviceID).DoAndReturn(func(_ context.Context, _ uuid.UUID) (model.Device, error) {
+		return stored, nil
+	}).Times(2)
+	repo.EXPECT().Get(gomock.Any(), deviceID).Return(model.Device{ID: deviceID, UserID: userID, RevokedAt: &revokedAt}, nil)
+
+	svc := newTestDevice(t, repo, false)
+	ctx := context.Background()
+
+	//  request must be made for device
+	_, err := svc.Enroll(ctx, userID, deviceID, testCSR(t, uuid.NewString()))
+	require.ErrorIs(t, err, model.ErrorParamNotValid)
+
+	certPEM, err := svc.Enroll(ctx, userID, deviceID, testCSR(t, deviceID.String()))
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.Equal(t, deviceID.String(), cert.Subject.CommonName)
+	require.Equal(t, cert.SerialNumber.Text(16), stored.CertSerial)
+	require.Equal(t, userID, stored.UserID)
+
+	_, err = cert.Verify(x509.VerifyOptions{Roots: svc.ClientCAs(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	require.NoError(t, err)
+
+	//  certificate is issued once
+	_, err = svc.Enroll(ctx, userID, deviceID, testCSR(t, deviceID.String()))
+	require.ErrorIs(t, err, model.ErrorConflictSaveDevice)
+	_, err = svc.Enroll(ctx, uuid.New(), deviceID, testCSR(t, deviceID.String()))
+	require.ErrorIs(t, err, model.ErrorConflictSaveDevice)
+
+	_, err = svc.Enroll(ctx, userID, deviceID, testCSR(t, deviceID.String()))
+	require.ErrorIs(t, err, model.ErrorDeviceRevoked)
+}
+
+func TestDevice_ValidateRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deviceID := uuid.New()
+	svc := newTestDevice(t, mock.NewMockDeviceRepository(ctrl), false)
+	legacy := newTestDevice(t, mock.NewMockDeviceRepository(ctrl), true)
+
+	require.NoError(t, svc.ValidateRequest(deviceID, testCSR(t, deviceID.String())))
+	require.ErrorIs(t, svc.ValidateRequest(deviceID, testCSR(t, uuid.NewString())), model.ErrorParamNotValid)
+	require.ErrorIs(t, svc.ValidateRequest(deviceID, []byte("csr")), model.ErrorParamNotValid)
+
+	//  request is required unless legacy devices are accepted
+	require.ErrorIs(t, svc.ValidateRequest(deviceID, nil), model.ErrorParamNotValid)
+	require.NoError(t, legacy.ValidateRequest(deviceID, nil))
+}
+
+func TestDevice_EnrollWithoutRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID := uuid.New(), uuid.New()
+	revokedAt := time.Now()
+	device := model.Device{ID: deviceID, UserID: userID}
+	revoked := device
+	revoked.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name   string
+		device model.Device
+		err    error
+		legacy bool
+		want   error
+	}{
+		{name: "new device", err: model.ErrorItemNotFound, want: model.ErrorParamNotValid},
+		{name: "new device if legacy accepted", err: model.ErrorItemNotFound, legacy: true},
+		{name: "enrolled device", device: device, want: model.ErrorConflictSaveDevice},
+		{name: "revoked device", device: revoked, want: model.ErrorDeviceRevoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mock.NewMockDeviceRepository(ctrl)
+			repo.EXPECT().Get(gomock.Any(), deviceID).Return(tt.device, tt.err)
+
+			cert, err := newTestDevice(t, repo, tt.legacy).Enroll(context.Background(), userID, deviceID, nil)
+			require.Empty(t, cert)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestDevice_EnrollLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID := uuid.New(), uuid.New()
+	revokedAt := time.Now()
+
+	repo := mock.NewMockDeviceRepository(ctrl)
+	repo.EXPECT().Get(gomock.Any(), deviceID).Return(model.Device{}, model.ErrorItemNotFound)
+	repo.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().Get(gomock.Any(), deviceID).Return(model.Device{ID: deviceID, UserID: userID}, nil).Times(2)
+	repo.EXPECT().Get(gomock.Any(), deviceID).Return(model.Device{ID: deviceID, UserID: userID, RevokedAt: &revokedAt}, nil)
+
+	svc := newTestDevice(t, repo, false)
+	ctx := context.Background()
+
+	cert, err := svc.EnrollLogin(ctx, userID, deviceID, testCSR(t, deviceID.String()))
+	require.NoError(t, err)
+	require.NotEmpty(t, cert)
+
+	//  enrolled device logs in without new certificate
+	cert, err = svc.EnrollLogin(ctx, userID, deviceID, testCSR(t, deviceID.String()))
+	require.NoError(t, err)
+	require.Empty(t, cert)
+	cert, err = svc.EnrollLogin(ctx, userID, deviceID, nil)
+	require.NoError(t, err)
+	require.Empty(t, cert)
+
+	_, err = svc.EnrollLogin(ctx, userID, deviceID, nil)
+	require.ErrorIs(t, err, model.ErrorDeviceRevoked)
+}
+
+func TestDevice_CheckDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID, deviceID := uuid.New(), uuid.New()
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: deviceID.String()}, SerialNumber: randSerial(t)}
+	revokedAt := time.Now()
+	device := model.Device{ID: deviceID, UserID: userID, CertSerial: cert.SerialNumber.Text(16)}
+	revoked := device
+	revoked.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name   string
+		device model.Device
+		err    error
+		userID uuid.UUID
+		certs  []*x509.Certificate
+		legacy bool
+		want   error
+	}{
+		{name: "device certificate", device: device, userID: userID, certs: []*x509.Certificate{cert}},
+		{name: "not enrolled without certificate", err: model.ErrorItemNotFound, userID: userID, want: model.ErrorDeviceCertMismatch},
+		{name: "not enrolled with certificate", err: model.ErrorItemNotFound, userID: userID,
+			certs: []*x509.Certificate{cert}, want: model.ErrorDeviceCertMismatch},
+		{name: "legacy device without certificate", err: model.ErrorItemNotFound, userID: userID, legacy: true},
+		{name: "legacy device with certificate", err: model.ErrorItemNotFound, userID: userID, legacy: true,
+			certs: []*x509.Certificate{cert}, want: model.ErrorDeviceCertMismatch},
+		{name: "enrolled device without certificate if legacy accepted", device: device, userID: userID, legacy: true,
+			want: model.ErrorDeviceCertMismatch},
+		{name: "without certificate", device: device, userID: userID, want: model.ErrorDeviceCertMismatch},
+		{name: "other user", device: device, userID: uuid.New(), certs: []*x509.Certificate{cert}, want: model.ErrorDeviceCertMismatch},
+		{name: "revoked", device: revoked, userID: userID, certs: []*x509.Certificate{cert}, want: model.ErrorDeviceRevoked},
+		{name: "certificate of other device", device: device, userID: userID, want: model.ErrorDeviceCertMismatch,
+			certs: []*x509.Certificate{{Subject: pkix.Name{CommonName: uuid.NewString()}, SerialNumber: cert.SerialNumber}}},
+		{name: "reissued certificate", device: device, userID: userID, want: model.ErrorDeviceCertMismatch,
+			certs: []*x509.Certificate{{Subject: cert.Subject, SerialNumber: randSerial(t)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mock.NewMockDeviceRepository(ctrl)
+			repo.EXPECT().Get(gomock.Any(), deviceID).Return(tt.device, tt.err)
+
+			err := newTestDevice(t, repo, tt.legacy).CheckDevice(context.Background(), tt.userID, deviceID, tt.certs)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+}
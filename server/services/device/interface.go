@@ -0,0 +1,27 @@
+package device
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+type DeviceManager interface {
+	//  Checks certificate request of device before user is created, ErrorParamNotValid if request is not valid
+	ValidateRequest(deviceID uuid.UUID, csrPEM []byte) error
+	//  Signs certificate request of new user device, returns PEM encoded device certificate.
+	//  Without request checks that device is enrolled by user
+	Enroll(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, csrPEM []byte) ([]byte, error)
+	//  Enrolls device of user login, returns empty certificate if device is enrolled already
+	EnrollLogin(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, csrPEM []byte) ([]byte, error)
+	//  Checks that request of token device is made with device certificate, certs are verified client certificates
+	CheckDevice(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID, certs []*x509.Certificate) error
+	//  Revokes user device, token and certificate of revoked device are not accepted
+	Revoke(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error
+	GetUserDevices(ctx context.Context, userID uuid.UUID) ([]model.Device, error)
+	//  Returns pool of device CA, client certificates are verified with it
+	ClientCAs() *x509.CertPool
+}
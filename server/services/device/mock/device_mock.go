@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: server/services/device/interface.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	x509 "crypto/x509"
+	reflect "reflect"
+
+	model "github.com/Xrefullx/YanDip/server/model"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockDeviceManager is a mock of DeviceManager interface.
+type MockDeviceManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceManagerMockRecorder
+}
+
+// MockDeviceManagerMockRecorder is the mock recorder for MockDeviceManager.
+type MockDeviceManagerMockRecorder struct {
+	mock *MockDeviceManager
+}
+
+// NewMockDeviceManager creates a new mock instance.
+func NewMockDeviceManager(ctrl *gomock.Controller) *MockDeviceManager {
+	mock := &MockDeviceManager{ctrl: ctrl}
+	mock.recorder = &MockDeviceManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceManager) EXPECT() *MockDeviceManagerMockRecorder {
+	return m.recorder
+}
+
+// CheckDevice mocks base method.
+func (m *MockDeviceManager) CheckDevice(ctx context.Context, userID, deviceID uuid.UUID, certs []*x509.Certificate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckDevice", ctx, userID, deviceID, certs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckDevice indicates an expected call of CheckDevice.
+func (mr *MockDeviceManagerMockRecorder) CheckDevice(ctx, userID, deviceID, certs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckDevice", reflect.TypeOf((*MockDeviceManager)(nil).CheckDevice), ctx, userID, deviceID, certs)
+}
+
+// ClientCAs mocks base method.
+func (m *MockDeviceManager) ClientCAs() *x509.CertPool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientCAs")
+	ret0, _ := ret[0].(*x509.CertPool)
+	return ret0
+}
+
+// ClientCAs indicates an expected call of ClientCAs.
+func (mr *MockDeviceManagerMockRecorder) ClientCAs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientCAs", reflect.TypeOf((*MockDeviceManager)(nil).ClientCAs))
+}
+
+// Enroll mocks base method.
+func (m *MockDeviceManager) Enroll(ctx context.Context, userID, deviceID uuid.UUID, csrPEM []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enroll", ctx, userID, deviceID, csrPEM)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enroll indicates an expected call of Enroll.
+func (mr *MockDeviceManagerMockRecorder) Enroll(ctx, userID, deviceID, csrPEM interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockDeviceManager)(nil).Enroll), ctx, userID, deviceID, csrPEM)
+}
+
+// EnrollLogin mocks base method.
+func (m *MockDeviceManager) EnrollLogin(ctx context.Context, userID, deviceID uuid.UUID, csrPEM []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollLogin", ctx, userID, deviceID, csrPEM)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollLogin indicates an expected call of EnrollLogin.
+func (mr *MockDeviceManagerMockRecorder) EnrollLogin(ctx, userID, deviceID, csrPEM interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollLogin", reflect.TypeOf((*MockDeviceManager)(nil).EnrollLogin), ctx, userID, deviceID, csrPEM)
+}
+
+// GetUserDevices mocks base method.
+func (m *MockDeviceManager) GetUserDevices(ctx context.Context, userID uuid.UUID) ([]model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDevices", ctx, userID)
+	ret0, _ := ret[0].([]model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDevices indicates an expected call of GetUserDevices.
+func (mr *MockDeviceManagerMockRecorder) GetUserDevices(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDevices", reflect.TypeOf((*MockDeviceManager)(nil).GetUserDevices), ctx, userID)
+}
+
+// Revoke mocks base method.
+func (m *MockDeviceManager) Revoke(ctx context.Context, userID, deviceID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, userID, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockDeviceManagerMockRecorder) Revoke(ctx, userID, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockDeviceManager)(nil).Revoke), ctx, userID, deviceID)
+}
+
+// ValidateRequest mocks base method.
+func (m *MockDeviceManager) ValidateRequest(deviceID uuid.UUID, csrPEM []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRequest", deviceID, csrPEM)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateRequest indicates an expected call of ValidateRequest.
+func (mr *MockDeviceManagerMockRecorder) ValidateRequest(deviceID, csrPEM interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRequest", reflect.TypeOf((*MockDeviceManager)(nil).ValidateRequest), deviceID, csrPEM)
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+// subscriberBuffer is count of events kept for slow subscriber, newer events are dropped.
+// Event is only notification to sync, dropped event is received with sync of kept one.
+const subscriberBuffer = 16
+
+var _ EventBroker = (*Broker)(nil)
+
+type subscriber struct {
+	deviceID uuid.UUID
+	events   chan model.ChangeEvent
+}
+
+// Broker publishes user secret changes to subscribed devices in memory
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[*subscriber]struct{}
+	closed bool
+}
+
+// NewBroker returns new instance of events broker
+func NewBroker() *Broker {
+	return &Broker{
+		subs: make(map[uuid.UUID]map[*subscriber]struct{}),
+	}
+}
+
+// Publish sends change to subscribed devices of user, originating device is skipped.
+// Publish never blocks, event is dropped for subscriber with full buffer.
+func (b *Broker) Publish(e model.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[e.UserID] {
+		if sub.deviceID == e.DeviceID {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe subscribes device to changes of user secrets.
+// Events channel is closed by unsubscribe function or broker close.
+func (b *Broker) Subscribe(userID uuid.UUID, deviceID uuid.UUID) (<-chan model.ChangeEvent, func()) {
+	sub := &subscriber{
+		deviceID: deviceID,
+		events:   make(chan model.ChangeEvent, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*subscriber]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	return sub.events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subs[userID][sub]; !ok {
+			return
+		}
+
+		delete(b.subs[userID], sub)
+		if len(b.subs[userID]) == 0 {
+			delete(b.subs, userID)
+		}
+		close(sub.events)
+	}
+}
+
+// Close closes all subscriptions, new subscriptions are closed at once
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for userID, subs := range b.subs {
+		for sub := range subs {
+			close(sub.events)
+		}
+		delete(b.subs, userID)
+	}
+	b.closed = true
+}
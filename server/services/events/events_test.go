@@ -0,0 +1,59 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	b := NewBroker()
+	userID, otherUserID := uuid.New(), uuid.New()
+	laptop, desktop := uuid.New(), uuid.New()
+
+	laptopEvents, unsubLaptop := b.Subscribe(userID, laptop)
+	desktopEvents, unsubDesktop := b.Subscribe(userID, desktop)
+	otherEvents, unsubOther := b.Subscribe(otherUserID, uuid.New())
+	defer unsubOther()
+
+	e := model.ChangeEvent{UserID: userID, DeviceID: laptop, SecretID: uuid.New(), Ver: 2}
+	b.Publish(e)
+
+	//  only other devices of user are notified
+	require.Equal(t, e, <-desktopEvents)
+	require.Empty(t, laptopEvents)
+	require.Empty(t, otherEvents)
+
+	//  full buffer does not block publisher
+	for i := 0; i < subscriberBuffer*2; i++ {
+		b.Publish(e)
+	}
+	require.Len(t, desktopEvents, subscriberBuffer)
+
+	unsubDesktop()
+	unsubDesktop()
+	b.Publish(e)
+
+	unsubLaptop()
+	_, ok := <-laptopEvents
+	require.False(t, ok)
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker()
+
+	events, unsubscribe := b.Subscribe(uuid.New(), uuid.New())
+	b.Close()
+
+	_, ok := <-events
+	require.False(t, ok)
+	unsubscribe()
+
+	//  subscription after close is closed
+	events, _ = b.Subscribe(uuid.New(), uuid.New())
+	_, ok = <-events
+	require.False(t, ok)
+}
@@ -0,0 +1,17 @@
+package events
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+// EventBroker is the interface that wraps methods of publishing user secret changes to connected devices.
+type EventBroker interface {
+	//  Publishes change to all subscribed devices of user except originating device
+	Publish(e model.ChangeEvent)
+	//  Subscribes device to changes of user secrets, returns events channel and unsubscribe function
+	Subscribe(userID uuid.UUID, deviceID uuid.UUID) (<-chan model.ChangeEvent, func())
+	//  Closes all subscriptions
+	Close()
+}
@@ -0,0 +1,75 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: server/services/events/interface.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	model "github.com/Xrefullx/YanDip/server/model"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockEventBroker is a mock of EventBroker interface.
+type MockEventBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventBrokerMockRecorder
+}
+
+// MockEventBrokerMockRecorder is the mock recorder for MockEventBroker.
+type MockEventBrokerMockRecorder struct {
+	mock *MockEventBroker
+}
+
+// NewMockEventBroker creates a new mock instance.
+func NewMockEventBroker(ctrl *gomock.Controller) *MockEventBroker {
+	mock := &MockEventBroker{ctrl: ctrl}
+	mock.recorder = &MockEventBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventBroker) EXPECT() *MockEventBrokerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockEventBroker) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockEventBrokerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockEventBroker)(nil).Close))
+}
+
+// Publish mocks base method.
+func (m *MockEventBroker) Publish(e model.ChangeEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Publish", e)
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventBrokerMockRecorder) Publish(e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventBroker)(nil).Publish), e)
+}
+
+// Subscribe mocks base method.
+func (m *MockEventBroker) Subscribe(userID, deviceID uuid.UUID) (<-chan model.ChangeEvent, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", userID, deviceID)
+	ret0, _ := ret[0].(<-chan model.ChangeEvent)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockEventBrokerMockRecorder) Subscribe(userID, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockEventBroker)(nil).Subscribe), userID, deviceID)
+}
@@ -2,6 +2,7 @@ package secret
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -9,9 +10,15 @@ import (
 )
 
 type SecretManager interface {
-	Add(ctx context.Context, secret model.Secret) (uuid.UUID, int, error)
-	Update(ctx context.Context, secret model.Secret) (uuid.UUID, int, error)
+	//  Adds secret made by device, returns stored secret
+	Add(ctx context.Context, secret model.Secret, deviceID uuid.UUID) (model.Secret, error)
+	//  Updates secret by device if update is based on stored version, returns stored secret
+	Update(ctx context.Context, secret model.Secret, deviceID uuid.UUID) (model.Secret, error)
 	Get(ctx context.Context, id uuid.UUID, userID uuid.UUID) (model.Secret, error)
-	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
-	GetUserSyncList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]int, error)
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, deviceID uuid.UUID) error
+	GetUserSyncList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]model.SecretVersion, error)
+	GetUserTombstones(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error)
+	GetUserCursor(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetChanges(ctx context.Context, userID uuid.UUID, cursor int64, limit int) (model.ChangeFeed, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
 }
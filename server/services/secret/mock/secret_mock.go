@@ -6,9 +6,10 @@ package mock
 
 import (
 	context "context"
-	model "github.com/Xrefullx/YanDip/server/model"
 	reflect "reflect"
+	time "time"
 
+	model "github.com/Xrefullx/YanDip/server/model"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 )
@@ -36,34 +37,33 @@ func (m *MockSecretManager) EXPECT() *MockSecretManagerMockRecorder {
 	return m.recorder
 }
 
-// AddUpdate mocks base method.
-func (m *MockSecretManager) AddUpdate(ctx context.Context, secret model.Secret) (uuid.UUID, int, error) {
+// Add mocks base method.
+func (m *MockSecretManager) Add(ctx context.Context, secret model.Secret, deviceID uuid.UUID) (model.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUpdate", ctx, secret)
-	ret0, _ := ret[0].(uuid.UUID)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "Add", ctx, secret, deviceID)
+	ret0, _ := ret[0].(model.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// AddUpdate indicates an expected call of AddUpdate.
-func (mr *MockSecretManagerMockRecorder) AddUpdate(ctx, secret interface{}) *gomock.Call {
+// Add indicates an expected call of Add.
+func (mr *MockSecretManagerMockRecorder) Add(ctx, secret, deviceID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUpdate", reflect.TypeOf((*MockSecretManager)(nil).AddUpdate), ctx, secret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockSecretManager)(nil).Add), ctx, secret, deviceID)
 }
 
 // Delete mocks base method.
-func (m *MockSecretManager) Delete(ctx context.Context, id, userID uuid.UUID) error {
+func (m *MockSecretManager) Delete(ctx context.Context, id, userID, deviceID uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id, userID)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, userID, deviceID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockSecretManagerMockRecorder) Delete(ctx, id, userID interface{}) *gomock.Call {
+func (mr *MockSecretManagerMockRecorder) Delete(ctx, id, userID, deviceID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSecretManager)(nil).Delete), ctx, id, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSecretManager)(nil).Delete), ctx, id, userID, deviceID)
 }
 
 // Get mocks base method.
@@ -81,11 +81,41 @@ func (mr *MockSecretManagerMockRecorder) Get(ctx, id, userID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSecretManager)(nil).Get), ctx, id, userID)
 }
 
+// GetChanges mocks base method.
+func (m *MockSecretManager) GetChanges(ctx context.Context, userID uuid.UUID, cursor int64, limit int) (model.ChangeFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, userID, cursor, limit)
+	ret0, _ := ret[0].(model.ChangeFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockSecretManagerMockRecorder) GetChanges(ctx, userID, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockSecretManager)(nil).GetChanges), ctx, userID, cursor, limit)
+}
+
+// GetUserCursor mocks base method.
+func (m *MockSecretManager) GetUserCursor(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserCursor", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserCursor indicates an expected call of GetUserCursor.
+func (mr *MockSecretManagerMockRecorder) GetUserCursor(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCursor", reflect.TypeOf((*MockSecretManager)(nil).GetUserCursor), ctx, userID)
+}
+
 // GetUserSyncList mocks base method.
-func (m *MockSecretManager) GetUserSyncList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]int, error) {
+func (m *MockSecretManager) GetUserSyncList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]model.SecretVersion, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserSyncList", ctx, userID)
-	ret0, _ := ret[0].(map[uuid.UUID]int)
+	ret0, _ := ret[0].(map[uuid.UUID]model.SecretVersion)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -95,3 +125,48 @@ func (mr *MockSecretManagerMockRecorder) GetUserSyncList(ctx, userID interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSyncList", reflect.TypeOf((*MockSecretManager)(nil).GetUserSyncList), ctx, userID)
 }
+
+// GetUserTombstones mocks base method.
+func (m *MockSecretManager) GetUserTombstones(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTombstones", ctx, userID)
+	ret0, _ := ret[0].([]model.Tombstone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTombstones indicates an expected call of GetUserTombstones.
+func (mr *MockSecretManagerMockRecorder) GetUserTombstones(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTombstones", reflect.TypeOf((*MockSecretManager)(nil).GetUserTombstones), ctx, userID)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockSecretManager) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockSecretManagerMockRecorder) PurgeDeleted(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockSecretManager)(nil).PurgeDeleted), ctx, retention)
+}
+
+// Update mocks base method.
+func (m *MockSecretManager) Update(ctx context.Context, secret model.Secret, deviceID uuid.UUID) (model.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, secret, deviceID)
+	ret0, _ := ret[0].(model.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSecretManagerMockRecorder) Update(ctx, secret, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSecretManager)(nil).Update), ctx, secret, deviceID)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -21,55 +22,96 @@ func NewSecret(repo storage.SecretRepository) (*Secret, error) {
 	}, nil
 }
 
-func (s *Secret) Add(ctx context.Context, secret model.Secret) (uuid.UUID, int, error) {
+// Add adds new secret, version vector is started with change of device
+func (s *Secret) Add(ctx context.Context, secret model.Secret, deviceID uuid.UUID) (model.Secret, error) {
 	log.Printf("add secret %+v", secret)
 	if err := secret.ValidateAdd(); err != nil {
-		return uuid.Nil, 0, fmt.Errorf("secret not valid to add: %w", err)
+		return model.Secret{}, fmt.Errorf("secret not valid to add: %w", err)
 	}
 
+	secret.Vector = model.VersionVector{}.Increment(deviceID)
+	secret.Hash = model.DataHash(secret.Data)
+
 	id, err := s.storage.Add(ctx, secret)
 	if err != nil {
-		return uuid.Nil, 0, err
+		return model.Secret{}, err
 	}
+	secret.ID = id
 
-	return id, secret.Ver, nil
+	return secret, nil
 }
-func (s *Secret) Update(ctx context.Context, secret model.Secret) (uuid.UUID, int, error) {
+
+// Update updates secret if update is based on stored version.
+// Vector of update is vector of version edit is based on, it must be equal to stored vector or descend from it.
+// Without vector, version of update must be equal to stored version.
+// Returns ErrorVersionConflict if update is based on older version or is concurrent to stored version.
+func (s *Secret) Update(ctx context.Context, secret model.Secret, deviceID uuid.UUID) (model.Secret, error) {
 	if err := secret.ValidateUpdate(); err != nil {
-		return uuid.Nil, 0, fmt.Errorf("secret not valid to update: %w", err)
+		return model.Secret{}, fmt.Errorf("secret not valid to update: %w", err)
 	}
 
 	dbSecret, err := s.storage.Get(ctx, secret.ID, secret.UserID)
 	if err != nil {
-		return uuid.Nil, 0, err
+		return model.Secret{}, err
 	}
 
 	if dbSecret.IsDeleted {
-		return uuid.Nil, 0, model.ErrorItemIsDeleted
+		return model.Secret{}, model.ErrorItemIsDeleted
 	}
 
-	//  incoming version can be > local, in collision fix
-	//  version increments by server version
-	if dbSecret.Ver > secret.Ver {
-		return uuid.Nil, 0, model.ErrorVersionToLow
+	if err := checkBase(secret, dbSecret); err != nil {
+		return model.Secret{}, err
 	}
 
 	dbSecret.Data = secret.Data
+	dbSecret.Hash = model.DataHash(secret.Data)
+	dbSecret.Meta = secret.Meta
 	dbSecret.Ver = dbSecret.Ver + 1
+	dbSecret.Vector = dbSecret.Vector.Merge(secret.Vector).Increment(deviceID)
 
 	if err := s.storage.Update(ctx, dbSecret); err != nil {
-		return uuid.Nil, 0, err
+		return model.Secret{}, err
 	}
 
-	return dbSecret.ID, dbSecret.Ver, nil
+	return dbSecret, nil
 }
 
-func (s *Secret) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+// checkBase returns ErrorVersionConflict if update is not based on stored secret version
+func checkBase(secret model.Secret, dbSecret model.Secret) error {
+	//  client without vectors
+	if len(secret.Vector) == 0 {
+		if secret.Ver != dbSecret.Ver {
+			return fmt.Errorf("%w: version %v, stored version %v", model.ErrorVersionConflict, secret.Ver, dbSecret.Ver)
+		}
+		return nil
+	}
+
+	switch secret.Vector.Compare(dbSecret.Vector) {
+	case model.VectorOrders["EQUAL"], model.VectorOrders["DESCENDANT"]:
+		return nil
+	case model.VectorOrders["ANCESTOR"]:
+		return fmt.Errorf("%w: update is based on older version", model.ErrorVersionConflict)
+	default:
+		return fmt.Errorf("%w: update is concurrent to stored version", model.ErrorVersionConflict)
+	}
+}
+
+// Delete marks secret deleted by device, deleted secret is kept as tombstone, its data is purged after retention.
+// Deleting of deleted secret is not error, tombstone is not changed.
+func (s *Secret) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, deviceID uuid.UUID) error {
 	if id == uuid.Nil {
 		return fmt.Errorf("%w: id is nil", model.ErrorParamNotValid)
 	}
 
-	return s.storage.Delete(ctx, id, userID)
+	dbSecret, err := s.storage.Get(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if dbSecret.IsDeleted {
+		return nil
+	}
+
+	return s.storage.Delete(ctx, id, userID, deviceID)
 }
 
 func (s *Secret) Get(ctx context.Context, id uuid.UUID, userID uuid.UUID) (model.Secret, error) {
@@ -80,9 +122,76 @@ func (s *Secret) Get(ctx context.Context, id uuid.UUID, userID uuid.UUID) (model
 	return s.storage.Get(ctx, id, userID)
 }
 
-func (s *Secret) GetUserSyncList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]int, error) {
+// GetUserSyncList returns versions and data hashes of user secrets
+func (s *Secret) GetUserSyncList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]model.SecretVersion, error) {
 	if userID == uuid.Nil {
 		return nil, fmt.Errorf("%w: userr id is nil", model.ErrorParamNotValid)
 	}
 	return s.storage.GetUserVersionList(ctx, userID)
 }
+
+// GetUserTombstones returns tombstones of user deleted secrets
+func (s *Secret) GetUserTombstones(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error) {
+	if userID == uuid.Nil {
+		return nil, fmt.Errorf("%w: user id is nil", model.ErrorParamNotValid)
+	}
+
+	return s.storage.GetUserTombstones(ctx, userID)
+}
+
+// GetUserCursor returns current change feed cursor of user
+func (s *Secret) GetUserCursor(ctx context.Context, userID uuid.UUID) (int64, error) {
+	if userID == uuid.Nil {
+		return 0, fmt.Errorf("%w: user id is nil", model.ErrorParamNotValid)
+	}
+
+	cursor, _, err := s.storage.GetChangeCursor(ctx, userID)
+	return cursor, err
+}
+
+// GetChanges returns page of user secrets changes after cursor, deleted secrets included.
+// Returns ErrorCursorExpired if changes after cursor are purged or cursor is unknown to server,
+// client must do full sync then.
+func (s *Secret) GetChanges(ctx context.Context, userID uuid.UUID, cursor int64, limit int) (model.ChangeFeed, error) {
+	if userID == uuid.Nil {
+		return model.ChangeFeed{}, fmt.Errorf("%w: user id is nil", model.ErrorParamNotValid)
+	}
+	if cursor < 0 || limit <= 0 {
+		return model.ChangeFeed{}, fmt.Errorf("%w: cursor %v, limit %v", model.ErrorParamNotValid, cursor, limit)
+	}
+
+	last, floor, err := s.storage.GetChangeCursor(ctx, userID)
+	if err != nil {
+		return model.ChangeFeed{}, err
+	}
+	if cursor < floor || cursor > last {
+		return model.ChangeFeed{}, model.ErrorCursorExpired
+	}
+
+	//  one more change is requested to know if there are more
+	changes, err := s.storage.GetChanges(ctx, userID, cursor, limit+1)
+	if err != nil {
+		return model.ChangeFeed{}, err
+	}
+
+	res := model.ChangeFeed{Changes: changes, Cursor: cursor}
+	if len(changes) > limit {
+		res.Changes = changes[:limit]
+		res.HasMore = true
+	}
+	if len(res.Changes) > 0 {
+		res.Cursor = res.Changes[len(res.Changes)-1].Seq
+	}
+
+	return res, nil
+}
+
+// PurgeDeleted removes data of secrets deleted longer than retention ago.
+// Tombstones are kept, so clients with cursor older than purge still get deletions.
+func (s *Secret) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, fmt.Errorf("%w: retention %v", model.ErrorParamNotValid, retention)
+	}
+
+	return s.storage.PurgeDeleted(ctx, time.Now().Add(-retention))
+}
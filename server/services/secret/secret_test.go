@@ -0,0 +1,139 @@
+package secret
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/storage/mock"
+)
+
+func TestVersionVector_Compare(t *testing.T) {
+	a, b := uuid.NewString(), uuid.NewString()
+	base := model.VersionVector{a: 1, b: 1}
+
+	tests := []struct {
+		name   string
+		vector model.VersionVector
+		order  int
+	}{
+		{name: "equal", vector: model.VersionVector{a: 1, b: 1}, order: model.VectorOrders["EQUAL"]},
+		{name: "descendant", vector: model.VersionVector{a: 2, b: 1}, order: model.VectorOrders["DESCENDANT"]},
+		{name: "new device descendant", vector: model.VersionVector{a: 1, b: 1, uuid.NewString(): 1}, order: model.VectorOrders["DESCENDANT"]},
+		{name: "ancestor", vector: model.VersionVector{a: 1}, order: model.VectorOrders["ANCESTOR"]},
+		{name: "concurrent", vector: model.VersionVector{a: 2}, order: model.VectorOrders["CONCURRENT"]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.order, tt.vector.Compare(base))
+		})
+	}
+}
+
+// TestSecret_UpdateRace tests that of updates based on the same version only one is written
+func TestSecret_UpdateRace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deviceA, deviceB := uuid.New(), uuid.New()
+	base := model.Secret{ID: uuid.New(), UserID: uuid.New(), Ver: 3, Data: "stored", Vector: model.VersionVector{deviceA.String(): 1}}
+	stored := base
+
+	//  repository writes secret if stored version is not changed, as compare-and-swap
+	var mu sync.Mutex
+	repo := mock.NewMockSecretRepository(ctrl)
+	repo.EXPECT().Get(gomock.Any(), base.ID, base.UserID).Return(base, nil).Times(2)
+	repo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, secret model.Secret) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if stored.Ver != secret.Ver-1 {
+			return model.ErrorVersionConflict
+		}
+		stored = secret
+		return nil
+	}).Times(2)
+
+	svc, err := NewSecret(repo)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, device := range []uuid.UUID{deviceA, deviceB} {
+		wg.Add(1)
+		go func(i int, device uuid.UUID) {
+			defer wg.Done()
+			_, errs[i] = svc.Update(context.Background(),
+				model.Secret{ID: base.ID, UserID: base.UserID, Ver: 3, Data: "edit " + device.String(), Vector: model.VersionVector{deviceA.String(): 1}}, device)
+		}(i, device)
+	}
+	wg.Wait()
+
+	//  second update is rejected, first one is not overwritten
+	if errs[0] == nil {
+		require.ErrorIs(t, errs[1], model.ErrorVersionConflict)
+	} else {
+		require.ErrorIs(t, errs[0], model.ErrorVersionConflict)
+		require.NoError(t, errs[1])
+	}
+	require.Equal(t, 4, stored.Ver)
+}
+
+func TestSecret_Update(t *testing.T) {
+	deviceA, deviceB := uuid.New(), uuid.New()
+	userID := uuid.New()
+	stored := model.Secret{
+		ID:     uuid.New(),
+		UserID: userID,
+		Ver:    3,
+		Data:   "stored",
+		Vector: model.VersionVector{deviceA.String(): 2, deviceB.String(): 1},
+	}
+
+	tests := []struct {
+		name     string
+		ver      int
+		vector   model.VersionVector
+		conflict bool
+	}{
+		{name: "based on stored version", ver: 3, vector: stored.Vector},
+		{name: "based on older version", ver: 3, vector: model.VersionVector{deviceA.String(): 1, deviceB.String(): 1}, conflict: true},
+		{name: "concurrent to stored version", ver: 3, vector: model.VersionVector{deviceA.String(): 1, deviceB.String(): 2}, conflict: true},
+		{name: "without vector, same version", ver: 3},
+		{name: "without vector, bumped version", ver: 4, conflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockSecretRepository(ctrl)
+			repo.EXPECT().Get(gomock.Any(), stored.ID, userID).Return(stored, nil)
+
+			svc, err := NewSecret(repo)
+			require.NoError(t, err)
+
+			if !tt.conflict {
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			res, err := svc.Update(context.Background(), model.Secret{ID: stored.ID, UserID: userID, Ver: tt.ver, Data: "edit", Vector: tt.vector}, deviceB)
+			if tt.conflict {
+				require.ErrorIs(t, err, model.ErrorVersionConflict)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, 4, res.Ver)
+			require.Equal(t, model.DataHash("edit"), res.Hash)
+			require.Equal(t, model.VersionVector{deviceA.String(): 2, deviceB.String(): 2}, res.Vector)
+		})
+	}
+}
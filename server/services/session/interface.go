@@ -0,0 +1,22 @@
+package session
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+type SessionManager interface {
+	//  Issues short-lived access token and new refresh token to user device
+	Issue(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) (model.TokenPair, error)
+	//  Returns refresh token if it can be exchanged, ErrorTokenRevoked if token is not accepted
+	Lookup(ctx context.Context, refreshToken string) (model.RefreshToken, error)
+	//  Exchanges refresh token returned by Lookup for new token pair, ErrorTokenRevoked if it is used already
+	Rotate(ctx context.Context, used model.RefreshToken) (model.TokenPair, error)
+	//  Revokes refresh tokens of user device
+	RevokeDevice(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error
+	//  Removes expired refresh tokens, returns count of removed
+	PurgeExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,154 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/storage"
+)
+
+var _ SessionManager = (*Session)(nil)
+
+// refreshTokenSize is count of random bytes of refresh token
+const refreshTokenSize = 32
+
+type Session struct {
+	storage    storage.TokenRepository
+	jwtAuth    *jwtauth.JWTAuth
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewSession returns session service, access tokens are signed with jwtAuth
+func NewSession(repo storage.TokenRepository, jwtAuth *jwtauth.JWTAuth, accessTTL time.Duration, refreshTTL time.Duration) (*Session, error) {
+	if accessTTL <= 0 || refreshTTL <= 0 {
+		return nil, errors.New("token lifetime must be positive")
+	}
+
+	return &Session{
+		storage:    repo,
+		jwtAuth:    jwtAuth,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}, nil
+}
+
+// Issue issues access token and new refresh token to user device, used on login
+func (s *Session) Issue(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) (model.TokenPair, error) {
+	token, refresh, err := s.newRefreshToken(userID, deviceID)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	if err := s.storage.Add(ctx, token); err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return s.tokenPair(userID, deviceID, refresh)
+}
+
+// Lookup returns refresh token if it can be exchanged, token is not used by lookup.
+// Token presented again is reused by someone else, all tokens of device are revoked then.
+func (s *Session) Lookup(ctx context.Context, refreshToken string) (model.RefreshToken, error) {
+	used, err := s.storage.GetByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, model.ErrorItemNotFound) {
+		return model.RefreshToken{}, model.ErrorTokenRevoked
+	}
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+
+	if used.RevokedAt != nil || time.Now().After(used.ExpiresAt) {
+		return model.RefreshToken{}, model.ErrorTokenRevoked
+	}
+	if used.UsedAt != nil {
+		log.Printf("refresh token of device %v of user %v is reused, device tokens are revoked", used.DeviceID, used.UserID)
+		if err := s.storage.RevokeDevice(ctx, used.UserID, used.DeviceID); err != nil {
+			return model.RefreshToken{}, err
+		}
+		return model.RefreshToken{}, model.ErrorTokenRevoked
+	}
+
+	return used, nil
+}
+
+// Rotate exchanges refresh token for new token pair of the same device, token is accepted once.
+// Token is marked used and new token is stored atomically, ErrorTokenRevoked returned if it is used or revoked meanwhile.
+func (s *Session) Rotate(ctx context.Context, used model.RefreshToken) (model.TokenPair, error) {
+	token, refresh, err := s.newRefreshToken(used.UserID, used.DeviceID)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	if err := s.storage.Rotate(ctx, used.ID, token); err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return s.tokenPair(used.UserID, used.DeviceID, refresh)
+}
+
+// RevokeDevice revokes refresh tokens of user device
+func (s *Session) RevokeDevice(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error {
+	return s.storage.RevokeDevice(ctx, userID, deviceID)
+}
+
+// PurgeExpired removes expired refresh tokens
+func (s *Session) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.storage.PurgeExpired(ctx, time.Now())
+}
+
+// tokenPair returns access token of user device with refresh token
+func (s *Session) tokenPair(userID uuid.UUID, deviceID uuid.UUID, refresh string) (model.TokenPair, error) {
+	claims := map[string]interface{}{
+		"user_id":   userID.String(),
+		"device_id": deviceID.String(),
+	}
+	jwtauth.SetExpiryIn(claims, s.accessTTL)
+
+	_, access, err := s.jwtAuth.Encode(claims)
+	if err != nil {
+		return model.TokenPair{}, fmt.Errorf("%w: %v", model.ErrGeneratingToken, err)
+	}
+
+	return model.TokenPair{
+		UserID:       userID,
+		DeviceID:     deviceID,
+		AccessToken:  access,
+		RefreshToken: refresh,
+	}, nil
+}
+
+// newRefreshToken returns record of new refresh token and token
+func (s *Session) newRefreshToken(userID uuid.UUID, deviceID uuid.UUID) (model.RefreshToken, string, error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return model.RefreshToken{}, "", fmt.Errorf("%w: %v", model.ErrGeneratingToken, err)
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(b)
+
+	return model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		DeviceID:  deviceID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}, refresh, nil
+}
+
+// hashToken returns hash refresh token is stored by
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/storage/mock"
+)
+
+func TestSession_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jwtAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	userID, deviceID := uuid.New(), uuid.New()
+
+	var issued model.RefreshToken
+	repo := mock.NewMockTokenRepository(ctrl)
+	repo.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, token model.RefreshToken) error {
+		issued = token
+		return nil
+	})
+
+	svc, err := NewSession(repo, jwtAuth, time.Minute, time.Hour)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	pair, err := svc.Issue(ctx, userID, deviceID)
+	require.NoError(t, err)
+	require.NotEmpty(t, pair.RefreshToken)
+	require.Equal(t, hashToken(pair.RefreshToken), issued.TokenHash)
+	require.Equal(t, deviceID, issued.DeviceID)
+
+	//  access token is bound to device and expires
+	token, err := jwtauth.VerifyToken(jwtAuth, pair.AccessToken)
+	require.NoError(t, err)
+	claims := token.PrivateClaims()
+	require.Equal(t, userID.String(), claims["user_id"])
+	require.Equal(t, deviceID.String(), claims["device_id"])
+	require.WithinDuration(t, time.Now().Add(time.Minute), token.Expiration(), 5*time.Second)
+
+	//  token is rotated
+	repo.EXPECT().GetByHash(gomock.Any(), issued.TokenHash).Return(issued, nil)
+	repo.EXPECT().Rotate(gomock.Any(), issued.ID, gomock.Any()).Return(nil)
+	found, err := svc.Lookup(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, issued.ID, found.ID)
+	rotated, err := svc.Rotate(ctx, found)
+	require.NoError(t, err)
+	require.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+	require.Equal(t, userID, rotated.UserID)
+
+	//  reused token revokes device
+	usedAt := time.Now()
+	used := issued
+	used.UsedAt = &usedAt
+	repo.EXPECT().GetByHash(gomock.Any(), issued.TokenHash).Return(used, nil)
+	repo.EXPECT().RevokeDevice(gomock.Any(), userID, deviceID).Return(nil)
+	_, err = svc.Lookup(ctx, pair.RefreshToken)
+	require.ErrorIs(t, err, model.ErrorTokenRevoked)
+
+	//  expired token
+	expired := issued
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	repo.EXPECT().GetByHash(gomock.Any(), issued.TokenHash).Return(expired, nil)
+	_, err = svc.Lookup(ctx, pair.RefreshToken)
+	require.ErrorIs(t, err, model.ErrorTokenRevoked)
+
+	//  token used meanwhile
+	repo.EXPECT().Rotate(gomock.Any(), issued.ID, gomock.Any()).Return(model.ErrorTokenRevoked)
+	_, err = svc.Rotate(ctx, issued)
+	require.ErrorIs(t, err, model.ErrorTokenRevoked)
+
+	//  unknown token
+	repo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(model.RefreshToken{}, model.ErrorItemNotFound)
+	_, err = svc.Lookup(ctx, "unknown")
+	require.ErrorIs(t, err, model.ErrorTokenRevoked)
+}
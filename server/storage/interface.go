@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -15,11 +16,47 @@ type UserRepository interface {
 	GetByLogin(ctx context.Context, login string) (model.User, error)
 }
 
+type DeviceRepository interface {
+	//  Adds enrolled device, returns ErrorConflictSaveDevice if device id exists
+	Add(ctx context.Context, device model.Device) error
+	//  Returns device by id, ErrorItemNotFound if device is not enrolled
+	Get(ctx context.Context, id uuid.UUID) (model.Device, error)
+	//  Returns devices of user, revoked included
+	GetUserDevices(ctx context.Context, userID uuid.UUID) ([]model.Device, error)
+	//  Marks device of user revoked
+	Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
+
+type TokenRepository interface {
+	//  Adds issued refresh token
+	Add(ctx context.Context, token model.RefreshToken) error
+	//  Returns refresh token by hash, ErrorItemNotFound if token is not issued
+	GetByHash(ctx context.Context, hash string) (model.RefreshToken, error)
+	//  Marks token used and adds token issued instead, ErrorTokenRevoked if token is used or revoked already
+	Rotate(ctx context.Context, usedID uuid.UUID, token model.RefreshToken) error
+	//  Revokes refresh tokens of user device
+	RevokeDevice(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error
+	//  Removes tokens expired before time, returns count of removed
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
 type SecretRepository interface {
 	Add(ctx context.Context, secret model.Secret) (uuid.UUID, error)
 	Get(ctx context.Context, id uuid.UUID, userID uuid.UUID) (model.Secret, error)
+	//  Writes secret if stored version is secret.Ver-1, ErrorVersionConflict if it is changed by other update
 	Update(ctx context.Context, secret model.Secret) error
-	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	//  Marks secret deleted by device, tombstone version is incremented
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, deviceID uuid.UUID) error
+
+	//  Returns versions and data hashes of user secrets, deleted secrets excluded
+	GetUserVersionList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]model.SecretVersion, error)
+	//  Returns tombstones of user deleted secrets
+	GetUserTombstones(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error)
 
-	GetUserVersionList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]int, error)
+	//  Returns up to limit changes of user secrets with sequence number greater than cursor, including deleted
+	GetChanges(ctx context.Context, userID uuid.UUID, cursor int64, limit int) ([]model.SecretChange, error)
+	//  Returns last change sequence number of user secrets, and floor cursor - changes before it are purged
+	GetChangeCursor(ctx context.Context, userID uuid.UUID) (int64, int64, error)
+	//  Removes data of secrets deleted before time, tombstones are kept, returns count of purged
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
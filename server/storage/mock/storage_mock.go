@@ -6,116 +6,241 @@ package mock
 
 import (
 	context "context"
-	model "github.com/Xrefullx/YanDip/server/model"
-	storage "github.com/Xrefullx/YanDip/server/storage"
 	reflect "reflect"
+	time "time"
 
+	model "github.com/Xrefullx/YanDip/server/model"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 )
 
-// MockStorage is a mock of Storage interface.
-type MockStorage struct {
+// MockUserRepository is a mock of UserRepository interface.
+type MockUserRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockStorageMockRecorder
+	recorder *MockUserRepositoryMockRecorder
 }
 
-// MockStorageMockRecorder is the mock recorder for MockStorage.
-type MockStorageMockRecorder struct {
-	mock *MockStorage
+// MockUserRepositoryMockRecorder is the mock recorder for MockUserRepository.
+type MockUserRepositoryMockRecorder struct {
+	mock *MockUserRepository
 }
 
-// NewMockStorage creates a new mock instance.
-func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
-	mock := &MockStorage{ctrl: ctrl}
-	mock.recorder = &MockStorageMockRecorder{mock}
+// NewMockUserRepository creates a new mock instance.
+func NewMockUserRepository(ctrl *gomock.Controller) *MockUserRepository {
+	mock := &MockUserRepository{ctrl: ctrl}
+	mock.recorder = &MockUserRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Secret mocks base method.
-func (m *MockStorage) Secret() storage.SecretRepository {
+// Create mocks base method.
+func (m *MockUserRepository) Create(ctx context.Context, user model.User) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, user)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
+}
+
+// GetByLogin mocks base method.
+func (m *MockUserRepository) GetByLogin(ctx context.Context, login string) (model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Secret")
-	ret0, _ := ret[0].(storage.SecretRepository)
+	ret := m.ctrl.Call(m, "GetByLogin", ctx, login)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByLogin indicates an expected call of GetByLogin.
+func (mr *MockUserRepositoryMockRecorder) GetByLogin(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogin", reflect.TypeOf((*MockUserRepository)(nil).GetByLogin), ctx, login)
+}
+
+// MockDeviceRepository is a mock of DeviceRepository interface.
+type MockDeviceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceRepositoryMockRecorder
+}
+
+// MockDeviceRepositoryMockRecorder is the mock recorder for MockDeviceRepository.
+type MockDeviceRepositoryMockRecorder struct {
+	mock *MockDeviceRepository
+}
+
+// NewMockDeviceRepository creates a new mock instance.
+func NewMockDeviceRepository(ctrl *gomock.Controller) *MockDeviceRepository {
+	mock := &MockDeviceRepository{ctrl: ctrl}
+	mock.recorder = &MockDeviceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceRepository) EXPECT() *MockDeviceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockDeviceRepository) Add(ctx context.Context, device model.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, device)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Secret indicates an expected call of Secret.
-func (mr *MockStorageMockRecorder) Secret() *gomock.Call {
+// Add indicates an expected call of Add.
+func (mr *MockDeviceRepositoryMockRecorder) Add(ctx, device interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Secret", reflect.TypeOf((*MockStorage)(nil).Secret))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockDeviceRepository)(nil).Add), ctx, device)
 }
 
-// User mocks base method.
-func (m *MockStorage) User() storage.UserRepository {
+// Get mocks base method.
+func (m *MockDeviceRepository) Get(ctx context.Context, id uuid.UUID) (model.Device, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "User")
-	ret0, _ := ret[0].(storage.UserRepository)
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDeviceRepositoryMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeviceRepository)(nil).Get), ctx, id)
+}
+
+// GetUserDevices mocks base method.
+func (m *MockDeviceRepository) GetUserDevices(ctx context.Context, userID uuid.UUID) ([]model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDevices", ctx, userID)
+	ret0, _ := ret[0].([]model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDevices indicates an expected call of GetUserDevices.
+func (mr *MockDeviceRepositoryMockRecorder) GetUserDevices(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDevices", reflect.TypeOf((*MockDeviceRepository)(nil).GetUserDevices), ctx, userID)
+}
+
+// Revoke mocks base method.
+func (m *MockDeviceRepository) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id, userID)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// User indicates an expected call of User.
-func (mr *MockStorageMockRecorder) User() *gomock.Call {
+// Revoke indicates an expected call of Revoke.
+func (mr *MockDeviceRepositoryMockRecorder) Revoke(ctx, id, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "User", reflect.TypeOf((*MockStorage)(nil).User))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockDeviceRepository)(nil).Revoke), ctx, id, userID)
 }
 
-// MockUserRepository is a mock of UserRepository interface.
-type MockUserRepository struct {
+// MockTokenRepository is a mock of TokenRepository interface.
+type MockTokenRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockUserRepositoryMockRecorder
+	recorder *MockTokenRepositoryMockRecorder
 }
 
-// MockUserRepositoryMockRecorder is the mock recorder for MockUserRepository.
-type MockUserRepositoryMockRecorder struct {
-	mock *MockUserRepository
+// MockTokenRepositoryMockRecorder is the mock recorder for MockTokenRepository.
+type MockTokenRepositoryMockRecorder struct {
+	mock *MockTokenRepository
 }
 
-// NewMockUserRepository creates a new mock instance.
-func NewMockUserRepository(ctrl *gomock.Controller) *MockUserRepository {
-	mock := &MockUserRepository{ctrl: ctrl}
-	mock.recorder = &MockUserRepositoryMockRecorder{mock}
+// NewMockTokenRepository creates a new mock instance.
+func NewMockTokenRepository(ctrl *gomock.Controller) *MockTokenRepository {
+	mock := &MockTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockTokenRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
+func (m *MockTokenRepository) EXPECT() *MockTokenRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Create mocks base method.
-func (m *MockUserRepository) Create(ctx context.Context, user model.User) (model.User, error) {
+// Add mocks base method.
+func (m *MockTokenRepository) Add(ctx context.Context, token model.RefreshToken) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, user)
-	ret0, _ := ret[0].(model.User)
+	ret := m.ctrl.Call(m, "Add", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockTokenRepositoryMockRecorder) Add(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockTokenRepository)(nil).Add), ctx, token)
+}
+
+// GetByHash mocks base method.
+func (m *MockTokenRepository) GetByHash(ctx context.Context, hash string) (model.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", ctx, hash)
+	ret0, _ := ret[0].(model.RefreshToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Create indicates an expected call of Create.
-func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockTokenRepositoryMockRecorder) GetByHash(ctx, hash interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockTokenRepository)(nil).GetByHash), ctx, hash)
 }
 
-// GetByLogin mocks base method.
-func (m *MockUserRepository) GetByLogin(ctx context.Context, login string) (model.User, error) {
+// PurgeExpired mocks base method.
+func (m *MockTokenRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByLogin", ctx, login)
-	ret0, _ := ret[0].(model.User)
+	ret := m.ctrl.Call(m, "PurgeExpired", ctx, before)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByLogin indicates an expected call of GetByLogin.
-func (mr *MockUserRepositoryMockRecorder) GetByLogin(ctx, login interface{}) *gomock.Call {
+// PurgeExpired indicates an expected call of PurgeExpired.
+func (mr *MockTokenRepositoryMockRecorder) PurgeExpired(ctx, before interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogin", reflect.TypeOf((*MockUserRepository)(nil).GetByLogin), ctx, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpired", reflect.TypeOf((*MockTokenRepository)(nil).PurgeExpired), ctx, before)
+}
+
+// RevokeDevice mocks base method.
+func (m *MockTokenRepository) RevokeDevice(ctx context.Context, userID, deviceID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeDevice", ctx, userID, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeDevice indicates an expected call of RevokeDevice.
+func (mr *MockTokenRepositoryMockRecorder) RevokeDevice(ctx, userID, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeDevice", reflect.TypeOf((*MockTokenRepository)(nil).RevokeDevice), ctx, userID, deviceID)
+}
+
+// Rotate mocks base method.
+func (m *MockTokenRepository) Rotate(ctx context.Context, usedID uuid.UUID, token model.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx, usedID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockTokenRepositoryMockRecorder) Rotate(ctx, usedID, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockTokenRepository)(nil).Rotate), ctx, usedID, token)
 }
 
 // MockSecretRepository is a mock of SecretRepository interface.
@@ -157,17 +282,17 @@ func (mr *MockSecretRepositoryMockRecorder) Add(ctx, secret interface{}) *gomock
 }
 
 // Delete mocks base method.
-func (m *MockSecretRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+func (m *MockSecretRepository) Delete(ctx context.Context, id, userID, deviceID uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id, userID)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, userID, deviceID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockSecretRepositoryMockRecorder) Delete(ctx, id, userID interface{}) *gomock.Call {
+func (mr *MockSecretRepositoryMockRecorder) Delete(ctx, id, userID, deviceID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSecretRepository)(nil).Delete), ctx, id, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSecretRepository)(nil).Delete), ctx, id, userID, deviceID)
 }
 
 // Get mocks base method.
@@ -185,11 +310,57 @@ func (mr *MockSecretRepositoryMockRecorder) Get(ctx, id, userID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSecretRepository)(nil).Get), ctx, id, userID)
 }
 
+// GetChangeCursor mocks base method.
+func (m *MockSecretRepository) GetChangeCursor(ctx context.Context, userID uuid.UUID) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangeCursor", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetChangeCursor indicates an expected call of GetChangeCursor.
+func (mr *MockSecretRepositoryMockRecorder) GetChangeCursor(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangeCursor", reflect.TypeOf((*MockSecretRepository)(nil).GetChangeCursor), ctx, userID)
+}
+
+// GetChanges mocks base method.
+func (m *MockSecretRepository) GetChanges(ctx context.Context, userID uuid.UUID, cursor int64, limit int) ([]model.SecretChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, userID, cursor, limit)
+	ret0, _ := ret[0].([]model.SecretChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockSecretRepositoryMockRecorder) GetChanges(ctx, userID, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockSecretRepository)(nil).GetChanges), ctx, userID, cursor, limit)
+}
+
+// GetUserTombstones mocks base method.
+func (m *MockSecretRepository) GetUserTombstones(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTombstones", ctx, userID)
+	ret0, _ := ret[0].([]model.Tombstone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTombstones indicates an expected call of GetUserTombstones.
+func (mr *MockSecretRepositoryMockRecorder) GetUserTombstones(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTombstones", reflect.TypeOf((*MockSecretRepository)(nil).GetUserTombstones), ctx, userID)
+}
+
 // GetUserVersionList mocks base method.
-func (m *MockSecretRepository) GetUserVersionList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]int, error) {
+func (m *MockSecretRepository) GetUserVersionList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]model.SecretVersion, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserVersionList", ctx, userID)
-	ret0, _ := ret[0].(map[uuid.UUID]int)
+	ret0, _ := ret[0].(map[uuid.UUID]model.SecretVersion)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -200,6 +371,21 @@ func (mr *MockSecretRepositoryMockRecorder) GetUserVersionList(ctx, userID inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVersionList", reflect.TypeOf((*MockSecretRepository)(nil).GetUserVersionList), ctx, userID)
 }
 
+// PurgeDeleted mocks base method.
+func (m *MockSecretRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockSecretRepositoryMockRecorder) PurgeDeleted(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockSecretRepository)(nil).PurgeDeleted), ctx, before)
+}
+
 // Update mocks base method.
 func (m *MockSecretRepository) Update(ctx context.Context, secret model.Secret) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,121 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/lib/pq"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/services/logpkg"
+	"github.com/Xrefullx/YanDip/server/storage"
+)
+
+var _ storage.DeviceRepository = (*deviceRepository)(nil)
+
+// deviceRepository implements DeviceRepository interface, provides actions with device records in psql storage.
+type deviceRepository struct {
+	db *sql.DB
+}
+
+// newDeviceRepository inits new device repository.
+func newDeviceRepository(db *sql.DB) *deviceRepository {
+	return &deviceRepository{
+		db: db,
+	}
+}
+
+// Add saves enrolled device.
+// If device id exist return ErrorConflictSaveDevice
+func (r *deviceRepository) Add(ctx context.Context, device model.Device) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO devices (id, user_id, cert_serial) VALUES ($1, $2, $3)",
+		device.ID, device.UserID, device.CertSerial)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == pgerrcode.UniqueViolation {
+			return model.ErrorConflictSaveDevice
+		}
+
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Get selects device by id
+// if not found, returns ErrorItemNotFound
+func (r *deviceRepository) Get(ctx context.Context, id uuid.UUID) (model.Device, error) {
+	var device model.Device
+
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, user_id, cert_serial, created_at, revoked_at FROM devices WHERE id = $1", id,
+	).Scan(&device.ID, &device.UserID, &device.CertSerial, &device.CreatedAt, &device.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Device{}, model.ErrorItemNotFound
+		}
+		return model.Device{}, err
+	}
+
+	return device, nil
+}
+
+// GetUserDevices selects devices of user, oldest first
+func (r *deviceRepository) GetUserDevices(ctx context.Context, userID uuid.UUID) ([]model.Device, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, user_id, cert_serial, created_at, revoked_at FROM devices WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logpkg.ErrorLog(err.Error())
+		}
+	}()
+
+	res := make([]model.Device, 0)
+	for rows.Next() {
+		var device model.Device
+		if err := rows.Scan(&device.ID, &device.UserID, &device.CertSerial, &device.CreatedAt, &device.RevokedAt); err != nil {
+			return nil, err
+		}
+
+		res = append(res, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		logpkg.ErrorLog(err.Error())
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Revoke marks device of user revoked, revoked device stays revoked
+// if not found, returns ErrorItemNotFound
+func (r *deviceRepository) Revoke(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE devices SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	exists, err := res.RowsAffected()
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	if exists == 0 {
+		return model.ErrorItemNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package psql
+
+import (
+	"github.com/google/uuid"
+	"github.com/icrowley/fake"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+func (s *TestSuite) TestDevices_AddRevoke() {
+	user, err := s.storage.UserRepo.Create(s.ctx, model.User{
+		Login:        fake.CharactersN(8),
+		PasswordHash: fake.CharactersN(60),
+		MasterHash:   fake.CharactersN(60),
+	})
+	s.Require().NoError(err)
+
+	device := model.Device{ID: uuid.New(), UserID: user.ID, CertSerial: "0a1b"}
+
+	s.Run("Add device", func() {
+		s.Require().NoError(s.storage.DeviceRepo.Add(s.ctx, device))
+		s.Require().ErrorIs(s.storage.DeviceRepo.Add(s.ctx, device), model.ErrorConflictSaveDevice)
+
+		res, err := s.storage.DeviceRepo.Get(s.ctx, device.ID)
+		s.Require().NoError(err)
+		s.Assert().Equal(device.CertSerial, res.CertSerial)
+		s.Assert().Nil(res.RevokedAt)
+
+		list, err := s.storage.DeviceRepo.GetUserDevices(s.ctx, user.ID)
+		s.Require().NoError(err)
+		s.Assert().Len(list, 1)
+	})
+
+	s.Run("Revoke device", func() {
+		s.Require().ErrorIs(s.storage.DeviceRepo.Revoke(s.ctx, device.ID, uuid.New()), model.ErrorItemNotFound)
+		s.Require().NoError(s.storage.DeviceRepo.Revoke(s.ctx, device.ID, user.ID))
+
+		res, err := s.storage.DeviceRepo.Get(s.ctx, device.ID)
+		s.Require().NoError(err)
+		s.Assert().NotNil(res.RevokedAt)
+	})
+
+	s.Run("Get not enrolled", func() {
+		_, err := s.storage.DeviceRepo.Get(s.ctx, uuid.New())
+		s.Require().ErrorIs(err, model.ErrorItemNotFound)
+	})
+}
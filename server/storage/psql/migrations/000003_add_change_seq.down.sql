@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN change_floor;
+DROP INDEX secrets_user_change_seq;
+ALTER TABLE secrets DROP COLUMN changed_at, DROP COLUMN change_seq;
+DROP SEQUENCE secrets_change_seq;
@@ -0,0 +1,10 @@
+CREATE SEQUENCE IF NOT EXISTS secrets_change_seq;
+
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS change_seq bigint not null default nextval('secrets_change_seq'),
+    ADD COLUMN IF NOT EXISTS changed_at timestamptz not null default now();
+
+CREATE INDEX IF NOT EXISTS secrets_user_change_seq ON secrets (user_id, change_seq);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS change_floor bigint not null default 0;
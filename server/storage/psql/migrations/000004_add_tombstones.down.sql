@@ -0,0 +1 @@
+ALTER TABLE secrets DROP COLUMN deleted_by, DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+ALTER TABLE secrets
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_by uuid not null default '00000000-0000-0000-0000-000000000000';
+
+UPDATE secrets SET deleted_at = changed_at WHERE is_deleted AND deleted_at IS NULL;
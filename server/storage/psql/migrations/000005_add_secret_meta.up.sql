@@ -0,0 +1 @@
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS meta text not null default '';
@@ -0,0 +1 @@
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS vector jsonb not null default '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS data_hash text not null default '';
+UPDATE secrets SET data_hash = encode(sha256(convert_to(data, 'UTF8')), 'hex') WHERE data IS NOT NULL AND data_hash = '';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS devices
+(
+    id uuid primary key,
+    user_id uuid not null references users (id),
+    cert_serial text not null,
+    created_at timestamptz not null default now(),
+    revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS devices_user_id_idx ON devices (user_id);
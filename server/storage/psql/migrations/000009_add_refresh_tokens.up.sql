@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id uuid primary key,
+    user_id uuid not null references users (id),
+    device_id uuid not null,
+    token_hash text not null unique,
+    expires_at timestamptz not null,
+    created_at timestamptz not null default now(),
+    used_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_device_idx ON refresh_tokens (user_id, device_id);
@@ -3,7 +3,11 @@ package psql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/Xrefullx/YanDip/server/model"
@@ -26,19 +30,36 @@ func newSecretRepository(db *sql.DB) *secretRepository {
 }
 
 func (r *secretRepository) Add(ctx context.Context, secret model.Secret) (uuid.UUID, error) {
-	err := r.db.QueryRowContext(
+	vector, err := encodeVector(secret.Vector)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	//  user row is locked before change_seq is taken.
+	//  Sequence values are taken in statement order, not in commit order, so changes of user are written one at a time:
+	//  change with lower seq is committed before higher seq is taken, and change cursor of client never skips a change.
+	err = r.db.QueryRowContext(
 		ctx,
-		"INSERT INTO secrets(ver,user_id,data,is_deleted) VALUES($1,$2,$3,$4) "+
+		"WITH owner AS (SELECT id FROM users WHERE id = $2 FOR UPDATE) "+
+			"INSERT INTO secrets(ver,user_id,data,meta,vector,data_hash,is_deleted) "+
+			"SELECT $1::int,owner.id,$3::text,$4::text,$5::jsonb,$6::text,$7::boolean FROM owner "+
 			"RETURNING id",
 		secret.Ver,
 		secret.UserID,
 		secret.Data,
+		secret.Meta,
+		vector,
+		secret.Hash,
 		secret.IsDeleted,
 	).Scan(
 		&secret.ID,
 	)
 
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, model.ErrorItemNotFound
+		}
+
 		logpkg.ErrorLog(err.Error())
 		return uuid.Nil, err
 	}
@@ -48,14 +69,18 @@ func (r *secretRepository) Add(ctx context.Context, secret model.Secret) (uuid.U
 
 func (r *secretRepository) Get(ctx context.Context, id uuid.UUID, userID uuid.UUID) (model.Secret, error) {
 	res := model.Secret{}
+	var vector []byte
 	if err := r.db.QueryRowContext(ctx,
-		"SELECT id, ver,user_id,data,is_deleted FROM secrets WHERE id=$1 AND user_id=$2",
+		"SELECT id, ver,user_id,data,meta,vector,data_hash,is_deleted FROM secrets WHERE id=$1 AND user_id=$2",
 		id, userID,
 	).Scan(
 		&res.ID,
 		&res.Ver,
 		&res.UserID,
 		&res.Data,
+		&res.Meta,
+		&vector,
+		&res.Hash,
 		&res.IsDeleted,
 	); err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
@@ -66,48 +91,67 @@ func (r *secretRepository) Get(ctx context.Context, id uuid.UUID, userID uuid.UU
 		return model.Secret{}, err
 	}
 
+	if err := json.Unmarshal(vector, &res.Vector); err != nil {
+		logpkg.ErrorLog(err.Error())
+		return model.Secret{}, err
+	}
+
 	return res, nil
 }
 
+// Update writes secret if stored version is previous version of secret, so update based on stored version is not lost.
+// Returns ErrorVersionConflict if stored version is changed after it was read.
 func (r *secretRepository) Update(ctx context.Context, el model.Secret) error {
+	//  user row is locked before change_seq is taken, changes of user are committed in seq order
 	query := `
-		UPDATE secrets
-		SET ver = $2, user_id = $3, data=$4, is_deleted = $5
-		WHERE id = $1 AND user_id = $3;
+		WITH owner AS (
+			SELECT id FROM users WHERE id = $3 FOR UPDATE
+		), updated AS (
+			UPDATE secrets
+			SET ver = $2, data=$4, is_deleted = $5, meta = $6, vector = $7, data_hash = $8,
+				change_seq = nextval('secrets_change_seq'), changed_at = now()
+			FROM owner
+			WHERE secrets.id = $1 AND secrets.user_id = owner.id AND secrets.ver = $2 - 1
+			RETURNING secrets.id
+		)
+		SELECT EXISTS(SELECT 1 FROM updated), EXISTS(SELECT 1 FROM secrets WHERE id = $1 AND user_id = $3);
 `
 
-	stmt, err := r.db.Prepare(query)
-	if err != nil {
-		logpkg.ErrorLog(err.Error())
-		return err
-	}
-
-	res, err := stmt.ExecContext(ctx, el.ID, el.Ver, el.UserID, el.Data, el.IsDeleted)
+	vector, err := encodeVector(el.Vector)
 	if err != nil {
-		logpkg.ErrorLog(err.Error())
 		return err
 	}
 
-	exists, err := res.RowsAffected()
-	if err != nil {
+	var updated, exists bool
+	if err := r.db.QueryRowContext(ctx, query, el.ID, el.Ver, el.UserID, el.Data, el.IsDeleted, el.Meta, vector, el.Hash).
+		Scan(&updated, &exists); err != nil {
 		logpkg.ErrorLog(err.Error())
 		return err
 	}
 
-	if exists == 0 {
+	switch {
+	case updated:
+		return nil
+	case exists:
+		return fmt.Errorf("%w: secret is changed by other update", model.ErrorVersionConflict)
+	default:
 		err = model.ErrorItemNotFound
 		logpkg.ErrorLog(err.Error())
 		return err
 	}
-
-	return nil
 }
 
-func (r *secretRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+func (r *secretRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, deviceID uuid.UUID) error {
+	//  user row is locked before change_seq is taken, changes of user are committed in seq order
 	query := `
+		WITH owner AS (
+			SELECT id FROM users WHERE id = $2 FOR UPDATE
+		)
 		UPDATE secrets
-		SET is_deleted = $3
-		WHERE id = $1 AND user_id = $2;
+		SET is_deleted = $3, ver = ver + 1, deleted_at = now(), deleted_by = $4,
+			change_seq = nextval('secrets_change_seq'), changed_at = now()
+		FROM owner
+		WHERE secrets.id = $1 AND secrets.user_id = owner.id;
 `
 
 	stmt, err := r.db.Prepare(query)
@@ -116,7 +160,7 @@ func (r *secretRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid
 		return err
 	}
 
-	res, err := stmt.ExecContext(ctx, id, userID, true)
+	res, err := stmt.ExecContext(ctx, id, userID, true, deviceID)
 	if err != nil {
 		logpkg.ErrorLog(err.Error())
 		return err
@@ -137,10 +181,10 @@ func (r *secretRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid
 	return nil
 }
 
-func (r *secretRepository) GetUserVersionList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]int, error) {
+func (r *secretRepository) GetUserVersionList(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]model.SecretVersion, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		"SELECT id, ver from secrets WHERE user_id = $1 AND is_deleted = $2", userID, false)
+		"SELECT id, ver, data_hash from secrets WHERE user_id = $1 AND is_deleted = $2", userID, false)
 	if err != nil {
 		return nil, err
 	}
@@ -151,13 +195,13 @@ func (r *secretRepository) GetUserVersionList(ctx context.Context, userID uuid.U
 		}
 	}()
 
-	res := make(map[uuid.UUID]int)
+	res := make(map[uuid.UUID]model.SecretVersion)
 
 	for rows.Next() {
 		var key uuid.UUID
-		var val int
+		var val model.SecretVersion
 
-		err = rows.Scan(&key, &val)
+		err = rows.Scan(&key, &val.Ver, &val.Hash)
 		if err != nil {
 			return nil, err
 		}
@@ -173,3 +217,143 @@ func (r *secretRepository) GetUserVersionList(ctx context.Context, userID uuid.U
 
 	return res, nil
 }
+
+func (r *secretRepository) GetUserTombstones(ctx context.Context, userID uuid.UUID) ([]model.Tombstone, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, ver, COALESCE(deleted_at, changed_at), deleted_by FROM secrets WHERE user_id = $1 AND is_deleted = $2",
+		userID, true)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logpkg.ErrorLog(err.Error())
+		}
+	}()
+
+	res := make([]model.Tombstone, 0)
+
+	for rows.Next() {
+		var el model.Tombstone
+
+		if err := rows.Scan(&el.ID, &el.Ver, &el.DeletedAt, &el.DeletedBy); err != nil {
+			return nil, err
+		}
+
+		res = append(res, el)
+	}
+
+	if err := rows.Err(); err != nil {
+		logpkg.ErrorLog(err.Error())
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *secretRepository) GetChanges(ctx context.Context, userID uuid.UUID, cursor int64, limit int) ([]model.SecretChange, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id, ver, is_deleted, change_seq, COALESCE(deleted_at, changed_at), deleted_by FROM secrets "+
+			"WHERE user_id = $1 AND change_seq > $2 ORDER BY change_seq LIMIT $3",
+		userID, cursor, limit)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logpkg.ErrorLog(err.Error())
+		}
+	}()
+
+	res := make([]model.SecretChange, 0)
+
+	for rows.Next() {
+		var el model.SecretChange
+
+		var deletedAt time.Time
+		var deletedBy uuid.UUID
+		if err := rows.Scan(&el.ID, &el.Ver, &el.IsDeleted, &el.Seq, &deletedAt, &deletedBy); err != nil {
+			return nil, err
+		}
+		if el.IsDeleted {
+			el.DeletedAt, el.DeletedBy = deletedAt, deletedBy
+		}
+
+		res = append(res, el)
+	}
+
+	if err := rows.Err(); err != nil {
+		logpkg.ErrorLog(err.Error())
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *secretRepository) GetChangeCursor(ctx context.Context, userID uuid.UUID) (int64, int64, error) {
+	var cursor, floor int64
+
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT max(change_seq) FROM secrets WHERE user_id = u.id), 0), u.change_floor
+		FROM users u
+		WHERE u.id = $1`,
+		userID,
+	).Scan(&cursor, &floor); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, model.ErrorItemNotFound
+		}
+
+		logpkg.ErrorLog(err.Error())
+		return 0, 0, err
+	}
+
+	//  purged changes can be newer than existing ones
+	if floor > cursor {
+		cursor = floor
+	}
+
+	return cursor, floor, nil
+}
+
+// PurgeDeleted removes data of deleted secrets, rows are kept as tombstones with version, deletion time and device.
+// Tombstone keeps its change_seq, so deletion stays in change feed and clients offline for long apply it.
+func (r *secretRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE secrets
+		SET data = '', meta = '', vector = '{}', data_hash = ''
+		WHERE is_deleted AND changed_at < $1 AND (COALESCE(data, '') <> '' OR meta <> '')`,
+		before,
+	)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// encodeVector returns json of version vector, empty vector is stored as empty object
+func encodeVector(v model.VersionVector) (string, error) {
+	if v == nil {
+		v = model.VersionVector{}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
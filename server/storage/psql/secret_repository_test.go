@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/icrowley/fake"
@@ -41,6 +42,7 @@ func (s *TestSuite) TestSecret_AddGet() {
 		s.Assert().EqualValues(secret.UserID, secretRes.UserID)
 		s.Assert().EqualValues(secret.Ver, secretRes.Ver)
 		s.Assert().EqualValues(secret.Data, secretRes.Data)
+		s.Assert().EqualValues(secret.Vector, secretRes.Vector)
 		s.Assert().EqualValues(secret.IsDeleted, secretRes.IsDeleted)
 
 	})
@@ -48,6 +50,93 @@ func (s *TestSuite) TestSecret_AddGet() {
 	s.dropTables()
 }
 
+func (s *TestSuite) TestSecret_UpdateRace() {
+	s.Run("Updates from the same base", func() {
+		user, err := s.storage.User().Create(s.ctx, user)
+		s.Require().NoError(err)
+
+		secret := getMockSecret(user.ID)
+		secret.ID, err = s.storage.Secret().Add(s.ctx, secret)
+		s.Require().NoError(err)
+
+		//  both updates are based on stored version, only one of them is written
+		count := 2
+		errs := make(chan error, count)
+		for i := 0; i < count; i++ {
+			update := secret
+			update.Ver = secret.Ver + 1
+			update.Data = fake.CharactersN(100)
+			go func() {
+				errs <- s.storage.Secret().Update(context.Background(), update)
+			}()
+		}
+
+		var conflicts int
+		for i := 0; i < count; i++ {
+			if err := <-errs; err != nil {
+				s.Require().ErrorIs(err, model.ErrorVersionConflict)
+				conflicts++
+			}
+		}
+		s.Assert().Equal(1, conflicts)
+
+		stored, err := s.storage.Secret().Get(s.ctx, secret.ID, user.ID)
+		s.Require().NoError(err)
+		s.Assert().Equal(secret.Ver+1, stored.Ver)
+
+		err = s.storage.Secret().Update(s.ctx, model.Secret{ID: uuid.New(), UserID: user.ID, Ver: 1})
+		s.Require().ErrorIs(err, model.ErrorItemNotFound)
+	})
+
+	s.dropTables()
+}
+
+func (s *TestSuite) TestSecret_PurgeDeleted() {
+	s.Run("Purged secret is kept as tombstone", func() {
+		user, err := s.storage.User().Create(s.ctx, user)
+		s.Require().NoError(err)
+
+		secret := getMockSecret(user.ID)
+		secret.ID, err = s.storage.Secret().Add(s.ctx, secret)
+		s.Require().NoError(err)
+
+		deviceID := uuid.New()
+		s.Require().NoError(s.storage.Secret().Delete(s.ctx, secret.ID, user.ID, deviceID))
+
+		count, err := s.storage.Secret().PurgeDeleted(s.ctx, time.Now().Add(time.Hour))
+		s.Require().NoError(err)
+		s.Assert().EqualValues(1, count)
+
+		//  data is removed, deletion is still synced by full list and change feed
+		stored, err := s.storage.Secret().Get(s.ctx, secret.ID, user.ID)
+		s.Require().NoError(err)
+		s.Assert().True(stored.IsDeleted)
+		s.Assert().Empty(stored.Data)
+
+		tombs, err := s.storage.Secret().GetUserTombstones(s.ctx, user.ID)
+		s.Require().NoError(err)
+		s.Require().Len(tombs, 1)
+		s.Assert().Equal(secret.ID, tombs[0].ID)
+		s.Assert().Equal(deviceID, tombs[0].DeletedBy)
+
+		changes, err := s.storage.Secret().GetChanges(s.ctx, user.ID, 0, 10)
+		s.Require().NoError(err)
+		s.Require().Len(changes, 1)
+		s.Assert().True(changes[0].IsDeleted)
+
+		_, floor, err := s.storage.Secret().GetChangeCursor(s.ctx, user.ID)
+		s.Require().NoError(err)
+		s.Assert().Zero(floor)
+
+		//  purged tombstone is not purged again
+		count, err = s.storage.Secret().PurgeDeleted(s.ctx, time.Now().Add(time.Hour))
+		s.Require().NoError(err)
+		s.Assert().Zero(count)
+	})
+
+	s.dropTables()
+}
+
 func (s *TestSuite) TestStorage_GetUserVersionList() {
 	s.Run("Add list and get list of ver", func() {
 		user, err := s.storage.User().Create(s.ctx, user)
@@ -56,6 +145,7 @@ func (s *TestSuite) TestStorage_GetUserVersionList() {
 
 		for i := 0; i < count; i++ {
 			secret := getMockSecret(user.ID)
+			secret.Hash = model.DataHash(secret.Data)
 
 			id, err := s.storage.Secret().Add(context.Background(), secret)
 			s.Require().NoError(err)
@@ -73,7 +163,8 @@ func (s *TestSuite) TestStorage_GetUserVersionList() {
 
 			s.Assert().True(ok)
 
-			s.Assert().EqualValues(item, el.Ver)
+			s.Assert().EqualValues(el.Ver, item.Ver)
+			s.Assert().EqualValues(el.Hash, item.Hash)
 		}
 	})
 
@@ -93,5 +184,6 @@ func getMockSecret(userID uuid.UUID) model.Secret {
 		Ver:       rand.Intn(20),
 		IsDeleted: false,
 		Data:      fake.CharactersN(2000),
+		Vector:    model.VersionVector{uuid.NewString(): 1},
 	}
 }
@@ -11,6 +11,8 @@ import (
 type Storage struct {
 	SecretRepo   *secretRepository
 	UserRepo     *userRepository
+	DeviceRepo   *deviceRepository
+	TokenRepo    *tokenRepository
 	db           *sql.DB
 	conStringDSN string
 }
@@ -38,6 +40,8 @@ func NewStorage(dsn string) (*Storage, error) {
 
 	st.SecretRepo = newSecretRepository(db)
 	st.UserRepo = newUserRepository(db)
+	st.DeviceRepo = newDeviceRepository(db)
+	st.TokenRepo = newTokenRepository(db)
 
 	return st, nil
 }
@@ -47,6 +51,16 @@ func (s *Storage) User() storage.UserRepository {
 	return s.UserRepo
 }
 
+// Device returns devices repository.
+func (s *Storage) Device() storage.DeviceRepository {
+	return s.DeviceRepo
+}
+
+// Token returns refresh tokens repository.
+func (s *Storage) Token() storage.TokenRepository {
+	return s.TokenRepo
+}
+
 // Secret returns users repository.
 func (s *Storage) Secret() storage.SecretRepository {
 	return s.SecretRepo
@@ -0,0 +1,123 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Xrefullx/YanDip/server/model"
+	"github.com/Xrefullx/YanDip/server/services/logpkg"
+	"github.com/Xrefullx/YanDip/server/storage"
+)
+
+var _ storage.TokenRepository = (*tokenRepository)(nil)
+
+// tokenRepository implements TokenRepository interface, provides actions with refresh token records in psql storage.
+type tokenRepository struct {
+	db *sql.DB
+}
+
+// newTokenRepository inits new refresh token repository.
+func newTokenRepository(db *sql.DB) *tokenRepository {
+	return &tokenRepository{
+		db: db,
+	}
+}
+
+// Add saves issued refresh token
+func (r *tokenRepository) Add(ctx context.Context, token model.RefreshToken) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (id, user_id, device_id, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		token.ID, token.UserID, token.DeviceID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash selects refresh token by hash
+// if not found, returns ErrorItemNotFound
+func (r *tokenRepository) GetByHash(ctx context.Context, hash string) (model.RefreshToken, error) {
+	var token model.RefreshToken
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, device_id, token_hash, expires_at, created_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1`, hash,
+	).Scan(&token.ID, &token.UserID, &token.DeviceID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt,
+		&token.UsedAt, &token.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.RefreshToken{}, model.ErrorItemNotFound
+		}
+		logpkg.ErrorLog(err.Error())
+		return model.RefreshToken{}, err
+	}
+
+	return token, nil
+}
+
+// Rotate marks token used and adds token issued instead of it with one statement,
+// so token is exchanged once by concurrent requests.
+// if token is used or revoked, returns ErrorTokenRevoked
+func (r *tokenRepository) Rotate(ctx context.Context, usedID uuid.UUID, token model.RefreshToken) error {
+	res, err := r.db.ExecContext(ctx, `
+		WITH used AS (
+			UPDATE refresh_tokens SET used_at = now()
+			WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+			RETURNING id
+		)
+		INSERT INTO refresh_tokens (id, user_id, device_id, token_hash, expires_at)
+		SELECT $2, $3, $4, $5, $6 FROM used`,
+		usedID, token.ID, token.UserID, token.DeviceID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	added, err := res.RowsAffected()
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	if added == 0 {
+		return model.ErrorTokenRevoked
+	}
+
+	return nil
+}
+
+// RevokeDevice marks not revoked refresh tokens of user device revoked
+func (r *tokenRepository) RevokeDevice(ctx context.Context, userID uuid.UUID, deviceID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND device_id = $2 AND revoked_at IS NULL",
+		userID, deviceID)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// PurgeExpired removes refresh tokens expired before time, returns count of removed
+func (r *tokenRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < $1", before)
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logpkg.ErrorLog(err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
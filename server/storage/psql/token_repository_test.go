@@ -0,0 +1,60 @@
+package psql
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/icrowley/fake"
+
+	"github.com/Xrefullx/YanDip/server/model"
+)
+
+func (s *TestSuite) TestTokens_Rotate() {
+	user, err := s.storage.UserRepo.Create(s.ctx, model.User{
+		Login:        fake.CharactersN(8),
+		PasswordHash: fake.CharactersN(60),
+		MasterHash:   fake.CharactersN(60),
+	})
+	s.Require().NoError(err)
+
+	deviceID := uuid.New()
+	first := model.RefreshToken{ID: uuid.New(), UserID: user.ID, DeviceID: deviceID,
+		TokenHash: fake.CharactersN(64), ExpiresAt: time.Now().Add(time.Hour)}
+	second := first
+	second.ID, second.TokenHash = uuid.New(), fake.CharactersN(64)
+
+	s.Run("Add token", func() {
+		s.Require().NoError(s.storage.TokenRepo.Add(s.ctx, first))
+
+		res, err := s.storage.TokenRepo.GetByHash(s.ctx, first.TokenHash)
+		s.Require().NoError(err)
+		s.Assert().Equal(first.ID, res.ID)
+		s.Assert().Equal(deviceID, res.DeviceID)
+		s.Assert().Nil(res.UsedAt)
+	})
+
+	s.Run("Rotate token once", func() {
+		s.Require().NoError(s.storage.TokenRepo.Rotate(s.ctx, first.ID, second))
+
+		third := second
+		third.ID, third.TokenHash = uuid.New(), fake.CharactersN(64)
+		s.Require().ErrorIs(s.storage.TokenRepo.Rotate(s.ctx, first.ID, third), model.ErrorTokenRevoked)
+
+		res, err := s.storage.TokenRepo.GetByHash(s.ctx, first.TokenHash)
+		s.Require().NoError(err)
+		s.Assert().NotNil(res.UsedAt)
+	})
+
+	s.Run("Revoke device tokens", func() {
+		s.Require().NoError(s.storage.TokenRepo.RevokeDevice(s.ctx, user.ID, deviceID))
+
+		res, err := s.storage.TokenRepo.GetByHash(s.ctx, second.TokenHash)
+		s.Require().NoError(err)
+		s.Assert().NotNil(res.RevokedAt)
+	})
+
+	s.Run("Get not issued", func() {
+		_, err := s.storage.TokenRepo.GetByHash(s.ctx, fake.CharactersN(64))
+		s.Require().ErrorIs(err, model.ErrorItemNotFound)
+	})
+}